@@ -27,17 +27,29 @@ REPO_PREFIX="${REPO_PREFIX:?REPO_PREFIX env variable must be specified}"
 while IFS= read -d $'\0' -r dir; do
     # build image
     svcname="$(basename "${dir}")"
+    # src/common is a library, not a service
+    if [ $svcname == "common" ]
+    then
+        continue
+    fi
     builddir="${dir}"
+    dockerfile="Dockerfile"
     #PR 516 moved cartservice build artifacts one level down to src
     if [ $svcname == "cartservice" ] 
     then
         builddir="${dir}/src"
     fi
+    # the Go services share the module in src/common
+    if [ -f "${dir}/go.mod" ]
+    then
+        builddir="${dir}/.."
+        dockerfile="${svcname}/Dockerfile"
+    fi
     image="${REPO_PREFIX}/$svcname:$TAG"
     (
         cd "${builddir}"
         log "Building: ${image}"
-        docker build -t "${image}" .
+        docker build -t "${image}" -f "${dockerfile}" .
 
         log "Pushing: ${image}"
         docker push "${image}"
@@ -0,0 +1,211 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/resilience"
+)
+
+// Stages of a checkout that get a slice of the deadline of the request.
+// Emailing the confirmation is not one of them: it is delivered through the
+// outbox after the order is placed, bounded by the outbox attempt timeout.
+const (
+	budgetCart     = "cart"     // reading the cart
+	budgetCatalog  = "catalog"  // looking up and converting the item prices
+	budgetQuote    = "quote"    // quoting shipping
+	budgetCurrency = "currency" // converting the shipping cost
+	budgetCharge   = "charge"   // charging the card
+	budgetShip     = "ship"     // shipping the order
+)
+
+var (
+	placeOrderStages   = []string{budgetCart, budgetCatalog, budgetQuote, budgetCurrency, budgetCharge, budgetShip}
+	previewOrderStages = []string{budgetCart, budgetCatalog, budgetQuote, budgetCurrency}
+)
+
+// stageBudget is the share of a stage in the time left when it starts.
+type stageBudget struct {
+	// Weight is the share of the stage relative to the stages after it.
+	Weight float64 `json:"weight"`
+
+	// Min is the time below which the stage fails right away rather than
+	// start a call that cannot complete.
+	Min resilience.Duration `json:"min,omitempty"`
+}
+
+// budgetPolicy controls how the deadline of a request is partitioned across
+// its stages. Each stage gets the time left, less Reserve, split between it
+// and the stages after it according to their weights; the time a stage does
+// not use is passed on to the later ones.
+type budgetPolicy struct {
+	// Default is the budget of requests that come without a deadline.
+	Default resilience.Duration `json:"default"`
+
+	// Reserve is kept back from the stages to store the order and reply.
+	Reserve resilience.Duration `json:"reserve"`
+
+	Stages map[string]stageBudget `json:"stages"`
+}
+
+var defaultBudgetPolicy = budgetPolicy{
+	Default: resilience.Duration(20 * time.Second),
+	Reserve: resilience.Duration(200 * time.Millisecond),
+	Stages: map[string]stageBudget{
+		budgetCart:     {Weight: 1, Min: resilience.Duration(20 * time.Millisecond)},
+		budgetCatalog:  {Weight: 2, Min: resilience.Duration(50 * time.Millisecond)},
+		budgetQuote:    {Weight: 1, Min: resilience.Duration(20 * time.Millisecond)},
+		budgetCurrency: {Weight: 1, Min: resilience.Duration(20 * time.Millisecond)},
+		budgetCharge:   {Weight: 4, Min: resilience.Duration(500 * time.Millisecond)},
+		budgetShip:     {Weight: 2, Min: resilience.Duration(100 * time.Millisecond)},
+	},
+}
+
+// loadBudgetPolicy returns the policy in the JSON file named by
+// CHECKOUT_BUDGET_CONFIG, or the default one if it is unset.
+func loadBudgetPolicy() (budgetPolicy, error) {
+	path := os.Getenv("CHECKOUT_BUDGET_CONFIG")
+	if path == "" {
+		return defaultBudgetPolicy, nil
+	}
+	log.Infof("loading deadline budget policy from %q", path)
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return budgetPolicy{}, err
+	}
+	var p budgetPolicy
+	if err := json.Unmarshal(b, &p); err != nil {
+		return budgetPolicy{}, fmt.Errorf("failed to parse deadline budget policy %s: %v", path, err)
+	}
+	if err := p.validate(); err != nil {
+		return budgetPolicy{}, fmt.Errorf("invalid deadline budget policy %s: %v", path, err)
+	}
+	return p, nil
+}
+
+func (p budgetPolicy) validate() error {
+	if p.Default <= 0 {
+		return fmt.Errorf("default must be positive")
+	}
+	if p.Reserve < 0 {
+		return fmt.Errorf("reserve must not be negative")
+	}
+	for _, name := range placeOrderStages {
+		s, ok := p.Stages[name]
+		if !ok {
+			return fmt.Errorf("missing stage %s", name)
+		}
+		if s.Weight <= 0 || s.Min < 0 {
+			return fmt.Errorf("stage %s: weight must be positive and min not negative", name)
+		}
+	}
+	return nil
+}
+
+// deadlineBudget hands out the slices of the deadline of one request to its
+// stages, in order.
+type deadlineBudget struct {
+	policy   budgetPolicy
+	deadline time.Time
+	stages   []string // the stages not started yet
+	now      func() time.Time
+}
+
+// startBudget returns the budget of a request going through stages, and
+// ctx bounded by the default budget if it has no deadline.
+func (cs *checkoutService) startBudget(ctx context.Context, stages []string) (context.Context, context.CancelFunc, *deadlineBudget) {
+	policy := cs.budget
+	if policy.Stages == nil {
+		policy = defaultBudgetPolicy
+	}
+	cancel := context.CancelFunc(func() {})
+	if _, ok := ctx.Deadline(); !ok {
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(policy.Default))
+	}
+	deadline, _ := ctx.Deadline()
+	return ctx, cancel, &deadlineBudget{policy: policy, deadline: deadline, stages: stages, now: time.Now}
+}
+
+// stage returns ctx bounded by the slice of the budget of the named stage.
+// It fails with DeadlineExceeded if the slice is less than the minimum of the
+// stage. Stages skipped over forfeit their share.
+func (b *deadlineBudget) stage(ctx context.Context, name string) (context.Context, context.CancelFunc, error) {
+	slice, err := b.slice(name)
+	if err != nil {
+		return ctx, func() {}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, slice)
+	return ctx, cancel, nil
+}
+
+// slice hands out the slice of the budget of the named stage, failing with
+// DeadlineExceeded if it is less than the minimum of the stage.
+func (b *deadlineBudget) slice(name string) (time.Duration, error) {
+	for i, s := range b.stages {
+		if s == name {
+			b.stages = b.stages[i:]
+			break
+		}
+	}
+	var total float64
+	for _, s := range b.stages {
+		total += b.policy.Stages[s].Weight
+	}
+	sb := b.policy.Stages[name]
+	left := b.deadline.Sub(b.now()) - time.Duration(b.policy.Reserve)
+	slice := left
+	if total > 0 {
+		slice = time.Duration(float64(left) * sb.Weight / total)
+	}
+	if len(b.stages) > 0 && b.stages[0] == name {
+		b.stages = b.stages[1:]
+	}
+	if slice <= 0 || slice < time.Duration(sb.Min) {
+		return 0, budgetExhaustedError(name, slice)
+	}
+	return slice, nil
+}
+
+// run calls fn with ctx bounded by the slice of the budget of the named
+// stage, in the span of the stage.
+func (b *deadlineBudget) run(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	return traceStage(ctx, name, func(ctx context.Context) error {
+		ctx, cancel, err := b.stage(ctx, name)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+		return fn(ctx)
+	})
+}
+
+// runToDeadline is like run, but only checks that the slice of the named
+// stage is not below its minimum: fn gets all the time left until the
+// deadline of ctx. It is for calls whose outcome is unknown if they are cut
+// short.
+func (b *deadlineBudget) runToDeadline(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	return traceStage(ctx, name, func(ctx context.Context) error {
+		if _, err := b.slice(name); err != nil {
+			return err
+		}
+		return fn(ctx)
+	})
+}
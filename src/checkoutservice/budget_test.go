@@ -0,0 +1,158 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/resilience"
+)
+
+func TestDeadlineBudgetPartitionsTimeLeft(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := &deadlineBudget{
+		policy: budgetPolicy{
+			Reserve: resilience.Duration(time.Second),
+			Stages: map[string]stageBudget{
+				"a": {Weight: 1},
+				"b": {Weight: 2},
+				"c": {Weight: 1, Min: resilience.Duration(1500 * time.Millisecond)},
+			},
+		},
+		deadline: now.Add(9 * time.Second),
+		stages:   []string{"a", "b", "c"},
+		now:      func() time.Time { return now },
+	}
+	stage := func(name string, took, want time.Duration) {
+		t.Helper()
+		ctx, cancel, err := b.stage(context.Background(), name)
+		if err != nil {
+			t.Fatalf("stage %s: %v", name, err)
+		}
+		defer cancel()
+		deadline, _ := ctx.Deadline()
+		if got := deadline.Sub(time.Now()); got > want || got < want-time.Second {
+			t.Errorf("stage %s has %s left, want %s", name, got, want)
+		}
+		now = now.Add(took)
+	}
+	// 8s to share: a gets a quarter of it and finishes early, leaving b two
+	// thirds of the rest.
+	stage("a", time.Second, 2*time.Second)
+	stage("b", 6*time.Second, 14*time.Second/3)
+
+	_, _, err := b.stage(context.Background(), "c")
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("stage c with less than its minimum left: got %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestPlaceOrderFailsFastWhenBudgetIsExhausted(t *testing.T) {
+	f := newFakeDownstream()
+	cs, _ := newTestCheckoutService(t, f)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+	cs.budget = budgetPolicy{Stages: map[string]stageBudget{}}
+	for _, s := range placeOrderStages {
+		cs.budget.Stages[s] = stageBudget{Weight: 1}
+	}
+	cs.budget.Stages[budgetCharge] = stageBudget{Weight: 1, Min: resilience.Duration(time.Second)}
+
+	// Charging gets about half of the second left, less than its minimum.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err := cs.PlaceOrder(ctx, testPlaceOrderRequest("u1"))
+	if took := time.Since(start); took > 500*time.Millisecond {
+		t.Errorf("PlaceOrder() took %s, want it to fail before the deadline", took)
+	}
+	st := status.Convert(err)
+	if st.Code() != codes.DeadlineExceeded {
+		t.Fatalf("PlaceOrder() = %v, want DeadlineExceeded", err)
+	}
+	var info *errdetails.ErrorInfo
+	for _, d := range st.Details() {
+		if d, ok := d.(*errdetails.ErrorInfo); ok {
+			info = d
+		}
+	}
+	if info.GetReason() != reasonDownstreamTimeout || info.GetMetadata()["stage"] != budgetCharge {
+		t.Errorf("error info = %v, want %s in stage %s", info, reasonDownstreamTimeout, budgetCharge)
+	}
+	if len(f.charges) != 0 || len(f.shipments) != 0 {
+		t.Errorf("charges = %v, shipments = %v; want none", f.charges, f.shipments)
+	}
+}
+
+func TestPlaceOrderChargeUsesWholeDeadline(t *testing.T) {
+	f := newFakeDownstream()
+	f.chargeHang = true
+	cs, _ := newTestCheckoutService(t, f)
+	cs.idempotency = newIdempotencyCache(time.Minute)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+	cs.budget = budgetPolicy{Stages: map[string]stageBudget{}}
+	for _, s := range placeOrderStages {
+		cs.budget.Stages[s] = stageBudget{Weight: 1}
+	}
+
+	// Charging would get about half of the time left if it were cut short.
+	req := testPlaceOrderRequest("u1")
+	req.IdempotencyKey = "form-token"
+	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := cs.PlaceOrder(ctx, req)
+	if took := time.Since(start); took < 350*time.Millisecond {
+		t.Errorf("PlaceOrder() gave up on the charge after %s, want it to wait for the deadline", took)
+	}
+	st := status.Convert(err)
+	var info *errdetails.ErrorInfo
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.RetryInfo:
+			t.Errorf("charge timeout is retryable: %v", d)
+		}
+	}
+	if info.GetReason() != reasonPaymentUnknown {
+		t.Errorf("error info = %v, want %s", info, reasonPaymentUnknown)
+	}
+
+	// The charge may have gone through: the key must not charge again.
+	if _, err := cs.PlaceOrder(context.Background(), req); status.Code(err) != st.Code() {
+		t.Errorf("retry = %v, want the first outcome %v", err, st.Err())
+	}
+	if f.chargeAttempts != 1 {
+		t.Errorf("charge attempted %d times, want 1", f.chargeAttempts)
+	}
+}
+
+func TestBudgetPolicyValidation(t *testing.T) {
+	if err := defaultBudgetPolicy.validate(); err != nil {
+		t.Errorf("default policy is invalid: %v", err)
+	}
+	p := defaultBudgetPolicy
+	p.Stages = map[string]stageBudget{budgetCart: {Weight: 1}}
+	if err := p.validate(); err == nil {
+		t.Error("policy missing stages is valid")
+	}
+}
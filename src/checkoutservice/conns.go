@@ -0,0 +1,112 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/grpcutil"
+)
+
+const (
+	// keepaliveTime is how long a connection with outstanding RPCs may be
+	// silent before it is pinged. Pings are not sent on idle connections, as
+	// servers reject them by default, and not more often than the 5 minute
+	// minimum that gRPC servers enforce unless configured otherwise, which
+	// none of the downstream services are.
+	keepaliveTime    = 5 * time.Minute
+	keepaliveTimeout = 10 * time.Second
+)
+
+// connManager dials every downstream service once and hands out the shared
+// connections. Connections are established lazily and re-established with
+// exponential backoff if they break.
+type connManager struct {
+	tracing bool
+
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn // by downstream name
+}
+
+func newConnManager(tracing bool) *connManager {
+	return &connManager{
+		tracing: tracing,
+		conns:   make(map[string]*grpc.ClientConn),
+	}
+}
+
+// dialOptions returns the options shared by all downstream connections.
+func (m *connManager) dialOptions() []grpc.DialOption {
+	return append(grpcutil.DialOptions(m.tracing),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    keepaliveTime,
+			Timeout: keepaliveTimeout,
+		}),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff: backoff.Config{
+				BaseDelay:  100 * time.Millisecond,
+				Multiplier: 1.6,
+				Jitter:     0.2,
+				MaxDelay:   5 * time.Second,
+			},
+			MinConnectTimeout: 3 * time.Second,
+		}))
+}
+
+// dial returns the connection to the downstream called name, creating it on
+// first use. The options apply, after the shared ones, to this downstream
+// only; they are ignored once the connection exists.
+func (m *connManager) dial(name, addr string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if conn, ok := m.conns[name]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.Dial(addr, append(m.dialOptions(), opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect %s service: %+v", name, err)
+	}
+	m.conns[name] = conn
+	return conn, nil
+}
+
+// mustDial is like dial but panics if the connection cannot be set up.
+func (m *connManager) mustDial(name, addr string, opts ...grpc.DialOption) *grpc.ClientConn {
+	conn, err := m.dial(name, addr, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return conn
+}
+
+// Close closes all connections.
+func (m *connManager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var firstErr error
+	for name, conn := range m.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(m.conns, name)
+	}
+	return firstErr
+}
@@ -0,0 +1,61 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+func TestConnManagerReusesConnections(t *testing.T) {
+	addr := newFakeDownstream().start(t)
+	m := newConnManager(false)
+	defer m.Close()
+
+	var intercepted []string
+	record := func(name string) grpc.DialOption {
+		return grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			intercepted = append(intercepted, name+" "+method)
+			return invoker(ctx, method, req, reply, cc, opts...)
+		})
+	}
+
+	cart := m.mustDial("cart", addr, record("cart"))
+	if again := m.mustDial("cart", addr, record("ignored")); again != cart {
+		t.Error("second dial of the same downstream returned a new connection")
+	}
+	currency := m.mustDial("currency", addr)
+	if currency == cart {
+		t.Error("different downstreams share a connection")
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := pb.NewCartServiceClient(cart).GetCart(ctx, &pb.GetCartRequest{UserId: "u1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := pb.NewCurrencyServiceClient(currency).GetSupportedCurrencies(ctx, &pb.Empty{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "cart /hipstershop.CartService/GetCart"
+	if len(intercepted) != 2 || intercepted[0] != want || intercepted[1] != want {
+		t.Errorf("intercepted = %v, want two calls of %q", intercepted, want)
+	}
+}
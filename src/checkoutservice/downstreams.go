@@ -0,0 +1,141 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+
+	"google.golang.org/grpc"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/fake"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+// defaultFakes stand in for the downstreams disabled with SHIPPING_SVC_DISABLED,
+// PAYMENT_SVC_DISABLED or EMAIL_SVC_DISABLED, unless FAKES_CONFIG fakes them
+// otherwise. They always succeed, at once.
+var defaultFakes = fake.Config{
+	"shipping": {Methods: map[string]fake.Method{
+		"GetQuote": {Responses: []fake.Response{
+			fake.Reply(&pb.GetQuoteResponse{CostUsd: &pb.Money{CurrencyCode: "USD", Units: 5}}),
+		}},
+		"ShipOrder": {Responses: []fake.Response{
+			fake.Reply(&pb.ShipOrderResponse{TrackingId: "Mock_Tracking_ID"}),
+		}},
+		"TrackShipment": {Responses: []fake.Response{
+			fake.Reply(&pb.ShipmentEvent{TrackingId: "Mock_Tracking_ID", Status: pb.OrderStatus_SHIPPED}),
+			fake.Reply(&pb.ShipmentEvent{TrackingId: "Mock_Tracking_ID", Status: pb.OrderStatus_DELIVERED}),
+		}},
+	}},
+	"payment": {Methods: map[string]fake.Method{
+		"Charge": {Responses: []fake.Response{
+			fake.Reply(&pb.ChargeResponse{TransactionId: "Mock_Transaction_ID"}),
+		}},
+	}},
+	"email": {},
+}
+
+// disabledEnv are the variables disabling the downstreams with default fakes.
+var disabledEnv = map[string]string{
+	"shipping": "SHIPPING_SVC_DISABLED",
+	"payment":  "PAYMENT_SVC_DISABLED",
+	"email":    "EMAIL_SVC_DISABLED",
+}
+
+// loadFakes returns the fakes of the downstreams that are not called: those
+// in the JSON file named by FAKES_CONFIG, if set, and the default fakes of
+// the downstreams disabled by their environment variable.
+func loadFakes() (fake.Config, error) {
+	fakes := fake.Config{}
+	for name, env := range disabledEnv {
+		if os.Getenv(env) != "" {
+			log.Infof("%s service disabled, using its default fake", name)
+			fakes[name] = defaultFakes[name]
+		}
+	}
+	path := os.Getenv("FAKES_CONFIG")
+	if path == "" {
+		return fakes, nil
+	}
+	log.Infof("loading fakes from %q", path)
+	c, err := fake.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	for name := range c {
+		log.Infof("%s service faked as configured in %q", name, path)
+	}
+	return fakes.Merge(c), nil
+}
+
+// mustMapDownstreamEnv is like mustMapEnv for the address of the downstream
+// called name, which is not needed if the downstream is faked.
+func (cs *checkoutService) mustMapDownstreamEnv(target *string, name, envKey string) {
+	if _, ok := cs.fakes[name]; ok {
+		return
+	}
+	mustMapEnv(target, envKey)
+}
+
+// fake returns the fake of the downstream called name, if it is faked. Its
+// calls are guarded by the resilience policy of the downstream, as real ones.
+func (cs *checkoutService) fake(name string) (*fake.Conn, bool) {
+	s, ok := cs.fakes[name]
+	if !ok {
+		return nil, false
+	}
+	c := cs.resilienceClient(name)
+	return fake.NewConn(name, s,
+		fake.WithUnaryInterceptor(c.UnaryClientInterceptor()),
+		fake.WithStreamInterceptor(c.StreamClientInterceptor())), true
+}
+
+// dialDownstreams sets up the clients of all downstream services, connecting
+// to those that are not faked.
+func (cs *checkoutService) dialDownstreams(conns *connManager) {
+	dial := func(name, addr string) *grpc.ClientConn {
+		return conns.mustDial(name, addr, cs.guard(name)...)
+	}
+	if f, ok := cs.fake("productcatalog"); ok {
+		cs.productCatalogSvc = fake.NewProductCatalogServiceClient(f)
+	} else {
+		cs.productCatalogSvc = pb.NewProductCatalogServiceClient(dial("productcatalog", cs.productCatalogSvcAddr))
+	}
+	if f, ok := cs.fake("cart"); ok {
+		cs.cartSvc = fake.NewCartServiceClient(f)
+	} else {
+		cs.cartSvc = pb.NewCartServiceClient(dial("cart", cs.cartSvcAddr))
+	}
+	if f, ok := cs.fake("currency"); ok {
+		cs.currencySvc = fake.NewCurrencyServiceClient(f)
+	} else {
+		cs.currencySvc = pb.NewCurrencyServiceClient(dial("currency", cs.currencySvcAddr))
+	}
+	if f, ok := cs.fake("shipping"); ok {
+		cs.shippingSvc = fake.NewShippingServiceClient(f)
+	} else {
+		cs.shippingSvc = pb.NewShippingServiceClient(dial("shipping", cs.shippingSvcAddr))
+	}
+	if f, ok := cs.fake("email"); ok {
+		cs.emailSvc = fake.NewEmailServiceClient(f)
+	} else {
+		cs.emailSvc = pb.NewEmailServiceClient(dial("email", cs.emailSvcAddr))
+	}
+	if f, ok := cs.fake("payment"); ok {
+		cs.paymentSvc = fake.NewPaymentServiceClient(f)
+	} else {
+		cs.paymentSvc = pb.NewPaymentServiceClient(dial("payment", cs.paymentSvcAddr))
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/fake"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+// withFakes redials the downstreams of cs with the given ones faked.
+func withFakes(t *testing.T, cs *checkoutService, fakes fake.Config) {
+	t.Helper()
+	cs.fakes = fakes
+	conns := newConnManager(false)
+	t.Cleanup(func() { conns.Close() })
+	cs.dialDownstreams(conns)
+}
+
+func TestPlaceOrderWithDefaultFakes(t *testing.T) {
+	f := newFakeDownstream()
+	cs, _ := newTestCheckoutService(t, f)
+	withFakes(t, cs, defaultFakes)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	resp, err := cs.PlaceOrder(context.Background(), testPlaceOrderRequest("u1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dispatchOutbox(t, cs)
+	if got := resp.GetOrder().GetShippingTrackingId(); got != "Mock_Tracking_ID" {
+		t.Errorf("tracking ID = %q, want the one of the fake", got)
+	}
+	if got := resp.GetOrder().GetShippingCost(); got.GetUnits() != 5 || got.GetNanos() != 0 {
+		t.Errorf("shipping cost = %v, want the 5.00 USD quoted by the fake", got)
+	}
+	if len(f.charges) != 0 || len(f.shipments) != 0 || len(f.emails) != 0 {
+		t.Errorf("faked downstreams were called: charges %v, shipments %v, emails %v", f.charges, f.shipments, f.emails)
+	}
+	if want := []string{"u1"}; !reflect.DeepEqual(f.emptied, want) {
+		t.Errorf("emptied carts = %v, want %v", f.emptied, want)
+	}
+}
+
+func TestPlaceOrderWithFailingFake(t *testing.T) {
+	f := newFakeDownstream()
+	cs, comp := newTestCheckoutService(t, f)
+	always := 1.0
+	withFakes(t, cs, fake.Config{
+		"payment": {Behavior: fake.Behavior{ErrorRate: &always, ErrorCode: codes.Unavailable}},
+	})
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	_, err := cs.PlaceOrder(context.Background(), testPlaceOrderRequest("u1"))
+	if got, want := status.Code(err), codes.Unavailable; got != want {
+		t.Fatalf("PlaceOrder() code = %s, want %s (err: %v)", got, want, err)
+	}
+	if len(f.shipments) != 0 || len(comp.refunds) != 0 {
+		t.Errorf("order went on after the charge failed: shipments %v, refunds %v", f.shipments, comp.refunds)
+	}
+}
+
+func TestLoadFakes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fakes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fakes.json")
+	err = ioutil.WriteFile(path, []byte(`{
+		"payment": {"latency": {"distribution": "constant", "value": "10ms"}},
+		"cart": {"error_rate": 0.5}
+	}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("SHIPPING_SVC_DISABLED", "1")
+	os.Setenv("PAYMENT_SVC_DISABLED", "1")
+	os.Setenv("FAKES_CONFIG", path)
+	defer func() {
+		os.Unsetenv("SHIPPING_SVC_DISABLED")
+		os.Unsetenv("PAYMENT_SVC_DISABLED")
+		os.Unsetenv("FAKES_CONFIG")
+	}()
+
+	fakes, err := loadFakes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range fakes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if want := []string{"cart", "payment", "shipping"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("faked downstreams = %v, want %v", names, want)
+	}
+	if !reflect.DeepEqual(fakes["shipping"], defaultFakes["shipping"]) {
+		t.Errorf("shipping fake = %+v, want the default one", fakes["shipping"])
+	}
+	if fakes["payment"].Latency == nil {
+		t.Errorf("payment fake = %+v, want the one of FAKES_CONFIG", fakes["payment"])
+	}
+
+	cs := &checkoutService{fakes: fakes}
+	cs.mustMapDownstreamEnv(&cs.cartSvcAddr, "cart", "CART_SERVICE_ADDR")
+	if cs.cartSvcAddr != "" {
+		t.Errorf("address of the faked cart service = %q, want none", cs.cartSvcAddr)
+	}
+}
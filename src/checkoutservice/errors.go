@@ -0,0 +1,241 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain is the domain of the ErrorInfo attached to checkout errors.
+const errorDomain = "checkoutservice.hipstershop"
+
+// Reasons reported in the ErrorInfo of checkout errors. Clients branch on
+// them, so they must not change.
+const (
+	reasonInvalidOrder          = "INVALID_ORDER"
+	reasonCartEmpty             = "CART_EMPTY"
+	reasonPromoCodeInvalid      = "PROMO_CODE_INVALID"
+	reasonProductNotFound       = "PRODUCT_NOT_FOUND"
+	reasonCurrencyUnsupported   = "CURRENCY_UNSUPPORTED"
+	reasonCardDeclined          = "CARD_DECLINED"
+	reasonPaymentUnknown        = "PAYMENT_OUTCOME_UNKNOWN"
+	reasonShippingUnavailable   = "SHIPPING_UNAVAILABLE"
+	reasonOrderRejected         = "ORDER_REJECTED"
+	reasonDownstreamTimeout     = "DOWNSTREAM_TIMEOUT"
+	reasonDownstreamUnavailable = "DOWNSTREAM_UNAVAILABLE"
+	reasonCancelled             = "CANCELLED"
+	reasonInternal              = "INTERNAL"
+)
+
+// retryDelay is the delay suggested to clients for failures that are
+// expected to go away on their own.
+const retryDelay = 2 * time.Second
+
+// checkoutError is a classified PlaceOrder failure. The gRPC server turns it
+// into a status with an ErrorInfo, a RetryInfo if the failure is transient,
+// and any other details.
+type checkoutError struct {
+	code     codes.Code
+	reason   string
+	msg      string
+	metadata map[string]string
+	retry    time.Duration // zero if retrying will not help
+	details  []proto.Message
+	cause    error
+
+	// charged is set if the card was, or may have been, charged for the
+	// order before it failed.
+	charged bool
+}
+
+func (e *checkoutError) Error() string { return e.msg }
+
+func (e *checkoutError) Unwrap() error { return e.cause }
+
+// GRPCStatus implements the interface used by the status package.
+func (e *checkoutError) GRPCStatus() *status.Status {
+	st := status.New(e.code, e.msg)
+	details := []proto.Message{&errdetails.ErrorInfo{
+		Reason:   e.reason,
+		Domain:   errorDomain,
+		Metadata: e.metadata,
+	}}
+	if e.retry > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: ptypes.DurationProto(e.retry)})
+	}
+	details = append(details, e.details...)
+	if ds, err := st.WithDetails(details...); err == nil {
+		st = ds
+	}
+	return st
+}
+
+// invalidOrderError returns an InvalidArgument error carrying violations as
+// google.rpc.BadRequest details.
+func invalidOrderError(reason string, violations []*errdetails.BadRequest_FieldViolation) error {
+	return &checkoutError{
+		code:    codes.InvalidArgument,
+		reason:  reason,
+		msg:     "invalid order",
+		details: []proto.Message{&errdetails.BadRequest{FieldViolations: violations}},
+	}
+}
+
+// internalError reports a failure that is not the client's fault and that
+// retrying will not fix.
+func internalError(cause error, format string, a ...interface{}) error {
+	return &checkoutError{
+		code:   codes.Internal,
+		reason: reasonInternal,
+		msg:    fmt.Sprintf(format, a...),
+		cause:  cause,
+	}
+}
+
+// asCheckoutError returns the checkoutError in err's chain, or an internal
+// error if err has not been classified.
+func asCheckoutError(err error) error {
+	var ce *checkoutError
+	if errors.As(err, &ce) {
+		return ce
+	}
+	return internalError(err, "%v", err)
+}
+
+// chargedError marks err as the failure of an order whose card was, or may
+// have been, charged. Running the order again could charge the card twice,
+// so the idempotency cache remembers such failures like successes.
+func chargedError(err error) error {
+	ce := *asCheckoutError(err).(*checkoutError)
+	ce.charged = true
+	return &ce
+}
+
+// isCharged reports whether err is the failure of an order whose card was,
+// or may have been, charged.
+func isCharged(err error) bool {
+	var ce *checkoutError
+	return errors.As(err, &ce) && ce.charged
+}
+
+// downstreamError classifies a failed call to service that the caller has no
+// more specific reason for. Timeouts and unavailability are transient; any
+// other failure is internal.
+func downstreamError(service string, err error) *checkoutError {
+	md := map[string]string{"service": service}
+	switch downstreamCode(err) {
+	case codes.DeadlineExceeded:
+		return &checkoutError{code: codes.DeadlineExceeded, reason: reasonDownstreamTimeout,
+			msg: fmt.Sprintf("%s service timed out", service), metadata: md, retry: retryDelay, cause: err}
+	case codes.Unavailable, codes.ResourceExhausted:
+		return &checkoutError{code: codes.Unavailable, reason: reasonDownstreamUnavailable,
+			msg: fmt.Sprintf("%s service is unavailable", service), metadata: md, retry: retryDelay, cause: err}
+	case codes.Canceled:
+		return &checkoutError{code: codes.Canceled, reason: reasonCancelled,
+			msg: "request cancelled", metadata: md, cause: err}
+	}
+	return &checkoutError{code: codes.Internal, reason: reasonInternal,
+		msg: fmt.Sprintf("%s service failed: %s", service, status.Convert(err).Message()), metadata: md, cause: err}
+}
+
+// orderRejectedError reports that fraud screening rejected the order. The
+// rules that fired are listed in the metadata.
+func orderRejectedError(reasons []string) *checkoutError {
+	return &checkoutError{code: codes.PermissionDenied, reason: reasonOrderRejected,
+		msg:      "order rejected by fraud screening",
+		metadata: map[string]string{"rules": strings.Join(reasons, ",")}}
+}
+
+// budgetExhaustedError reports that the time left for a stage of the
+// request, per the deadline budget, is too short to start it.
+func budgetExhaustedError(stage string, left time.Duration) *checkoutError {
+	if left < 0 {
+		left = 0
+	}
+	return &checkoutError{code: codes.DeadlineExceeded, reason: reasonDownstreamTimeout,
+		msg:      fmt.Sprintf("deadline too close to %s: %s left", stage, left.Round(time.Millisecond)),
+		metadata: map[string]string{"stage": stage}, retry: retryDelay}
+}
+
+// downstreamCode returns the status code of err, which may also be a bare
+// context error.
+func downstreamCode(err error) codes.Code {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return status.FromContextError(err).Code()
+	}
+	return status.Code(err)
+}
+
+func productNotFoundError(id string, err error) *checkoutError {
+	if status.Code(err) != codes.NotFound {
+		return downstreamError("productcatalog", err)
+	}
+	return &checkoutError{code: codes.NotFound, reason: reasonProductNotFound,
+		msg: fmt.Sprintf("failed to get product #%q", id), metadata: map[string]string{"product_id": id}, cause: err}
+}
+
+// isRejection reports whether err is the downstream service refusing the
+// request it was given, as opposed to failing to handle it.
+func isRejection(err error) bool {
+	switch downstreamCode(err) {
+	case codes.InvalidArgument, codes.FailedPrecondition:
+		return true
+	}
+	return false
+}
+
+func currencyError(currency string, err error) *checkoutError {
+	if !isRejection(err) {
+		return downstreamError("currency", err)
+	}
+	return &checkoutError{code: codes.InvalidArgument, reason: reasonCurrencyUnsupported,
+		msg: fmt.Sprintf("currency %s is not supported", currency), metadata: map[string]string{"currency": currency}, cause: err}
+}
+
+// paymentError classifies a failed charge. Only a charge the payment service
+// refused is a declined card. A charge that timed out or was cancelled may
+// still have gone through, so its outcome is unknown: it is not retryable,
+// and is treated as charged.
+func paymentError(err error) *checkoutError {
+	switch downstreamCode(err) {
+	case codes.DeadlineExceeded, codes.Canceled:
+		return &checkoutError{code: codes.DeadlineExceeded, reason: reasonPaymentUnknown,
+			msg: "payment outcome unknown, the card may have been charged", metadata: map[string]string{"service": "payment"},
+			cause: err, charged: true}
+	}
+	if !isRejection(err) {
+		return downstreamError("payment", err)
+	}
+	return &checkoutError{code: codes.FailedPrecondition, reason: reasonCardDeclined,
+		msg: fmt.Sprintf("card declined: %s", status.Convert(err).Message()), cause: err}
+}
+
+func shippingError(err error) *checkoutError {
+	if downstreamCode(err) == codes.DeadlineExceeded || downstreamCode(err) == codes.Canceled {
+		return downstreamError("shipping", err)
+	}
+	return &checkoutError{code: codes.Unavailable, reason: reasonShippingUnavailable,
+		msg: fmt.Sprintf("shipping unavailable: %s", status.Convert(err).Message()), retry: retryDelay, cause: err}
+}
@@ -0,0 +1,163 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+func TestPlaceOrderErrorDetails(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(f *fakeDownstream, req *pb.PlaceOrderRequest)
+		code      codes.Code
+		reason    string
+		retryable bool
+	}{
+		{
+			name:   "cart empty",
+			setup:  func(f *fakeDownstream, _ *pb.PlaceOrderRequest) { delete(f.cart, "u1") },
+			code:   codes.InvalidArgument,
+			reason: reasonCartEmpty,
+		},
+		{
+			name: "product not found",
+			setup: func(f *fakeDownstream, _ *pb.PlaceOrderRequest) {
+				f.cart["u1"] = append(f.cart["u1"], &pb.CartItem{ProductId: "NOPE", Quantity: 1})
+			},
+			code:   codes.NotFound,
+			reason: reasonProductNotFound,
+		},
+		{
+			name:   "currency unsupported",
+			setup:  func(_ *fakeDownstream, req *pb.PlaceOrderRequest) { req.UserCurrency = "XXX" },
+			code:   codes.InvalidArgument,
+			reason: reasonCurrencyUnsupported,
+		},
+		{
+			name: "card declined",
+			setup: func(f *fakeDownstream, _ *pb.PlaceOrderRequest) {
+				f.chargeErr = status.Error(codes.InvalidArgument, "Credit card info is invalid")
+			},
+			code:   codes.FailedPrecondition,
+			reason: reasonCardDeclined,
+		},
+		{
+			name: "payment timeout",
+			setup: func(f *fakeDownstream, _ *pb.PlaceOrderRequest) {
+				f.chargeErr = status.Error(codes.DeadlineExceeded, "too slow")
+			},
+			code:   codes.DeadlineExceeded,
+			reason: reasonPaymentUnknown,
+		},
+		{
+			name: "shipping unavailable",
+			setup: func(f *fakeDownstream, _ *pb.PlaceOrderRequest) {
+				f.shipErr = status.Error(codes.Unavailable, "no trucks")
+			},
+			code:      codes.Unavailable,
+			reason:    reasonShippingUnavailable,
+			retryable: true,
+		},
+		{
+			name: "shipping timeout",
+			setup: func(f *fakeDownstream, _ *pb.PlaceOrderRequest) {
+				f.shipErr = status.Error(codes.DeadlineExceeded, "too slow")
+			},
+			code:      codes.DeadlineExceeded,
+			reason:    reasonDownstreamTimeout,
+			retryable: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeDownstream()
+			cs, _ := newTestCheckoutService(t, f)
+			f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+			req := testPlaceOrderRequest("u1")
+			tt.setup(f, req)
+
+			_, err := cs.PlaceOrder(context.Background(), req)
+			st := status.Convert(err)
+			if st.Code() != tt.code {
+				t.Errorf("code = %s, want %s (err: %v)", st.Code(), tt.code, err)
+			}
+			var info *errdetails.ErrorInfo
+			var retry *errdetails.RetryInfo
+			for _, d := range st.Details() {
+				switch d := d.(type) {
+				case *errdetails.ErrorInfo:
+					info = d
+				case *errdetails.RetryInfo:
+					retry = d
+				}
+			}
+			if info.GetReason() != tt.reason || info.GetDomain() != errorDomain {
+				t.Errorf("ErrorInfo = %v, want reason %s", info, tt.reason)
+			}
+			if (retry != nil) != tt.retryable {
+				t.Errorf("RetryInfo = %v, want retryable %v", retry, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestDownstreamFailureClassification(t *testing.T) {
+	tests := []struct {
+		name     string
+		classify func(error) *checkoutError
+		err      error
+		code     codes.Code
+		reason   string
+	}{
+		{"payment refused", paymentError, status.Error(codes.InvalidArgument, "Credit card info is invalid"),
+			codes.FailedPrecondition, reasonCardDeclined},
+		{"payment precondition", paymentError, status.Error(codes.FailedPrecondition, "card expired"),
+			codes.FailedPrecondition, reasonCardDeclined},
+		{"payment internal", paymentError, status.Error(codes.Internal, "database down"),
+			codes.Internal, reasonInternal},
+		{"payment unknown", paymentError, status.Error(codes.Unknown, "boom"),
+			codes.Internal, reasonInternal},
+		{"payment unavailable", paymentError, status.Error(codes.Unavailable, "no backends"),
+			codes.Unavailable, reasonDownstreamUnavailable},
+		{"currency refused", func(err error) *checkoutError { return currencyError("XXX", err) },
+			status.Error(codes.InvalidArgument, "unsupported currency XXX"),
+			codes.InvalidArgument, reasonCurrencyUnsupported},
+		{"currency internal", func(err error) *checkoutError { return currencyError("EUR", err) },
+			status.Error(codes.Internal, "rates missing"),
+			codes.Internal, reasonInternal},
+		{"currency unknown", func(err error) *checkoutError { return currencyError("EUR", err) },
+			status.Error(codes.Unknown, "boom"),
+			codes.Internal, reasonInternal},
+		{"currency timeout", func(err error) *checkoutError { return currencyError("EUR", err) },
+			context.DeadlineExceeded,
+			codes.DeadlineExceeded, reasonDownstreamTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ce := tt.classify(tt.err)
+			if ce.code != tt.code || ce.reason != tt.reason {
+				t.Errorf("classified as %s %s, want %s %s", ce.code, ce.reason, tt.code, tt.reason)
+			}
+		})
+	}
+}
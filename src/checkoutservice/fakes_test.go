@@ -0,0 +1,319 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"math"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/fraud"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/orderstore"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/outbox"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/promo"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/tax"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+// fakeDownstream implements every service checkoutservice depends on, so a
+// single in-process gRPC server can stand in for all of them.
+type fakeDownstream struct {
+	mu        sync.Mutex
+	cart      map[string][]*pb.CartItem
+	products  map[string]*pb.Product
+	chargeErr error
+	shipErr   error
+	emailErr  error
+	emptyErr  error
+	rates     map[string]float64 // USD to currency code
+
+	// chargeHang makes Charge wait for its deadline instead of replying.
+	chargeHang bool
+
+	productLookups int
+	conversions    int
+	chargeAttempts int
+
+	charges   []*pb.ChargeRequest
+	shipments []*pb.ShipOrderRequest
+	emails    []*pb.SendOrderConfirmationRequest
+	emptied   []string
+
+	// tracking is streamed by TrackShipment for the shipments of ShipOrder.
+	tracking  []pb.OrderStatus
+	cancelled []string
+}
+
+func newFakeDownstream() *fakeDownstream {
+	return &fakeDownstream{
+		cart:  map[string][]*pb.CartItem{},
+		rates: map[string]float64{},
+		products: map[string]*pb.Product{
+			"OLJCESPC7Z": {Id: "OLJCESPC7Z", Name: "Vintage Typewriter",
+				PriceUsd: &pb.Money{CurrencyCode: "USD", Units: 67, Nanos: 990000000}},
+			"66VCHSJNUP": {Id: "66VCHSJNUP", Name: "Vintage Camera Lens",
+				PriceUsd: &pb.Money{CurrencyCode: "USD", Units: 12, Nanos: 490000000}},
+		},
+	}
+}
+
+// start serves f on a local port and returns its address.
+func (f *fakeDownstream) start(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterCartServiceServer(srv, f)
+	pb.RegisterProductCatalogServiceServer(srv, f)
+	pb.RegisterCurrencyServiceServer(srv, f)
+	pb.RegisterShippingServiceServer(srv, f)
+	pb.RegisterPaymentServiceServer(srv, f)
+	pb.RegisterEmailServiceServer(srv, f)
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+	return l.Addr().String()
+}
+
+func (f *fakeDownstream) AddItem(ctx context.Context, req *pb.AddItemRequest) (*pb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cart[req.UserId] = append(f.cart[req.UserId], req.Item)
+	return &pb.Empty{}, nil
+}
+
+func (f *fakeDownstream) GetCart(ctx context.Context, req *pb.GetCartRequest) (*pb.Cart, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &pb.Cart{UserId: req.UserId, Items: f.cart[req.UserId]}, nil
+}
+
+func (f *fakeDownstream) EmptyCart(ctx context.Context, req *pb.EmptyCartRequest) (*pb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.emptyErr != nil {
+		return nil, f.emptyErr
+	}
+	delete(f.cart, req.UserId)
+	f.emptied = append(f.emptied, req.UserId)
+	return &pb.Empty{}, nil
+}
+
+func (f *fakeDownstream) ListProducts(ctx context.Context, _ *pb.Empty) (*pb.ListProductsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := &pb.ListProductsResponse{}
+	for _, p := range f.products {
+		out.Products = append(out.Products, p)
+	}
+	return out, nil
+}
+
+func (f *fakeDownstream) GetProduct(ctx context.Context, req *pb.GetProductRequest) (*pb.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.productLookups++
+	p, ok := f.products[req.Id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no product with ID %s", req.Id)
+	}
+	return p, nil
+}
+
+func (f *fakeDownstream) SearchProducts(ctx context.Context, req *pb.SearchProductsRequest) (*pb.SearchProductsResponse, error) {
+	return &pb.SearchProductsResponse{}, nil
+}
+
+func (f *fakeDownstream) GetSupportedCurrencies(ctx context.Context, _ *pb.Empty) (*pb.GetSupportedCurrenciesResponse, error) {
+	return &pb.GetSupportedCurrenciesResponse{CurrencyCodes: []string{"USD"}}, nil
+}
+
+// Convert supports identity conversions and conversions from USD to the
+// currencies in f.rates.
+func (f *fakeDownstream) Convert(ctx context.Context, req *pb.CurrencyConversionRequest) (*pb.Money, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.conversions++
+	if req.From.GetCurrencyCode() == req.ToCode {
+		return req.From, nil
+	}
+	rate, ok := f.rates[req.ToCode]
+	if req.From.GetCurrencyCode() != "USD" || !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported currency %s", req.ToCode)
+	}
+	v := (float64(req.From.GetUnits()) + float64(req.From.GetNanos())/1e9) * rate
+	units := math.Floor(v)
+	return &pb.Money{
+		CurrencyCode: req.ToCode,
+		Units:        int64(units),
+		Nanos:        int32(math.Floor((v - units) * 1e9))}, nil
+}
+
+func (f *fakeDownstream) GetQuote(ctx context.Context, req *pb.GetQuoteRequest) (*pb.GetQuoteResponse, error) {
+	return &pb.GetQuoteResponse{CostUsd: &pb.Money{CurrencyCode: "USD", Units: 8, Nanos: 990000000}}, nil
+}
+
+func (f *fakeDownstream) ShipOrder(ctx context.Context, req *pb.ShipOrderRequest) (*pb.ShipOrderResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.shipErr != nil {
+		return nil, f.shipErr
+	}
+	f.shipments = append(f.shipments, req)
+	return &pb.ShipOrderResponse{TrackingId: "TRACK-1"}, nil
+}
+
+func (f *fakeDownstream) TrackShipment(req *pb.TrackShipmentRequest, stream pb.ShippingService_TrackShipmentServer) error {
+	if req.GetTrackingId() != "TRACK-1" {
+		return status.Errorf(codes.NotFound, "no shipment with tracking ID %s", req.GetTrackingId())
+	}
+	f.mu.Lock()
+	tracking := f.tracking
+	f.mu.Unlock()
+	for i, s := range tracking {
+		if err := stream.Send(&pb.ShipmentEvent{TrackingId: req.GetTrackingId(), Status: s, Time: int64(i)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeDownstream) CancelShipment(ctx context.Context, req *pb.CancelShipmentRequest) (*pb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cancelled = append(f.cancelled, req.GetTrackingId())
+	return &pb.Empty{}, nil
+}
+
+func (f *fakeDownstream) Charge(ctx context.Context, req *pb.ChargeRequest) (*pb.ChargeResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.chargeAttempts++
+	if f.chargeHang {
+		f.mu.Unlock()
+		<-ctx.Done()
+		f.mu.Lock()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	if f.chargeErr != nil {
+		return nil, f.chargeErr
+	}
+	f.charges = append(f.charges, req)
+	return &pb.ChargeResponse{TransactionId: "TX-1"}, nil
+}
+
+func (f *fakeDownstream) SendOrderConfirmation(ctx context.Context, req *pb.SendOrderConfirmationRequest) (*pb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.emailErr != nil {
+		return nil, f.emailErr
+	}
+	f.emails = append(f.emails, req)
+	return &pb.Empty{}, nil
+}
+
+// fakeCompensator records the compensations requested by the saga.
+type fakeCompensator struct {
+	mu        sync.Mutex
+	refunds   []string
+	cancelled []string
+	err       error
+}
+
+func (c *fakeCompensator) refundPayment(ctx context.Context, txID string, amount *pb.Money) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refunds = append(c.refunds, txID)
+	return c.err
+}
+
+func (c *fakeCompensator) cancelShipment(ctx context.Context, trackingID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cancelled = append(c.cancelled, trackingID)
+	return c.err
+}
+
+// testOutboxPolicy retries failed side effects as soon as the outbox is
+// dispatched again.
+var testOutboxPolicy = outbox.Policy{
+	MaxAttempts:    3,
+	AttemptTimeout: time.Second,
+	BatchSize:      10,
+}
+
+// newTestCheckoutService returns a checkoutService whose downstreams are
+// all served by f.
+func newTestCheckoutService(t *testing.T, f *fakeDownstream) (*checkoutService, *fakeCompensator) {
+	t.Helper()
+	addr := f.start(t)
+	comp := &fakeCompensator{}
+	cs := &checkoutService{
+		productCatalogSvcAddr: addr,
+		cartSvcAddr:           addr,
+		currencySvcAddr:       addr,
+		shippingSvcAddr:       addr,
+		emailSvcAddr:          addr,
+		paymentSvcAddr:        addr,
+		compensator:           comp,
+		orders:                orderstore.NewMemory(),
+		taxes:                 &tax.Engine{},
+		promos:                &promo.Engine{},
+		fraud:                 &fraud.Engine{},
+	}
+	cs.outbox = cs.newOutboxDispatcher(outbox.NewMemory(), testOutboxPolicy)
+	conns := newConnManager(false)
+	t.Cleanup(func() { conns.Close() })
+	cs.dialDownstreams(conns)
+	return cs, comp
+}
+
+// dispatchOutbox delivers the side effects that are due, as the background
+// dispatcher would.
+func dispatchOutbox(t *testing.T, cs *checkoutService) {
+	t.Helper()
+	if err := cs.outbox.DispatchDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testPlaceOrderRequest(userID string) *pb.PlaceOrderRequest {
+	return &pb.PlaceOrderRequest{
+		UserId:       userID,
+		UserCurrency: "USD",
+		Email:        "someone@example.com",
+		Address: &pb.Address{
+			StreetAddress: "1600 Amphitheatre Parkway",
+			City:          "Mountain View",
+			State:         "CA",
+			Country:       "United States",
+			ZipCode:       94043,
+		},
+		CreditCard: &pb.CreditCardInfo{
+			CreditCardNumber:          "4432-8015-6152-0454",
+			CreditCardCvv:             672,
+			CreditCardExpirationYear:  2099,
+			CreditCardExpirationMonth: 1,
+		},
+	}
+}
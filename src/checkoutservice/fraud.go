@@ -0,0 +1,91 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/fraud"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/tracing"
+)
+
+const defaultFraudRulesPath = "fraud_rules.json"
+
+// loadFraudScorer loads the fraud rules file named by FRAUD_RULES_FILE. If it
+// is unset, fraud_rules.json is used if it exists and every order is accepted
+// otherwise.
+func loadFraudScorer() (fraud.Scorer, error) {
+	path := os.Getenv("FRAUD_RULES_FILE")
+	if path == "" {
+		if _, err := os.Stat(defaultFraudRulesPath); os.IsNotExist(err) {
+			log.Warnf("no fraud rules at %q, accepting every order", defaultFraudRulesPath)
+			return &fraud.Engine{}, nil
+		}
+		path = defaultFraudRulesPath
+	}
+	log.Infof("loading fraud rules from %q", path)
+	return fraud.Load(path)
+}
+
+// cardFingerprint identifies a credit card number without revealing it.
+func cardFingerprint(number string) string {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, number)
+	if digits == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(digits))
+	return hex.EncodeToString(sum[:16])
+}
+
+// screenOrder scores the order about to be charged total. If the scorer
+// fails, the order goes through but is flagged for review.
+func (cs *checkoutService) screenOrder(ctx context.Context, req *pb.PlaceOrderRequest, total pb.Money) fraud.Result {
+	billing := req.GetBillingAddress()
+	if billing == nil {
+		billing = req.GetAddress()
+	}
+	res, err := cs.fraud.Score(ctx, fraud.Order{
+		UserID:          req.GetUserId(),
+		CardFingerprint: cardFingerprint(req.GetCreditCard().GetCreditCardNumber()),
+		BillingCountry:  billing.GetCountry(),
+		ShippingCountry: req.GetAddress().GetCountry(),
+		Total:           total,
+	})
+	if err != nil {
+		log.Errorf("fraud screening failed, flagging the order for review: %+v", err)
+		tracing.Fallback(ctx, "review", err)
+		return fraud.Result{Decision: fraud.Review, Reasons: []string{"screening_failed"}}
+	}
+	return res
+}
+
+// fraudCheck converts res to the record kept with the order.
+func fraudCheck(res fraud.Result) *pb.FraudCheck {
+	return &pb.FraudCheck{
+		Decision: pb.FraudDecision(pb.FraudDecision_value[string(res.Decision)]),
+		Reasons:  res.Reasons,
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fraud screens orders for fraud before they are charged.
+package fraud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+// Decision is the outcome of screening an order.
+type Decision string
+
+const (
+	// Accept lets the order through.
+	Accept Decision = "ACCEPT"
+	// Review lets the order through but flags it to be looked at by hand.
+	Review Decision = "REVIEW"
+	// Reject refuses the order.
+	Reject Decision = "REJECT"
+)
+
+// severity orders decisions from the most to the least lenient.
+func (d Decision) severity() int {
+	switch d {
+	case Review:
+		return 1
+	case Reject:
+		return 2
+	}
+	return 0
+}
+
+func (d *Decision) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	switch v := Decision(strings.ToUpper(s)); v {
+	case Accept, Review, Reject:
+		*d = v
+		return nil
+	}
+	return fmt.Errorf("invalid decision %q", s)
+}
+
+// Order is what is known of an order when it is screened.
+type Order struct {
+	UserID string
+	// CardFingerprint identifies the credit card without revealing its
+	// number.
+	CardFingerprint string
+	BillingCountry  string
+	ShippingCountry string
+	// Total is the amount about to be charged.
+	Total pb.Money
+}
+
+// Result is the outcome of screening an order.
+type Result struct {
+	Decision Decision
+	// Reasons names the rules that led to the decision.
+	Reasons []string
+}
+
+// Scorer screens orders. Implementations must be safe for concurrent use.
+type Scorer interface {
+	Score(ctx context.Context, o Order) (Result, error)
+}
+
+// Amount is an amount of money in major units, such as 99.95. In JSON it is
+// written as a decimal number or string.
+type Amount struct {
+	big.Rat
+}
+
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	s := string(bytes.Trim(b, `"`))
+	if _, ok := a.SetString(s); !ok {
+		return fmt.Errorf("invalid amount %s", b)
+	}
+	return nil
+}
+
+// Velocity keys.
+const (
+	ByUser = "user_id"
+	ByCard = "card"
+)
+
+// VelocityRule limits how many orders a user or card may place in a window.
+type VelocityRule struct {
+	Name string `json:"name"`
+	// Key is ByUser or ByCard.
+	Key string `json:"key"`
+	// Window is a duration such as "1h".
+	Window string `json:"window"`
+	// Max is the number of orders allowed in the window. Orders beyond it get
+	// Decision.
+	Max      int      `json:"max"`
+	Decision Decision `json:"decision"`
+}
+
+// AmountThresholds flag orders of large amounts in one currency. Unset
+// thresholds do not apply.
+type AmountThresholds struct {
+	Review *Amount `json:"review,omitempty"`
+	Reject *Amount `json:"reject,omitempty"`
+}
+
+// Rules is the content of a fraud rules file.
+type Rules struct {
+	Velocity []VelocityRule `json:"velocity,omitempty"`
+
+	// CountryMismatch is the decision for orders whose billing and shipping
+	// countries differ. Empty accepts them.
+	CountryMismatch Decision `json:"country_mismatch,omitempty"`
+
+	// Amounts holds the thresholds of the order total, by currency code.
+	Amounts map[string]AmountThresholds `json:"amounts,omitempty"`
+}
+
+type velocityRule struct {
+	VelocityRule
+	window time.Duration
+}
+
+// Engine is a Scorer applying Rules. The zero Engine accepts every order.
+type Engine struct {
+	velocity        []velocityRule
+	countryMismatch Decision
+	amounts         map[string]AmountThresholds
+	now             func() time.Time
+
+	mu        sync.Mutex
+	seen      map[string][]time.Time // order times by velocity key
+	maxWindow time.Duration
+	lastSweep time.Time
+}
+
+// New returns an Engine applying rules.
+func New(rules Rules) (*Engine, error) {
+	e := &Engine{
+		countryMismatch: rules.CountryMismatch,
+		amounts:         make(map[string]AmountThresholds),
+		now:             time.Now,
+		seen:            make(map[string][]time.Time),
+	}
+	names := make(map[string]bool)
+	for i, r := range rules.Velocity {
+		if r.Name == "" {
+			return nil, fmt.Errorf("velocity rule #%d has no name", i)
+		}
+		if names[r.Name] {
+			return nil, fmt.Errorf("velocity rule %s is defined twice", r.Name)
+		}
+		names[r.Name] = true
+		if r.Key != ByUser && r.Key != ByCard {
+			return nil, fmt.Errorf("velocity rule %s: key must be %q or %q", r.Name, ByUser, ByCard)
+		}
+		w, err := time.ParseDuration(r.Window)
+		if err != nil || w <= 0 {
+			return nil, fmt.Errorf("velocity rule %s: invalid window %q", r.Name, r.Window)
+		}
+		if r.Max < 0 || r.Decision == "" {
+			return nil, fmt.Errorf("velocity rule %s needs a non-negative max and a decision", r.Name)
+		}
+		e.velocity = append(e.velocity, velocityRule{VelocityRule: r, window: w})
+		if w > e.maxWindow {
+			e.maxWindow = w
+		}
+	}
+	for c, t := range rules.Amounts {
+		if (t.Review != nil && t.Review.Sign() < 0) || (t.Reject != nil && t.Reject.Sign() < 0) {
+			return nil, fmt.Errorf("negative amount threshold for %s", c)
+		}
+		e.amounts[strings.ToUpper(c)] = t
+	}
+	return e, nil
+}
+
+// Load returns an Engine applying the rules in the JSON file at path.
+func Load(path string) (*Engine, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules Rules
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse fraud rules %s: %v", path, err)
+	}
+	return New(rules)
+}
+
+// Score screens o. The order counts towards the velocity limits whatever
+// the decision, so that repeated rejected attempts keep being rejected.
+func (e *Engine) Score(_ context.Context, o Order) (Result, error) {
+	var res Result
+	flag := func(d Decision, reason string) {
+		if d.severity() > res.Decision.severity() {
+			res.Decision = d
+		}
+		res.Reasons = append(res.Reasons, reason)
+	}
+	res.Decision = Accept
+
+	if len(e.velocity) > 0 {
+		for _, r := range e.velocityHits(o) {
+			flag(r.Decision, r.Name)
+		}
+	}
+
+	if e.countryMismatch != "" && o.BillingCountry != "" && o.ShippingCountry != "" &&
+		!strings.EqualFold(strings.TrimSpace(o.BillingCountry), strings.TrimSpace(o.ShippingCountry)) {
+		flag(e.countryMismatch, "country_mismatch")
+	}
+
+	if t, ok := e.amounts[strings.ToUpper(o.Total.GetCurrencyCode())]; ok {
+		total := toRat(o.Total)
+		switch {
+		case t.Reject != nil && total.Cmp(&t.Reject.Rat) >= 0:
+			flag(Reject, "amount_over_reject_threshold")
+		case t.Review != nil && total.Cmp(&t.Review.Rat) >= 0:
+			flag(Review, "amount_over_review_threshold")
+		}
+	}
+	sort.Strings(res.Reasons)
+	return res, nil
+}
+
+// velocityHits records o and returns the velocity rules it breaks.
+func (e *Engine) velocityHits(o Order) []velocityRule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	if now.Sub(e.lastSweep) > e.maxWindow {
+		e.sweepLocked(now)
+	}
+	keys := map[string]string{
+		ByUser: ByUser + ":" + o.UserID,
+		ByCard: ByCard + ":" + o.CardFingerprint,
+	}
+	for k, key := range keys {
+		if (k == ByUser && o.UserID == "") || (k == ByCard && o.CardFingerprint == "") {
+			delete(keys, k)
+			continue
+		}
+		e.seen[key] = append(e.seen[key], now)
+	}
+	var hits []velocityRule
+	for _, r := range e.velocity {
+		key, ok := keys[r.Key]
+		if !ok {
+			continue
+		}
+		var n int
+		for _, t := range e.seen[key] {
+			if now.Sub(t) < r.window {
+				n++
+			}
+		}
+		if n > r.Max {
+			hits = append(hits, r)
+		}
+	}
+	return hits
+}
+
+// sweepLocked forgets the orders older than every window.
+func (e *Engine) sweepLocked(now time.Time) {
+	for key, times := range e.seen {
+		i := 0
+		for i < len(times) && now.Sub(times[i]) >= e.maxWindow {
+			i++
+		}
+		if i == len(times) {
+			delete(e.seen, key)
+		} else {
+			e.seen[key] = times[i:]
+		}
+	}
+	e.lastSweep = now
+}
+
+func toRat(m pb.Money) *big.Rat {
+	r := new(big.Rat).SetInt64(m.GetUnits())
+	return r.Add(r, big.NewRat(int64(m.GetNanos()), 1e9))
+}
@@ -0,0 +1,186 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fraud
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+func amount(s string) *Amount {
+	var a Amount
+	if _, ok := a.SetString(s); !ok {
+		panic("invalid amount " + s)
+	}
+	return &a
+}
+
+func usd(units int64, nanos int32) pb.Money {
+	return pb.Money{CurrencyCode: "USD", Units: units, Nanos: nanos}
+}
+
+func TestZeroEngineAcceptsEverything(t *testing.T) {
+	var e Engine
+	res, err := e.Score(context.Background(), Order{UserID: "u1", BillingCountry: "US", ShippingCountry: "FR", Total: usd(1e9, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Decision != Accept || len(res.Reasons) != 0 {
+		t.Errorf("Score() = %+v, want accept", res)
+	}
+}
+
+func TestScore(t *testing.T) {
+	e, err := New(Rules{
+		CountryMismatch: Review,
+		Amounts: map[string]AmountThresholds{
+			"usd": {Review: amount("1000"), Reject: amount("10000")},
+			"JPY": {Reject: amount("1000000")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		o    Order
+		want Result
+	}{
+		{"small order", Order{BillingCountry: "US", ShippingCountry: "us", Total: usd(999, 990000000)},
+			Result{Decision: Accept}},
+		{"review threshold", Order{Total: usd(1000, 0)},
+			Result{Decision: Review, Reasons: []string{"amount_over_review_threshold"}}},
+		{"reject threshold", Order{Total: usd(10000, 0)},
+			Result{Decision: Reject, Reasons: []string{"amount_over_reject_threshold"}}},
+		{"threshold in another currency", Order{Total: pb.Money{CurrencyCode: "EUR", Units: 1e6}},
+			Result{Decision: Accept}},
+		{"country mismatch", Order{BillingCountry: "US", ShippingCountry: "FR", Total: usd(1, 0)},
+			Result{Decision: Review, Reasons: []string{"country_mismatch"}}},
+		{"most severe decision wins", Order{BillingCountry: "US", ShippingCountry: "FR", Total: usd(20000, 0)},
+			Result{Decision: Reject, Reasons: []string{"amount_over_reject_threshold", "country_mismatch"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := e.Score(context.Background(), tt.o)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Score() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVelocity(t *testing.T) {
+	e, err := New(Rules{Velocity: []VelocityRule{
+		{Name: "user_hourly", Key: ByUser, Window: "1h", Max: 2, Decision: Review},
+		{Name: "card_daily", Key: ByCard, Window: "24h", Max: 3, Decision: Reject},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+	score := func(o Order) Result {
+		t.Helper()
+		res, err := e.Score(context.Background(), o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	for i := 0; i < 2; i++ {
+		if res := score(Order{UserID: "u1", CardFingerprint: "c1"}); res.Decision != Accept {
+			t.Fatalf("order %d = %+v, want accept", i+1, res)
+		}
+	}
+	if res := score(Order{UserID: "u1", CardFingerprint: "c1"}); res.Decision != Review || !reflect.DeepEqual(res.Reasons, []string{"user_hourly"}) {
+		t.Errorf("third order of the user in an hour = %+v, want review for user_hourly", res)
+	}
+	// Another user is not held back by u1, but the card is shared.
+	if res := score(Order{UserID: "u2", CardFingerprint: "c1"}); res.Decision != Reject || !reflect.DeepEqual(res.Reasons, []string{"card_daily"}) {
+		t.Errorf("fourth order on the card in a day = %+v, want reject for card_daily", res)
+	}
+
+	now = now.Add(time.Hour)
+	if res := score(Order{UserID: "u1", CardFingerprint: "c2"}); res.Decision != Accept {
+		t.Errorf("order of the user an hour later = %+v, want accept", res)
+	}
+	now = now.Add(24 * time.Hour)
+	if res := score(Order{UserID: "u3", CardFingerprint: "c1"}); res.Decision != Accept {
+		t.Errorf("order on the card a day later = %+v, want accept", res)
+	}
+	if len(e.seen) != 2 {
+		t.Errorf("tracking %d keys after the windows passed, want 2", len(e.seen))
+	}
+}
+
+func TestNewValidatesRules(t *testing.T) {
+	for _, rules := range []Rules{
+		{Velocity: []VelocityRule{{Key: ByUser, Window: "1h", Decision: Review}}},
+		{Velocity: []VelocityRule{{Name: "r", Key: "ip", Window: "1h", Decision: Review}}},
+		{Velocity: []VelocityRule{{Name: "r", Key: ByUser, Window: "soon", Decision: Review}}},
+		{Velocity: []VelocityRule{{Name: "r", Key: ByUser, Window: "1h"}}},
+		{Amounts: map[string]AmountThresholds{"USD": {Review: amount("-1")}}},
+	} {
+		if _, err := New(rules); err == nil {
+			t.Errorf("New(%+v) succeeded", rules)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fraud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fraud_rules.json")
+	err = ioutil.WriteFile(path, []byte(`{
+		"velocity": [{"name": "user_hourly", "key": "user_id", "window": "1h", "max": 5, "decision": "review"}],
+		"country_mismatch": "REVIEW",
+		"amounts": {"USD": {"review": "500.50", "reject": 5000}}
+	}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := e.Score(context.Background(), Order{UserID: "u1", Total: usd(500, 500000000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Decision != Review {
+		t.Errorf("Score() = %+v, want review", res)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(`{"country_mismatch": "MAYBE"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() accepted an invalid decision")
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/fraud"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+func TestPlaceOrderRejectedByFraudScreening(t *testing.T) {
+	f := newFakeDownstream()
+	cs, _ := newTestCheckoutService(t, f)
+	cs.promos = newTestPromoEngine(t)
+	scorer, err := fraud.New(fraud.Rules{CountryMismatch: fraud.Reject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs.fraud = scorer
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	req := testPlaceOrderRequest("u1")
+	req.PromoCodes = []string{"ONCE"}
+	req.BillingAddress = &pb.Address{Country: "France"}
+	_, err = cs.PlaceOrder(context.Background(), req)
+	st := status.Convert(err)
+	if st.Code() != codes.PermissionDenied {
+		t.Fatalf("PlaceOrder() = %v, want PermissionDenied", err)
+	}
+	var info *errdetails.ErrorInfo
+	for _, d := range st.Details() {
+		if d, ok := d.(*errdetails.ErrorInfo); ok {
+			info = d
+		}
+	}
+	if info.GetReason() != reasonOrderRejected || info.GetMetadata()["rules"] != "country_mismatch" {
+		t.Errorf("error info = %v, want %s for country_mismatch", info, reasonOrderRejected)
+	}
+	if len(f.charges) != 0 || len(f.shipments) != 0 {
+		t.Errorf("charges = %v, shipments = %v; want none", f.charges, f.shipments)
+	}
+
+	// The single-use code was released along with the order.
+	req.BillingAddress = nil
+	if _, err := cs.PlaceOrder(context.Background(), req); err != nil {
+		t.Errorf("PlaceOrder() with matching countries = %v", err)
+	}
+}
+
+func TestPlaceOrderRecordsFraudCheck(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeDownstream()
+	cs, _ := newTestCheckoutService(t, f)
+	scorer, err := fraud.New(fraud.Rules{CountryMismatch: fraud.Review})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs.fraud = scorer
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	req := testPlaceOrderRequest("u1")
+	req.BillingAddress = &pb.Address{Country: "Canada"}
+	placed, err := cs.PlaceOrder(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.charges) != 1 {
+		t.Errorf("charged %d times, want once: review does not hold the order", len(f.charges))
+	}
+	rec, err := cs.GetOrder(ctx, &pb.GetOrderRequest{OrderId: placed.GetOrder().GetOrderId()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := rec.GetFraudCheck()
+	if check.GetDecision() != pb.FraudDecision_REVIEW || !reflect.DeepEqual(check.GetReasons(), []string{"country_mismatch"}) {
+		t.Errorf("fraud check = %v, want review for country_mismatch", check)
+	}
+}
+
+// TestDefaultFraudRulesAcceptDemoCard checks that the shipped rules do not
+// reject the card that the frontend and the load generator use for every
+// order.
+func TestDefaultFraudRulesAcceptDemoCard(t *testing.T) {
+	f := newFakeDownstream()
+	cs, _ := newTestCheckoutService(t, f)
+	scorer, err := fraud.Load(defaultFraudRulesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs.fraud = scorer
+
+	for i := 0; i < 100; i++ {
+		user := fmt.Sprintf("u%d", i)
+		f.cart[user] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+		if _, err := cs.PlaceOrder(context.Background(), testPlaceOrderRequest(user)); err != nil {
+			t.Fatalf("order %d with the demo card: %v", i+1, err)
+		}
+	}
+}
+
+func TestCardFingerprint(t *testing.T) {
+	a := cardFingerprint("4432-8015-6152-0454")
+	if a == "" || a != cardFingerprint("4432 8015 6152 0454") {
+		t.Errorf("fingerprints of the same card differ")
+	}
+	if a == cardFingerprint("4432-8015-6152-0455") {
+		t.Errorf("fingerprints of different cards are equal")
+	}
+	if cardFingerprint("") != "" {
+		t.Errorf("fingerprint of no card is not empty")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+const defaultIdempotencyWindow = 10 * time.Minute
+
+// idempotentCall is a PlaceOrder attempt that is either in flight or has
+// completed within the idempotency window, successfully or after charging
+// the card.
+type idempotentCall struct {
+	done    chan struct{}
+	resp    *pb.PlaceOrderResponse
+	err     error
+	expires time.Time
+}
+
+type idempotencyExpiry struct {
+	key     string
+	call    *idempotentCall
+	expires time.Time
+}
+
+// idempotencyCache deduplicates PlaceOrder requests that carry the same
+// idempotency key. Successful results are remembered for the configured
+// window, and so are failures after the card was charged, as retrying those
+// could charge it again. Other failed attempts are forgotten so the client
+// can retry them.
+type idempotencyCache struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu     sync.Mutex
+	calls  map[string]*idempotentCall
+	expiry []idempotencyExpiry // ordered by expiry, as the window is fixed
+}
+
+func newIdempotencyCache(window time.Duration) *idempotencyCache {
+	return &idempotencyCache{
+		window: window,
+		now:    time.Now,
+		calls:  make(map[string]*idempotentCall),
+	}
+}
+
+// do returns the result of the first call made with key. If no such call is
+// in flight or remembered, fn is run and its result is shared with every
+// concurrent caller using the same key.
+func (c *idempotencyCache) do(ctx context.Context, key string, fn func() (*pb.PlaceOrderResponse, error)) (*pb.PlaceOrderResponse, error) {
+	c.mu.Lock()
+	c.evictLocked()
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		log.Infof("duplicate PlaceOrder request (idempotency key %q)", key)
+		select {
+		case <-call.done:
+			return call.resp, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &idempotentCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	call.resp, call.err = fn()
+
+	c.mu.Lock()
+	if call.err != nil && !isCharged(call.err) {
+		delete(c.calls, key)
+	} else {
+		call.expires = c.now().Add(c.window)
+		c.expiry = append(c.expiry, idempotencyExpiry{key: key, call: call, expires: call.expires})
+	}
+	c.mu.Unlock()
+	close(call.done)
+	return call.resp, call.err
+}
+
+// evictLocked drops the remembered results whose window has passed.
+func (c *idempotencyCache) evictLocked() {
+	now := c.now()
+	n := 0
+	for ; n < len(c.expiry) && !now.Before(c.expiry[n].expires); n++ {
+		e := c.expiry[n]
+		if c.calls[e.key] == e.call {
+			delete(c.calls, e.key)
+		}
+	}
+	c.expiry = c.expiry[n:]
+}
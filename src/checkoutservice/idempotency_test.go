@@ -0,0 +1,199 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+func TestIdempotencyCacheSharesInFlightCall(t *testing.T) {
+	c := newIdempotencyCache(time.Minute)
+	release := make(chan struct{})
+	var calls int
+	fn := func() (*pb.PlaceOrderResponse, error) {
+		calls++
+		<-release
+		return &pb.PlaceOrderResponse{Order: &pb.OrderResult{OrderId: "o1"}}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]*pb.PlaceOrderResponse, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = c.do(context.Background(), "k", fn)
+		}(i)
+	}
+	// Give the goroutines a chance to queue up behind the first call.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("fn called %d times, want 1", calls)
+	}
+	for i, r := range results {
+		if r.GetOrder().GetOrderId() != "o1" {
+			t.Errorf("result #%d = %v, want order o1", i, r)
+		}
+	}
+}
+
+func TestIdempotencyCacheForgetsFailures(t *testing.T) {
+	c := newIdempotencyCache(time.Minute)
+	errFail := errors.New("declined")
+	if _, err := c.do(context.Background(), "k", func() (*pb.PlaceOrderResponse, error) { return nil, errFail }); err != errFail {
+		t.Fatalf("do() = %v, want %v", err, errFail)
+	}
+	resp, err := c.do(context.Background(), "k", func() (*pb.PlaceOrderResponse, error) {
+		return &pb.PlaceOrderResponse{Order: &pb.OrderResult{OrderId: "o2"}}, nil
+	})
+	if err != nil || resp.GetOrder().GetOrderId() != "o2" {
+		t.Errorf("retry after failure = (%v, %v), want order o2", resp, err)
+	}
+}
+
+func TestIdempotencyCacheRemembersChargedFailures(t *testing.T) {
+	c := newIdempotencyCache(time.Minute)
+	errCharged := chargedError(errors.New("shipping failed"))
+	var calls int
+	fn := func() (*pb.PlaceOrderResponse, error) {
+		calls++
+		return nil, errCharged
+	}
+	c.do(context.Background(), "k", fn)
+	if _, err := c.do(context.Background(), "k", fn); err != errCharged {
+		t.Errorf("retry after charged failure = %v, want %v", err, errCharged)
+	}
+	if calls != 1 {
+		t.Errorf("fn called %d times, want 1", calls)
+	}
+}
+
+func TestIdempotencyCacheExpires(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := newIdempotencyCache(time.Minute)
+	c.now = func() time.Time { return now }
+
+	var calls int
+	fn := func() (*pb.PlaceOrderResponse, error) {
+		calls++
+		return &pb.PlaceOrderResponse{}, nil
+	}
+	c.do(context.Background(), "k", fn)
+	now = now.Add(59 * time.Second)
+	c.do(context.Background(), "k", fn)
+	if calls != 1 {
+		t.Fatalf("fn called %d times within the window, want 1", calls)
+	}
+	now = now.Add(time.Second)
+	c.do(context.Background(), "k", fn)
+	if calls != 2 {
+		t.Errorf("fn called %d times after the window, want 2", calls)
+	}
+}
+
+func TestIdempotencyCacheWaiterHonoursContext(t *testing.T) {
+	c := newIdempotencyCache(time.Minute)
+	release := make(chan struct{})
+	defer close(release)
+	go c.do(context.Background(), "k", func() (*pb.PlaceOrderResponse, error) {
+		<-release
+		return &pb.PlaceOrderResponse{}, nil
+	})
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.do(ctx, "k", nil); err != context.DeadlineExceeded {
+		t.Errorf("do() = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestPlaceOrderDuplicateKeyChargesOnce(t *testing.T) {
+	f := newFakeDownstream()
+	cs, _ := newTestCheckoutService(t, f)
+	cs.idempotency = newIdempotencyCache(time.Minute)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	req := testPlaceOrderRequest("u1")
+	req.IdempotencyKey = "form-token"
+	first, err := cs.PlaceOrder(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := cs.PlaceOrder(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.GetOrder().GetOrderId() != second.GetOrder().GetOrderId() {
+		t.Errorf("order ids differ: %q vs %q", first.GetOrder().GetOrderId(), second.GetOrder().GetOrderId())
+	}
+	if len(f.charges) != 1 {
+		t.Errorf("card charged %d times, want 1", len(f.charges))
+	}
+}
+
+func TestPlaceOrderDuplicateKeyAfterChargedFailureChargesOnce(t *testing.T) {
+	f := newFakeDownstream()
+	f.shipErr = status.Error(codes.Unavailable, "no trucks")
+	cs, _ := newTestCheckoutService(t, f)
+	cs.compensator = serviceCompensator{cs: cs}
+	cs.idempotency = newIdempotencyCache(time.Minute)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	req := testPlaceOrderRequest("u1")
+	req.IdempotencyKey = "form-token"
+	_, first := cs.PlaceOrder(context.Background(), req)
+	if first == nil {
+		t.Fatal("PlaceOrder() succeeded, want a shipping failure")
+	}
+	f.shipErr = nil
+	_, second := cs.PlaceOrder(context.Background(), req)
+	if status.Code(second) != status.Code(first) {
+		t.Errorf("retry = %v, want the first outcome %v", second, first)
+	}
+	if len(f.charges) != 1 {
+		t.Errorf("card charged %d times, want 1", len(f.charges))
+	}
+}
+
+func TestPlaceOrderDuplicateKeyAfterDeclineRetries(t *testing.T) {
+	f := newFakeDownstream()
+	f.chargeErr = status.Error(codes.InvalidArgument, "Credit card info is invalid")
+	cs, _ := newTestCheckoutService(t, f)
+	cs.idempotency = newIdempotencyCache(time.Minute)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	req := testPlaceOrderRequest("u1")
+	req.IdempotencyKey = "form-token"
+	if _, err := cs.PlaceOrder(context.Background(), req); err == nil {
+		t.Fatal("PlaceOrder() succeeded, want a declined card")
+	}
+	f.chargeErr = nil
+	if _, err := cs.PlaceOrder(context.Background(), req); err != nil {
+		t.Errorf("retry after a declined card = %v, want success", err)
+	}
+}
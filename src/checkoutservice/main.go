@@ -16,30 +16,32 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"net"
 	"os"
 	"time"
-	"strings"
 	"errors"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
-	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
-	pb "github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/genproto"
-	money "github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/money"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/fraud"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/orderstore"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/outbox"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/promo"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/tax"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/fake"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/faultinject"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/grpcutil"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/logging"
+	money "github.com/GoogleCloudPlatform/microservices-demo/src/common/money"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/resilience"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/tracing"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
-
-	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/jaeger"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	tracesdk "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 )
 
 const (
@@ -50,32 +52,49 @@ const (
 var log *logrus.Logger
 
 func init() {
-	log = logrus.New()
+	log = logging.New()
 	log.Level = logrus.DebugLevel
-	log.Formatter = &logrus.JSONFormatter{
-		FieldMap: logrus.FieldMap{
-			logrus.FieldKeyTime:  "timestamp",
-			logrus.FieldKeyLevel: "severity",
-			logrus.FieldKeyMsg:   "message",
-		},
-		TimestampFormat: time.RFC3339Nano,
-	}
-	log.Out = os.Stdout
 }
 
 type checkoutService struct {
 	productCatalogSvcAddr string
-	cartSvcAddr           string
-	currencySvcAddr       string
-	shippingSvcAddr       string
-	emailSvcAddr          string
-	paymentSvcAddr        string
+	productCatalogSvc     pb.ProductCatalogServiceClient
+
+	cartSvcAddr string
+	cartSvc     pb.CartServiceClient
+
+	currencySvcAddr string
+	currencySvc     pb.CurrencyServiceClient
+
+	shippingSvcAddr string
+	shippingSvc     pb.ShippingServiceClient
+
+	emailSvcAddr string
+	emailSvc     pb.EmailServiceClient
+
+	paymentSvcAddr string
+	paymentSvc     pb.PaymentServiceClient
+
+	compensator compensator
+	idempotency *idempotencyCache
+	orders      orderstore.Store
+	taxes       *tax.Engine
+	promos      *promo.Engine
+	fraud       fraud.Scorer
+	outbox      *outbox.Dispatcher
+
+	resilience  resilience.Config
+	downstreams *expvar.Map // resilience metrics by downstream
+	fakes       fake.Config // of the downstreams that are faked
+	budget      budgetPolicy
 }
 
 func main() {
-	if os.Getenv("DISABLE_TRACING") == "" {
+	if tracing.Enabled() {
 		log.Info("Tracing enabled.")
-		initOpenTelemetry(log)
+		if _, err := tracing.Init("checkoutservice", log); err != nil {
+			log.Fatal(err)
+		}
 
 	} else {
 		log.Info("Tracing disabled.")
@@ -87,92 +106,102 @@ func main() {
 	}
 
 	svc := new(checkoutService)
-	if os.Getenv("SHIPPING_SVC_DISABLED") == "" {
-		mustMapEnv(&svc.shippingSvcAddr, "SHIPPING_SERVICE_ADDR")
-	}
-	mustMapEnv(&svc.productCatalogSvcAddr, "PRODUCT_CATALOG_SERVICE_ADDR")
-	mustMapEnv(&svc.cartSvcAddr, "CART_SERVICE_ADDR")
-	mustMapEnv(&svc.currencySvcAddr, "CURRENCY_SERVICE_ADDR")
-	if os.Getenv("EMAIL_SVC_DISABLED") == "" {
-		mustMapEnv(&svc.emailSvcAddr, "EMAIL_SERVICE_ADDR")
-	}
-	if os.Getenv("PAYMENT_SVC_DISABLED") == "" {
-		mustMapEnv(&svc.paymentSvcAddr, "PAYMENT_SERVICE_ADDR")
-	}
+	svc.compensator = serviceCompensator{cs: svc}
 
-	log.Infof("service config: %+v", svc)
+	idempotencyWindow := defaultIdempotencyWindow
+	if s := os.Getenv("IDEMPOTENCY_WINDOW"); s != "" {
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			log.Fatalf("failed to parse IDEMPOTENCY_WINDOW (%s) as time.Duration: %+v", s, err)
+		}
+		idempotencyWindow = v
+	}
+	svc.idempotency = newIdempotencyCache(idempotencyWindow)
 
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+	orders, err := openOrderStore()
 	if err != nil {
 		log.Fatal(err)
 	}
+	svc.orders = orders
 
-	var srv *grpc.Server
-
-	if os.Getenv("DISABLE_TRACING") == "" {
-		srv = grpc.NewServer(
-			grpc.UnaryInterceptor(otelgrpc.UnaryServerInterceptor()),
-			grpc.StreamInterceptor(otelgrpc.StreamServerInterceptor()),
-		)
-	} else {
-		srv = grpc.NewServer()
+	taxes, err := loadTaxEngine()
+	if err != nil {
+		log.Fatalf("failed to load tax rules: %+v", err)
 	}
+	svc.taxes = taxes
 
+	promos, err := loadPromoEngine()
+	if err != nil {
+		log.Fatalf("failed to load promotions: %+v", err)
+	}
+	svc.promos = promos
 
-	pb.RegisterCheckoutServiceServer(srv, svc)
-	healthpb.RegisterHealthServer(srv, svc)
-	log.Infof("starting to listen on tcp: %q", lis.Addr().String())
-	err = srv.Serve(lis)
-	log.Fatal(err)
-}
-
-// for reference, see also:
-// https://github.com/open-telemetry/opentelemetry-go/blob/main/example/jaeger/main.go
-func createTracerProvider(log logrus.FieldLogger) (*tracesdk.TracerProvider, error) {
-
-	// Create the Jaeger exporter
-
-	svcAddr := os.Getenv("JAEGER_SERVICE_ADDR")
-	if svcAddr == "" {
-		return nil, errors.New("missing JAEGER_SERVICE_ADDR, can't initialize Jaeger exporter")
+	scorer, err := loadFraudScorer()
+	if err != nil {
+		log.Fatalf("failed to load fraud rules: %+v", err)
 	}
+	svc.fraud = scorer
 
-	splitJaegerAddr := strings.Split(svcAddr, ":")
-	jaegerAgentHost := splitJaegerAddr[0]
-	jaegerAgentPort := splitJaegerAddr[1]
+	outboxStore, err := openOutbox()
+	if err != nil {
+		log.Fatal(err)
+	}
+	svc.outbox = svc.newOutboxDispatcher(outboxStore, outbox.DefaultPolicy)
+	expvar.Publish("outbox", svc.outbox.Metrics())
 
-	exporter, err := jaeger.New(jaeger.WithAgentEndpoint(jaeger.WithAgentHost(jaegerAgentHost), jaeger.WithAgentPort(jaegerAgentPort)));
+	resilienceConfig, err := loadResilienceConfig()
 	if err != nil {
-		return nil, err
+		log.Fatalf("failed to load resilience policies: %+v", err)
 	}
+	svc.resilience = resilienceConfig
 
-	log.Info("created jaeger exporter to agent at " + svcAddr)
+	budget, err := loadBudgetPolicy()
+	if err != nil {
+		log.Fatalf("failed to load deadline budget policy: %+v", err)
+	}
+	svc.budget = budget
+	svc.downstreams = new(expvar.Map).Init()
+	expvar.Publish("downstreams", svc.downstreams)
+	if addr := os.Getenv("METRICS_ADDR"); addr != "" {
+		go serveMetrics(addr)
+	}
 
-	tp := tracesdk.NewTracerProvider(
-		tracesdk.WithBatcher(exporter, tracesdk.WithMaxExportBatchSize(95)),
-		// see https://pkg.go.dev/go.opentelemetry.io/otel/sdk/trace#ParentBased
-		tracesdk.WithSampler(tracesdk.ParentBased(tracesdk.AlwaysSample())),
-		tracesdk.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String("checkoutservice"),
-		)),
-	)
-	return tp, nil
-}
+	fakes, err := loadFakes()
+	if err != nil {
+		log.Fatalf("failed to load fakes: %+v", err)
+	}
+	svc.fakes = fakes
 
-func initOpenTelemetry(log logrus.FieldLogger) {
+	svc.mustMapDownstreamEnv(&svc.shippingSvcAddr, "shipping", "SHIPPING_SERVICE_ADDR")
+	svc.mustMapDownstreamEnv(&svc.productCatalogSvcAddr, "productcatalog", "PRODUCT_CATALOG_SERVICE_ADDR")
+	svc.mustMapDownstreamEnv(&svc.cartSvcAddr, "cart", "CART_SERVICE_ADDR")
+	svc.mustMapDownstreamEnv(&svc.currencySvcAddr, "currency", "CURRENCY_SERVICE_ADDR")
+	svc.mustMapDownstreamEnv(&svc.emailSvcAddr, "email", "EMAIL_SERVICE_ADDR")
+	svc.mustMapDownstreamEnv(&svc.paymentSvcAddr, "payment", "PAYMENT_SERVICE_ADDR")
 
+	log.Infof("service config: %+v", svc)
 
+	conns := newConnManager(tracing.Enabled())
+	svc.dialDownstreams(conns)
+	go svc.outbox.Run(context.Background())
 
-	tp, err := createTracerProvider(log)
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	otel.SetTracerProvider(tp)
-
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	faults, err := faultinject.FromEnv(log)
+	if err != nil {
+		log.Fatalf("failed to load faults: %+v", err)
+	}
+	srv := grpcutil.NewServer(tracing.Enabled(), faults.ServerOptions()...)
 
+	pb.RegisterCheckoutServiceServer(srv, svc)
+	healthpb.RegisterHealthServer(srv, svc)
+	log.Infof("starting to listen on tcp: %q", lis.Addr().String())
+	if err := grpcutil.Serve(srv, lis, log); err != nil {
+		log.Fatal(err)
+	}
 }
 
 func mustMapEnv(target *string, envKey string) {
@@ -194,37 +223,125 @@ func (cs *checkoutService) Watch(req *healthpb.HealthCheckRequest, ws healthpb.H
 func (cs *checkoutService) PlaceOrder(ctx context.Context, req *pb.PlaceOrderRequest) (*pb.PlaceOrderResponse, error) {
 	log.Infof("[PlaceOrder] user_id=%q user_currency=%q", req.UserId, req.UserCurrency)
 
+	if req.GetIdempotencyKey() == "" || cs.idempotency == nil {
+		return cs.placeOrder(ctx, req)
+	}
+	// Keys are chosen by clients, so scope them to the user placing the order.
+	key := req.GetUserId() + "/" + req.GetIdempotencyKey()
+	return cs.idempotency.do(ctx, key, func() (*pb.PlaceOrderResponse, error) {
+		return cs.placeOrder(ctx, req)
+	})
+}
+
+func (cs *checkoutService) placeOrder(ctx context.Context, req *pb.PlaceOrderRequest) (resp *pb.PlaceOrderResponse, err error) {
+	if violations := validateOrderRequest(req, time.Now()); len(violations) > 0 {
+		return nil, invalidOrderError(reasonInvalidOrder, violations)
+	}
+
 	orderID, err := uuid.NewUUID()
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to generate order uuid")
-	}
+		return nil, internalError(err, "failed to generate order uuid")
+	}
+
+	tracing.SetAttributes(ctx,
+		tracing.OrderIDKey.String(orderID.String()),
+		tracing.UserIDKey.String(req.GetUserId()),
+		tracing.MoneyCurrencyKey.String(req.GetUserCurrency()),
+		tracing.EmailKey.String(req.GetEmail()))
+	sg := newSaga(orderID.String())
+	ctx, cancel, budget := cs.startBudget(ctx, placeOrderStages)
+	defer cancel()
+
+	// Once the card is charged, failing the order is final: a retry with the
+	// same idempotency key must not charge it again.
+	charged := false
+	defer func() {
+		if err != nil && charged {
+			err = chargedError(err)
+		}
+	}()
+
+	prep, err := cs.prepareOrderItemsAndShippingQuoteFromCart(ctx, budget, req.UserId, req.UserCurrency, req.Address, req.PromoCodes)
+	if err != nil {
+		return nil, prepareError(err)
+	}
+	var releasePromos func()
+	err = traceStage(ctx, "redeem", func(context.Context) (err error) {
+		releasePromos, err = cs.promos.Redeem(prep.discounts)
+		return err
+	})
+	var codeErr *promo.CodeError
+	if errors.As(err, &codeErr) {
+		return nil, invalidOrderError(reasonPromoCodeInvalid, orderViolations{{Field: "promo_codes", Description: codeErr.Error()}})
+	} else if err != nil {
+		return nil, internalError(err, "failed to redeem promo codes: %+v", err)
+	}
+	sg.complete(stepQuote, func(context.Context) error {
+		releasePromos()
+		return nil
+	})
 
-	prep, err := cs.prepareOrderItemsAndShippingQuoteFromCart(ctx, req.UserId, req.UserCurrency, req.Address)
+	total, err := prep.total(req.UserCurrency)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, err.Error())
+		sg.abort(ctx)
+		return nil, internalError(err, "failed to total order: %+v", err)
 	}
+	tracing.SetAttributes(ctx, tracing.Money(&total)...)
 
-	total := pb.Money{CurrencyCode: req.UserCurrency,
-		Units: 0,
-		Nanos: 0}
-	total = money.Must(money.Sum(total, *prep.shippingCostLocalized))
-	for _, it := range prep.orderItems {
-		multPrice := money.MultiplySlow(*it.Cost, uint32(it.GetItem().GetQuantity()))
-		total = money.Must(money.Sum(total, multPrice))
+	var screening fraud.Result
+	err = traceStage(ctx, "fraud", func(ctx context.Context) error {
+		screening = cs.screenOrder(ctx, req, total)
+		tracing.SetAttributes(ctx, fraudDecisionKey.String(string(screening.Decision)))
+		if screening.Decision == fraud.Reject {
+			return orderRejectedError(screening.Reasons)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warnf("[order %s] rejected by fraud screening: %v", orderID, screening.Reasons)
+		sg.abort(ctx)
+		return nil, err
+	}
+	if screening.Decision == fraud.Review {
+		log.Warnf("[order %s] flagged for fraud review: %v", orderID, screening.Reasons)
 	}
 
-	txID, err := cs.chargeCard(ctx, &total, req.CreditCard)
+	// Cutting the charge short would not cancel it, only leave its outcome
+	// unknown, so it may take all the time left.
+	var txID string
+	err = budget.runToDeadline(ctx, budgetCharge, func(ctx context.Context) (err error) {
+		txID, err = cs.chargeCard(ctx, &total, req.CreditCard)
+		return err
+	})
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to charge card: %+v", err)
+		if isCharged(err) {
+			log.Errorf("[order %s] outcome of the charge of %d.%09d %s unknown, it may have to be refunded by hand: %+v",
+				orderID, total.GetUnits(), total.GetNanos(), total.GetCurrencyCode(), err)
+		}
+		sg.abort(ctx)
+		return nil, asCheckoutError(err)
 	}
+	charged = true
 	log.Infof("payment went through (transaction_id: %s)", txID)
-
-	shippingTrackingID, err := cs.shipOrder(ctx, req.Address, prep.cartItems)
+	sg.complete(stepCharge, func(ctx context.Context) error {
+		return cs.compensator.refundPayment(ctx, txID, &total)
+	})
+
+	var shippingTrackingID string
+	err = budget.run(ctx, budgetShip, func(ctx context.Context) (err error) {
+		shippingTrackingID, err = cs.shipOrder(ctx, req.Address, prep.cartItems)
+		return err
+	})
 	if err != nil {
-		return nil, status.Errorf(codes.Unavailable, "shipping error: %+v", err)
+		if cerr := sg.abort(ctx); cerr != nil {
+			return nil, internalError(err, "shipping error: %+v (compensation failed: %+v)", err, cerr)
+		}
+		return nil, asCheckoutError(err)
 	}
-
-	_ = cs.emptyUserCart(ctx, req.UserId)
+	tracing.SetAttributes(ctx, tracing.ShippingTrackingIDKey.String(shippingTrackingID))
+	sg.complete(stepShip, func(ctx context.Context) error {
+		return cs.compensator.cancelShipment(ctx, shippingTrackingID)
+	})
 
 	orderResult := &pb.OrderResult{
 		OrderId:            orderID.String(),
@@ -232,266 +349,236 @@ func (cs *checkoutService) PlaceOrder(ctx context.Context, req *pb.PlaceOrderReq
 		ShippingCost:       prep.shippingCostLocalized,
 		ShippingAddress:    req.Address,
 		Items:              prep.orderItems,
+		TotalTax:           &prep.tax.Total,
+		TaxInclusive:       prep.tax.Inclusive,
+		Discounts:          orderDiscounts(prep.discounts),
+		TotalDiscount:      &prep.discounts.Total,
+		Total:              &total,
+	}
+
+	// An order that cannot be looked up later is rolled back.
+	err = traceStage(ctx, "store", func(ctx context.Context) error {
+		return cs.orders.Save(ctx, &pb.OrderRecord{
+			Order:      orderResult,
+			UserId:     req.UserId,
+			PlacedAt:   time.Now().Unix(),
+			FraudCheck: fraudCheck(screening),
+		})
+	})
+	if err != nil {
+		if cerr := sg.abort(ctx); cerr != nil {
+			return nil, internalError(err, "failed to store order: %+v (compensation failed: %+v)", err, cerr)
+		}
+		return nil, internalError(err, "failed to store order: %+v", err)
+	}
+	sg.complete(stepStore, func(ctx context.Context) error {
+		return cs.orders.Delete(ctx, orderResult.OrderId)
+	})
+
+	// Emptying the cart and sending the confirmation are left to the outbox
+	// dispatcher, so that a slow or failing service does not hold up the
+	// order, and failures are retried instead of lost. An order whose side
+	// effects cannot be queued would never be confirmed, so it is rolled
+	// back.
+	err = traceStage(ctx, "outbox", func(ctx context.Context) error {
+		msgs, err := orderSideEffects(req, orderResult)
+		if err != nil {
+			return err
+		}
+		return cs.outbox.Enqueue(ctx, msgs...)
+	})
+	if err != nil {
+		if cerr := sg.abort(ctx); cerr != nil {
+			return nil, internalError(err, "failed to queue cart emptying and confirmation: %+v (compensation failed: %+v)", err, cerr)
+		}
+		return nil, internalError(err, "failed to queue cart emptying and confirmation: %+v", err)
 	}
-
-	if err := cs.sendOrderConfirmation(ctx, req.Email, orderResult); err != nil {
-		log.Warnf("failed to send order confirmation to %q: %+v", req.Email, err)
-	} else {
-		log.Infof("order confirmation email sent to %q", req.Email)
-	}
-	resp := &pb.PlaceOrderResponse{Order: orderResult}
-	return resp, nil
+	sg.complete(stepOutbox, nil)
+	log.Debugf("[order %s] completed steps: %v", orderResult.OrderId, sg.steps())
+	return &pb.PlaceOrderResponse{Order: orderResult}, nil
 }
 
 type orderPrep struct {
 	orderItems            []*pb.OrderItem
 	cartItems             []*pb.CartItem
 	shippingCostLocalized *pb.Money
+	discounts             promo.Result
+	tax                   tax.Result
 }
 
-func (cs *checkoutService) prepareOrderItemsAndShippingQuoteFromCart(ctx context.Context, userID, userCurrency string, address *pb.Address) (orderPrep, error) {
-	var out orderPrep
-	cartItems, err := cs.getUserCart(ctx, userID)
+// total returns the amount to charge for the order.
+func (p orderPrep) total(currency string) (pb.Money, error) {
+	total := pb.Money{CurrencyCode: currency,
+		Units: 0,
+		Nanos: 0}
+	total, err := money.Sum(total, *p.shippingCostLocalized)
 	if err != nil {
-		return out, fmt.Errorf("cart failure: %+v", err)
+		return pb.Money{}, err
 	}
-	orderItems, err := cs.prepOrderItems(ctx, cartItems, userCurrency)
+	for _, it := range p.orderItems {
+		multPrice, err := money.Multiply(*it.Cost, int64(it.GetItem().GetQuantity()))
+		if err != nil {
+			return pb.Money{}, err
+		}
+		if total, err = money.Sum(total, multPrice); err != nil {
+			return pb.Money{}, err
+		}
+	}
+	if total, err = money.Subtract(total, p.discounts.Total); err != nil {
+		return pb.Money{}, err
+	}
+	if !p.tax.Inclusive {
+		if total, err = money.Sum(total, p.tax.Total); err != nil {
+			return pb.Money{}, err
+		}
+	}
+	return money.Round(total, priceRounding)
+}
+
+// prepareError converts an error of prepareOrderItemsAndShippingQuoteFromCart
+// to the error returned to clients.
+func prepareError(err error) error {
+	var codeErr *promo.CodeError
+	if err == errEmptyCart {
+		return invalidOrderError(reasonCartEmpty, orderViolations{{Field: "cart", Description: "cart is empty"}})
+	} else if errors.As(err, &codeErr) {
+		return invalidOrderError(reasonPromoCodeInvalid, orderViolations{{Field: "promo_codes", Description: codeErr.Error()}})
+	}
+	return asCheckoutError(err)
+}
+
+// prepareOrderItemsAndShippingQuoteFromCart prices the cart of userID,
+// giving each call the slice of budget of its stage.
+func (cs *checkoutService) prepareOrderItemsAndShippingQuoteFromCart(ctx context.Context, budget *deadlineBudget, userID, userCurrency string, address *pb.Address, promoCodes []string) (orderPrep, error) {
+	var out orderPrep
+	var cartItems []*pb.CartItem
+	err := budget.run(ctx, budgetCart, func(ctx context.Context) (err error) {
+		cartItems, err = cs.getUserCart(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return out, fmt.Errorf("cart failure: %w", err)
+	}
+	tracing.SetAttributes(ctx, tracing.CartItemsKey.Int(cartQuantity(cartItems)))
+	if len(cartItems) == 0 {
+		return out, errEmptyCart
+	}
+	var orderItems []*pb.OrderItem
+	var products map[string]*pb.Product
+	err = budget.run(ctx, budgetCatalog, func(ctx context.Context) (err error) {
+		orderItems, products, err = cs.prepOrderItems(ctx, cartItems, userCurrency)
+		return err
+	})
 	if err != nil {
-		return out, fmt.Errorf("failed to prepare order: %+v", err)
+		return out, fmt.Errorf("failed to prepare order: %w", err)
 	}
-	shippingUSD, err := cs.quoteShipping(ctx, address, cartItems)
+	var shippingUSD *pb.Money
+	err = budget.run(ctx, budgetQuote, func(ctx context.Context) (err error) {
+		shippingUSD, err = cs.quoteShipping(ctx, address, cartItems)
+		return err
+	})
 	if err != nil {
-		return out, fmt.Errorf("shipping quote failure: %+v", err)
+		return out, fmt.Errorf("shipping quote failure: %w", err)
 	}
-	shippingPrice, err := cs.convertCurrency(ctx, shippingUSD, userCurrency)
+	var shippingPrice *pb.Money
+	err = budget.run(ctx, budgetCurrency, func(ctx context.Context) (err error) {
+		shippingPrice, err = cs.convertCurrency(ctx, shippingUSD, userCurrency)
+		if err != nil {
+			return err
+		}
+		shippingPrice, err = roundPrice(shippingPrice)
+		return err
+	})
 	if err != nil {
-		return out, fmt.Errorf("failed to convert shipping cost to currency: %+v", err)
+		return out, fmt.Errorf("failed to convert shipping cost to currency: %w", err)
+	}
+	var discounts promo.Result
+	err = traceStage(ctx, "promotions", func(context.Context) (err error) {
+		discounts, err = cs.applyPromotions(userID, userCurrency, orderItems, products, shippingPrice, promoCodes)
+		return err
+	})
+	var codeErr *promo.CodeError
+	if errors.As(err, &codeErr) {
+		return out, err
+	} else if err != nil {
+		return out, fmt.Errorf("failed to apply promotions: %w", err)
+	}
+	var orderTax tax.Result
+	err = traceStage(ctx, "tax", func(context.Context) (err error) {
+		orderTax, err = cs.taxOrderItems(orderItems, products, address, userCurrency)
+		return err
+	})
+	if err != nil {
+		return out, fmt.Errorf("failed to compute tax: %w", err)
 	}
 
 	out.shippingCostLocalized = shippingPrice
 	out.cartItems = cartItems
 	out.orderItems = orderItems
+	out.discounts = discounts
+	out.tax = orderTax
 	return out, nil
 }
 
 func (cs *checkoutService) quoteShipping(ctx context.Context, address *pb.Address, items []*pb.CartItem) (*pb.Money, error) {
-
-	if os.Getenv("SHIPPING_SVC_DISABLED") != "" {
-		log.Info("Shipping service disabled. Mocking call, always 5.00 USD shipping quote.")
-
-		return &pb.Money{
-				CurrencyCode: "USD",
-				Units:        int64(5),
-				Nanos:        int32(0)},
-			error(nil)
-	}
-
-	var conn *grpc.ClientConn
-	var err error
-	if os.Getenv("DISABLE_TRACING") == "" {
-		conn, err = grpc.DialContext(ctx, cs.shippingSvcAddr,
-			grpc.WithInsecure(),
-			grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()), grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()))
-	} else {
-		conn, err = grpc.DialContext(ctx, cs.shippingSvcAddr,
-			grpc.WithInsecure())
-	}
-
-	if err != nil {
-		return nil, fmt.Errorf("could not connect shipping service: %+v", err)
-	}
-	defer conn.Close()
-
-	shippingQuote, err := pb.NewShippingServiceClient(conn).
+	shippingQuote, err := cs.shippingSvc.
 		GetQuote(ctx, &pb.GetQuoteRequest{
 			Address: address,
 			Items:   items})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get shipping quote: %+v", err)
+		return nil, shippingError(err)
 	}
 	return shippingQuote.GetCostUsd(), nil
 }
 
 func (cs *checkoutService) getUserCart(ctx context.Context, userID string) ([]*pb.CartItem, error) {
-	var conn *grpc.ClientConn
-	var err error
-	if os.Getenv("DISABLE_TRACING") == "" {
-		conn, err = grpc.DialContext(ctx, cs.cartSvcAddr, grpc.WithInsecure(),
-			grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()), grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()))
-	} else {
-		conn, err = grpc.DialContext(ctx, cs.cartSvcAddr, grpc.WithInsecure())
-	}
-
-	if err != nil {
-		return nil, fmt.Errorf("could not connect cart service: %+v", err)
-	}
-	defer conn.Close()
-
-	cart, err := pb.NewCartServiceClient(conn).GetCart(ctx, &pb.GetCartRequest{UserId: userID})
+	cart, err := cs.cartSvc.GetCart(ctx, &pb.GetCartRequest{UserId: userID})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user cart during checkout: %+v", err)
+		return nil, downstreamError("cart", err)
 	}
 	return cart.GetItems(), nil
 }
 
 func (cs *checkoutService) emptyUserCart(ctx context.Context, userID string) error {
-	var conn *grpc.ClientConn
-	var err error
-	if os.Getenv("DISABLE_TRACING") == "" {
-		conn, err = grpc.DialContext(ctx, cs.cartSvcAddr, grpc.WithInsecure(),
-			grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()), grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()))
-	} else {
-		conn, err = grpc.DialContext(ctx, cs.cartSvcAddr, grpc.WithInsecure())
-	}
-
-	if err != nil {
-		return fmt.Errorf("could not connect cart service: %+v", err)
-	}
-	defer conn.Close()
-
-	if _, err = pb.NewCartServiceClient(conn).EmptyCart(ctx, &pb.EmptyCartRequest{UserId: userID}); err != nil {
-		return fmt.Errorf("failed to empty user cart during checkout: %+v", err)
+	if _, err := cs.cartSvc.EmptyCart(ctx, &pb.EmptyCartRequest{UserId: userID}); err != nil {
+		return fmt.Errorf("failed to empty user cart during checkout: %w", err)
 	}
 	return nil
 }
 
-func (cs *checkoutService) prepOrderItems(ctx context.Context, items []*pb.CartItem, userCurrency string) ([]*pb.OrderItem, error) {
-	out := make([]*pb.OrderItem, len(items))
-
-	var conn *grpc.ClientConn
-	var err error
-	if os.Getenv("DISABLE_TRACING") == "" {
-		conn, err = grpc.DialContext(ctx, cs.productCatalogSvcAddr, grpc.WithInsecure(),
-			grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()), grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()))
-	} else {
-		conn, err = grpc.DialContext(ctx, cs.productCatalogSvcAddr, grpc.WithInsecure())
-	}
-
-	if err != nil {
-		return nil, fmt.Errorf("could not connect product catalog service: %+v", err)
-	}
-	defer conn.Close()
-	cl := pb.NewProductCatalogServiceClient(conn)
-
-	for i, item := range items {
-		product, err := cl.GetProduct(ctx, &pb.GetProductRequest{Id: item.GetProductId()})
-		if err != nil {
-			return nil, fmt.Errorf("failed to get product #%q", item.GetProductId())
-		}
-		price, err := cs.convertCurrency(ctx, product.GetPriceUsd(), userCurrency)
-		if err != nil {
-			return nil, fmt.Errorf("failed to convert price of %q to %s", item.GetProductId(), userCurrency)
-		}
-		out[i] = &pb.OrderItem{
-			Item: item,
-			Cost: price}
-	}
-	return out, nil
-}
-
 func (cs *checkoutService) convertCurrency(ctx context.Context, from *pb.Money, toCurrency string) (*pb.Money, error) {
-	var conn *grpc.ClientConn
-	var err error
-	if os.Getenv("DISABLE_TRACING") == "" {
-		conn, err = grpc.DialContext(ctx, cs.currencySvcAddr, grpc.WithInsecure(),
-			grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()), grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()))
-	} else {
-		conn, err = grpc.DialContext(ctx, cs.currencySvcAddr, grpc.WithInsecure())
-	}
-
-
-	if err != nil {
-		return nil, fmt.Errorf("could not connect currency service: %+v", err)
-	}
-	defer conn.Close()
-	result, err := pb.NewCurrencyServiceClient(conn).Convert(ctx, &pb.CurrencyConversionRequest{
+	result, err := cs.currencySvc.Convert(ctx, &pb.CurrencyConversionRequest{
 		From:   from,
 		ToCode: toCurrency})
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert currency: %+v", err)
+		return nil, currencyError(toCurrency, err)
 	}
 	return result, err
 }
 
 func (cs *checkoutService) chargeCard(ctx context.Context, amount *pb.Money, paymentInfo *pb.CreditCardInfo) (string, error) {
-	if os.Getenv("PAYMENT_SVC_DISABLED") != "" {
-		log.Info("Payment service disabled. Mocking call, always return 'Mock_Transaction_ID'")
-		return "Mock_Transaction_ID", nil
-	}
-	var conn *grpc.ClientConn
-	var err error
-	if os.Getenv("DISABLE_TRACING") == "" {
-		conn, err = grpc.DialContext(ctx, cs.paymentSvcAddr, grpc.WithInsecure(),
-			grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()), grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()))
-	} else {
-		conn, err = grpc.DialContext(ctx, cs.paymentSvcAddr, grpc.WithInsecure())
-	}
-
-	if err != nil {
-		return "", fmt.Errorf("failed to connect payment service: %+v", err)
-	}
-	defer conn.Close()
-
-	paymentResp, err := pb.NewPaymentServiceClient(conn).Charge(ctx, &pb.ChargeRequest{
+	paymentResp, err := cs.paymentSvc.Charge(ctx, &pb.ChargeRequest{
 		Amount:     amount,
 		CreditCard: paymentInfo})
 	if err != nil {
-		return "", fmt.Errorf("could not charge the card: %+v", err)
+		return "", paymentError(err)
 	}
 	return paymentResp.GetTransactionId(), nil
 }
 
 func (cs *checkoutService) sendOrderConfirmation(ctx context.Context, email string, order *pb.OrderResult) error {
-	if os.Getenv("EMAIL_SVC_DISABLED") != "" {
-		log.Info("Email Service disabled. Skipping call.")
-		return nil
-	}
-	var conn *grpc.ClientConn
-	var err error
-	if os.Getenv("DISABLE_TRACING") == "" {
-		conn, err = grpc.DialContext(ctx, cs.emailSvcAddr, grpc.WithInsecure(),
-			grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()), grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()))
-	} else {
-		conn, err = grpc.DialContext(ctx, cs.emailSvcAddr, grpc.WithInsecure())
-	}
-
-	if err != nil {
-		return fmt.Errorf("failed to connect email service: %+v", err)
-	}
-	defer conn.Close()
-	_, err = pb.NewEmailServiceClient(conn).SendOrderConfirmation(ctx, &pb.SendOrderConfirmationRequest{
+	_, err := cs.emailSvc.SendOrderConfirmation(ctx, &pb.SendOrderConfirmationRequest{
 		Email: email,
 		Order: order})
 	return err
 }
 
 func (cs *checkoutService) shipOrder(ctx context.Context, address *pb.Address, items []*pb.CartItem) (string, error) {
-
-	if os.Getenv("SHIPPING_SVC_DISABLED") != "" {
-		log.Info("Shipping service disabled. Mocking call, always return 'Mock_Tracking_ID'")
-		return "Mock_Tracking_ID", nil
-	}
-
-	var conn *grpc.ClientConn
-	var err error
-	if os.Getenv("DISABLE_TRACING") == "" {
-		conn, err = grpc.DialContext(ctx, cs.shippingSvcAddr, grpc.WithInsecure(),
-			grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()), grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()))
-	} else {
-		conn, err = grpc.DialContext(ctx, cs.shippingSvcAddr, grpc.WithInsecure())
-	}
-
-	if err != nil {
-		return "", fmt.Errorf("failed to connect email service: %+v", err)
-	}
-	defer conn.Close()
-	resp, err := pb.NewShippingServiceClient(conn).ShipOrder(ctx, &pb.ShipOrderRequest{
+	resp, err := cs.shippingSvc.ShipOrder(ctx, &pb.ShipOrderRequest{
 		Address: address,
 		Items:   items})
 	if err != nil {
-		return "", fmt.Errorf("shipment failed: %+v", err)
+		return "", shippingError(err)
 	}
 	return resp.GetTrackingId(), nil
 }
-
-// TODO: Dial and create client once, reuse.
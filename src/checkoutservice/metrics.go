@@ -0,0 +1,31 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"expvar"
+	"net/http"
+)
+
+// serveMetrics serves the metrics published with expvar, such as the outbox
+// counters, as JSON at /debug/vars on addr.
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	log.Infof("serving metrics on %q", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Errorf("metrics server failed: %+v", err)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/orderstore"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+const defaultOrderStorePath = "orders.db"
+
+// openOrderStore creates the order store selected by ORDER_STORE ("memory",
+// the default, or "bolt"). The bolt store is kept at ORDER_STORE_PATH.
+func openOrderStore() (orderstore.Store, error) {
+	switch kind := os.Getenv("ORDER_STORE"); kind {
+	case "", "memory":
+		log.Info("keeping orders in memory")
+		return orderstore.NewMemory(), nil
+	case "bolt":
+		path := defaultOrderStorePath
+		if v := os.Getenv("ORDER_STORE_PATH"); v != "" {
+			path = v
+		}
+		log.Infof("keeping orders in bolt database %q", path)
+		return orderstore.OpenBolt(path)
+	default:
+		return nil, fmt.Errorf("unknown ORDER_STORE %q", kind)
+	}
+}
+
+func (cs *checkoutService) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.OrderRecord, error) {
+	log.Infof("[GetOrder] order_id=%q", req.GetOrderId())
+	if req.GetOrderId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "order_id is required")
+	}
+	rec, err := cs.orders.Get(ctx, req.GetOrderId())
+	if err == orderstore.ErrNotFound {
+		return nil, status.Errorf(codes.NotFound, "no order with ID %s", req.GetOrderId())
+	} else if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to look up order: %+v", err)
+	}
+	return rec, nil
+}
+
+func (cs *checkoutService) ListOrders(ctx context.Context, req *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
+	log.Infof("[ListOrders] user_id=%q page_token=%q", req.GetUserId(), req.GetPageToken())
+	if req.GetUserId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id is required")
+	}
+	if req.GetPageSize() < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must not be negative")
+	}
+	recs, next, err := cs.orders.List(ctx, req.GetUserId(), int(req.GetPageSize()), req.GetPageToken())
+	if err == orderstore.ErrInvalidPageToken {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid page_token")
+	} else if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list orders: %+v", err)
+	}
+	return &pb.ListOrdersResponse{Orders: recs, NextPageToken: next}, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+func TestPlacedOrdersCanBeLookedUp(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeDownstream()
+	cs, _ := newTestCheckoutService(t, f)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	placed, err := cs.PlaceOrder(ctx, testPlaceOrderRequest("u1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := placed.GetOrder().GetOrderId()
+
+	rec, err := cs.GetOrder(ctx, &pb.GetOrderRequest{OrderId: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(rec.GetOrder(), placed.GetOrder()) || rec.GetUserId() != "u1" || rec.GetPlacedAt() == 0 {
+		t.Errorf("GetOrder() = %v, want the placed order of u1", rec)
+	}
+
+	list, err := cs.ListOrders(ctx, &pb.ListOrdersRequest{UserId: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.GetOrders()) != 1 || list.GetOrders()[0].GetOrder().GetOrderId() != id || list.GetNextPageToken() != "" {
+		t.Errorf("ListOrders() = %v, want only order %s", list, id)
+	}
+
+	if _, err := cs.GetOrder(ctx, &pb.GetOrderRequest{OrderId: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetOrder(missing) = %v, want NotFound", err)
+	}
+	if _, err := cs.ListOrders(ctx, &pb.ListOrdersRequest{UserId: "u1", PageToken: "bogus"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ListOrders(bogus token) = %v, want InvalidArgument", err)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orderstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	bolt "go.etcd.io/bbolt"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+var (
+	// ordersBucket maps order IDs to marshalled OrderRecords.
+	ordersBucket = []byte("orders")
+	// usersBucket holds one nested bucket per user, mapping per-user sequence
+	// numbers to order IDs. Deleted orders stay mapped, so that page tokens
+	// remain valid, and are skipped.
+	usersBucket = []byte("users")
+)
+
+// boltStore keeps orders in an embedded bbolt database file.
+type boltStore struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens (creating if needed) the bbolt database at path.
+func OpenBolt(path string) (Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open order database %q: %+v", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{ordersBucket, usersBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize order database %q: %+v", path, err)
+	}
+	return &boltStore{db: db}, nil
+}
+
+func seqKey(seq uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], seq)
+	return b[:]
+}
+
+func (s *boltStore) Save(_ context.Context, rec *pb.OrderRecord) error {
+	id := []byte(rec.GetOrder().GetOrderId())
+	data, err := proto.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		orders := tx.Bucket(ordersBucket)
+		if orders.Get(id) != nil {
+			return ErrAlreadyExists
+		}
+		user, err := tx.Bucket(usersBucket).CreateBucketIfNotExists([]byte(rec.GetUserId()))
+		if err != nil {
+			return err
+		}
+		seq, err := user.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := user.Put(seqKey(seq), id); err != nil {
+			return err
+		}
+		return orders.Put(id, data)
+	})
+}
+
+func (s *boltStore) Get(_ context.Context, orderID string) (*pb.OrderRecord, error) {
+	var rec pb.OrderRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(ordersBucket).Get([]byte(orderID))
+		if data == nil {
+			return ErrNotFound
+		}
+		return proto.Unmarshal(data, &rec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (s *boltStore) List(_ context.Context, userID string, pageSize int, pageToken string) ([]*pb.OrderRecord, string, error) {
+	pageSize = clampPageSize(pageSize)
+	var (
+		out  []*pb.OrderRecord
+		next string
+	)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		user := tx.Bucket(usersBucket).Bucket([]byte(userID))
+		if user == nil {
+			if pageToken != "" {
+				return ErrInvalidPageToken
+			}
+			return nil
+		}
+		orders := tx.Bucket(ordersBucket)
+
+		c := user.Cursor()
+		k, id := c.Last()
+		if pageToken != "" {
+			seq, err := decodePageToken(pageToken)
+			if err != nil {
+				return err
+			}
+			want := seqKey(seq)
+			if k, id = c.Seek(want); !bytes.Equal(k, want) {
+				return ErrInvalidPageToken
+			}
+		}
+		for ; k != nil && len(out) < pageSize; k, id = c.Prev() {
+			data := orders.Get(id)
+			if data == nil {
+				continue
+			}
+			var rec pb.OrderRecord
+			if err := proto.Unmarshal(data, &rec); err != nil {
+				return err
+			}
+			out = append(out, &rec)
+		}
+		if k != nil {
+			next = encodePageToken(binary.BigEndian.Uint64(k))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return out, next, nil
+}
+
+func (s *boltStore) Delete(_ context.Context, orderID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(ordersBucket).Delete([]byte(orderID))
+	})
+}
+
+func (s *boltStore) Close() error { return s.db.Close() }
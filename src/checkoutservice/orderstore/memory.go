@@ -0,0 +1,102 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orderstore
+
+import (
+	"context"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+// memoryStore keeps orders in process memory. They are lost on restart.
+type memoryStore struct {
+	mu     sync.RWMutex
+	orders map[string]*pb.OrderRecord
+	// byUser holds the order IDs in the order they were saved. Deleted
+	// orders stay listed here, so that page tokens remain valid, and are
+	// skipped.
+	byUser map[string][]string
+}
+
+// NewMemory returns a Store backed by process memory.
+func NewMemory() Store {
+	return &memoryStore{
+		orders: make(map[string]*pb.OrderRecord),
+		byUser: make(map[string][]string),
+	}
+}
+
+func (s *memoryStore) Save(_ context.Context, rec *pb.OrderRecord) error {
+	id := rec.GetOrder().GetOrderId()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orders[id]; ok {
+		return ErrAlreadyExists
+	}
+	s.orders[id] = proto.Clone(rec).(*pb.OrderRecord)
+	s.byUser[rec.GetUserId()] = append(s.byUser[rec.GetUserId()], id)
+	return nil
+}
+
+func (s *memoryStore) Get(_ context.Context, orderID string) (*pb.OrderRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec, ok := s.orders[orderID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return proto.Clone(rec).(*pb.OrderRecord), nil
+}
+
+func (s *memoryStore) List(_ context.Context, userID string, pageSize int, pageToken string) ([]*pb.OrderRecord, string, error) {
+	pageSize = clampPageSize(pageSize)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// The sequence number of an order is its index in byUser.
+	ids := s.byUser[userID]
+	next := uint64(len(ids))
+	if pageToken != "" {
+		seq, err := decodePageToken(pageToken)
+		if err != nil || seq >= uint64(len(ids)) {
+			return nil, "", ErrInvalidPageToken
+		}
+		next = seq + 1
+	}
+
+	var out []*pb.OrderRecord
+	for next > 0 && len(out) < pageSize {
+		next--
+		if rec, ok := s.orders[ids[next]]; ok {
+			out = append(out, proto.Clone(rec).(*pb.OrderRecord))
+		}
+	}
+	if next == 0 {
+		return out, "", nil
+	}
+	return out, encodePageToken(next - 1), nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, orderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.orders, orderID)
+	return nil
+}
+
+func (s *memoryStore) Close() error { return nil }
@@ -0,0 +1,88 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package orderstore keeps placed orders so they can be looked up after
+// checkout.
+package orderstore
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+const (
+	// DefaultPageSize is used by List when no page size is given.
+	DefaultPageSize = 10
+	// MaxPageSize caps the page size accepted by List.
+	MaxPageSize = 100
+)
+
+var (
+	ErrNotFound         = errors.New("order not found")
+	ErrAlreadyExists    = errors.New("order already exists")
+	ErrInvalidPageToken = errors.New("invalid page token")
+)
+
+// Store persists order records.
+type Store interface {
+	// Save stores a newly placed order. It returns ErrAlreadyExists if an
+	// order with the same ID was saved before.
+	Save(ctx context.Context, rec *pb.OrderRecord) error
+
+	// Get returns the order with the given ID, or ErrNotFound.
+	Get(ctx context.Context, orderID string) (*pb.OrderRecord, error)
+
+	// List returns up to pageSize orders of userID, most recent first,
+	// starting at pageToken. The returned token is empty on the last page.
+	List(ctx context.Context, userID string, pageSize int, pageToken string) ([]*pb.OrderRecord, string, error)
+
+	// Delete removes the order with the given ID, if any, to roll back an
+	// order that failed after it was saved.
+	Delete(ctx context.Context, orderID string) error
+
+	Close() error
+}
+
+// clampPageSize maps a requested page size to the range accepted by List.
+func clampPageSize(n int) int {
+	if n <= 0 {
+		return DefaultPageSize
+	}
+	if n > MaxPageSize {
+		return MaxPageSize
+	}
+	return n
+}
+
+// Page tokens encode the per-user sequence number of the next order to
+// return. Sequence numbers are assigned on Save and never reused, so tokens
+// stay valid while new orders are placed.
+
+func encodePageToken(seq uint64) string {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], seq)
+	return hex.EncodeToString(b[:])
+}
+
+func decodePageToken(tok string) (uint64, error) {
+	b, err := hex.DecodeString(tok)
+	if err != nil || len(b) != 8 {
+		return 0, ErrInvalidPageToken
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orderstore
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+func record(id, user string) *pb.OrderRecord {
+	return &pb.OrderRecord{
+		Order:    &pb.OrderResult{OrderId: id, ShippingTrackingId: "T-" + id},
+		UserId:   user,
+		PlacedAt: 1600000000,
+	}
+}
+
+func forEachStore(t *testing.T, fn func(t *testing.T, s Store)) {
+	t.Run("memory", func(t *testing.T) { fn(t, NewMemory()) })
+	t.Run("bolt", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "orderstore")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		s, err := OpenBolt(filepath.Join(dir, "orders.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		fn(t, s)
+	})
+}
+
+func TestSaveAndGet(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		want := record("o1", "u1")
+		if err := s.Save(ctx, want); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Save(ctx, want); err != ErrAlreadyExists {
+			t.Errorf("second Save() = %v, want %v", err, ErrAlreadyExists)
+		}
+		got, err := s.Get(ctx, "o1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !proto.Equal(got, want) {
+			t.Errorf("Get() = %v, want %v", got, want)
+		}
+		if _, err := s.Get(ctx, "missing"); err != ErrNotFound {
+			t.Errorf("Get(missing) = %v, want %v", err, ErrNotFound)
+		}
+	})
+}
+
+func TestListPaginates(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		for i := 0; i < 5; i++ {
+			if err := s.Save(ctx, record(fmt.Sprintf("o%d", i), "u1")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s.Save(ctx, record("other", "u2"))
+
+		var ids []string
+		tok := ""
+		for pages := 0; ; pages++ {
+			if pages > 5 {
+				t.Fatal("too many pages")
+			}
+			recs, next, err := s.List(ctx, "u1", 2, tok)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range recs {
+				ids = append(ids, r.GetOrder().GetOrderId())
+			}
+			if next == "" {
+				break
+			}
+			tok = next
+			// Orders placed while paging must not shift later pages.
+			if pages == 0 {
+				s.Save(ctx, record("new", "u1"))
+			}
+		}
+		if want := []string{"o4", "o3", "o2", "o1", "o0"}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("listed %v, want %v", ids, want)
+		}
+	})
+}
+
+func TestListEmptyAndInvalidToken(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		recs, next, err := s.List(ctx, "nobody", 0, "")
+		if err != nil || len(recs) != 0 || next != "" {
+			t.Errorf("List(nobody) = (%v, %q, %v), want empty", recs, next, err)
+		}
+		s.Save(ctx, record("o1", "u1"))
+		if _, _, err := s.List(ctx, "u1", 0, "not-a-token"); err != ErrInvalidPageToken {
+			t.Errorf("List(bad token) = %v, want %v", err, ErrInvalidPageToken)
+		}
+		if _, _, err := s.List(ctx, "u1", 0, encodePageToken(42)); err != ErrInvalidPageToken {
+			t.Errorf("List(unknown token) = %v, want %v", err, ErrInvalidPageToken)
+		}
+	})
+}
+
+func TestDelete(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		for i := 0; i < 3; i++ {
+			if err := s.Save(ctx, record(fmt.Sprintf("o%d", i), "u1")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		recs, tok, err := s.List(ctx, "u1", 1, "")
+		if err != nil || len(recs) != 1 {
+			t.Fatalf("List() = (%v, %v), want one order", recs, err)
+		}
+		if err := s.Delete(ctx, "o1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Delete(ctx, "missing"); err != nil {
+			t.Errorf("Delete(missing) = %v, want nil", err)
+		}
+		if _, err := s.Get(ctx, "o1"); err != ErrNotFound {
+			t.Errorf("Get(deleted) = %v, want %v", err, ErrNotFound)
+		}
+		// The token handed out before the deletion still works.
+		recs, _, err = s.List(ctx, "u1", 0, tok)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, r := range recs {
+			ids = append(ids, r.GetOrder().GetOrderId())
+		}
+		if want := []string{"o0"}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("listed %v after deleting o1, want %v", ids, want)
+		}
+	})
+}
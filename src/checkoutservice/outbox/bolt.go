@@ -0,0 +1,178 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// pendingBucket maps message IDs to JSON-encoded pending messages.
+	pendingBucket = []byte("pending")
+	// deadBucket maps message IDs to JSON-encoded dead letters.
+	deadBucket = []byte("dead")
+)
+
+// boltStore keeps messages in an embedded bbolt database file.
+type boltStore struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens (creating if needed) the bbolt database at path.
+func OpenBolt(path string) (Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open outbox database %q: %+v", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{pendingBucket, deadBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize outbox database %q: %+v", path, err)
+	}
+	return &boltStore{db: db}, nil
+}
+
+func idKey(id uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], id)
+	return b[:]
+}
+
+func put(b *bolt.Bucket, m *Message) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return b.Put(idKey(m.ID), data)
+}
+
+func (s *boltStore) Add(_ context.Context, msgs []*Message) error {
+	ids := make([]uint64, len(msgs))
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		pending := tx.Bucket(pendingBucket)
+		for i, m := range msgs {
+			id, err := pending.NextSequence()
+			if err != nil {
+				return err
+			}
+			ids[i] = id
+			c := *m
+			c.ID = id
+			if err := put(pending, &c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i, m := range msgs {
+		m.ID = ids[i]
+	}
+	return nil
+}
+
+func (s *boltStore) Due(_ context.Context, now time.Time, limit int) ([]*Message, error) {
+	var out []*Message
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(pendingBucket).Cursor()
+		for k, v := c.First(); k != nil && len(out) < limit; k, v = c.Next() {
+			var m Message
+			if err := json.Unmarshal(v, &m); err != nil {
+				return err
+			}
+			if !m.NextAttempt.After(now) {
+				out = append(out, &m)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *boltStore) Update(_ context.Context, msg *Message) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		pending := tx.Bucket(pendingBucket)
+		if pending.Get(idKey(msg.ID)) == nil {
+			return ErrNotFound
+		}
+		return put(pending, msg)
+	})
+}
+
+func (s *boltStore) Delete(_ context.Context, id uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(pendingBucket).Delete(idKey(id))
+	})
+}
+
+func (s *boltStore) DeadLetter(_ context.Context, msg *Message) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		pending := tx.Bucket(pendingBucket)
+		if pending.Get(idKey(msg.ID)) == nil {
+			return ErrNotFound
+		}
+		if err := pending.Delete(idKey(msg.ID)); err != nil {
+			return err
+		}
+		return put(tx.Bucket(deadBucket), msg)
+	})
+}
+
+func (s *boltStore) DeadLetters(_ context.Context) ([]*Message, error) {
+	var out []*Message
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(deadBucket).ForEach(func(_, v []byte) error {
+			var m Message
+			if err := json.Unmarshal(v, &m); err != nil {
+				return err
+			}
+			out = append(out, &m)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *boltStore) Pending(_ context.Context) (int, error) {
+	var n int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(pendingBucket).Stats().KeyN
+		return nil
+	})
+	return n, err
+}
+
+func (s *boltStore) Close() error { return s.db.Close() }
@@ -0,0 +1,240 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Handler delivers a message. If it fails, the message is retried later
+// unless the error is permanent.
+type Handler func(ctx context.Context, msg *Message) error
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as a failure that retrying will not fix. Messages
+// failing with it are dead-lettered right away.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// Policy controls how messages are retried.
+type Policy struct {
+	// MaxAttempts is the number of failed attempts after which a message is
+	// dead-lettered.
+	MaxAttempts int
+
+	// The delay before the n-th retry is InitialBackoff * Multiplier^(n-1),
+	// capped at MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+
+	// AttemptTimeout bounds a single delivery attempt.
+	AttemptTimeout time.Duration
+
+	// PollInterval is how often the dispatcher looks for messages that have
+	// become due. Enqueued messages are dispatched right away.
+	PollInterval time.Duration
+
+	// BatchSize is the number of due messages loaded at once.
+	BatchSize int
+}
+
+// DefaultPolicy gives up on a message after retrying it for about an hour.
+var DefaultPolicy = Policy{
+	MaxAttempts:    15,
+	InitialBackoff: time.Second,
+	MaxBackoff:     10 * time.Minute,
+	Multiplier:     2,
+	AttemptTimeout: 10 * time.Second,
+	PollInterval:   time.Second,
+	BatchSize:      32,
+}
+
+// Backoff returns the delay before retrying a message that has failed
+// attempts times.
+func (p Policy) Backoff(attempts int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempts && d < float64(p.MaxBackoff); i++ {
+		d *= p.Multiplier
+	}
+	if d > float64(p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+	return time.Duration(d)
+}
+
+// Dispatcher delivers the messages of a Store with the Handler registered
+// for their kind.
+type Dispatcher struct {
+	store    Store
+	policy   Policy
+	log      logrus.FieldLogger
+	handlers map[string]Handler
+	wake     chan struct{}
+	now      func() time.Time
+
+	metrics      *expvar.Map
+	enqueued     expvar.Int
+	delivered    expvar.Int
+	retried      expvar.Int
+	deadLettered expvar.Int
+}
+
+// NewDispatcher returns a Dispatcher for store. Handlers must be registered
+// before it is run.
+func NewDispatcher(store Store, policy Policy, log logrus.FieldLogger) *Dispatcher {
+	d := &Dispatcher{
+		store:    store,
+		policy:   policy,
+		log:      log,
+		handlers: make(map[string]Handler),
+		wake:     make(chan struct{}, 1),
+		now:      time.Now,
+		metrics:  new(expvar.Map).Init(),
+	}
+	d.metrics.Set("enqueued", &d.enqueued)
+	d.metrics.Set("delivered", &d.delivered)
+	d.metrics.Set("retried", &d.retried)
+	d.metrics.Set("dead_lettered", &d.deadLettered)
+	d.metrics.Set("pending", expvar.Func(func() interface{} {
+		n, _ := store.Pending(context.Background())
+		return n
+	}))
+	return d
+}
+
+// Handle registers the handler for messages of the given kind.
+func (d *Dispatcher) Handle(kind string, h Handler) {
+	d.handlers[kind] = h
+}
+
+// Metrics returns the counters of the dispatcher, for publishing with
+// expvar.Publish.
+func (d *Dispatcher) Metrics() *expvar.Map {
+	return d.metrics
+}
+
+// Enqueue durably stores msgs for delivery as soon as possible.
+func (d *Dispatcher) Enqueue(ctx context.Context, msgs ...*Message) error {
+	now := d.now()
+	for _, m := range msgs {
+		m.CreatedAt = now
+		m.NextAttempt = now
+	}
+	if err := d.store.Add(ctx, msgs); err != nil {
+		return err
+	}
+	d.enqueued.Add(int64(len(msgs)))
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run delivers messages until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	t := time.NewTicker(d.policy.PollInterval)
+	defer t.Stop()
+	for {
+		if err := d.DispatchDue(ctx); err != nil && ctx.Err() == nil {
+			d.log.Errorf("outbox: failed to dispatch messages: %+v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-t.C:
+		}
+	}
+}
+
+// DispatchDue makes one delivery attempt for every message that is due.
+func (d *Dispatcher) DispatchDue(ctx context.Context) error {
+	for ctx.Err() == nil {
+		msgs, err := d.store.Due(ctx, d.now(), d.policy.BatchSize)
+		if err != nil {
+			return err
+		}
+		for _, m := range msgs {
+			if err := d.dispatch(ctx, m); err != nil {
+				return err
+			}
+		}
+		if len(msgs) < d.policy.BatchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+// dispatch attempts to deliver m and records the outcome. It returns an
+// error only if the outcome could not be stored.
+func (d *Dispatcher) dispatch(ctx context.Context, m *Message) error {
+	h, ok := d.handlers[m.Kind]
+	if !ok {
+		m.LastError = fmt.Sprintf("no handler for kind %q", m.Kind)
+		return d.deadLetter(ctx, m)
+	}
+
+	actx, cancel := context.WithTimeout(ctx, d.policy.AttemptTimeout)
+	err := h(actx, m)
+	cancel()
+	if err == nil {
+		if err := d.store.Delete(ctx, m.ID); err != nil {
+			return err
+		}
+		d.delivered.Add(1)
+		return nil
+	}
+
+	m.Attempts++
+	m.LastError = err.Error()
+	var perm *permanentError
+	if errors.As(err, &perm) || m.Attempts >= d.policy.MaxAttempts {
+		return d.deadLetter(ctx, m)
+	}
+	m.NextAttempt = d.now().Add(d.policy.Backoff(m.Attempts))
+	d.log.Warnf("outbox: %s message %d (%s) failed, attempt %d, retrying at %s: %v",
+		m.Kind, m.ID, m.Key, m.Attempts, m.NextAttempt.Format(time.RFC3339), err)
+	if err := d.store.Update(ctx, m); err != nil {
+		return err
+	}
+	d.retried.Add(1)
+	return nil
+}
+
+func (d *Dispatcher) deadLetter(ctx context.Context, m *Message) error {
+	d.log.Errorf("outbox: giving up on %s message %d (%s) after %d attempts: %s",
+		m.Kind, m.ID, m.Key, m.Attempts, m.LastError)
+	if err := d.store.DeadLetter(ctx, m); err != nil {
+		return err
+	}
+	d.deadLettered.Add(1)
+	return nil
+}
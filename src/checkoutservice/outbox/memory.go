@@ -0,0 +1,109 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memoryStore keeps messages in process memory. They are lost on restart.
+type memoryStore struct {
+	mu      sync.Mutex
+	lastID  uint64
+	pending map[uint64]*Message
+	dead    []*Message
+}
+
+// NewMemory returns a Store backed by process memory.
+func NewMemory() Store {
+	return &memoryStore{pending: make(map[uint64]*Message)}
+}
+
+func (s *memoryStore) Add(_ context.Context, msgs []*Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range msgs {
+		s.lastID++
+		m.ID = s.lastID
+		s.pending[m.ID] = m.clone()
+	}
+	return nil
+}
+
+func (s *memoryStore) Due(_ context.Context, now time.Time, limit int) ([]*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*Message
+	for _, m := range s.pending {
+		if !m.NextAttempt.After(now) {
+			out = append(out, m.clone())
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *memoryStore) Update(_ context.Context, msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.pending[msg.ID]; !ok {
+		return ErrNotFound
+	}
+	s.pending[msg.ID] = msg.clone()
+	return nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, id)
+	return nil
+}
+
+func (s *memoryStore) DeadLetter(_ context.Context, msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.pending[msg.ID]; !ok {
+		return ErrNotFound
+	}
+	delete(s.pending, msg.ID)
+	s.dead = append(s.dead, msg.clone())
+	return nil
+}
+
+func (s *memoryStore) DeadLetters(_ context.Context) ([]*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*Message, len(s.dead))
+	for i, m := range s.dead {
+		out[i] = m.clone()
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (s *memoryStore) Pending(_ context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending), nil
+}
+
+func (s *memoryStore) Close() error { return nil }
@@ -0,0 +1,83 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package outbox durably queues the side effects of placed orders, such as
+// confirmation e-mails, and delivers them in the background with retries.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("message not found")
+
+// Message is a side effect waiting to be delivered.
+type Message struct {
+	// ID is assigned by Store.Add.
+	ID uint64 `json:"id"`
+
+	// Kind selects the Handler that delivers the message.
+	Kind string `json:"kind"`
+
+	// Key identifies what the message is about, such as an order ID. It is
+	// only used in logs.
+	Key string `json:"key,omitempty"`
+
+	Payload []byte `json:"payload"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	// Attempts is the number of failed delivery attempts so far.
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+func (m *Message) clone() *Message {
+	c := *m
+	c.Payload = append([]byte(nil), m.Payload...)
+	return &c
+}
+
+// Store persists messages until they are delivered or given up on.
+type Store interface {
+	// Add stores new pending messages and assigns their IDs. Either all of
+	// them are stored or none is.
+	Add(ctx context.Context, msgs []*Message) error
+
+	// Due returns up to limit pending messages whose next attempt is not
+	// after now, in the order they were added.
+	Due(ctx context.Context, now time.Time, limit int) ([]*Message, error)
+
+	// Update stores the new state of a pending message, or returns
+	// ErrNotFound.
+	Update(ctx context.Context, msg *Message) error
+
+	// Delete removes a pending message that was delivered.
+	Delete(ctx context.Context, id uint64) error
+
+	// DeadLetter moves a pending message to the dead letters, where it is
+	// kept for inspection but never retried.
+	DeadLetter(ctx context.Context, msg *Message) error
+
+	// DeadLetters returns the dead letters in the order they were added.
+	DeadLetters(ctx context.Context) ([]*Message, error)
+
+	// Pending returns the number of messages waiting to be delivered.
+	Pending(ctx context.Context) (int, error)
+
+	Close() error
+}
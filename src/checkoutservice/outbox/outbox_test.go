@@ -0,0 +1,281 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var t0 = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func forEachStore(t *testing.T, fn func(t *testing.T, s Store)) {
+	t.Run("memory", func(t *testing.T) { fn(t, NewMemory()) })
+	t.Run("bolt", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "outbox")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		s, err := OpenBolt(filepath.Join(dir, "outbox.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		fn(t, s)
+	})
+}
+
+func ids(msgs []*Message) []uint64 {
+	out := make([]uint64, len(msgs))
+	for i, m := range msgs {
+		out[i] = m.ID
+	}
+	return out
+}
+
+func TestStore(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		msgs := []*Message{
+			{Kind: "a", Payload: []byte("1"), NextAttempt: t0},
+			{Kind: "b", Payload: []byte("2"), NextAttempt: t0.Add(time.Minute)},
+			{Kind: "c", Payload: []byte("3"), NextAttempt: t0},
+		}
+		if err := s.Add(ctx, msgs); err != nil {
+			t.Fatal(err)
+		}
+		if msgs[0].ID == 0 || msgs[0].ID >= msgs[1].ID || msgs[1].ID >= msgs[2].ID {
+			t.Fatalf("IDs = %v, want increasing", ids(msgs))
+		}
+
+		due, err := s.Due(ctx, t0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(due) != 2 || due[0].ID != msgs[0].ID || due[1].ID != msgs[2].ID || string(due[1].Payload) != "3" {
+			t.Fatalf("Due() = %v, want messages a and c", due)
+		}
+		if due, _ := s.Due(ctx, t0.Add(time.Hour), 1); len(due) != 1 || due[0].ID != msgs[0].ID {
+			t.Errorf("Due() with limit 1 = %v", ids(due))
+		}
+
+		msgs[0].Attempts = 1
+		msgs[0].NextAttempt = t0.Add(time.Hour)
+		if err := s.Update(ctx, msgs[0]); err != nil {
+			t.Fatal(err)
+		}
+		if due, _ := s.Due(ctx, t0, 10); len(due) != 1 || due[0].ID != msgs[2].ID {
+			t.Errorf("Due() after update = %v", ids(due))
+		}
+
+		if err := s.Delete(ctx, msgs[2].ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.DeadLetter(ctx, msgs[1]); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Update(ctx, msgs[1]); err != ErrNotFound {
+			t.Errorf("Update() of dead letter = %v, want %v", err, ErrNotFound)
+		}
+		dead, err := s.DeadLetters(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dead) != 1 || dead[0].Kind != "b" {
+			t.Errorf("DeadLetters() = %v", dead)
+		}
+		if n, err := s.Pending(ctx); err != nil || n != 1 {
+			t.Errorf("Pending() = %d, %v; want 1", n, err)
+		}
+	})
+}
+
+func TestBoltStoreIsDurable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "outbox.db")
+
+	s, err := OpenBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(context.Background(), []*Message{{Kind: "a", NextAttempt: t0}}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = OpenBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if due, err := s.Due(context.Background(), t0, 10); err != nil || len(due) != 1 {
+		t.Errorf("Due() after reopening = %v, %v", due, err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := Policy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Multiplier: 2}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := p.Backoff(i + 1); got != w {
+			t.Errorf("Backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+}
+
+type testClock struct{ now time.Time }
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func newTestDispatcher(policy Policy) (*Dispatcher, Store, *testClock) {
+	s := NewMemory()
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	d := NewDispatcher(s, policy, log)
+	clock := &testClock{now: t0}
+	d.now = clock.Now
+	return d, s, clock
+}
+
+var testPolicy = Policy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Second,
+	MaxBackoff:     time.Minute,
+	Multiplier:     2,
+	AttemptTimeout: time.Second,
+	BatchSize:      2,
+}
+
+func TestDispatcherRetriesWithBackoff(t *testing.T) {
+	d, s, clock := newTestDispatcher(testPolicy)
+	ctx := context.Background()
+	fails := 2
+	var calls int
+	d.Handle("email", func(ctx context.Context, m *Message) error {
+		calls++
+		if fails > 0 {
+			fails--
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	if err := d.Enqueue(ctx, &Message{Kind: "email"}); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		advance   time.Duration
+		wantCalls int
+	}{
+		{0, 1},                      // fails, retry in 1s
+		{500 * time.Millisecond, 1}, // not due yet
+		{500 * time.Millisecond, 2}, // fails, retry in 2s
+		{time.Second, 2},            // not due yet
+		{time.Second, 3},            // delivered
+		{time.Hour, 3},              // nothing left
+	}
+	for i, st := range steps {
+		clock.now = clock.now.Add(st.advance)
+		if err := d.DispatchDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if calls != st.wantCalls {
+			t.Fatalf("step %d: %d calls, want %d", i, calls, st.wantCalls)
+		}
+	}
+	if n, _ := s.Pending(ctx); n != 0 {
+		t.Errorf("%d messages pending, want 0", n)
+	}
+	if got := d.Metrics().String(); got != `{"dead_lettered": 0, "delivered": 1, "enqueued": 1, "pending": 0, "retried": 2}` {
+		t.Errorf("metrics = %s", got)
+	}
+}
+
+func TestDispatcherDeadLetters(t *testing.T) {
+	d, s, clock := newTestDispatcher(testPolicy)
+	ctx := context.Background()
+	d.Handle("flaky", func(context.Context, *Message) error { return errors.New("unavailable") })
+	d.Handle("bad", func(context.Context, *Message) error { return Permanent(errors.New("invalid")) })
+	err := d.Enqueue(ctx, &Message{Kind: "flaky"}, &Message{Kind: "bad"}, &Message{Kind: "unknown"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < testPolicy.MaxAttempts; i++ {
+		if err := d.DispatchDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+		clock.now = clock.now.Add(time.Hour)
+	}
+
+	dead, err := s.DeadLetters(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"bad": 1, "unknown": 0, "flaky": testPolicy.MaxAttempts}
+	if len(dead) != len(want) {
+		t.Fatalf("dead letters = %v", dead)
+	}
+	for _, m := range dead {
+		if m.Attempts != want[m.Kind] || m.LastError == "" {
+			t.Errorf("dead letter %s: attempts = %d (want %d), last error %q", m.Kind, m.Attempts, want[m.Kind], m.LastError)
+		}
+	}
+	if n, _ := s.Pending(ctx); n != 0 {
+		t.Errorf("%d messages pending, want 0", n)
+	}
+}
+
+func TestDispatcherRun(t *testing.T) {
+	d, _, _ := newTestDispatcher(Policy{MaxAttempts: 1, AttemptTimeout: time.Second, PollInterval: time.Hour, BatchSize: 1})
+	delivered := make(chan string, 1)
+	d.Handle("email", func(_ context.Context, m *Message) error {
+		delivered <- string(m.Payload)
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	if err := d.Enqueue(ctx, &Message{Kind: "email", Payload: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-delivered:
+		if got != "hello" {
+			t.Errorf("delivered %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("enqueued message was not dispatched")
+	}
+}
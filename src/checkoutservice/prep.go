@@ -0,0 +1,202 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/money"
+)
+
+// maxPriceLookups bounds the number of concurrent product lookups made while
+// pricing a single cart.
+const maxPriceLookups = 8
+
+// priceRounding is how amounts computed to the nano, such as converted prices
+// and taxes, are rounded to the minor unit of the currency of the order.
+const priceRounding = money.HalfEven
+
+var nanosPerUnit = big.NewInt(1000000000)
+
+// prepOrderItems prices the cart in userCurrency. Every distinct product is
+// looked up once, concurrently, and converted with a single exchange rate per
+// currency pair. The items are returned in cart order, along with the products
+// by ID. The first error to occur cancels the outstanding lookups and is
+// returned.
+func (cs *checkoutService) prepOrderItems(ctx context.Context, items []*pb.CartItem, userCurrency string) ([]*pb.OrderItem, map[string]*pb.Product, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var ids []string
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if id := item.GetProductId(); !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		prices   = make(map[string]*pb.Money, len(ids))
+		products = make(map[string]*pb.Product, len(ids))
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+		mu.Unlock()
+	}
+
+	rates := newRateCache(cs, userCurrency)
+	cl := cs.productCatalogSvc
+	jobs := make(chan string)
+	workers := maxPriceLookups
+	if len(ids) < workers {
+		workers = len(ids)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				product, err := cl.GetProduct(ctx, &pb.GetProductRequest{Id: id})
+				if err != nil {
+					fail(productNotFoundError(id, err))
+					continue
+				}
+				price, err := rates.convert(ctx, product.GetPriceUsd())
+				if err != nil {
+					fail(fmt.Errorf("failed to convert price of %q to %s: %w", id, userCurrency, err))
+					continue
+				}
+				mu.Lock()
+				prices[id] = price
+				products[id] = product
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, id := range ids {
+		select {
+		case jobs <- id:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, downstreamError("productcatalog", err)
+	}
+	out := make([]*pb.OrderItem, len(items))
+	for i, item := range items {
+		out[i] = &pb.OrderItem{
+			Item: item,
+			Cost: prices[item.GetProductId()]}
+	}
+	return out, products, nil
+}
+
+// rateCache fetches the exchange rate to a target currency at most once per
+// source currency.
+type rateCache struct {
+	cs *checkoutService
+	to string
+
+	mu    sync.Mutex
+	rates map[string]*rateEntry
+}
+
+type rateEntry struct {
+	once sync.Once
+	rate *pb.Money
+	err  error
+}
+
+func newRateCache(cs *checkoutService, to string) *rateCache {
+	return &rateCache{cs: cs, to: to, rates: make(map[string]*rateEntry)}
+}
+
+// rate returns the value of one unit of currency from in the target currency.
+func (c *rateCache) rate(ctx context.Context, from string) (*pb.Money, error) {
+	c.mu.Lock()
+	e, ok := c.rates[from]
+	if !ok {
+		e = &rateEntry{}
+		c.rates[from] = e
+	}
+	c.mu.Unlock()
+
+	e.once.Do(func() {
+		e.rate, e.err = c.cs.convertCurrency(ctx, &pb.Money{CurrencyCode: from, Units: 1}, c.to)
+	})
+	return e.rate, e.err
+}
+
+// convert converts m to the target currency, rounded to its minor unit.
+func (c *rateCache) convert(ctx context.Context, m *pb.Money) (*pb.Money, error) {
+	rate, err := c.rate(ctx, m.GetCurrencyCode())
+	if err != nil {
+		return nil, err
+	}
+	converted, err := applyRate(m, rate)
+	if err != nil {
+		return nil, err
+	}
+	return roundPrice(converted)
+}
+
+// roundPrice rounds m to the minor unit of its currency, the precision prices
+// are shown and charged with.
+func roundPrice(m *pb.Money) (*pb.Money, error) {
+	rounded, err := money.Round(*m, priceRounding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to round %d.%09d %s: %w", m.GetUnits(), m.GetNanos(), m.GetCurrencyCode(), err)
+	}
+	return &rounded, nil
+}
+
+// applyRate multiplies m by rate, the value of one unit of m's currency in
+// another currency. Fractions of a nano are truncated, like CurrencyService
+// does.
+func applyRate(m, rate *pb.Money) (*pb.Money, error) {
+	toNanos := func(m *pb.Money) *big.Int {
+		n := new(big.Int).Mul(big.NewInt(m.GetUnits()), nanosPerUnit)
+		return n.Add(n, big.NewInt(int64(m.GetNanos())))
+	}
+	p := new(big.Int).Mul(toNanos(m), toNanos(rate))
+	p.Quo(p, nanosPerUnit)
+
+	units, nanos := new(big.Int).QuoRem(p, nanosPerUnit, new(big.Int))
+	if !units.IsInt64() {
+		return nil, fmt.Errorf("converted amount of %d.%09d %s overflows", m.GetUnits(), m.GetNanos(), m.GetCurrencyCode())
+	}
+	return &pb.Money{
+		CurrencyCode: rate.GetCurrencyCode(),
+		Units:        units.Int64(),
+		Nanos:        int32(nanos.Int64())}, nil
+}
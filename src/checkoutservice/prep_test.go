@@ -0,0 +1,158 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+func TestPrepOrderItems(t *testing.T) {
+	f := newFakeDownstream()
+	f.rates["EUR"] = 0.5
+	cs, _ := newTestCheckoutService(t, f)
+
+	items := []*pb.CartItem{
+		{ProductId: "OLJCESPC7Z", Quantity: 1},
+		{ProductId: "66VCHSJNUP", Quantity: 2},
+		{ProductId: "OLJCESPC7Z", Quantity: 3},
+	}
+	out, products, err := cs.prepOrderItems(context.Background(), items, "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Converted prices are rounded to the cent, halves to even.
+	want := []*pb.Money{
+		{CurrencyCode: "EUR", Units: 34, Nanos: 0},
+		{CurrencyCode: "EUR", Units: 6, Nanos: 240000000},
+		{CurrencyCode: "EUR", Units: 34, Nanos: 0},
+	}
+	if len(out) != len(want) {
+		t.Fatalf("got %d items, want %d", len(out), len(want))
+	}
+	for i, it := range out {
+		if it.GetItem() != items[i] {
+			t.Errorf("item %d = %v, want %v", i, it.GetItem(), items[i])
+		}
+		if !proto.Equal(it.GetCost(), want[i]) {
+			t.Errorf("cost of item %d = %v, want %v", i, it.GetCost(), want[i])
+		}
+	}
+	if len(products) != 2 || products["66VCHSJNUP"].GetName() != "Vintage Camera Lens" {
+		t.Errorf("products = %v", products)
+	}
+	if f.productLookups != 2 {
+		t.Errorf("looked up %d products, want 2", f.productLookups)
+	}
+	if f.conversions != 1 {
+		t.Errorf("made %d conversions, want 1", f.conversions)
+	}
+}
+
+func TestPrepOrderItemsManyProducts(t *testing.T) {
+	f := newFakeDownstream()
+	var items []*pb.CartItem
+	for i := 0; i < 3*maxPriceLookups; i++ {
+		id := string(rune('A' + i))
+		f.products[id] = &pb.Product{Id: id, PriceUsd: &pb.Money{CurrencyCode: "USD", Units: int64(i)}}
+		items = append(items, &pb.CartItem{ProductId: id, Quantity: 1})
+	}
+	cs, _ := newTestCheckoutService(t, f)
+
+	out, _, err := cs.prepOrderItems(context.Background(), items, "USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, it := range out {
+		if got := it.GetCost().GetUnits(); got != int64(i) {
+			t.Errorf("cost of item %d = %d, want %d", i, got, i)
+		}
+	}
+}
+
+func TestPrepOrderItemsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		items    []*pb.CartItem
+		currency string
+		want     string
+	}{
+		{
+			name:     "unknown product",
+			items:    []*pb.CartItem{{ProductId: "OLJCESPC7Z"}, {ProductId: "NOPE"}},
+			currency: "USD",
+			want:     `failed to get product #"NOPE"`,
+		},
+		{
+			name:     "unsupported currency",
+			items:    []*pb.CartItem{{ProductId: "OLJCESPC7Z"}},
+			currency: "XXX",
+			want:     `failed to convert price of "OLJCESPC7Z" to XXX`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, _ := newTestCheckoutService(t, newFakeDownstream())
+			_, _, err := cs.prepOrderItems(context.Background(), tt.items, tt.currency)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("prepOrderItems() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyRate(t *testing.T) {
+	tests := []struct {
+		amount, rate *pb.Money
+		want         *pb.Money
+	}{
+		{
+			amount: &pb.Money{CurrencyCode: "USD", Units: 67, Nanos: 990000000},
+			rate:   &pb.Money{CurrencyCode: "USD", Units: 1},
+			want:   &pb.Money{CurrencyCode: "USD", Units: 67, Nanos: 990000000},
+		},
+		{
+			amount: &pb.Money{CurrencyCode: "USD", Units: 12, Nanos: 490000000},
+			rate:   &pb.Money{CurrencyCode: "JPY", Units: 112, Nanos: 740000000},
+			want:   &pb.Money{CurrencyCode: "JPY", Units: 1408, Nanos: 122600000},
+		},
+		{
+			// 0.000000001 * 0.5 truncates to zero.
+			amount: &pb.Money{CurrencyCode: "USD", Nanos: 1},
+			rate:   &pb.Money{CurrencyCode: "EUR", Nanos: 500000000},
+			want:   &pb.Money{CurrencyCode: "EUR"},
+		},
+	}
+	for _, tt := range tests {
+		got, err := applyRate(tt.amount, tt.rate)
+		if err != nil {
+			t.Errorf("applyRate(%v, %v) failed: %+v", tt.amount, tt.rate, err)
+		} else if !proto.Equal(got, tt.want) {
+			t.Errorf("applyRate(%v, %v) = %v, want %v", tt.amount, tt.rate, got, tt.want)
+		}
+	}
+
+	_, err := applyRate(&pb.Money{CurrencyCode: "USD", Units: math.MaxInt64},
+		&pb.Money{CurrencyCode: "EUR", Units: 2})
+	if err == nil {
+		t.Error("applyRate() did not report overflow")
+	}
+}
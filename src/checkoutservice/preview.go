@@ -0,0 +1,59 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+// PreviewOrder prices the user's cart with the same code as PlaceOrder, so
+// that the total shown before checkout is the total that gets charged.
+// Nothing is charged, shipped, redeemed or emptied.
+func (cs *checkoutService) PreviewOrder(ctx context.Context, req *pb.PreviewOrderRequest) (*pb.PreviewOrderResponse, error) {
+	log.Infof("[PreviewOrder] user_id=%q user_currency=%q", req.GetUserId(), req.GetUserCurrency())
+
+	var v orderViolations
+	if req.GetUserId() == "" {
+		v.add("user_id", "user ID is required")
+	}
+	if req.GetUserCurrency() == "" {
+		v.add("user_currency", "currency is required")
+	}
+	if len(v) > 0 {
+		return nil, invalidOrderError(reasonInvalidOrder, v)
+	}
+
+	ctx, cancel, budget := cs.startBudget(ctx, previewOrderStages)
+	defer cancel()
+	prep, err := cs.prepareOrderItemsAndShippingQuoteFromCart(ctx, budget, req.GetUserId(), req.GetUserCurrency(), req.GetAddress(), req.GetPromoCodes())
+	if err != nil {
+		return nil, prepareError(err)
+	}
+	total, err := prep.total(req.GetUserCurrency())
+	if err != nil {
+		return nil, internalError(err, "failed to total order: %+v", err)
+	}
+	return &pb.PreviewOrderResponse{
+		Items:         prep.orderItems,
+		ShippingCost:  prep.shippingCostLocalized,
+		TotalTax:      &prep.tax.Total,
+		TaxInclusive:  prep.tax.Inclusive,
+		Discounts:     orderDiscounts(prep.discounts),
+		TotalDiscount: &prep.discounts.Total,
+		Total:         &total,
+	}, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+func TestPreviewOrderMatchesCharge(t *testing.T) {
+	f := newFakeDownstream()
+	cs, _ := newTestCheckoutService(t, f)
+	cs.promos = newTestPromoEngine(t)
+	cs.taxes = newTestTaxEngine(t, `{"jurisdictions": [{"country": "United States", "rate": "0.1"}]}`)
+	f.cart["u1"] = []*pb.CartItem{
+		{ProductId: "OLJCESPC7Z", Quantity: 2},
+		{ProductId: "66VCHSJNUP", Quantity: 1},
+	}
+	order := testPlaceOrderRequest("u1")
+	order.PromoCodes = []string{"HALF", "ONCE"}
+
+	preview, err := cs.PreviewOrder(context.Background(), &pb.PreviewOrderRequest{
+		UserId:       order.UserId,
+		UserCurrency: order.UserCurrency,
+		Address:      order.Address,
+		PromoCodes:   order.PromoCodes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.charges) != 0 || len(f.shipments) != 0 || len(f.emptied) != 0 {
+		t.Fatalf("preview charged %v, shipped %v, emptied %v", f.charges, f.shipments, f.emptied)
+	}
+	if len(preview.GetItems()) != 2 || len(preview.GetDiscounts()) != 2 {
+		t.Errorf("preview = %v", preview)
+	}
+
+	// ONCE can be used a single time, so it must not have been redeemed.
+	resp, err := cs.PlaceOrder(context.Background(), order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.charges) != 1 {
+		t.Fatalf("made %d charges, want 1", len(f.charges))
+	}
+	if got := f.charges[0].GetAmount(); !proto.Equal(got, preview.GetTotal()) {
+		t.Errorf("charged %v, preview total %v", got, preview.GetTotal())
+	}
+	if got := resp.GetOrder().GetTotal(); !proto.Equal(got, preview.GetTotal()) {
+		t.Errorf("order total %v, preview total %v", got, preview.GetTotal())
+	}
+}
+
+func TestPreviewOrderErrors(t *testing.T) {
+	f := newFakeDownstream()
+	cs, _ := newTestCheckoutService(t, f)
+
+	_, err := cs.PreviewOrder(context.Background(), &pb.PreviewOrderRequest{UserId: "u1"})
+	if got := violatedFields(t, err); len(got) != 1 || got[0] != "user_currency" {
+		t.Errorf("violations = %v, want [user_currency]", got)
+	}
+
+	_, err = cs.PreviewOrder(context.Background(), &pb.PreviewOrderRequest{UserId: "u1", UserCurrency: "USD"})
+	if got := violatedFields(t, err); len(got) != 1 || got[0] != "cart" {
+		t.Errorf("violations = %v, want [cart]", got)
+	}
+
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "NOPE", Quantity: 1}}
+	_, err = cs.PreviewOrder(context.Background(), &pb.PreviewOrderRequest{UserId: "u1", UserCurrency: "USD"})
+	if got, want := status.Code(err), codes.NotFound; got != want {
+		t.Errorf("code = %s, want %s (err: %v)", got, want, err)
+	}
+}
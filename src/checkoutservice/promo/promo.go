@@ -0,0 +1,457 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package promo applies promotion codes to orders.
+package promo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/money"
+)
+
+// Kind is the type of a promotion.
+type Kind string
+
+const (
+	// PercentOff takes Percent off the eligible items.
+	PercentOff Kind = "percent_off"
+	// AmountOff takes a fixed Amount off the eligible items. It only applies
+	// to orders in the currency of Amount.
+	AmountOff Kind = "amount_off"
+	// BuyXGetY makes Get of every Buy+Get units of an eligible item free.
+	BuyXGetY Kind = "buy_x_get_y"
+	// FreeShipping waives the shipping cost.
+	FreeShipping Kind = "free_shipping"
+)
+
+// Percent is a percentage such as 15 for 15%. In JSON it is written as a
+// decimal number or string.
+type Percent struct {
+	big.Rat
+}
+
+func (p *Percent) UnmarshalJSON(b []byte) error {
+	s := string(bytes.Trim(b, `"`))
+	if _, ok := p.SetString(s); !ok {
+		return fmt.Errorf("invalid percentage %s", b)
+	}
+	return nil
+}
+
+// Promotion is a promotion redeemable with a code.
+type Promotion struct {
+	// Code is matched case-insensitively.
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Kind        Kind   `json:"type"`
+
+	Percent *Percent  `json:"percent,omitempty"` // PercentOff
+	Amount  *pb.Money `json:"amount,omitempty"`  // AmountOff
+	Buy     int32     `json:"buy,omitempty"`     // BuyXGetY
+	Get     int32     `json:"get,omitempty"`     // BuyXGetY
+
+	// Categories restricts the promotion to products in any of them. An
+	// empty list makes every product eligible.
+	Categories []string `json:"categories,omitempty"`
+
+	// Starts and Expires bound when the code can be used. Zero values leave
+	// the range open.
+	Starts  time.Time `json:"starts,omitempty"`
+	Expires time.Time `json:"expires,omitempty"`
+
+	// MaxUses limits the number of orders the code can be used on, in total
+	// and per user. Zero means unlimited.
+	MaxUses        int `json:"max_uses,omitempty"`
+	MaxUsesPerUser int `json:"max_uses_per_user,omitempty"`
+}
+
+// Rules is the content of a promotions file.
+type Rules struct {
+	Promotions []Promotion `json:"promotions"`
+}
+
+// Line is an order line.
+type Line struct {
+	ProductID  string
+	Categories []string
+	UnitPrice  pb.Money
+	Quantity   int32
+}
+
+// Order is an order to apply promotions to.
+type Order struct {
+	UserID   string
+	Currency string
+	Lines    []Line
+	Shipping pb.Money
+}
+
+// Discount is the amount a single promotion takes off an order.
+type Discount struct {
+	Code        string
+	Description string
+	Amount      pb.Money
+}
+
+// Result holds the discounts of an order.
+type Result struct {
+	Discounts []Discount // one per applied code
+	Lines     []pb.Money // discount on each whole line, in order
+	Shipping  pb.Money
+	Total     pb.Money
+	Codes     []string // applied codes, normalized
+	userID    string
+}
+
+// CodeError reports a code that cannot be applied to an order.
+type CodeError struct {
+	Code   string
+	Reason string
+}
+
+func (e *CodeError) Error() string {
+	return fmt.Sprintf("promo code %q %s", e.Code, e.Reason)
+}
+
+func normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Engine applies promotions and keeps track of their usage. Usage is kept in
+// memory and starts over when the process restarts. The zero Engine knows no
+// promotions.
+type Engine struct {
+	now    func() time.Time
+	promos map[string]*Promotion
+
+	mu       sync.Mutex
+	uses     map[string]int
+	userUses map[string]map[string]int
+}
+
+// New returns an Engine offering the given promotions.
+func New(rules Rules) (*Engine, error) {
+	e := &Engine{
+		now:      time.Now,
+		promos:   make(map[string]*Promotion),
+		uses:     make(map[string]int),
+		userUses: make(map[string]map[string]int),
+	}
+	for i := range rules.Promotions {
+		p := &rules.Promotions[i]
+		if err := validate(p); err != nil {
+			return nil, fmt.Errorf("promotion #%d (%q): %v", i, p.Code, err)
+		}
+		code := normalize(p.Code)
+		if _, dup := e.promos[code]; dup {
+			return nil, fmt.Errorf("promotion %q is defined twice", p.Code)
+		}
+		e.promos[code] = p
+	}
+	return e, nil
+}
+
+func validate(p *Promotion) error {
+	if normalize(p.Code) == "" {
+		return fmt.Errorf("no code")
+	}
+	switch p.Kind {
+	case PercentOff:
+		if p.Percent == nil || p.Percent.Sign() <= 0 || p.Percent.Cmp(big.NewRat(100, 1)) > 0 {
+			return fmt.Errorf("percent must be in (0, 100]")
+		}
+	case AmountOff:
+		if p.Amount == nil || p.Amount.GetCurrencyCode() == "" || !money.IsPositive(*p.Amount) {
+			return fmt.Errorf("amount must be positive and have a currency")
+		}
+	case BuyXGetY:
+		if p.Buy <= 0 || p.Get <= 0 {
+			return fmt.Errorf("buy and get must be positive")
+		}
+	case FreeShipping:
+	default:
+		return fmt.Errorf("unknown type %q", p.Kind)
+	}
+	if !p.Expires.IsZero() && p.Expires.Before(p.Starts) {
+		return fmt.Errorf("expires before it starts")
+	}
+	if p.MaxUses < 0 || p.MaxUsesPerUser < 0 {
+		return fmt.Errorf("usage limits must not be negative")
+	}
+	return nil
+}
+
+// Load returns an Engine offering the promotions in the JSON file at path.
+func Load(path string) (*Engine, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules Rules
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse promotions %s: %v", path, err)
+	}
+	return New(rules)
+}
+
+// usableLocked returns why the promotion cannot be used by userID now, or
+// "" if it can.
+func (e *Engine) usableLocked(p *Promotion, code, userID string) string {
+	now := e.now()
+	switch {
+	case !p.Starts.IsZero() && now.Before(p.Starts):
+		return "is not active yet"
+	case !p.Expires.IsZero() && !now.Before(p.Expires):
+		return "has expired"
+	case p.MaxUses > 0 && e.uses[code] >= p.MaxUses:
+		return "has been used up"
+	case p.MaxUsesPerUser > 0 && e.userUses[code][userID] >= p.MaxUsesPerUser:
+		return "has already been used"
+	}
+	return ""
+}
+
+// Apply computes the discounts the codes give on order without redeeming
+// them. Every code must exist, be active, have uses left and apply to the
+// order; otherwise a *CodeError is returned. Repeated codes count once. The
+// discounts of several codes add up, but never exceed the price of a line
+// or of shipping.
+func (e *Engine) Apply(order Order, codes []string) (Result, error) {
+	res := Result{
+		Lines:    make([]pb.Money, len(order.Lines)),
+		Shipping: pb.Money{CurrencyCode: order.Currency},
+		Total:    pb.Money{CurrencyCode: order.Currency},
+		userID:   order.UserID,
+	}
+	lineAmounts := make([]pb.Money, len(order.Lines))
+	for i, l := range order.Lines {
+		res.Lines[i] = pb.Money{CurrencyCode: order.Currency}
+		amount, err := money.Multiply(l.UnitPrice, int64(l.Quantity))
+		if err != nil {
+			return Result{}, err
+		}
+		lineAmounts[i] = amount
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range codes {
+		code := normalize(c)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		p, ok := e.promos[code]
+		if !ok {
+			return Result{}, &CodeError{Code: c, Reason: "does not exist"}
+		}
+		e.mu.Lock()
+		reason := e.usableLocked(p, code, order.UserID)
+		e.mu.Unlock()
+		if reason != "" {
+			return Result{}, &CodeError{Code: c, Reason: reason}
+		}
+
+		d, err := p.apply(order, lineAmounts, res.Lines, &res.Shipping)
+		if err != nil {
+			return Result{}, err
+		}
+		if money.IsZero(d) {
+			return Result{}, &CodeError{Code: c, Reason: "does not apply to this order"}
+		}
+		res.Discounts = append(res.Discounts, Discount{Code: code, Description: p.Description, Amount: d})
+		res.Codes = append(res.Codes, code)
+		if res.Total, err = money.Sum(res.Total, d); err != nil {
+			return Result{}, err
+		}
+	}
+	return res, nil
+}
+
+// eligible reports whether a product in categories qualifies.
+func (p *Promotion) eligible(categories []string) bool {
+	if len(p.Categories) == 0 {
+		return true
+	}
+	for _, c := range categories {
+		for _, pc := range p.Categories {
+			if c == pc {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// apply adds the discounts of p to lineDiscounts and shippingDiscount,
+// capping them at lineAmounts and the shipping cost, and returns the amount
+// it took off.
+func (p *Promotion) apply(order Order, lineAmounts, lineDiscounts []pb.Money, shippingDiscount *pb.Money) (pb.Money, error) {
+	total := pb.Money{CurrencyCode: order.Currency}
+	add := func(discount *pb.Money, limit, d pb.Money) error {
+		room, err := money.Subtract(limit, *discount)
+		if err != nil {
+			return err
+		}
+		if over, err := money.Less(room, d); err != nil {
+			return err
+		} else if over {
+			d = room
+		}
+		if *discount, err = money.Sum(*discount, d); err != nil {
+			return err
+		}
+		total, err = money.Sum(total, d)
+		return err
+	}
+
+	if p.Kind == FreeShipping {
+		return total, add(shippingDiscount, order.Shipping, order.Shipping)
+	}
+
+	var eligible []int
+	for i, l := range order.Lines {
+		if p.eligible(l.Categories) {
+			eligible = append(eligible, i)
+		}
+	}
+	lineDiscount := make(map[int]pb.Money, len(eligible))
+	switch p.Kind {
+	case PercentOff:
+		rate := new(big.Rat).Quo(&p.Percent.Rat, big.NewRat(100, 1))
+		for _, i := range eligible {
+			d, err := money.MultiplyRat(lineAmounts[i], rate)
+			if err != nil {
+				return pb.Money{}, err
+			}
+			if d, err = money.Round(d, money.HalfEven); err != nil {
+				return pb.Money{}, err
+			}
+			lineDiscount[i] = d
+		}
+	case BuyXGetY:
+		for _, i := range eligible {
+			l := order.Lines[i]
+			free := l.Quantity / (p.Buy + p.Get) * p.Get
+			if free > 0 {
+				d, err := money.Multiply(l.UnitPrice, int64(free))
+				if err != nil {
+					return pb.Money{}, err
+				}
+				lineDiscount[i] = d
+			}
+		}
+	case AmountOff:
+		if p.Amount.GetCurrencyCode() != order.Currency {
+			return total, nil
+		}
+		amounts := make([]pb.Money, len(eligible))
+		for j, i := range eligible {
+			amounts[j] = lineAmounts[i]
+		}
+		shares, err := allocate(*p.Amount, amounts)
+		if err != nil {
+			return pb.Money{}, err
+		}
+		for j, d := range shares {
+			lineDiscount[eligible[j]] = d
+		}
+	}
+	for _, i := range eligible {
+		if d, ok := lineDiscount[i]; ok {
+			if err := add(&lineDiscounts[i], lineAmounts[i], d); err != nil {
+				return pb.Money{}, err
+			}
+		}
+	}
+	return total, nil
+}
+
+// Redeem records that the codes of res were used on an order. It fails with
+// a *CodeError if a code ran out of uses since res was computed. The returned
+// function gives the uses back, e.g. if the order fails later on.
+func (e *Engine) Redeem(res Result) (release func(), err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, code := range res.Codes {
+		if reason := e.usableLocked(e.promos[code], code, res.userID); reason != "" {
+			return nil, &CodeError{Code: code, Reason: reason}
+		}
+	}
+	for _, code := range res.Codes {
+		e.uses[code]++
+		if e.userUses[code] == nil {
+			e.userUses[code] = make(map[string]int)
+		}
+		e.userUses[code][res.userID]++
+	}
+	return func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		for _, code := range res.Codes {
+			e.uses[code]--
+			e.userUses[code][res.userID]--
+		}
+	}, nil
+}
+
+var nanosPerUnit = big.NewInt(1000000000)
+
+func nanos(m pb.Money) *big.Int {
+	n := new(big.Int).Mul(big.NewInt(m.GetUnits()), nanosPerUnit)
+	return n.Add(n, big.NewInt(int64(m.GetNanos())))
+}
+
+// allocate splits amount over parts in proportion to them, capped at their
+// sum, in minor units of the currency.
+func allocate(amount pb.Money, parts []pb.Money) ([]pb.Money, error) {
+	minorUnit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(9-money.MinorUnits(amount.GetCurrencyCode()))), nil)
+	ratios := make([]int64, len(parts))
+	sum := pb.Money{CurrencyCode: amount.GetCurrencyCode()}
+	var nonZero bool
+	for i, p := range parts {
+		r := new(big.Int).Quo(nanos(p), minorUnit)
+		if !r.IsInt64() {
+			return nil, money.ErrOverflow
+		}
+		ratios[i] = r.Int64()
+		nonZero = nonZero || ratios[i] > 0
+		var err error
+		if sum, err = money.Sum(sum, p); err != nil {
+			return nil, err
+		}
+	}
+	if !nonZero {
+		out := make([]pb.Money, len(parts))
+		for i := range out {
+			out[i] = pb.Money{CurrencyCode: amount.GetCurrencyCode()}
+		}
+		return out, nil
+	}
+	if over, err := money.Less(sum, amount); err != nil {
+		return nil, err
+	} else if over {
+		amount = sum
+	}
+	return money.Allocate(amount, ratios)
+}
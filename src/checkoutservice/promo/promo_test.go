@@ -0,0 +1,296 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package promo
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+const testRules = `{
+	"promotions": [
+		{"code": "TENOFF", "description": "10% off", "type": "percent_off", "percent": 10},
+		{"code": "VINTAGE50", "type": "percent_off", "percent": "50", "categories": ["vintage"]},
+		{"code": "FIVE", "type": "amount_off", "amount": {"currency_code": "USD", "units": 5}},
+		{"code": "HUGE", "type": "amount_off", "amount": {"currency_code": "USD", "units": 1000}},
+		{"code": "B2G1", "type": "buy_x_get_y", "buy": 2, "get": 1},
+		{"code": "SHIPFREE", "type": "free_shipping"},
+		{"code": "OLD", "type": "free_shipping", "expires": "2020-01-01T00:00:00Z"},
+		{"code": "SOON", "type": "free_shipping", "starts": "2030-01-01T00:00:00Z"},
+		{"code": "ONCE", "type": "free_shipping", "max_uses_per_user": 1},
+		{"code": "TWICE", "type": "free_shipping", "max_uses": 2}
+	]
+}`
+
+func newTestEngine(t *testing.T) *Engine {
+	t.Helper()
+	var rules Rules
+	if err := json.Unmarshal([]byte(testRules), &rules); err != nil {
+		t.Fatal(err)
+	}
+	e, err := New(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.now = func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) }
+	return e
+}
+
+func usd(u int64, n int32) pb.Money { return pb.Money{CurrencyCode: "USD", Units: u, Nanos: n} }
+
+func testOrder(userID string) Order {
+	return Order{
+		UserID:   userID,
+		Currency: "USD",
+		Lines: []Line{
+			{ProductID: "A", Categories: []string{"vintage"}, UnitPrice: usd(10, 0), Quantity: 3},
+			{ProductID: "B", Categories: []string{"kitchen"}, UnitPrice: usd(2, 500000000), Quantity: 4},
+		},
+		Shipping: usd(8, 990000000),
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		codes     []string
+		wantLines []pb.Money
+		wantShip  pb.Money
+		wantTotal pb.Money
+	}{
+		{
+			name:      "no codes",
+			wantLines: []pb.Money{usd(0, 0), usd(0, 0)},
+			wantShip:  usd(0, 0),
+			wantTotal: usd(0, 0),
+		},
+		{
+			name:      "percent off",
+			codes:     []string{"tenoff"},
+			wantLines: []pb.Money{usd(3, 0), usd(1, 0)},
+			wantShip:  usd(0, 0),
+			wantTotal: usd(4, 0),
+		},
+		{
+			name:      "category scoped",
+			codes:     []string{"VINTAGE50"},
+			wantLines: []pb.Money{usd(15, 0), usd(0, 0)},
+			wantShip:  usd(0, 0),
+			wantTotal: usd(15, 0),
+		},
+		{
+			name:      "amount off is allocated",
+			codes:     []string{"FIVE"},
+			wantLines: []pb.Money{usd(3, 750000000), usd(1, 250000000)},
+			wantShip:  usd(0, 0),
+			wantTotal: usd(5, 0),
+		},
+		{
+			name:      "amount off is capped",
+			codes:     []string{"HUGE"},
+			wantLines: []pb.Money{usd(30, 0), usd(10, 0)},
+			wantShip:  usd(0, 0),
+			wantTotal: usd(40, 0),
+		},
+		{
+			name:      "buy x get y",
+			codes:     []string{"B2G1"},
+			wantLines: []pb.Money{usd(10, 0), usd(2, 500000000)},
+			wantShip:  usd(0, 0),
+			wantTotal: usd(12, 500000000),
+		},
+		{
+			name:      "free shipping",
+			codes:     []string{"SHIPFREE"},
+			wantLines: []pb.Money{usd(0, 0), usd(0, 0)},
+			wantShip:  usd(8, 990000000),
+			wantTotal: usd(8, 990000000),
+		},
+		{
+			name:      "stacked codes never exceed the price",
+			codes:     []string{"VINTAGE50", "HUGE", "SHIPFREE", "VINTAGE50"},
+			wantLines: []pb.Money{usd(30, 0), usd(10, 0)},
+			wantShip:  usd(8, 990000000),
+			wantTotal: usd(48, 990000000),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := newTestEngine(t).Apply(testOrder("u1"), tt.codes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(res.Lines, tt.wantLines) {
+				t.Errorf("line discounts = %v, want %v", res.Lines, tt.wantLines)
+			}
+			if !reflect.DeepEqual(res.Shipping, tt.wantShip) {
+				t.Errorf("shipping discount = %v, want %v", res.Shipping, tt.wantShip)
+			}
+			if !reflect.DeepEqual(res.Total, tt.wantTotal) {
+				t.Errorf("total discount = %v, want %v", res.Total, tt.wantTotal)
+			}
+			sum := usd(0, 0)
+			for _, d := range res.Discounts {
+				sum.Units += d.Amount.Units
+				sum.Nanos += d.Amount.Nanos
+			}
+			if sum.Nanos >= 1000000000 {
+				sum.Units, sum.Nanos = sum.Units+1, sum.Nanos-1000000000
+			}
+			if !reflect.DeepEqual(sum, tt.wantTotal) {
+				t.Errorf("itemized discounts add up to %v, want %v", sum, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestApplyRejectsCodes(t *testing.T) {
+	e := newTestEngine(t)
+	eur := testOrder("u1")
+	eur.Currency = "EUR"
+	for i := range eur.Lines {
+		eur.Lines[i].UnitPrice.CurrencyCode = "EUR"
+	}
+	eur.Shipping.CurrencyCode = "EUR"
+
+	tests := []struct {
+		name  string
+		order Order
+		code  string
+		want  string
+	}{
+		{"unknown", testOrder("u1"), "NOPE", "does not exist"},
+		{"expired", testOrder("u1"), "OLD", "has expired"},
+		{"not started", testOrder("u1"), "SOON", "is not active yet"},
+		{"other currency", eur, "FIVE", "does not apply to this order"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := e.Apply(tt.order, []string{tt.code})
+			cerr, ok := err.(*CodeError)
+			if !ok || cerr.Reason != tt.want {
+				t.Errorf("Apply() error = %v, want reason %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedeemEnforcesUsageLimits(t *testing.T) {
+	e := newTestEngine(t)
+	redeem := func(user, code string) error {
+		res, err := e.Apply(testOrder(user), []string{code})
+		if err != nil {
+			return err
+		}
+		_, err = e.Redeem(res)
+		return err
+	}
+
+	if err := redeem("u1", "ONCE"); err != nil {
+		t.Fatal(err)
+	}
+	if err := redeem("u1", "ONCE"); err == nil {
+		t.Error("per-user limit not enforced")
+	}
+	if err := redeem("u2", "ONCE"); err != nil {
+		t.Errorf("other user: %v", err)
+	}
+
+	if err := redeem("u1", "TWICE"); err != nil {
+		t.Fatal(err)
+	}
+	res, err := e.Apply(testOrder("u2"), []string{"TWICE"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := redeem("u3", "TWICE"); err != nil {
+		t.Fatal(err)
+	}
+	// res was computed before the last use was taken.
+	if _, err := e.Redeem(res); err == nil {
+		t.Error("global limit not enforced")
+	}
+}
+
+func TestRedeemRelease(t *testing.T) {
+	e := newTestEngine(t)
+	res, err := e.Apply(testOrder("u1"), []string{"ONCE"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release, err := e.Redeem(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if _, err := e.Apply(testOrder("u1"), []string{"ONCE"}); err != nil {
+		t.Errorf("code not usable after release: %v", err)
+	}
+}
+
+func TestNewRejectsInvalidPromotions(t *testing.T) {
+	tests := map[string]string{
+		"no code":          `{"promotions": [{"type": "free_shipping"}]}`,
+		"unknown type":     `{"promotions": [{"code": "X", "type": "bogo"}]}`,
+		"percent too high": `{"promotions": [{"code": "X", "type": "percent_off", "percent": 101}]}`,
+		"no amount":        `{"promotions": [{"code": "X", "type": "amount_off"}]}`,
+		"no currency":      `{"promotions": [{"code": "X", "type": "amount_off", "amount": {"units": 1}}]}`,
+		"zero get":         `{"promotions": [{"code": "X", "type": "buy_x_get_y", "buy": 1}]}`,
+		"duplicate":        `{"promotions": [{"code": "X", "type": "free_shipping"}, {"code": "x", "type": "free_shipping"}]}`,
+		"expires before start": `{"promotions": [{"code": "X", "type": "free_shipping",
+			"starts": "2021-01-01T00:00:00Z", "expires": "2020-01-01T00:00:00Z"}]}`,
+	}
+	for name, rules := range tests {
+		t.Run(name, func(t *testing.T) {
+			var r Rules
+			if err := json.Unmarshal([]byte(rules), &r); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := New(r); err == nil {
+				t.Error("New() succeeded")
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	got, err := allocate(usd(0, 40000000), []pb.Money{usd(1, 0), usd(1, 0), usd(1, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []pb.Money{usd(0, 20000000), usd(0, 10000000), usd(0, 10000000)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("allocate() = %v, want %v", got, want)
+	}
+
+	// The amount is capped at the sum of the parts.
+	got, err = allocate(usd(5, 0), []pb.Money{usd(1, 0), usd(2, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []pb.Money{usd(1, 0), usd(2, 0)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("allocate() over the sum = %v, want %v", got, want)
+	}
+}
+
+func TestLoadDefaultPromotions(t *testing.T) {
+	if _, err := Load("../promotions.json"); err != nil {
+		t.Fatal(err)
+	}
+}
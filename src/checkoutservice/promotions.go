@@ -0,0 +1,83 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/promo"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+const defaultPromotionsPath = "promotions.json"
+
+// loadPromoEngine loads the promotions file named by PROMOTIONS_FILE. If it
+// is unset, promotions.json is used if it exists and no promotions are
+// offered otherwise.
+func loadPromoEngine() (*promo.Engine, error) {
+	path := os.Getenv("PROMOTIONS_FILE")
+	if path == "" {
+		if _, err := os.Stat(defaultPromotionsPath); os.IsNotExist(err) {
+			log.Warnf("no promotions at %q, not offering any", defaultPromotionsPath)
+			return &promo.Engine{}, nil
+		}
+		path = defaultPromotionsPath
+	}
+	log.Infof("loading promotions from %q", path)
+	return promo.Load(path)
+}
+
+// applyPromotions sets the discount of every order item and returns the
+// discounts of the whole order. Codes that cannot be applied are reported
+// as *promo.CodeError.
+func (cs *checkoutService) applyPromotions(userID, userCurrency string, items []*pb.OrderItem, products map[string]*pb.Product, shippingCost *pb.Money, codes []string) (promo.Result, error) {
+	order := promo.Order{
+		UserID:   userID,
+		Currency: userCurrency,
+		Lines:    make([]promo.Line, len(items)),
+		Shipping: *shippingCost,
+	}
+	for i, it := range items {
+		order.Lines[i] = promo.Line{
+			ProductID:  it.GetItem().GetProductId(),
+			Categories: products[it.GetItem().GetProductId()].GetCategories(),
+			UnitPrice:  *it.GetCost(),
+			Quantity:   it.GetItem().GetQuantity(),
+		}
+	}
+	res, err := cs.promos.Apply(order, codes)
+	if err != nil {
+		return promo.Result{}, err
+	}
+	for i, it := range items {
+		discount := res.Lines[i]
+		it.Discount = &discount
+	}
+	return res, nil
+}
+
+// orderDiscounts converts the discounts of res for OrderResult.
+func orderDiscounts(res promo.Result) []*pb.Discount {
+	var out []*pb.Discount
+	for _, d := range res.Discounts {
+		amount := d.Amount
+		out = append(out, &pb.Discount{
+			PromoCode:   d.Code,
+			Description: d.Description,
+			Amount:      &amount,
+		})
+	}
+	return out
+}
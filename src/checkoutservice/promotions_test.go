@@ -0,0 +1,130 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/promo"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+const testPromotions = `{
+	"promotions": [
+		{"code": "HALF", "description": "Half off", "type": "percent_off", "percent": 50},
+		{"code": "SHIPFREE", "description": "Free shipping", "type": "free_shipping"},
+		{"code": "ONCE", "type": "free_shipping", "max_uses": 1}
+	]
+}`
+
+func newTestPromoEngine(t *testing.T) *promo.Engine {
+	t.Helper()
+	var r promo.Rules
+	if err := json.Unmarshal([]byte(testPromotions), &r); err != nil {
+		t.Fatal(err)
+	}
+	e, err := promo.New(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestPlaceOrderAppliesPromotions(t *testing.T) {
+	f := newFakeDownstream()
+	cs, _ := newTestCheckoutService(t, f)
+	cs.promos = newTestPromoEngine(t)
+	cs.taxes = newTestTaxEngine(t, `{"jurisdictions": [{"country": "United States", "rate": "0.1"}]}`)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 2}}
+
+	req := testPlaceOrderRequest("u1")
+	req.PromoCodes = []string{"half", "SHIPFREE"}
+	resp, err := cs.PlaceOrder(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := resp.GetOrder()
+
+	wantDiscounts := []*pb.Discount{
+		{PromoCode: "HALF", Description: "Half off", Amount: &pb.Money{CurrencyCode: "USD", Units: 67, Nanos: 990000000}},
+		{PromoCode: "SHIPFREE", Description: "Free shipping", Amount: &pb.Money{CurrencyCode: "USD", Units: 8, Nanos: 990000000}},
+	}
+	if len(order.GetDiscounts()) != len(wantDiscounts) {
+		t.Fatalf("discounts = %v, want %v", order.GetDiscounts(), wantDiscounts)
+	}
+	for i, d := range order.GetDiscounts() {
+		if !proto.Equal(d, wantDiscounts[i]) {
+			t.Errorf("discount %d = %v, want %v", i, d, wantDiscounts[i])
+		}
+	}
+	if want := (&pb.Money{CurrencyCode: "USD", Units: 67, Nanos: 990000000}); !proto.Equal(order.GetItems()[0].GetDiscount(), want) {
+		t.Errorf("item discount = %v, want %v", order.GetItems()[0].GetDiscount(), want)
+	}
+	// Tax is charged on the discounted price.
+	if want := (&pb.Money{CurrencyCode: "USD", Units: 6, Nanos: 800000000}); !proto.Equal(order.GetTotalTax(), want) {
+		t.Errorf("total tax = %v, want %v", order.GetTotalTax(), want)
+	}
+	// 2*67.99 + 8.99 - 67.99 - 8.99 + 6.80
+	if want := (&pb.Money{CurrencyCode: "USD", Units: 74, Nanos: 790000000}); !proto.Equal(f.charges[0].GetAmount(), want) {
+		t.Errorf("charged %v, want %v", f.charges[0].GetAmount(), want)
+	}
+}
+
+func TestPlaceOrderRejectsInvalidPromoCode(t *testing.T) {
+	f := newFakeDownstream()
+	cs, _ := newTestCheckoutService(t, f)
+	cs.promos = newTestPromoEngine(t)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	req := testPlaceOrderRequest("u1")
+	req.PromoCodes = []string{"BOGUS"}
+	_, err := cs.PlaceOrder(context.Background(), req)
+	if got, want := violatedFields(t, err), []string{"promo_codes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("violated fields = %v, want %v", got, want)
+	}
+	if len(f.charges) != 0 {
+		t.Errorf("card charged %d times", len(f.charges))
+	}
+}
+
+func TestPlaceOrderReleasesPromoCodeOnFailure(t *testing.T) {
+	f := newFakeDownstream()
+	cs, _ := newTestCheckoutService(t, f)
+	cs.promos = newTestPromoEngine(t)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+	f.shipErr = errors.New("no trucks")
+
+	req := testPlaceOrderRequest("u1")
+	req.PromoCodes = []string{"ONCE"}
+	if _, err := cs.PlaceOrder(context.Background(), req); err == nil {
+		t.Fatal("PlaceOrder() succeeded")
+	}
+
+	f.shipErr = nil
+	if _, err := cs.PlaceOrder(context.Background(), req); err != nil {
+		t.Fatalf("code not usable after failed order: %v", err)
+	}
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+	_, err := cs.PlaceOrder(context.Background(), req)
+	if got, want := violatedFields(t, err), []string{"promo_codes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("violated fields = %v, want %v", got, want)
+	}
+}
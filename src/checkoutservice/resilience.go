@@ -0,0 +1,93 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"expvar"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/resilience"
+)
+
+// defaultResilience holds the policies of the downstream services unless
+// overridden by RESILIENCE_CONFIG. Only RPCs that can safely run twice are
+// retried: charging a card, shipping an order or sending an email are not.
+// Charges are given longer than any order, as a charge cut short may still
+// go through.
+var defaultResilience = resilience.Config{
+	"productcatalog": {
+		Timeout: resilience.Duration(time.Second),
+		Retry:   resilience.RetryPolicy{Idempotent: []string{"ListProducts", "GetProduct", "SearchProducts"}},
+	},
+	"cart": {
+		Timeout: resilience.Duration(time.Second),
+		Retry:   resilience.RetryPolicy{Idempotent: []string{"GetCart", "EmptyCart"}},
+	},
+	"currency": {
+		Timeout: resilience.Duration(time.Second),
+		Retry:   resilience.RetryPolicy{Idempotent: []string{"GetSupportedCurrencies", "Convert"}},
+	},
+	"shipping": {
+		Timeout: resilience.Duration(2 * time.Second),
+		Retry:   resilience.RetryPolicy{Idempotent: []string{"GetQuote", "CancelShipment"}},
+	},
+	"payment": {
+		Timeout: resilience.Duration(time.Minute),
+	},
+	"email": {
+		Timeout: resilience.Duration(5 * time.Second),
+	},
+}
+
+// loadResilienceConfig returns the policies of the downstream services. The
+// JSON file named by RESILIENCE_CONFIG, if set, replaces the policies of the
+// services it lists.
+func loadResilienceConfig() (resilience.Config, error) {
+	path := os.Getenv("RESILIENCE_CONFIG")
+	if path == "" {
+		return defaultResilience, nil
+	}
+	log.Infof("loading resilience policies from %q", path)
+	c, err := resilience.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return defaultResilience.Merge(c), nil
+}
+
+// resilienceClient returns the guard enforcing the resilience policy of the
+// downstream called name. Its circuit breaker metrics are added to
+// downstreams.
+func (cs *checkoutService) resilienceClient(name string) *resilience.Client {
+	c := resilience.NewClient(name, cs.resilience.Policy(name))
+	if cs.downstreams == nil {
+		cs.downstreams = new(expvar.Map).Init()
+	}
+	cs.downstreams.Set(name, c.Metrics())
+	return c
+}
+
+// guard returns the options installing the resilience policy of the
+// downstream called name on its connection.
+func (cs *checkoutService) guard(name string) []grpc.DialOption {
+	c := cs.resilienceClient(name)
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(c.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(c.StreamClientInterceptor()),
+	}
+}
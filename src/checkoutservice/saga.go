@@ -0,0 +1,134 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/tracing"
+)
+
+// compensationTimeout bounds how long the compensations of an aborted order
+// may take. They run on a context detached from the (possibly already
+// cancelled) request.
+const compensationTimeout = 10 * time.Second
+
+// checkoutStep names a stage of PlaceOrder tracked by the saga.
+type checkoutStep string
+
+const (
+	stepQuote  checkoutStep = "quote"
+	stepCharge checkoutStep = "charge"
+	stepShip   checkoutStep = "ship"
+	stepStore  checkoutStep = "store"
+	stepOutbox checkoutStep = "outbox"
+)
+
+// compensation undoes the side effect of a completed checkout step.
+type compensation func(ctx context.Context) error
+
+// compensator reverses the payment and shipping side effects of an order
+// whose later steps failed.
+type compensator interface {
+	refundPayment(ctx context.Context, txID string, amount *pb.Money) error
+	cancelShipment(ctx context.Context, trackingID string) error
+}
+
+// errManualRefund is the compensation error of charges that have to be
+// refunded by hand.
+var errManualRefund = errors.New("payment service cannot refund, the charge must be refunded by hand")
+
+// serviceCompensator is the default compensator. It cancels shipments
+// through ShippingService. PaymentService exposes no refund RPC, so refunds
+// are logged to be made by hand and fail the compensation, so that the
+// order is not reported as rolled back while the customer stays charged.
+type serviceCompensator struct {
+	cs *checkoutService
+}
+
+func (serviceCompensator) refundPayment(ctx context.Context, txID string, amount *pb.Money) error {
+	log.Errorf("refund required: transaction_id=%q amount=%d.%09d %s", txID,
+		amount.GetUnits(), amount.GetNanos(), amount.GetCurrencyCode())
+	return errManualRefund
+}
+
+func (c serviceCompensator) cancelShipment(ctx context.Context, trackingID string) error {
+	_, err := c.cs.shippingSvc.CancelShipment(ctx, &pb.CancelShipmentRequest{
+		TrackingId: trackingID})
+	return err
+}
+
+type sagaStep struct {
+	name       checkoutStep
+	compensate compensation
+}
+
+// saga records the completed steps of a single order so their side effects
+// can be rolled back, in reverse order, when a later step fails.
+type saga struct {
+	orderID   string
+	completed []sagaStep
+}
+
+func newSaga(orderID string) *saga {
+	return &saga{orderID: orderID}
+}
+
+// complete records that step has finished. comp may be nil if the step has
+// nothing to undo.
+func (s *saga) complete(step checkoutStep, comp compensation) {
+	s.completed = append(s.completed, sagaStep{name: step, compensate: comp})
+}
+
+// steps returns the completed steps in the order they were recorded.
+func (s *saga) steps() []checkoutStep {
+	out := make([]checkoutStep, len(s.completed))
+	for i, st := range s.completed {
+		out[i] = st.name
+	}
+	return out
+}
+
+// abort runs the registered compensations in reverse order. Every
+// compensation is attempted; the first error is returned.
+func (s *saga) abort(ctx context.Context) error {
+	cctx, cancel := context.WithTimeout(
+		trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx)),
+		compensationTimeout)
+	defer cancel()
+
+	var firstErr error
+	for i := len(s.completed) - 1; i >= 0; i-- {
+		st := s.completed[i]
+		if st.compensate == nil {
+			continue
+		}
+		log.Infof("[order %s] compensating step %q", s.orderID, st.name)
+		tracing.AddEvent(cctx, "compensation", compensatedStepKey.String(string(st.name)))
+		if err := st.compensate(cctx); err != nil {
+			log.Errorf("[order %s] compensation of step %q failed: %+v", s.orderID, st.name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	s.completed = nil
+	return firstErr
+}
@@ -0,0 +1,170 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/outbox"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+func TestSagaAbortRunsCompensationsInReverse(t *testing.T) {
+	var order []string
+	comp := func(name string, err error) compensation {
+		return func(context.Context) error {
+			order = append(order, name)
+			return err
+		}
+	}
+	errFirst := errors.New("charge compensation failed")
+
+	sg := newSaga("order-1")
+	sg.complete(stepQuote, nil)
+	sg.complete(stepCharge, comp("charge", errFirst))
+	sg.complete(stepShip, comp("ship", errors.New("ignored")))
+	if got, want := sg.steps(), []checkoutStep{stepQuote, stepCharge, stepShip}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("steps() = %v, want %v", got, want)
+	}
+
+	err := sg.abort(context.Background())
+	if want := []string{"ship", "charge"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("compensation order = %v, want %v", order, want)
+	}
+	if err == nil || err.Error() != "ignored" {
+		t.Errorf("abort() = %v, want the first error encountered", err)
+	}
+	if len(sg.steps()) != 0 {
+		t.Errorf("steps() after abort = %v, want none", sg.steps())
+	}
+}
+
+func TestSagaAbortUsesLiveContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	sg := newSaga("order-1")
+	sg.complete(stepCharge, func(ctx context.Context) error { return ctx.Err() })
+	if err := sg.abort(ctx); err != nil {
+		t.Errorf("abort() = %v, want compensation to run on a live context", err)
+	}
+}
+
+func TestPlaceOrderRefundsWhenShippingFails(t *testing.T) {
+	f := newFakeDownstream()
+	f.shipErr = status.Error(codes.Unavailable, "no trucks")
+	cs, comp := newTestCheckoutService(t, f)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 2}}
+
+	_, err := cs.PlaceOrder(context.Background(), testPlaceOrderRequest("u1"))
+	if got, want := status.Code(err), codes.Unavailable; got != want {
+		t.Fatalf("PlaceOrder() code = %s, want %s (err: %v)", got, want, err)
+	}
+	if want := []string{"TX-1"}; !reflect.DeepEqual(comp.refunds, want) {
+		t.Errorf("refunds = %v, want %v", comp.refunds, want)
+	}
+	if len(comp.cancelled) != 0 {
+		t.Errorf("cancelled shipments = %v, want none", comp.cancelled)
+	}
+	if len(f.emptied) != 0 || len(f.cart["u1"]) != 1 {
+		t.Errorf("cart was emptied after a failed order")
+	}
+}
+
+func TestPlaceOrderReportsFailedCompensation(t *testing.T) {
+	f := newFakeDownstream()
+	f.shipErr = status.Error(codes.Unavailable, "no trucks")
+	cs, comp := newTestCheckoutService(t, f)
+	comp.err = errors.New("refund rejected")
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	_, err := cs.PlaceOrder(context.Background(), testPlaceOrderRequest("u1"))
+	if got, want := status.Code(err), codes.Internal; got != want {
+		t.Errorf("PlaceOrder() code = %s, want %s (err: %v)", got, want, err)
+	}
+}
+
+func TestPlaceOrderReportsManualRefund(t *testing.T) {
+	f := newFakeDownstream()
+	f.shipErr = status.Error(codes.Unavailable, "no trucks")
+	cs, _ := newTestCheckoutService(t, f)
+	cs.compensator = serviceCompensator{cs: cs}
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	_, err := cs.PlaceOrder(context.Background(), testPlaceOrderRequest("u1"))
+	if got, want := status.Code(err), codes.Internal; got != want {
+		t.Errorf("PlaceOrder() code = %s, want %s as the charge was not refunded (err: %v)", got, want, err)
+	}
+	if len(f.charges) != 1 {
+		t.Errorf("charges = %d, want 1", len(f.charges))
+	}
+}
+
+// failingOutboxStore is an outbox store that cannot take new messages.
+type failingOutboxStore struct {
+	outbox.Store
+}
+
+func (failingOutboxStore) Add(context.Context, []*outbox.Message) error {
+	return errors.New("disk full")
+}
+
+func TestPlaceOrderCompensatesWhenSideEffectsCannotBeQueued(t *testing.T) {
+	f := newFakeDownstream()
+	cs, comp := newTestCheckoutService(t, f)
+	cs.outbox = cs.newOutboxDispatcher(failingOutboxStore{outbox.NewMemory()}, testOutboxPolicy)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	_, err := cs.PlaceOrder(context.Background(), testPlaceOrderRequest("u1"))
+	if got, want := status.Code(err), codes.Internal; got != want {
+		t.Fatalf("PlaceOrder() code = %s, want %s (err: %v)", got, want, err)
+	}
+	if want := []string{"TX-1"}; !reflect.DeepEqual(comp.refunds, want) {
+		t.Errorf("refunds = %v, want %v", comp.refunds, want)
+	}
+	if want := []string{"TRACK-1"}; !reflect.DeepEqual(comp.cancelled, want) {
+		t.Errorf("cancelled shipments = %v, want %v", comp.cancelled, want)
+	}
+	if recs, _, err := cs.orders.List(context.Background(), "u1", 0, ""); err != nil || len(recs) != 0 {
+		t.Errorf("stored orders = (%v, %v), want the failed order removed", recs, err)
+	}
+}
+
+func TestPlaceOrderNoCompensationOnSuccess(t *testing.T) {
+	f := newFakeDownstream()
+	cs, comp := newTestCheckoutService(t, f)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	resp, err := cs.PlaceOrder(context.Background(), testPlaceOrderRequest("u1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.GetOrder().GetShippingTrackingId(); got != "TRACK-1" {
+		t.Errorf("tracking id = %q, want %q", got, "TRACK-1")
+	}
+	if len(comp.refunds) != 0 || len(comp.cancelled) != 0 {
+		t.Errorf("unexpected compensations: refunds=%v cancelled=%v", comp.refunds, comp.cancelled)
+	}
+	dispatchOutbox(t, cs)
+	if want := []string{"u1"}; !reflect.DeepEqual(f.emptied, want) {
+		t.Errorf("emptied carts = %v, want %v", f.emptied, want)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/outbox"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+const defaultOutboxPath = "outbox.db"
+
+// Kinds of the outbox messages for the side effects of a placed order.
+const (
+	outboxOrderConfirmation = "order_confirmation"
+	outboxEmptyCart         = "empty_cart"
+)
+
+// openOutbox creates the outbox store selected by OUTBOX_STORE ("memory",
+// the default, or "bolt"). The bolt store is kept at OUTBOX_PATH, which must
+// be on a volume for the outbox to survive a restart.
+func openOutbox() (outbox.Store, error) {
+	switch kind := os.Getenv("OUTBOX_STORE"); kind {
+	case "", "memory":
+		log.Warn("keeping outbox in memory, undelivered side effects are lost on restart")
+		return outbox.NewMemory(), nil
+	case "bolt":
+		path := defaultOutboxPath
+		if v := os.Getenv("OUTBOX_PATH"); v != "" {
+			path = v
+		}
+		log.Infof("keeping outbox in bolt database %q", path)
+		return outbox.OpenBolt(path)
+	default:
+		return nil, fmt.Errorf("unknown OUTBOX_STORE %q", kind)
+	}
+}
+
+// newOutboxDispatcher returns a dispatcher delivering the side effects of
+// placed orders from store.
+func (cs *checkoutService) newOutboxDispatcher(store outbox.Store, policy outbox.Policy) *outbox.Dispatcher {
+	d := outbox.NewDispatcher(store, policy, log)
+	d.Handle(outboxOrderConfirmation, func(ctx context.Context, m *outbox.Message) error {
+		var req pb.SendOrderConfirmationRequest
+		if err := proto.Unmarshal(m.Payload, &req); err != nil {
+			return outbox.Permanent(err)
+		}
+		return permanentIfInvalid(cs.sendOrderConfirmation(ctx, req.GetEmail(), req.GetOrder()))
+	})
+	d.Handle(outboxEmptyCart, func(ctx context.Context, m *outbox.Message) error {
+		var req pb.EmptyCartRequest
+		if err := proto.Unmarshal(m.Payload, &req); err != nil {
+			return outbox.Permanent(err)
+		}
+		return permanentIfInvalid(cs.emptyUserCart(ctx, req.GetUserId()))
+	})
+	return d
+}
+
+// permanentIfInvalid marks errors about the request itself as permanent, as
+// they will not go away on retry. The status may be wrapped.
+func permanentIfInvalid(err error) error {
+	var se interface{ GRPCStatus() *status.Status }
+	if !errors.As(err, &se) {
+		return err
+	}
+	switch se.GRPCStatus().Code() {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.Unimplemented:
+		return outbox.Permanent(err)
+	}
+	return err
+}
+
+// orderSideEffects returns the outbox messages for what remains to be done
+// once order has been paid for, shipped and stored.
+func orderSideEffects(req *pb.PlaceOrderRequest, order *pb.OrderResult) ([]*outbox.Message, error) {
+	emptyCart, err := proto.Marshal(&pb.EmptyCartRequest{UserId: req.GetUserId()})
+	if err != nil {
+		return nil, err
+	}
+	confirmation, err := proto.Marshal(&pb.SendOrderConfirmationRequest{Email: req.GetEmail(), Order: order})
+	if err != nil {
+		return nil, err
+	}
+	return []*outbox.Message{
+		{Kind: outboxEmptyCart, Key: order.GetOrderId(), Payload: emptyCart},
+		{Kind: outboxOrderConfirmation, Key: order.GetOrderId(), Payload: confirmation},
+	}, nil
+}
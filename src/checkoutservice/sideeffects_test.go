@@ -0,0 +1,105 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/outbox"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+func TestPlaceOrderDefersSideEffects(t *testing.T) {
+	f := newFakeDownstream()
+	f.emailErr = status.Error(codes.Unavailable, "mail server down")
+	cs, _ := newTestCheckoutService(t, f)
+	store := outbox.NewMemory()
+	cs.outbox = cs.newOutboxDispatcher(store, testOutboxPolicy)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	resp, err := cs.PlaceOrder(context.Background(), testPlaceOrderRequest("u1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.emptied) != 0 || len(f.emails) != 0 {
+		t.Fatalf("side effects ran during PlaceOrder: emptied %v, emails %v", f.emptied, f.emails)
+	}
+
+	dispatchOutbox(t, cs)
+	if want := []string{"u1"}; !reflect.DeepEqual(f.emptied, want) {
+		t.Errorf("emptied carts = %v, want %v", f.emptied, want)
+	}
+	if n, _ := store.Pending(context.Background()); n != 1 {
+		t.Fatalf("%d messages pending, want the failed confirmation", n)
+	}
+
+	f.emailErr = nil
+	dispatchOutbox(t, cs)
+	if len(f.emails) != 1 || f.emails[0].GetOrder().GetOrderId() != resp.GetOrder().GetOrderId() {
+		t.Errorf("emails = %v, want the confirmation of the order", f.emails)
+	}
+	if n, _ := store.Pending(context.Background()); n != 0 {
+		t.Errorf("%d messages pending, want 0", n)
+	}
+}
+
+func TestOutboxDeadLettersRejectedConfirmation(t *testing.T) {
+	f := newFakeDownstream()
+	f.emailErr = status.Error(codes.InvalidArgument, "no such mailbox")
+	cs, _ := newTestCheckoutService(t, f)
+	store := outbox.NewMemory()
+	cs.outbox = cs.newOutboxDispatcher(store, testOutboxPolicy)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	if _, err := cs.PlaceOrder(context.Background(), testPlaceOrderRequest("u1")); err != nil {
+		t.Fatal(err)
+	}
+	dispatchOutbox(t, cs)
+
+	dead, err := store.DeadLetters(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || dead[0].Kind != outboxOrderConfirmation || dead[0].Attempts != 1 {
+		t.Errorf("dead letters = %v, want the confirmation after one attempt", dead)
+	}
+}
+
+func TestOutboxDeadLettersRejectedCartEmptying(t *testing.T) {
+	f := newFakeDownstream()
+	f.emptyErr = status.Error(codes.InvalidArgument, "no such user")
+	cs, _ := newTestCheckoutService(t, f)
+	store := outbox.NewMemory()
+	cs.outbox = cs.newOutboxDispatcher(store, testOutboxPolicy)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	if _, err := cs.PlaceOrder(context.Background(), testPlaceOrderRequest("u1")); err != nil {
+		t.Fatal(err)
+	}
+	dispatchOutbox(t, cs)
+
+	dead, err := store.DeadLetters(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || dead[0].Kind != outboxEmptyCart || dead[0].Attempts != 1 {
+		t.Errorf("dead letters = %v, want the cart emptying after one attempt", dead)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/tracing"
+)
+
+var tracer = otel.Tracer("checkoutservice")
+
+// Attributes of the checkout spans.
+const (
+	failedStageKey     = attribute.Key("checkout.failed_stage")
+	compensatedStepKey = attribute.Key("checkout.compensated_step")
+	fraudDecisionKey   = attribute.Key("fraud.decision")
+)
+
+// traceStage runs fn in a child span named after the stage of the checkout.
+// If fn fails, the stage is recorded on the span of ctx as the one that
+// failed.
+func traceStage(ctx context.Context, stage string, fn func(ctx context.Context) error) error {
+	err := tracing.Run(ctx, tracer, "checkout."+stage, fn)
+	if err != nil {
+		tracing.SetAttributes(ctx, failedStageKey.String(stage))
+	}
+	return err
+}
+
+// cartQuantity returns the quantity of items in the cart.
+func cartQuantity(items []*pb.CartItem) int {
+	n := 0
+	for _, it := range items {
+		n += int(it.GetQuantity())
+	}
+	return n
+}
@@ -0,0 +1,127 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/tracing"
+)
+
+var (
+	spansOnce sync.Once
+	spans     *tracetest.InMemoryExporter
+)
+
+// recordSpans installs a tracer provider exporting to the returned exporter,
+// emptied of the spans of earlier tests.
+func recordSpans() *tracetest.InMemoryExporter {
+	spansOnce.Do(func() {
+		spans = tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(tracesdk.NewTracerProvider(tracesdk.WithSyncer(spans)))
+	})
+	spans.Reset()
+	return spans
+}
+
+// placeOrderTraced places req in a span standing for the server span of
+// PlaceOrder, and returns the attributes and events of that span.
+func placeOrderTraced(t *testing.T, cs *checkoutService, req *pb.PlaceOrderRequest) (map[attribute.Key]attribute.Value, []string, error) {
+	t.Helper()
+	exporter := recordSpans()
+	ctx, span := otel.Tracer("test").Start(context.Background(), "PlaceOrder")
+	_, err := cs.PlaceOrder(ctx, req)
+	span.End()
+
+	stubs := exporter.GetSpans()
+	root := stubs[len(stubs)-1]
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range root.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	var events []string
+	for _, e := range root.Events {
+		events = append(events, e.Name)
+	}
+	return attrs, events, err
+}
+
+func TestPlaceOrderSpans(t *testing.T) {
+	f := newFakeDownstream()
+	cs, _ := newTestCheckoutService(t, f)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 2}}
+
+	attrs, _, err := placeOrderTraced(t, cs, testPlaceOrderRequest("u1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range spans.GetSpans() {
+		names = append(names, s.Name)
+	}
+	want := []string{
+		"checkout.cart", "checkout.catalog", "checkout.quote", "checkout.currency",
+		"checkout.promotions", "checkout.tax", "checkout.redeem", "checkout.fraud",
+		"checkout.charge", "checkout.ship", "checkout.store", "checkout.outbox", "PlaceOrder",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("spans = %v, want %v", names, want)
+	}
+
+	if attrs[tracing.OrderIDKey].AsString() == "" {
+		t.Error("order.id not recorded")
+	}
+	total := tracing.Money(&pb.Money{CurrencyCode: "USD", Units: 144, Nanos: 970000000})
+	for _, kv := range append(total,
+		tracing.UserIDKey.String("u1"),
+		tracing.CartItemsKey.Int(2),
+		tracing.ShippingTrackingIDKey.String("TRACK-1")) {
+		if got := attrs[kv.Key]; got != kv.Value {
+			t.Errorf("%s = %v, want %v", kv.Key, got.Emit(), kv.Value.Emit())
+		}
+	}
+	if _, ok := attrs[tracing.EmailKey]; ok {
+		t.Error("email recorded without TRACES_RECORD_PII")
+	}
+}
+
+func TestPlaceOrderSpansRecordFailedStage(t *testing.T) {
+	f := newFakeDownstream()
+	f.shipErr = status.Error(codes.Unavailable, "no trucks")
+	cs, _ := newTestCheckoutService(t, f)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	attrs, events, err := placeOrderTraced(t, cs, testPlaceOrderRequest("u1"))
+	if err == nil {
+		t.Fatal("PlaceOrder() succeeded")
+	}
+	if got := attrs[failedStageKey].AsString(); got != "ship" {
+		t.Errorf("failed stage = %q, want ship", got)
+	}
+	if want := []string{"compensation", "compensation"}; !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v for the charge and the promo codes", events, want)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tax computes the sales tax or VAT of an order from a set of rules
+// keyed by jurisdiction and product category.
+package tax
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/money"
+)
+
+// Rate is a tax rate such as 0.0725 for 7.25%. In JSON it is written as a
+// decimal number or string.
+type Rate struct {
+	big.Rat
+}
+
+func (r *Rate) UnmarshalJSON(b []byte) error {
+	s := string(bytes.Trim(b, `"`))
+	if _, ok := r.SetString(s); !ok {
+		return fmt.Errorf("invalid tax rate %s", b)
+	}
+	return nil
+}
+
+// Jurisdiction holds the tax rules of a country, or of a state within it.
+type Jurisdiction struct {
+	// Country and State are matched case-insensitively against the shipping
+	// address. An empty State matches any state of the country that has no
+	// rules of its own.
+	Country string `json:"country"`
+	State   string `json:"state,omitempty"`
+
+	// Inclusive is set if product prices already include the tax.
+	Inclusive bool `json:"inclusive"`
+
+	// Rate applies to products in none of the categories of CategoryRates.
+	Rate Rate `json:"rate"`
+
+	// CategoryRates overrides Rate for the given product categories. For a
+	// product in several listed categories, the first of its categories wins.
+	CategoryRates map[string]*Rate `json:"category_rates,omitempty"`
+}
+
+// Rules is the content of a tax rules file.
+type Rules struct {
+	Jurisdictions []Jurisdiction `json:"jurisdictions"`
+}
+
+// Line is an order line to tax.
+type Line struct {
+	// Amount is the price of the whole line in the order currency.
+	Amount     pb.Money
+	Categories []string
+}
+
+// Result is the tax of an order.
+type Result struct {
+	Lines     []pb.Money // tax of each line, in order
+	Total     pb.Money
+	Inclusive bool
+}
+
+type jurisdictionKey struct {
+	country, state string
+}
+
+func newJurisdictionKey(country, state string) jurisdictionKey {
+	return jurisdictionKey{
+		country: strings.ToLower(strings.TrimSpace(country)),
+		state:   strings.ToLower(strings.TrimSpace(state)),
+	}
+}
+
+// Engine computes taxes. The zero Engine knows no jurisdictions and taxes
+// nothing.
+type Engine struct {
+	jurisdictions map[jurisdictionKey]*Jurisdiction
+}
+
+// New returns an Engine applying rules.
+func New(rules Rules) (*Engine, error) {
+	e := &Engine{jurisdictions: make(map[jurisdictionKey]*Jurisdiction)}
+	for i := range rules.Jurisdictions {
+		j := &rules.Jurisdictions[i]
+		if j.Country == "" {
+			return nil, fmt.Errorf("jurisdiction #%d has no country", i)
+		}
+		if j.Rate.Sign() < 0 {
+			return nil, fmt.Errorf("jurisdiction %s/%s has a negative rate", j.Country, j.State)
+		}
+		for c, r := range j.CategoryRates {
+			if r == nil || r.Sign() < 0 {
+				return nil, fmt.Errorf("jurisdiction %s/%s has an invalid rate for category %q", j.Country, j.State, c)
+			}
+		}
+		k := newJurisdictionKey(j.Country, j.State)
+		if _, dup := e.jurisdictions[k]; dup {
+			return nil, fmt.Errorf("jurisdiction %s/%s is defined twice", j.Country, j.State)
+		}
+		e.jurisdictions[k] = j
+	}
+	return e, nil
+}
+
+// Load returns an Engine applying the rules in the JSON file at path.
+func Load(path string) (*Engine, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules Rules
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse tax rules %s: %v", path, err)
+	}
+	return New(rules)
+}
+
+// jurisdiction returns the rules for addr, or nil if it is not taxed.
+func (e *Engine) jurisdiction(addr *pb.Address) *Jurisdiction {
+	if j, ok := e.jurisdictions[newJurisdictionKey(addr.GetCountry(), addr.GetState())]; ok {
+		return j
+	}
+	return e.jurisdictions[newJurisdictionKey(addr.GetCountry(), "")]
+}
+
+func (j *Jurisdiction) rate(categories []string) *big.Rat {
+	for _, c := range categories {
+		if r, ok := j.CategoryRates[c]; ok {
+			return &r.Rat
+		}
+	}
+	return &j.Rate.Rat
+}
+
+// Compute returns the tax of lines shipped to addr. All amounts must be in
+// currency. Each line is taxed, and rounded to the minor unit of currency with
+// halves to even, separately; the total is the sum of the line taxes.
+func (e *Engine) Compute(addr *pb.Address, currency string, lines []Line) (Result, error) {
+	res := Result{
+		Lines: make([]pb.Money, len(lines)),
+		Total: pb.Money{CurrencyCode: currency},
+	}
+	for i := range res.Lines {
+		res.Lines[i] = pb.Money{CurrencyCode: currency}
+	}
+	j := e.jurisdiction(addr)
+	if j == nil {
+		return res, nil
+	}
+	res.Inclusive = j.Inclusive
+
+	for i, l := range lines {
+		if l.Amount.GetCurrencyCode() != currency {
+			return Result{}, money.ErrMismatchingCurrency
+		}
+		rate := j.rate(l.Categories)
+		if j.Inclusive {
+			// The amount is price*(1+rate), of which price*rate is tax.
+			rate = new(big.Rat).Quo(rate, new(big.Rat).Add(big.NewRat(1, 1), rate))
+		}
+		t, err := money.MultiplyRat(l.Amount, rate)
+		if err != nil {
+			return Result{}, err
+		}
+		if t, err = money.Round(t, money.HalfEven); err != nil {
+			return Result{}, err
+		}
+		if res.Total, err = money.Sum(res.Total, t); err != nil {
+			return Result{}, err
+		}
+		res.Lines[i] = t
+	}
+	return res, nil
+}
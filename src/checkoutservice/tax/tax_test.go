@@ -0,0 +1,152 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tax
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+const testRules = `{
+	"jurisdictions": [
+		{"country": "United States", "rate": "0.05"},
+		{"country": "United States", "state": "CA", "rate": 0.0725,
+			"category_rates": {"gardening": "0", "vintage": "0.1"}},
+		{"country": "Germany", "inclusive": true, "rate": "0.19"}
+	]
+}`
+
+func newTestEngine(t *testing.T) *Engine {
+	t.Helper()
+	var rules Rules
+	if err := json.Unmarshal([]byte(testRules), &rules); err != nil {
+		t.Fatal(err)
+	}
+	e, err := New(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func usd(u int64, n int32) pb.Money { return pb.Money{CurrencyCode: "USD", Units: u, Nanos: n} }
+
+func TestCompute(t *testing.T) {
+	e := newTestEngine(t)
+	lines := []Line{
+		{Amount: usd(100, 0), Categories: []string{"cookware"}},
+		{Amount: usd(10, 0), Categories: []string{"gardening", "vintage"}},
+		{Amount: usd(20, 0), Categories: []string{"music", "vintage"}},
+	}
+	tests := []struct {
+		name string
+		addr *pb.Address
+		want Result
+	}{
+		{
+			name: "state rules",
+			addr: &pb.Address{Country: "united states ", State: "ca"},
+			want: Result{
+				Lines: []pb.Money{usd(7, 250000000), usd(0, 0), usd(2, 0)},
+				Total: usd(9, 250000000),
+			},
+		},
+		{
+			name: "country fallback",
+			addr: &pb.Address{Country: "United States", State: "WA"},
+			want: Result{
+				Lines: []pb.Money{usd(5, 0), usd(0, 500000000), usd(1, 0)},
+				Total: usd(6, 500000000),
+			},
+		},
+		{
+			name: "inclusive",
+			addr: &pb.Address{Country: "Germany"},
+			want: Result{
+				// 100 * 0.19/1.19 = 15.966386554..., rounded to the cent.
+				Lines:     []pb.Money{usd(15, 970000000), usd(1, 600000000), usd(3, 190000000)},
+				Total:     usd(20, 760000000),
+				Inclusive: true,
+			},
+		},
+		{
+			name: "untaxed",
+			addr: &pb.Address{Country: "France"},
+			want: Result{
+				Lines: []pb.Money{usd(0, 0), usd(0, 0), usd(0, 0)},
+				Total: usd(0, 0),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := e.Compute(tt.addr, "USD", lines)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compute() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeMismatchingCurrency(t *testing.T) {
+	e := newTestEngine(t)
+	_, err := e.Compute(&pb.Address{Country: "Germany"}, "EUR", []Line{{Amount: usd(1, 0)}})
+	if err == nil {
+		t.Error("Compute() did not fail on mismatching currency")
+	}
+}
+
+func TestZeroEngine(t *testing.T) {
+	var e Engine
+	got, err := e.Compute(&pb.Address{Country: "Germany"}, "EUR", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Result{Lines: []pb.Money{}, Total: pb.Money{CurrencyCode: "EUR"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Compute() = %+v, want %+v", got, want)
+	}
+}
+
+func TestNewRejectsInvalidRules(t *testing.T) {
+	tests := map[string]string{
+		"no country":             `{"jurisdictions": [{"rate": "0.1"}]}`,
+		"negative rate":          `{"jurisdictions": [{"country": "X", "rate": "-0.1"}]}`,
+		"negative category rate": `{"jurisdictions": [{"country": "X", "category_rates": {"a": "-1"}}]}`,
+		"duplicate":              `{"jurisdictions": [{"country": "X"}, {"country": "x"}]}`,
+	}
+	for name, rules := range tests {
+		t.Run(name, func(t *testing.T) {
+			var r Rules
+			if err := json.Unmarshal([]byte(rules), &r); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := New(r); err == nil {
+				t.Error("New() succeeded")
+			}
+		})
+	}
+}
+
+func TestLoadDefaultRules(t *testing.T) {
+	if _, err := Load("../tax_rules.json"); err != nil {
+		t.Fatal(err)
+	}
+}
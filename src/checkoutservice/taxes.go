@@ -0,0 +1,70 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/tax"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+	money "github.com/GoogleCloudPlatform/microservices-demo/src/common/money"
+)
+
+const defaultTaxRulesPath = "tax_rules.json"
+
+// loadTaxEngine loads the tax rules file named by TAX_RULES_FILE. If it is
+// unset, tax_rules.json is used if it exists and no tax is charged otherwise.
+func loadTaxEngine() (*tax.Engine, error) {
+	path := os.Getenv("TAX_RULES_FILE")
+	if path == "" {
+		if _, err := os.Stat(defaultTaxRulesPath); os.IsNotExist(err) {
+			log.Warnf("no tax rules at %q, not charging tax", defaultTaxRulesPath)
+			return &tax.Engine{}, nil
+		}
+		path = defaultTaxRulesPath
+	}
+	log.Infof("loading tax rules from %q", path)
+	return tax.Load(path)
+}
+
+// taxOrderItems sets the tax of every order item shipped to address and
+// returns the tax of the whole order. Items are taxed after their discount.
+func (cs *checkoutService) taxOrderItems(items []*pb.OrderItem, products map[string]*pb.Product, address *pb.Address, userCurrency string) (tax.Result, error) {
+	lines := make([]tax.Line, len(items))
+	for i, it := range items {
+		amount, err := money.Multiply(*it.GetCost(), int64(it.GetItem().GetQuantity()))
+		if err != nil {
+			return tax.Result{}, err
+		}
+		if it.GetDiscount() != nil {
+			if amount, err = money.Subtract(amount, *it.GetDiscount()); err != nil {
+				return tax.Result{}, err
+			}
+		}
+		lines[i] = tax.Line{
+			Amount:     amount,
+			Categories: products[it.GetItem().GetProductId()].GetCategories(),
+		}
+	}
+	res, err := cs.taxes.Compute(address, userCurrency, lines)
+	if err != nil {
+		return tax.Result{}, err
+	}
+	for i, it := range items {
+		lineTax := res.Lines[i]
+		it.Tax = &lineTax
+	}
+	return res, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/checkoutservice/tax"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+func newTestTaxEngine(t *testing.T, rules string) *tax.Engine {
+	t.Helper()
+	var r tax.Rules
+	if err := json.Unmarshal([]byte(rules), &r); err != nil {
+		t.Fatal(err)
+	}
+	e, err := tax.New(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestPlaceOrderTax(t *testing.T) {
+	tests := []struct {
+		name       string
+		rules      string
+		wantTax    *pb.Money
+		wantCharge *pb.Money
+	}{
+		{
+			name:       "exclusive",
+			rules:      `{"jurisdictions": [{"country": "United States", "state": "CA", "rate": "0.1"}]}`,
+			wantTax:    &pb.Money{CurrencyCode: "USD", Units: 6, Nanos: 800000000},
+			wantCharge: &pb.Money{CurrencyCode: "USD", Units: 83, Nanos: 780000000},
+		},
+		{
+			name:       "inclusive",
+			rules:      `{"jurisdictions": [{"country": "United States", "inclusive": true, "rate": "0.25"}]}`,
+			wantTax:    &pb.Money{CurrencyCode: "USD", Units: 13, Nanos: 600000000},
+			wantCharge: &pb.Money{CurrencyCode: "USD", Units: 76, Nanos: 980000000},
+		},
+		{
+			name:       "untaxed",
+			rules:      `{"jurisdictions": [{"country": "Germany", "rate": "0.19"}]}`,
+			wantTax:    &pb.Money{CurrencyCode: "USD"},
+			wantCharge: &pb.Money{CurrencyCode: "USD", Units: 76, Nanos: 980000000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeDownstream()
+			cs, _ := newTestCheckoutService(t, f)
+			cs.taxes = newTestTaxEngine(t, tt.rules)
+			f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+			resp, err := cs.PlaceOrder(context.Background(), testPlaceOrderRequest("u1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			order := resp.GetOrder()
+			if !proto.Equal(order.GetTotalTax(), tt.wantTax) {
+				t.Errorf("total tax = %v, want %v", order.GetTotalTax(), tt.wantTax)
+			}
+			if !proto.Equal(order.GetItems()[0].GetTax(), tt.wantTax) {
+				t.Errorf("item tax = %v, want %v", order.GetItems()[0].GetTax(), tt.wantTax)
+			}
+			if got := f.charges[0].GetAmount(); !proto.Equal(got, tt.wantCharge) {
+				t.Errorf("charged %v, want %v", got, tt.wantCharge)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+// errEmptyCart is returned when the user tries to check out an empty cart.
+var errEmptyCart = errors.New("cart is empty")
+
+// cardBrand identifies the issuer of a credit card by its number.
+type cardBrand string
+
+const (
+	brandVisa       cardBrand = "visa"
+	brandMastercard cardBrand = "mastercard"
+	brandAmex       cardBrand = "amex"
+	brandDiscover   cardBrand = "discover"
+	brandUnknown    cardBrand = ""
+)
+
+// cvvDigits returns the number of digits of the CVV of a card of brand b.
+// CVVs travel as integers, which drops their leading zeros, so a CVV may
+// have fewer digits but not more.
+func (b cardBrand) cvvDigits() int {
+	if b == brandAmex {
+		return 4
+	}
+	return 3
+}
+
+// detectCardBrand returns the brand of the card with the given digits.
+func detectCardBrand(digits string) cardBrand {
+	prefix := func(n int) int {
+		v := 0
+		for i := 0; i < n && i < len(digits); i++ {
+			v = v*10 + int(digits[i]-'0')
+		}
+		return v
+	}
+	switch {
+	case strings.HasPrefix(digits, "4"):
+		return brandVisa
+	case prefix(2) >= 51 && prefix(2) <= 55, prefix(4) >= 2221 && prefix(4) <= 2720:
+		return brandMastercard
+	case strings.HasPrefix(digits, "34"), strings.HasPrefix(digits, "37"):
+		return brandAmex
+	case strings.HasPrefix(digits, "6011"), strings.HasPrefix(digits, "65"):
+		return brandDiscover
+	default:
+		return brandUnknown
+	}
+}
+
+// luhnValid reports whether digits passes the Luhn checksum.
+func luhnValid(digits string) bool {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// cardDigits strips the spaces and dashes from a card number. ok is false if
+// anything other than digits remains.
+func cardDigits(number string) (digits string, ok bool) {
+	digits = strings.NewReplacer(" ", "", "-", "").Replace(number)
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return "", false
+		}
+	}
+	return digits, true
+}
+
+// orderViolations collects the field violations of a PlaceOrderRequest.
+type orderViolations []*errdetails.BadRequest_FieldViolation
+
+func (v *orderViolations) add(field, description string) {
+	*v = append(*v, &errdetails.BadRequest_FieldViolation{Field: field, Description: description})
+}
+
+// validateOrderRequest checks req for everything that can be verified
+// without calling other services. Field names are the proto paths of the
+// offending fields. now is used to check the card expiry.
+func validateOrderRequest(req *pb.PlaceOrderRequest, now time.Time) []*errdetails.BadRequest_FieldViolation {
+	var v orderViolations
+
+	if req.GetUserId() == "" {
+		v.add("user_id", "user ID is required")
+	}
+	if req.GetUserCurrency() == "" {
+		v.add("user_currency", "currency is required")
+	}
+
+	if email := req.GetEmail(); email == "" {
+		v.add("email", "e-mail address is required")
+	} else if addr, err := mail.ParseAddress(email); err != nil || addr.Name != "" || addr.Address != email {
+		v.add("email", "e-mail address is invalid")
+	}
+
+	addr := req.GetAddress()
+	if strings.TrimSpace(addr.GetStreetAddress()) == "" {
+		v.add("address.street_address", "street address is required")
+	}
+	if strings.TrimSpace(addr.GetCity()) == "" {
+		v.add("address.city", "city is required")
+	}
+	if strings.TrimSpace(addr.GetCountry()) == "" {
+		v.add("address.country", "country is required")
+	}
+	if addr.GetZipCode() <= 0 {
+		v.add("address.zip_code", "zip code is required")
+	}
+
+	card := req.GetCreditCard()
+	digits, ok := cardDigits(card.GetCreditCardNumber())
+	brand := detectCardBrand(digits)
+	switch {
+	case card.GetCreditCardNumber() == "":
+		v.add("credit_card.credit_card_number", "credit card number is required")
+	case !ok || len(digits) < 12 || len(digits) > 19:
+		v.add("credit_card.credit_card_number", "credit card number is malformed")
+	case !luhnValid(digits):
+		v.add("credit_card.credit_card_number", "credit card number is invalid")
+	}
+
+	month, year := card.GetCreditCardExpirationMonth(), card.GetCreditCardExpirationYear()
+	if month < 1 || month > 12 {
+		v.add("credit_card.credit_card_expiration_month", "expiration month is invalid")
+	} else if int(year) < now.Year() || int(year) == now.Year() && time.Month(month) < now.Month() {
+		v.add("credit_card.credit_card_expiration_year", "credit card has expired")
+	}
+
+	if cvv := card.GetCreditCardCvv(); cvv < 0 || len(strconv.Itoa(int(cvv))) > brand.cvvDigits() {
+		v.add("credit_card.credit_card_cvv", "CVV is invalid")
+	}
+	return v
+}
@@ -0,0 +1,230 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+func TestLuhnValid(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   bool
+	}{
+		{"4432801561520454", true},
+		{"4432801561520455", false},
+		{"378282246310005", true},
+		{"5555555555554444", true},
+		{"5555555555554440", false},
+	}
+	for _, tt := range tests {
+		if got := luhnValid(tt.digits); got != tt.want {
+			t.Errorf("luhnValid(%q) = %v, want %v", tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestDetectCardBrand(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   cardBrand
+	}{
+		{"4432801561520454", brandVisa},
+		{"5555555555554444", brandMastercard},
+		{"2223003122003222", brandMastercard},
+		{"378282246310005", brandAmex},
+		{"6011111111111117", brandDiscover},
+		{"3530111333300000", brandUnknown},
+	}
+	for _, tt := range tests {
+		if got := detectCardBrand(tt.digits); got != tt.want {
+			t.Errorf("detectCardBrand(%q) = %q, want %q", tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestValidateOrderRequest(t *testing.T) {
+	now := time.Date(2021, time.June, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		modify func(*pb.PlaceOrderRequest)
+		want   []string // violated fields
+	}{
+		{
+			name:   "valid",
+			modify: func(*pb.PlaceOrderRequest) {},
+		},
+		{
+			name: "card expiring this month",
+			modify: func(r *pb.PlaceOrderRequest) {
+				r.CreditCard.CreditCardExpirationYear, r.CreditCard.CreditCardExpirationMonth = 2021, 6
+			},
+		},
+		{
+			name: "expired card",
+			modify: func(r *pb.PlaceOrderRequest) {
+				r.CreditCard.CreditCardExpirationYear, r.CreditCard.CreditCardExpirationMonth = 2021, 5
+			},
+			want: []string{"credit_card.credit_card_expiration_year"},
+		},
+		{
+			name:   "bad month",
+			modify: func(r *pb.PlaceOrderRequest) { r.CreditCard.CreditCardExpirationMonth = 13 },
+			want:   []string{"credit_card.credit_card_expiration_month"},
+		},
+		{
+			name:   "luhn failure",
+			modify: func(r *pb.PlaceOrderRequest) { r.CreditCard.CreditCardNumber = "4432-8015-6152-0455" },
+			want:   []string{"credit_card.credit_card_number"},
+		},
+		{
+			name:   "malformed card number",
+			modify: func(r *pb.PlaceOrderRequest) { r.CreditCard.CreditCardNumber = "4432-80x5-6152-0454" },
+			want:   []string{"credit_card.credit_card_number"},
+		},
+		{
+			name:   "four digit CVV on visa",
+			modify: func(r *pb.PlaceOrderRequest) { r.CreditCard.CreditCardCvv = 1234 },
+			want:   []string{"credit_card.credit_card_cvv"},
+		},
+		{
+			name: "four digit CVV on amex",
+			modify: func(r *pb.PlaceOrderRequest) {
+				r.CreditCard.CreditCardNumber = "3782-822463-10005"
+				r.CreditCard.CreditCardCvv = 1234
+			},
+		},
+		{
+			name:   "CVV with leading zeros",
+			modify: func(r *pb.PlaceOrderRequest) { r.CreditCard.CreditCardCvv = 5 },
+		},
+		{
+			name: "CVV with a leading zero on amex",
+			modify: func(r *pb.PlaceOrderRequest) {
+				r.CreditCard.CreditCardNumber = "3782-822463-10005"
+				r.CreditCard.CreditCardCvv = 123
+			},
+		},
+		{
+			name: "five digit CVV on amex",
+			modify: func(r *pb.PlaceOrderRequest) {
+				r.CreditCard.CreditCardNumber = "3782-822463-10005"
+				r.CreditCard.CreditCardCvv = 12345
+			},
+			want: []string{"credit_card.credit_card_cvv"},
+		},
+		{
+			name:   "negative CVV",
+			modify: func(r *pb.PlaceOrderRequest) { r.CreditCard.CreditCardCvv = -1 },
+			want:   []string{"credit_card.credit_card_cvv"},
+		},
+		{
+			name:   "missing email",
+			modify: func(r *pb.PlaceOrderRequest) { r.Email = "" },
+			want:   []string{"email"},
+		},
+		{
+			name:   "bad email",
+			modify: func(r *pb.PlaceOrderRequest) { r.Email = "Someone <someone@example.com>" },
+			want:   []string{"email"},
+		},
+		{
+			name: "incomplete address",
+			modify: func(r *pb.PlaceOrderRequest) {
+				r.Address.City = " "
+				r.Address.ZipCode = 0
+			},
+			want: []string{"address.city", "address.zip_code"},
+		},
+		{
+			name: "no address or card",
+			modify: func(r *pb.PlaceOrderRequest) {
+				r.Address = nil
+				r.CreditCard = nil
+			},
+			want: []string{"address.street_address", "address.city", "address.country", "address.zip_code",
+				"credit_card.credit_card_number", "credit_card.credit_card_expiration_month"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testPlaceOrderRequest("u1")
+			tt.modify(req)
+			var got []string
+			for _, v := range validateOrderRequest(req, now) {
+				got = append(got, v.GetField())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("violated fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// violatedFields returns the fields in the BadRequest details of err.
+func violatedFields(t *testing.T, err error) []string {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("code = %s, want %s (err: %v)", st.Code(), codes.InvalidArgument, err)
+	}
+	var fields []string
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				fields = append(fields, v.GetField())
+			}
+		}
+	}
+	return fields
+}
+
+func TestPlaceOrderRejectsInvalidRequestBeforeCharging(t *testing.T) {
+	f := newFakeDownstream()
+	cs, _ := newTestCheckoutService(t, f)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	req := testPlaceOrderRequest("u1")
+	req.Email = "not an email"
+	_, err := cs.PlaceOrder(context.Background(), req)
+	if got, want := violatedFields(t, err), []string{"email"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("violated fields = %v, want %v", got, want)
+	}
+	if f.productLookups != 0 || len(f.charges) != 0 {
+		t.Errorf("downstreams called: product lookups=%d charges=%d", f.productLookups, len(f.charges))
+	}
+}
+
+func TestPlaceOrderRejectsEmptyCart(t *testing.T) {
+	f := newFakeDownstream()
+	cs, _ := newTestCheckoutService(t, f)
+
+	_, err := cs.PlaceOrder(context.Background(), testPlaceOrderRequest("u1"))
+	if got, want := violatedFields(t, err), []string{"cart"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("violated fields = %v, want %v", got, want)
+	}
+	if len(f.charges) != 0 {
+		t.Errorf("card charged %d times", len(f.charges))
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+// WatchOrder streams the lifecycle of an order: PAID once it has been
+// placed, then the updates of its shipment from ShippingService until it is
+// delivered or cancelled. Past events are replayed, so watching an order
+// that has already been delivered sends its whole history and ends.
+func (cs *checkoutService) WatchOrder(req *pb.WatchOrderRequest, stream pb.CheckoutService_WatchOrderServer) error {
+	log.Infof("[WatchOrder] order_id=%q", req.GetOrderId())
+	ctx := stream.Context()
+	rec, err := cs.GetOrder(ctx, &pb.GetOrderRequest{OrderId: req.GetOrderId()})
+	if err != nil {
+		return err
+	}
+	orderID := rec.GetOrder().GetOrderId()
+	if err := stream.Send(&pb.OrderEvent{OrderId: orderID, Status: pb.OrderStatus_PAID, Time: rec.GetPlacedAt()}); err != nil {
+		return err
+	}
+	return cs.trackShipment(ctx, rec.GetOrder().GetShippingTrackingId(), func(e *pb.ShipmentEvent) error {
+		t := e.GetTime()
+		if t == 0 {
+			// Fakes of the shipping service do not script the time.
+			t = time.Now().Unix()
+		}
+		return stream.Send(&pb.OrderEvent{OrderId: orderID, Status: e.GetStatus(), Time: t})
+	})
+}
+
+// trackShipment calls send with each update of the shipment trackingID until
+// it is delivered or cancelled. ShippingService forgets shipments some time
+// after they end, and on restart: there is then nothing more to track.
+func (cs *checkoutService) trackShipment(ctx context.Context, trackingID string, send func(*pb.ShipmentEvent) error) error {
+	events, err := cs.shippingSvc.TrackShipment(ctx, &pb.TrackShipmentRequest{
+		TrackingId: trackingID})
+	if err != nil {
+		return trackingError(trackingID, err)
+	}
+	for {
+		e, err := events.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return trackingError(trackingID, err)
+		}
+		if err := send(e); err != nil {
+			return err
+		}
+	}
+}
+
+// trackingError classifies a failure to track shipment trackingID. A
+// shipment unknown to ShippingService ends the tracking without error.
+func trackingError(trackingID string, err error) error {
+	if status.Code(err) == codes.NotFound {
+		log.Infof("shipment %s is no longer tracked", trackingID)
+		return nil
+	}
+	return downstreamError("shipping", err)
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+// recordingWatchStream collects the events sent by WatchOrder.
+type recordingWatchStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events []*pb.OrderEvent
+}
+
+func (s *recordingWatchStream) Context() context.Context { return s.ctx }
+
+func (s *recordingWatchStream) Send(e *pb.OrderEvent) error {
+	s.events = append(s.events, e)
+	return nil
+}
+
+func TestWatchOrder(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeDownstream()
+	f.tracking = []pb.OrderStatus{pb.OrderStatus_SHIPPED, pb.OrderStatus_IN_TRANSIT, pb.OrderStatus_DELIVERED}
+	cs, _ := newTestCheckoutService(t, f)
+	f.cart["u1"] = []*pb.CartItem{{ProductId: "OLJCESPC7Z", Quantity: 1}}
+
+	placed, err := cs.PlaceOrder(ctx, testPlaceOrderRequest("u1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := placed.GetOrder().GetOrderId()
+
+	stream := &recordingWatchStream{ctx: ctx}
+	if err := cs.WatchOrder(&pb.WatchOrderRequest{OrderId: id}, stream); err != nil {
+		t.Fatal(err)
+	}
+	var got []pb.OrderStatus
+	for _, e := range stream.events {
+		if e.GetOrderId() != id {
+			t.Errorf("event %v is not for order %s", e, id)
+		}
+		got = append(got, e.GetStatus())
+	}
+	want := []pb.OrderStatus{pb.OrderStatus_PAID, pb.OrderStatus_SHIPPED, pb.OrderStatus_IN_TRANSIT, pb.OrderStatus_DELIVERED}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WatchOrder() sent %v, want %v", got, want)
+	}
+	if stream.events[0].GetTime() == 0 {
+		t.Errorf("PAID event has no time")
+	}
+
+	err = cs.WatchOrder(&pb.WatchOrderRequest{OrderId: "missing"}, &recordingWatchStream{ctx: ctx})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("WatchOrder(missing) = %v, want NotFound", err)
+	}
+}
+
+func TestWatchOrderOfForgottenShipment(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeDownstream()
+	cs, _ := newTestCheckoutService(t, f)
+	// The shipping service no longer knows the shipment of an old order.
+	err := cs.orders.Save(ctx, &pb.OrderRecord{
+		Order:    &pb.OrderResult{OrderId: "old", ShippingTrackingId: "EVICTED"},
+		UserId:   "u1",
+		PlacedAt: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream := &recordingWatchStream{ctx: ctx}
+	if err := cs.WatchOrder(&pb.WatchOrderRequest{OrderId: "old"}, stream); err != nil {
+		t.Fatalf("WatchOrder() = %v, want the stream to end", err)
+	}
+	if len(stream.events) != 1 || stream.events[0].GetStatus() != pb.OrderStatus_PAID {
+		t.Errorf("WatchOrder() sent %v, want only PAID", stream.events)
+	}
+}
+
+func TestServiceCompensatorCancelsShipments(t *testing.T) {
+	f := newFakeDownstream()
+	cs, _ := newTestCheckoutService(t, f)
+	if err := (serviceCompensator{cs: cs}).cancelShipment(context.Background(), "TRACK-1"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f.cancelled, []string{"TRACK-1"}) {
+		t.Errorf("cancelled shipments = %v, want [TRACK-1]", f.cancelled)
+	}
+}
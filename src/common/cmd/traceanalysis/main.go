@@ -0,0 +1,150 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command traceanalysis reports the latency of the services from the spans
+// they exported, as Jaeger JSON or OTLP JSON, and compares runs.
+//
+// Usage:
+//
+//	traceanalysis report [flags] PATH...
+//	traceanalysis compare [flags] BASE OTHER
+//
+// Each PATH is a file, or a directory whose files are all read. BASE and
+// OTHER are the paths of the spans of two runs, separated by commas if there
+// are several.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/traceanalysis"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "report":
+		err = report(os.Args[2:])
+	case "compare":
+		err = compare(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "traceanalysis: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: traceanalysis report [flags] PATH...")
+	fmt.Fprintln(os.Stderr, "       traceanalysis compare [flags] BASE OTHER")
+	os.Exit(2)
+}
+
+// output holds the flags choosing how results are written.
+type output struct {
+	format string
+	table  string
+}
+
+func (o *output) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.format, "format", "text", "output format: text, csv or json")
+	fs.StringVar(&o.table, "table", "", "only write the table with this name, in text and csv")
+}
+
+func (o *output) write(w io.Writer, v interface{}, tables []traceanalysis.Table) error {
+	if o.table != "" {
+		var selected []traceanalysis.Table
+		for _, t := range tables {
+			if t.Name == o.table {
+				selected = append(selected, t)
+			}
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("no table %q", o.table)
+		}
+		tables = selected
+	}
+	switch o.format {
+	case "text":
+		return traceanalysis.WriteText(w, tables)
+	case "csv":
+		return traceanalysis.WriteCSV(w, tables)
+	case "json":
+		return traceanalysis.WriteJSON(w, v)
+	default:
+		return fmt.Errorf("unknown format %q", o.format)
+	}
+}
+
+// analyze loads the traces at paths and analyzes them.
+func analyze(paths []string, criticalPaths string) (traceanalysis.Report, error) {
+	traces, err := traceanalysis.Load(paths...)
+	if err != nil {
+		return traceanalysis.Report{}, err
+	}
+	var patterns []string
+	if criticalPaths != "" {
+		patterns = strings.Split(criticalPaths, ",")
+	}
+	return traceanalysis.Analyze(traces, patterns), nil
+}
+
+func report(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	var out output
+	out.register(fs)
+	criticalPaths := fs.String("critical-paths", strings.Join(traceanalysis.DefaultCriticalPaths, ","),
+		"comma-separated service:operation patterns of the operations whose critical paths are analyzed; a trailing * matches any suffix")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return errors.New("no spans to analyze")
+	}
+
+	r, err := analyze(fs.Args(), *criticalPaths)
+	if err != nil {
+		return err
+	}
+	return out.write(os.Stdout, r, r.Tables())
+}
+
+func compare(args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	var out output
+	out.register(fs)
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return errors.New("compare needs the spans of two runs")
+	}
+
+	var reports [2]traceanalysis.Report
+	for i, paths := range fs.Args() {
+		r, err := analyze(strings.Split(paths, ","), "")
+		if err != nil {
+			return err
+		}
+		reports[i] = r
+	}
+	c := traceanalysis.Compare(reports[0], reports[1])
+	return out.write(os.Stdout, c, c.Tables())
+}
@@ -0,0 +1,296 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+// The fakes of the clients of every downstream service. Each implements the
+// generated client interface of its service by answering from a Conn.
+
+// NewCartServiceClient returns a fake cart service client answering from conn.
+func NewCartServiceClient(conn *Conn) pb.CartServiceClient {
+	return cartServiceClient{conn}
+}
+
+type cartServiceClient struct{ cc *Conn }
+
+func (c cartServiceClient) AddItem(ctx context.Context, in *pb.AddItemRequest, opts ...grpc.CallOption) (*pb.Empty, error) {
+	out := new(pb.Empty)
+	if err := c.cc.Invoke(ctx, "/hipstershop.CartService/AddItem", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c cartServiceClient) GetCart(ctx context.Context, in *pb.GetCartRequest, opts ...grpc.CallOption) (*pb.Cart, error) {
+	out := new(pb.Cart)
+	if err := c.cc.Invoke(ctx, "/hipstershop.CartService/GetCart", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c cartServiceClient) EmptyCart(ctx context.Context, in *pb.EmptyCartRequest, opts ...grpc.CallOption) (*pb.Empty, error) {
+	out := new(pb.Empty)
+	if err := c.cc.Invoke(ctx, "/hipstershop.CartService/EmptyCart", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NewRecommendationServiceClient returns a fake recommendation service client
+// answering from conn.
+func NewRecommendationServiceClient(conn *Conn) pb.RecommendationServiceClient {
+	return recommendationServiceClient{conn}
+}
+
+type recommendationServiceClient struct{ cc *Conn }
+
+func (c recommendationServiceClient) ListRecommendations(ctx context.Context, in *pb.ListRecommendationsRequest, opts ...grpc.CallOption) (*pb.ListRecommendationsResponse, error) {
+	out := new(pb.ListRecommendationsResponse)
+	if err := c.cc.Invoke(ctx, "/hipstershop.RecommendationService/ListRecommendations", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NewProductCatalogServiceClient returns a fake product catalog service client
+// answering from conn.
+func NewProductCatalogServiceClient(conn *Conn) pb.ProductCatalogServiceClient {
+	return productCatalogServiceClient{conn}
+}
+
+type productCatalogServiceClient struct{ cc *Conn }
+
+func (c productCatalogServiceClient) ListProducts(ctx context.Context, in *pb.Empty, opts ...grpc.CallOption) (*pb.ListProductsResponse, error) {
+	out := new(pb.ListProductsResponse)
+	if err := c.cc.Invoke(ctx, "/hipstershop.ProductCatalogService/ListProducts", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c productCatalogServiceClient) GetProduct(ctx context.Context, in *pb.GetProductRequest, opts ...grpc.CallOption) (*pb.Product, error) {
+	out := new(pb.Product)
+	if err := c.cc.Invoke(ctx, "/hipstershop.ProductCatalogService/GetProduct", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c productCatalogServiceClient) SearchProducts(ctx context.Context, in *pb.SearchProductsRequest, opts ...grpc.CallOption) (*pb.SearchProductsResponse, error) {
+	out := new(pb.SearchProductsResponse)
+	if err := c.cc.Invoke(ctx, "/hipstershop.ProductCatalogService/SearchProducts", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NewShippingServiceClient returns a fake shipping service client answering
+// from conn.
+func NewShippingServiceClient(conn *Conn) pb.ShippingServiceClient {
+	return shippingServiceClient{conn}
+}
+
+type shippingServiceClient struct{ cc *Conn }
+
+func (c shippingServiceClient) GetQuote(ctx context.Context, in *pb.GetQuoteRequest, opts ...grpc.CallOption) (*pb.GetQuoteResponse, error) {
+	out := new(pb.GetQuoteResponse)
+	if err := c.cc.Invoke(ctx, "/hipstershop.ShippingService/GetQuote", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c shippingServiceClient) ShipOrder(ctx context.Context, in *pb.ShipOrderRequest, opts ...grpc.CallOption) (*pb.ShipOrderResponse, error) {
+	out := new(pb.ShipOrderResponse)
+	if err := c.cc.Invoke(ctx, "/hipstershop.ShippingService/ShipOrder", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c shippingServiceClient) TrackShipment(ctx context.Context, in *pb.TrackShipmentRequest, opts ...grpc.CallOption) (pb.ShippingService_TrackShipmentClient, error) {
+	stream, err := c.cc.NewStream(ctx, &grpc.StreamDesc{StreamName: "TrackShipment", ServerStreams: true}, "/hipstershop.ShippingService/TrackShipment", opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return shippingServiceTrackShipmentClient{stream}, nil
+}
+
+type shippingServiceTrackShipmentClient struct{ grpc.ClientStream }
+
+func (x shippingServiceTrackShipmentClient) Recv() (*pb.ShipmentEvent, error) {
+	m := new(pb.ShipmentEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c shippingServiceClient) CancelShipment(ctx context.Context, in *pb.CancelShipmentRequest, opts ...grpc.CallOption) (*pb.Empty, error) {
+	out := new(pb.Empty)
+	if err := c.cc.Invoke(ctx, "/hipstershop.ShippingService/CancelShipment", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NewCurrencyServiceClient returns a fake currency service client answering
+// from conn.
+func NewCurrencyServiceClient(conn *Conn) pb.CurrencyServiceClient {
+	return currencyServiceClient{conn}
+}
+
+type currencyServiceClient struct{ cc *Conn }
+
+func (c currencyServiceClient) GetSupportedCurrencies(ctx context.Context, in *pb.Empty, opts ...grpc.CallOption) (*pb.GetSupportedCurrenciesResponse, error) {
+	out := new(pb.GetSupportedCurrenciesResponse)
+	if err := c.cc.Invoke(ctx, "/hipstershop.CurrencyService/GetSupportedCurrencies", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c currencyServiceClient) Convert(ctx context.Context, in *pb.CurrencyConversionRequest, opts ...grpc.CallOption) (*pb.Money, error) {
+	out := new(pb.Money)
+	if err := c.cc.Invoke(ctx, "/hipstershop.CurrencyService/Convert", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NewPaymentServiceClient returns a fake payment service client answering from
+// conn.
+func NewPaymentServiceClient(conn *Conn) pb.PaymentServiceClient {
+	return paymentServiceClient{conn}
+}
+
+type paymentServiceClient struct{ cc *Conn }
+
+func (c paymentServiceClient) Charge(ctx context.Context, in *pb.ChargeRequest, opts ...grpc.CallOption) (*pb.ChargeResponse, error) {
+	out := new(pb.ChargeResponse)
+	if err := c.cc.Invoke(ctx, "/hipstershop.PaymentService/Charge", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NewEmailServiceClient returns a fake email service client answering from
+// conn.
+func NewEmailServiceClient(conn *Conn) pb.EmailServiceClient {
+	return emailServiceClient{conn}
+}
+
+type emailServiceClient struct{ cc *Conn }
+
+func (c emailServiceClient) SendOrderConfirmation(ctx context.Context, in *pb.SendOrderConfirmationRequest, opts ...grpc.CallOption) (*pb.Empty, error) {
+	out := new(pb.Empty)
+	if err := c.cc.Invoke(ctx, "/hipstershop.EmailService/SendOrderConfirmation", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NewCheckoutServiceClient returns a fake checkout service client answering
+// from conn.
+func NewCheckoutServiceClient(conn *Conn) pb.CheckoutServiceClient {
+	return checkoutServiceClient{conn}
+}
+
+type checkoutServiceClient struct{ cc *Conn }
+
+func (c checkoutServiceClient) PlaceOrder(ctx context.Context, in *pb.PlaceOrderRequest, opts ...grpc.CallOption) (*pb.PlaceOrderResponse, error) {
+	out := new(pb.PlaceOrderResponse)
+	if err := c.cc.Invoke(ctx, "/hipstershop.CheckoutService/PlaceOrder", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c checkoutServiceClient) GetOrder(ctx context.Context, in *pb.GetOrderRequest, opts ...grpc.CallOption) (*pb.OrderRecord, error) {
+	out := new(pb.OrderRecord)
+	if err := c.cc.Invoke(ctx, "/hipstershop.CheckoutService/GetOrder", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c checkoutServiceClient) ListOrders(ctx context.Context, in *pb.ListOrdersRequest, opts ...grpc.CallOption) (*pb.ListOrdersResponse, error) {
+	out := new(pb.ListOrdersResponse)
+	if err := c.cc.Invoke(ctx, "/hipstershop.CheckoutService/ListOrders", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c checkoutServiceClient) PreviewOrder(ctx context.Context, in *pb.PreviewOrderRequest, opts ...grpc.CallOption) (*pb.PreviewOrderResponse, error) {
+	out := new(pb.PreviewOrderResponse)
+	if err := c.cc.Invoke(ctx, "/hipstershop.CheckoutService/PreviewOrder", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c checkoutServiceClient) WatchOrder(ctx context.Context, in *pb.WatchOrderRequest, opts ...grpc.CallOption) (pb.CheckoutService_WatchOrderClient, error) {
+	stream, err := c.cc.NewStream(ctx, &grpc.StreamDesc{StreamName: "WatchOrder", ServerStreams: true}, "/hipstershop.CheckoutService/WatchOrder", opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return checkoutServiceWatchOrderClient{stream}, nil
+}
+
+type checkoutServiceWatchOrderClient struct{ grpc.ClientStream }
+
+func (x checkoutServiceWatchOrderClient) Recv() (*pb.OrderEvent, error) {
+	m := new(pb.OrderEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// NewAdServiceClient returns a fake ad service client answering from conn.
+func NewAdServiceClient(conn *Conn) pb.AdServiceClient {
+	return adServiceClient{conn}
+}
+
+type adServiceClient struct{ cc *Conn }
+
+func (c adServiceClient) GetAds(ctx context.Context, in *pb.AdRequest, opts ...grpc.CallOption) (*pb.AdResponse, error) {
+	out := new(pb.AdResponse)
+	if err := c.cc.Invoke(ctx, "/hipstershop.AdService/GetAds", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
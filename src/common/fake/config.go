@@ -0,0 +1,266 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fake provides in-process fakes of the downstream gRPC services,
+// configured with scripted responses, latency distributions and error rates,
+// to model degraded dependencies.
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+)
+
+// Duration is a time.Duration written in JSON as a string such as "250ms".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("invalid duration %s", b)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Latency distributions.
+const (
+	Constant    = "constant"    // always Value
+	Uniform     = "uniform"     // between Min and Max
+	Normal      = "normal"      // around Mean, with StdDev
+	LogNormal   = "lognormal"   // half below Median, 1% above P99
+	Exponential = "exponential" // with Mean
+)
+
+// Latency is the distribution of the time a fake takes to answer, or of the
+// delay added by other fault injectors. Samples are never negative.
+type Latency struct {
+	Distribution string `json:"distribution"`
+
+	Value  Duration `json:"value,omitempty"`
+	Min    Duration `json:"min,omitempty"`
+	Max    Duration `json:"max,omitempty"`
+	Mean   Duration `json:"mean,omitempty"`
+	StdDev Duration `json:"stddev,omitempty"`
+	Median Duration `json:"median,omitempty"`
+	P99    Duration `json:"p99,omitempty"`
+}
+
+// z99 is the 99th percentile of the standard normal distribution.
+const z99 = 2.3263478740408408
+
+// Validate reports whether the parameters of l fit its distribution. A nil
+// Latency is valid.
+func (l *Latency) Validate() error {
+	if l == nil {
+		return nil
+	}
+	switch l.Distribution {
+	case Constant:
+		if l.Value < 0 {
+			return fmt.Errorf("constant latency must not be negative")
+		}
+	case Uniform:
+		if l.Min < 0 || l.Max < l.Min {
+			return fmt.Errorf("uniform latency needs 0 <= min <= max")
+		}
+	case Normal:
+		if l.Mean < 0 || l.StdDev < 0 {
+			return fmt.Errorf("normal latency needs a mean and stddev that are not negative")
+		}
+	case LogNormal:
+		if l.Median <= 0 || l.P99 < l.Median {
+			return fmt.Errorf("lognormal latency needs 0 < median <= p99")
+		}
+	case Exponential:
+		if l.Mean <= 0 {
+			return fmt.Errorf("exponential latency needs a positive mean")
+		}
+	default:
+		return fmt.Errorf("unknown latency distribution %q", l.Distribution)
+	}
+	return nil
+}
+
+// Sample draws a latency from l, or returns 0 if l is nil.
+func (l *Latency) Sample(rnd *rand.Rand) time.Duration {
+	if l == nil {
+		return 0
+	}
+	var d float64
+	switch l.Distribution {
+	case Constant:
+		d = float64(l.Value)
+	case Uniform:
+		d = float64(l.Min) + rnd.Float64()*float64(l.Max-l.Min)
+	case Normal:
+		d = float64(l.Mean) + rnd.NormFloat64()*float64(l.StdDev)
+	case LogNormal:
+		mu := math.Log(float64(l.Median))
+		sigma := (math.Log(float64(l.P99)) - mu) / z99
+		d = math.Exp(mu + rnd.NormFloat64()*sigma)
+	case Exponential:
+		d = rnd.ExpFloat64() * float64(l.Mean)
+	}
+	if d < 0 {
+		return 0
+	}
+	return time.Duration(d)
+}
+
+// Behavior is how a fake answers calls, apart from their scripted responses.
+type Behavior struct {
+	Latency *Latency `json:"latency,omitempty"`
+
+	// ErrorRate is the fraction of calls that fail with ErrorCode, which is
+	// UNAVAILABLE if unset.
+	ErrorRate *float64   `json:"error_rate,omitempty"`
+	ErrorCode codes.Code `json:"error_code,omitempty"`
+}
+
+func (b Behavior) validate() error {
+	if b.ErrorRate != nil && (*b.ErrorRate < 0 || *b.ErrorRate > 1) {
+		return fmt.Errorf("error_rate must be between 0 and 1")
+	}
+	return b.Latency.Validate()
+}
+
+// override returns b with the fields set in o replaced.
+func (b Behavior) override(o Behavior) Behavior {
+	if o.Latency != nil {
+		b.Latency = o.Latency
+	}
+	if o.ErrorRate != nil {
+		b.ErrorRate = o.ErrorRate
+		b.ErrorCode = o.ErrorCode
+	}
+	return b
+}
+
+// Response is a scripted answer: either a reply message or an error.
+type Response struct {
+	// Message is the reply in its JSON form, such as {"tracking_id": "T1"}.
+	Message json.RawMessage `json:"message,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a scripted gRPC error.
+type Error struct {
+	Code    codes.Code `json:"code"`
+	Message string     `json:"message,omitempty"`
+}
+
+// Reply returns the response answering with m. It panics if m cannot be
+// written as JSON.
+func Reply(m proto.Message) Response {
+	s, err := (&jsonpb.Marshaler{OrigName: true}).MarshalToString(m)
+	if err != nil {
+		panic(err)
+	}
+	return Response{Message: json.RawMessage(s)}
+}
+
+// Method configures the fake of one RPC method.
+type Method struct {
+	Behavior
+
+	// Responses are the scripted answers. Unary calls get them in turn,
+	// starting over after the last one. Each call of a server-streaming
+	// method gets all of them, in order, ending at the first error. Without
+	// responses, calls are answered with an empty message.
+	Responses []Response `json:"responses,omitempty"`
+}
+
+// Service configures the fake of one downstream service.
+type Service struct {
+	// Behavior applies to the methods that do not override it.
+	Behavior
+
+	// Methods are the fakes of the service's methods by name, such as
+	// "GetQuote".
+	Methods map[string]Method `json:"methods,omitempty"`
+
+	// Seed makes the latencies and errors of the fake reproducible. If it is
+	// zero, they differ from run to run.
+	Seed int64 `json:"seed,omitempty"`
+}
+
+func (s Service) validate() error {
+	if err := s.Behavior.validate(); err != nil {
+		return err
+	}
+	for name, m := range s.Methods {
+		if err := m.Behavior.validate(); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		for _, r := range m.Responses {
+			if (len(r.Message) == 0) == (r.Error == nil) {
+				return fmt.Errorf("%s: every response needs either a message or an error", name)
+			}
+		}
+	}
+	return nil
+}
+
+// Config holds the fakes of the downstream services, by name. Services that
+// are not listed are not faked.
+type Config map[string]Service
+
+// Merge returns the fakes of c overridden, service by service, by those of
+// other.
+func (c Config) Merge(other Config) Config {
+	out := make(Config, len(c)+len(other))
+	for k, v := range c {
+		out[k] = v
+	}
+	for k, v := range other {
+		out[k] = v
+	}
+	return out
+}
+
+// Load reads the fakes in the JSON file at path, an object mapping downstream
+// names to fakes.
+func Load(path string) (Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse fakes %s: %v", path, err)
+	}
+	for name, s := range c {
+		if err := s.validate(); err != nil {
+			return nil, fmt.Errorf("invalid fake for %s: %v", name, err)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,282 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const instrumentationName = "github.com/GoogleCloudPlatform/microservices-demo/src/common/fake"
+
+// Conn answers gRPC calls in-process, as configured by a Service. The fake
+// clients of the downstream services, such as NewShippingServiceClient, make
+// their calls on a Conn.
+//
+// Every call is traced as a client span marked with the attribute fake=true.
+type Conn struct {
+	name    string
+	service Service
+	unary   []grpc.UnaryClientInterceptor
+	stream  []grpc.StreamClientInterceptor
+
+	mu    sync.Mutex
+	rnd   *rand.Rand
+	calls map[string]int // unary calls by method name
+}
+
+// Option configures a Conn.
+type Option func(*Conn)
+
+// WithUnaryInterceptor adds an interceptor to the unary calls of the Conn,
+// as grpc.WithChainUnaryInterceptor does for a grpc.ClientConn. The
+// interceptor is passed a nil *grpc.ClientConn.
+func WithUnaryInterceptor(i grpc.UnaryClientInterceptor) Option {
+	return func(c *Conn) { c.unary = append(c.unary, i) }
+}
+
+// WithStreamInterceptor adds an interceptor to the streaming calls of the
+// Conn, as grpc.WithChainStreamInterceptor does for a grpc.ClientConn. The
+// interceptor is passed a nil *grpc.ClientConn.
+func WithStreamInterceptor(i grpc.StreamClientInterceptor) Option {
+	return func(c *Conn) { c.stream = append(c.stream, i) }
+}
+
+// NewConn returns a Conn faking the downstream service called name.
+func NewConn(name string, s Service, opts ...Option) *Conn {
+	seed := s.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	c := &Conn{
+		name:    name,
+		service: s,
+		rnd:     rand.New(rand.NewSource(seed)),
+		calls:   make(map[string]int),
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Invoke performs a unary call.
+func (c *Conn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	ctx, span := c.startSpan(ctx, method)
+	defer span.End()
+	invoker := c.invoke
+	for i := len(c.unary) - 1; i >= 0; i-- {
+		interceptor, next := c.unary[i], invoker
+		invoker = func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return interceptor(ctx, method, req, reply, cc, next, opts...)
+		}
+	}
+	err := invoker(ctx, method, args, reply, nil, opts...)
+	endSpan(span, err)
+	return err
+}
+
+// NewStream begins a streaming call. Only server-streaming methods are
+// supported.
+func (c *Conn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx, span := c.startSpan(ctx, method)
+	streamer := c.newStream
+	for i := len(c.stream) - 1; i >= 0; i-- {
+		interceptor, next := c.stream[i], streamer
+		streamer = func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return interceptor(ctx, desc, cc, method, next, opts...)
+		}
+	}
+	cs, err := streamer(ctx, desc, nil, method, opts...)
+	if err != nil {
+		endSpan(span, err)
+		span.End()
+		return nil, err
+	}
+	return &spanStream{ClientStream: cs, span: span}, nil
+}
+
+func (c *Conn) startSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	name := strings.TrimPrefix(method, "/")
+	service, rpc := name, ""
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		service, rpc = name[:i], name[i+1:]
+	}
+	return otel.Tracer(instrumentationName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", rpc),
+			attribute.Bool("fake", true),
+		))
+}
+
+func endSpan(span trace.Span, err error) {
+	code := status.Code(err)
+	span.SetAttributes(attribute.Int64("rpc.grpc.status_code", int64(code)))
+	if err != nil {
+		span.SetStatus(otelcodes.Error, status.Convert(err).Message())
+	}
+}
+
+// method returns the fake of fullMethod, such as
+// "/hipstershop.ShippingService/GetQuote", and the behavior it has.
+func (c *Conn) method(fullMethod string) (Method, Behavior) {
+	m := c.service.Methods[fullMethod[strings.LastIndex(fullMethod, "/")+1:]]
+	return m, c.service.Behavior.override(m.Behavior)
+}
+
+// delay waits for a latency drawn from b, and then fails the call at the
+// error rate of b.
+func (c *Conn) delay(ctx context.Context, b Behavior) error {
+	c.mu.Lock()
+	d := b.Latency.Sample(c.rnd)
+	fail := b.ErrorRate != nil && c.rnd.Float64() < *b.ErrorRate
+	c.mu.Unlock()
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+		}
+		return status.Error(codes.Canceled, ctx.Err().Error())
+	case <-t.C:
+	}
+	if fail {
+		code := b.ErrorCode
+		if code == codes.OK {
+			code = codes.Unavailable
+		}
+		return status.Errorf(code, "fake %s failed", c.name)
+	}
+	return nil
+}
+
+func (c *Conn) invoke(ctx context.Context, fullMethod string, _, reply interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+	m, b := c.method(fullMethod)
+	if err := c.delay(ctx, b); err != nil {
+		return err
+	}
+	if len(m.Responses) == 0 {
+		return nil
+	}
+	c.mu.Lock()
+	n := c.calls[fullMethod]
+	c.calls[fullMethod]++
+	c.mu.Unlock()
+	return answer(m.Responses[n%len(m.Responses)], reply)
+}
+
+func (c *Conn) newStream(ctx context.Context, desc *grpc.StreamDesc, _ *grpc.ClientConn, fullMethod string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+	if desc.ClientStreams {
+		return nil, status.Errorf(codes.Unimplemented, "fake %s does not support client streaming", c.name)
+	}
+	m, b := c.method(fullMethod)
+	return &stream{ctx: ctx, conn: c, behavior: b, responses: m.Responses}, nil
+}
+
+// answer fills reply in with r, or returns the error of r.
+func answer(r Response, reply interface{}) error {
+	if r.Error != nil {
+		return status.Error(r.Error.Code, r.Error.Message)
+	}
+	m, ok := reply.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "fake reply %T is not a protocol buffer", reply)
+	}
+	if err := jsonpb.Unmarshal(bytes.NewReader(r.Message), m); err != nil {
+		return status.Errorf(codes.Internal, "invalid scripted %s: %v", proto.MessageName(m), err)
+	}
+	return nil
+}
+
+// stream is a server-streaming call answered with scripted responses, each
+// after a latency of its own.
+type stream struct {
+	ctx       context.Context
+	conn      *Conn
+	behavior  Behavior
+	responses []Response
+	next      int
+}
+
+func (s *stream) Header() (metadata.MD, error) { return nil, nil }
+func (s *stream) Trailer() metadata.MD         { return nil }
+func (s *stream) CloseSend() error             { return nil }
+func (s *stream) Context() context.Context     { return s.ctx }
+func (s *stream) SendMsg(interface{}) error    { return nil }
+
+func (s *stream) RecvMsg(m interface{}) error {
+	if s.next >= len(s.responses) {
+		return io.EOF
+	}
+	if err := s.conn.delay(s.ctx, s.behavior); err != nil {
+		s.next = len(s.responses)
+		return err
+	}
+	r := s.responses[s.next]
+	s.next++
+	if r.Error != nil {
+		s.next = len(s.responses)
+	}
+	return answer(r, m)
+}
+
+// spanStream ends the span of a streaming call once the stream is over.
+type spanStream struct {
+	grpc.ClientStream
+	span trace.Span
+	once sync.Once
+}
+
+func (s *spanStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.once.Do(func() {
+			if err == io.EOF {
+				endSpan(s.span, nil)
+			} else {
+				endSpan(s.span, err)
+			}
+			s.span.End()
+		})
+	}
+	return err
+}
+
+// String describes the Conn in logs.
+func (c *Conn) String() string {
+	return fmt.Sprintf("fake(%s)", c.name)
+}
@@ -0,0 +1,252 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+func rate(r float64) *float64 { return &r }
+
+func TestScriptedResponses(t *testing.T) {
+	conn := NewConn("shipping", Service{Methods: map[string]Method{
+		"ShipOrder": {Responses: []Response{
+			Reply(&pb.ShipOrderResponse{TrackingId: "T1"}),
+			{Error: &Error{Code: codes.ResourceExhausted, Message: "no trucks"}},
+			{Message: []byte(`{"tracking_id": "T3"}`)},
+		}},
+	}})
+	client := NewShippingServiceClient(conn)
+	ctx := context.Background()
+
+	for i, want := range []struct {
+		id   string
+		code codes.Code
+	}{{"T1", codes.OK}, {"", codes.ResourceExhausted}, {"T3", codes.OK}, {"T1", codes.OK}} {
+		res, err := client.ShipOrder(ctx, &pb.ShipOrderRequest{})
+		if status.Code(err) != want.code || res.GetTrackingId() != want.id {
+			t.Errorf("call %d = %q, %v; want %q, %v", i+1, res.GetTrackingId(), err, want.id, want.code)
+		}
+	}
+
+	// Methods without responses answer with an empty message.
+	if res, err := client.GetQuote(ctx, &pb.GetQuoteRequest{}); err != nil || res.GetCostUsd() != nil {
+		t.Errorf("GetQuote() = %v, %v; want an empty reply", res, err)
+	}
+}
+
+func TestInvalidScriptedResponse(t *testing.T) {
+	conn := NewConn("shipping", Service{Methods: map[string]Method{
+		"ShipOrder": {Responses: []Response{{Message: []byte(`{"tracking": 1}`)}}},
+	}})
+	_, err := NewShippingServiceClient(conn).ShipOrder(context.Background(), &pb.ShipOrderRequest{})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("ShipOrder() error = %v, want Internal", err)
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	conn := NewConn("shipping", Service{Methods: map[string]Method{
+		"TrackShipment": {Responses: []Response{
+			Reply(&pb.ShipmentEvent{Status: pb.OrderStatus_SHIPPED}),
+			Reply(&pb.ShipmentEvent{Status: pb.OrderStatus_DELIVERED}),
+			{Error: &Error{Code: codes.Unavailable}},
+			Reply(&pb.ShipmentEvent{Status: pb.OrderStatus_CANCELLED}),
+		}},
+	}})
+	for call := 0; call < 2; call++ {
+		events, err := NewShippingServiceClient(conn).TrackShipment(context.Background(), &pb.TrackShipmentRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []pb.OrderStatus
+		for {
+			e, err := events.Recv()
+			if err != nil {
+				if status.Code(err) != codes.Unavailable {
+					t.Errorf("stream ended with %v, want the scripted Unavailable error", err)
+				}
+				break
+			}
+			got = append(got, e.GetStatus())
+		}
+		if len(got) != 2 || got[0] != pb.OrderStatus_SHIPPED || got[1] != pb.OrderStatus_DELIVERED {
+			t.Errorf("call %d streamed %v, want [SHIPPED DELIVERED]", call+1, got)
+		}
+		if _, err := events.Recv(); err != io.EOF {
+			t.Errorf("Recv() after the error = %v, want EOF", err)
+		}
+	}
+}
+
+func TestErrorRate(t *testing.T) {
+	conn := NewConn("payment", Service{
+		Behavior: Behavior{ErrorRate: rate(0.25), ErrorCode: codes.Internal},
+		Methods: map[string]Method{
+			// The method overrides the rate of the service.
+			"Charge": {Behavior: Behavior{ErrorRate: rate(1)}},
+		},
+		Seed: 1,
+	})
+	client := NewPaymentServiceClient(conn)
+	if _, err := client.Charge(context.Background(), &pb.ChargeRequest{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("Charge() error = %v, want the default Unavailable", err)
+	}
+
+	conn = NewConn("email", Service{Behavior: Behavior{ErrorRate: rate(0.25), ErrorCode: codes.Internal}, Seed: 1})
+	failed := 0
+	for i := 0; i < 1000; i++ {
+		_, err := NewEmailServiceClient(conn).SendOrderConfirmation(context.Background(), &pb.SendOrderConfirmationRequest{})
+		if err != nil {
+			if status.Code(err) != codes.Internal {
+				t.Fatalf("error = %v, want Internal", err)
+			}
+			failed++
+		}
+	}
+	if failed < 200 || failed > 300 {
+		t.Errorf("%d of 1000 calls failed, want about 250", failed)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	conn := NewConn("cart", Service{Behavior: Behavior{
+		Latency: &Latency{Distribution: Constant, Value: Duration(time.Second)},
+	}})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := NewCartServiceClient(conn).GetCart(ctx, &pb.GetCartRequest{})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("GetCart() error = %v, want DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("GetCart() took %v, past the deadline of the caller", d)
+	}
+}
+
+func TestLatencyDistributions(t *testing.T) {
+	ms := func(n int) Duration { return Duration(time.Duration(n) * time.Millisecond) }
+	tests := []struct {
+		l        Latency
+		min, max time.Duration // of the samples
+		median   time.Duration // approximately
+	}{
+		{Latency{Distribution: Constant, Value: ms(20)}, 20 * time.Millisecond, 20 * time.Millisecond, 20 * time.Millisecond},
+		{Latency{Distribution: Uniform, Min: ms(10), Max: ms(30)}, 10 * time.Millisecond, 30 * time.Millisecond, 20 * time.Millisecond},
+		{Latency{Distribution: Normal, Mean: ms(20), StdDev: ms(5)}, 0, time.Second, 20 * time.Millisecond},
+		{Latency{Distribution: LogNormal, Median: ms(20), P99: ms(200)}, 0, time.Hour, 20 * time.Millisecond},
+		{Latency{Distribution: Exponential, Mean: ms(20)}, 0, time.Hour, 13863 * time.Microsecond}, // mean * ln 2
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for _, tt := range tests {
+		samples := make([]time.Duration, 10000)
+		for i := range samples {
+			samples[i] = tt.l.Sample(rnd)
+		}
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		if samples[0] < tt.min || samples[len(samples)-1] > tt.max {
+			t.Errorf("%s: samples range from %v to %v, want within [%v, %v]", tt.l.Distribution, samples[0], samples[len(samples)-1], tt.min, tt.max)
+		}
+		median := samples[len(samples)/2]
+		if median < tt.median*9/10 || median > tt.median*11/10 {
+			t.Errorf("%s: median = %v, want about %v", tt.l.Distribution, median, tt.median)
+		}
+	}
+}
+
+func TestInterceptors(t *testing.T) {
+	var calls []string
+	conn := NewConn("cart", Service{},
+		WithUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			calls = append(calls, "first "+method)
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}),
+		WithUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			calls = append(calls, "second "+method)
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}))
+	if _, err := NewCartServiceClient(conn).EmptyCart(context.Background(), &pb.EmptyCartRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"first /hipstershop.CartService/EmptyCart", "second /hipstershop.CartService/EmptyCart"}
+	if len(calls) != 2 || calls[0] != want[0] || calls[1] != want[1] {
+		t.Errorf("interceptors saw %q, want %q", calls, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fakes.json")
+	err = ioutil.WriteFile(path, []byte(`{
+		"shipping": {
+			"latency": {"distribution": "lognormal", "median": "20ms", "p99": "250ms"},
+			"error_rate": 0.1,
+			"error_code": "UNAVAILABLE",
+			"methods": {
+				"GetQuote": {"responses": [{"message": {"cost_usd": {"currency_code": "USD", "units": 7}}}]}
+			}
+		}
+	}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := c["shipping"]
+	if s.Latency.P99 != Duration(250*time.Millisecond) || *s.ErrorRate != 0.1 || s.ErrorCode != codes.Unavailable {
+		t.Errorf("Load() = %+v", s)
+	}
+	s.ErrorRate = nil
+	res, err := NewShippingServiceClient(NewConn("shipping", s)).GetQuote(context.Background(), &pb.GetQuoteRequest{})
+	if err != nil || res.GetCostUsd().GetUnits() != 7 {
+		t.Errorf("GetQuote() = %v, %v; want the scripted quote", res, err)
+	}
+
+	for _, invalid := range []string{
+		`{"shipping": {"error_rate": 2}}`,
+		`{"shipping": {"latency": {"distribution": "gamma"}}}`,
+		`{"shipping": {"latency": {"distribution": "uniform", "min": "2s", "max": "1s"}}}`,
+		`{"shipping": {"methods": {"GetQuote": {"responses": [{}]}}}}`,
+		`{"shipping": {"error_code": "BROKEN"}}`,
+	} {
+		if err := ioutil.WriteFile(path, []byte(invalid), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("Load() accepted %s", invalid)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package faultinject injects faults into the calls served by a service:
+// added latency, errors returned at a given rate and dropped responses,
+// scoped by method and caller metadata and reconfigurable at runtime.
+package faultinject
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/fake"
+)
+
+// Rule is a fault injected into the calls it matches.
+type Rule struct {
+	// Method is the full name of the matched RPCs, such as
+	// "/hipstershop.CartService/GetCart", or the path of the matched HTTP
+	// requests. A trailing "*" matches any suffix, so "/hipstershop.CartService/*"
+	// matches all methods of the service. Empty or "*" matches every call.
+	Method string `json:"method,omitempty"`
+
+	// Metadata is what the caller must send for the call to match, such as
+	// {"x-caller": "frontend"}: gRPC metadata or HTTP headers, with keys in
+	// any case. The value "*" only requires the key to be present.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Latency is added before the call is handled.
+	Latency *fake.Latency `json:"latency,omitempty"`
+
+	// ErrorRate is the fraction of calls that fail with ErrorCode, which is
+	// UNAVAILABLE if unset, without being handled.
+	ErrorRate float64    `json:"error_rate,omitempty"`
+	ErrorCode codes.Code `json:"error_code,omitempty"`
+
+	// DropRate is the fraction of calls that are handled but never answered,
+	// leaving the caller to time out.
+	DropRate float64 `json:"drop_rate,omitempty"`
+}
+
+func (r Rule) validate() error {
+	if r.ErrorRate < 0 || r.ErrorRate > 1 {
+		return fmt.Errorf("error_rate must be between 0 and 1")
+	}
+	if r.DropRate < 0 || r.DropRate > 1 {
+		return fmt.Errorf("drop_rate must be between 0 and 1")
+	}
+	return r.Latency.Validate()
+}
+
+// matches reports whether the rule applies to a call of method by a caller
+// that sent md. Keys of md are lower case.
+func (r Rule) matches(method string, md map[string][]string) bool {
+	switch {
+	case r.Method == "" || r.Method == "*":
+	case strings.HasSuffix(r.Method, "*"):
+		if !strings.HasPrefix(method, strings.TrimSuffix(r.Method, "*")) {
+			return false
+		}
+	case r.Method != method:
+		return false
+	}
+	for k, want := range r.Metadata {
+		values, ok := md[strings.ToLower(k)]
+		if !ok {
+			return false
+		}
+		if want == "*" {
+			continue
+		}
+		found := false
+		for _, v := range values {
+			found = found || v == want
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Config is the set of faults injected into a service.
+type Config struct {
+	// Rules are tried in order, and only the first one matching a call
+	// applies to it.
+	Rules []Rule `json:"rules"`
+
+	// Seed makes the injected faults reproducible. If it is zero, they
+	// differ from run to run.
+	Seed int64 `json:"seed,omitempty"`
+}
+
+func (c Config) validate() error {
+	for i, r := range c.Rules {
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("rule %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// parse reads the JSON config in b.
+func parse(b []byte) (Config, error) {
+	var c Config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return Config{}, err
+	}
+	return c, c.validate()
+}
+
+// Load reads the faults in the JSON file at path.
+func Load(path string) (Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	c, err := parse(b)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid faults %s: %v", path, err)
+	}
+	return c, nil
+}
+
+// Injector injects the faults of its config into the calls of the servers
+// it intercepts. The zero Injector is not usable; use New.
+type Injector struct {
+	mu     sync.Mutex
+	config Config
+	rnd    *rand.Rand
+}
+
+// New returns an injector of the faults in c.
+func New(c Config) (*Injector, error) {
+	i := new(Injector)
+	if err := i.SetConfig(c); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// FromEnv returns an injector of the faults in the JSON file named by
+// FAULTS_CONFIG, or of no faults if it is unset. If FAULTS_ADMIN_ADDR is set,
+// the faults can be changed at /faults on that address.
+func FromEnv(log logrus.FieldLogger) (*Injector, error) {
+	var c Config
+	if path := os.Getenv("FAULTS_CONFIG"); path != "" {
+		log.Infof("loading faults from %q", path)
+		var err error
+		if c, err = Load(path); err != nil {
+			return nil, err
+		}
+	}
+	i, err := New(c)
+	if err != nil {
+		return nil, err
+	}
+	if addr := os.Getenv("FAULTS_ADMIN_ADDR"); addr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/faults", i.AdminHandler())
+			log.Infof("serving fault injection admin on %q", addr)
+			if err := http.ListenAndServe(addr, mux); err != nil {
+				log.Errorf("fault injection admin server failed: %+v", err)
+			}
+		}()
+	}
+	return i, nil
+}
+
+// Config returns the faults being injected.
+func (i *Injector) Config() Config {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.config
+}
+
+// SetConfig replaces the faults being injected with those of c.
+func (i *Injector) SetConfig(c Config) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	seed := c.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.config = c
+	i.rnd = rand.New(rand.NewSource(seed))
+	return nil
+}
+
+// fault is what is injected into one call.
+type fault struct {
+	delay time.Duration
+	err   error // returned instead of handling the call
+	drop  bool  // the call is handled but not answered
+}
+
+// fault draws the fault injected into a call of method by a caller that sent
+// md.
+func (i *Injector) fault(method string, md map[string][]string) fault {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, r := range i.config.Rules {
+		if !r.matches(method, md) {
+			continue
+		}
+		f := fault{delay: r.Latency.Sample(i.rnd)}
+		if r.ErrorRate > 0 && i.rnd.Float64() < r.ErrorRate {
+			code := r.ErrorCode
+			if code == codes.OK {
+				code = codes.Unavailable
+			}
+			f.err = status.Errorf(code, "fault injected into %s", method)
+		} else if r.DropRate > 0 && i.rnd.Float64() < r.DropRate {
+			f.drop = true
+		}
+		return f
+	}
+	return fault{}
+}
+
+// wait sleeps for the delay of f, unless ctx is done first.
+func (f fault) wait(ctx context.Context) error {
+	if f.delay <= 0 {
+		return nil
+	}
+	t := time.NewTimer(f.delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return contextError(ctx)
+	case <-t.C:
+		return nil
+	}
+}
+
+// contextError returns the status error of the done ctx.
+func contextError(ctx context.Context) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+	}
+	return status.Error(codes.Canceled, ctx.Err().Error())
+}
+
+// incoming returns the metadata sent by the caller of a gRPC call.
+func incoming(ctx context.Context) map[string][]string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return md
+}
+
+// ServerOptions return the options installing the injector on a gRPC
+// server.
+func (i *Injector) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(i.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(i.StreamServerInterceptor()),
+	}
+}
+
+// UnaryServerInterceptor injects faults into unary calls.
+func (i *Injector) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		f := i.fault(info.FullMethod, incoming(ctx))
+		if err := f.wait(ctx); err != nil {
+			return nil, err
+		}
+		if f.err != nil {
+			return nil, f.err
+		}
+		resp, err := handler(ctx, req)
+		if f.drop {
+			<-ctx.Done()
+			return nil, contextError(ctx)
+		}
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor injects faults into streaming calls. Dropping a
+// stream drops every message sent on it.
+func (i *Injector) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		f := i.fault(info.FullMethod, incoming(ctx))
+		if err := f.wait(ctx); err != nil {
+			return err
+		}
+		if f.err != nil {
+			return f.err
+		}
+		if !f.drop {
+			return handler(srv, ss)
+		}
+		handler(srv, droppedStream{ss})
+		<-ctx.Done()
+		return contextError(ctx)
+	}
+}
+
+// droppedStream discards the messages sent on a stream.
+type droppedStream struct {
+	grpc.ServerStream
+}
+
+func (droppedStream) SendMsg(interface{}) error { return nil }
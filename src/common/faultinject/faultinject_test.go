@@ -0,0 +1,250 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package faultinject
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/fake"
+)
+
+const check = "/grpc.health.v1.Health/Check"
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		r      Rule
+		method string
+		md     map[string][]string
+		want   bool
+	}{
+		{Rule{}, check, nil, true},
+		{Rule{Method: "*"}, check, nil, true},
+		{Rule{Method: check}, check, nil, true},
+		{Rule{Method: check}, "/grpc.health.v1.Health/Watch", nil, false},
+		{Rule{Method: "/grpc.health.v1.Health/*"}, check, nil, true},
+		{Rule{Method: "/hipstershop.CartService/*"}, check, nil, false},
+		{Rule{Metadata: map[string]string{"X-Caller": "frontend"}}, check, map[string][]string{"x-caller": {"frontend"}}, true},
+		{Rule{Metadata: map[string]string{"x-caller": "frontend"}}, check, map[string][]string{"x-caller": {"loadgenerator"}}, false},
+		{Rule{Metadata: map[string]string{"x-caller": "*"}}, check, map[string][]string{"x-caller": {"loadgenerator"}}, true},
+		{Rule{Metadata: map[string]string{"x-caller": "*"}}, check, nil, false},
+	}
+	for _, tt := range tests {
+		if got := tt.r.matches(tt.method, tt.md); got != tt.want {
+			t.Errorf("%+v matches(%q, %v) = %v, want %v", tt.r, tt.method, tt.md, got, tt.want)
+		}
+	}
+}
+
+// serve starts a health server with faults injected by i, and returns a
+// client of it.
+func serve(t *testing.T, i *Injector) healthpb.HealthClient {
+	t.Helper()
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(i.ServerOptions()...)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestUnaryFaults(t *testing.T) {
+	i, err := New(Config{Rules: []Rule{
+		{Metadata: map[string]string{"x-caller": "broken"}, ErrorRate: 1, ErrorCode: codes.ResourceExhausted},
+		{Metadata: map[string]string{"x-caller": "lossy"}, DropRate: 1},
+		{Method: check, Latency: &fake.Latency{Distribution: fake.Constant, Value: fake.Duration(50 * time.Millisecond)}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := serve(t, i)
+	call := func(caller string) (time.Duration, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if caller != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "x-caller", caller)
+		}
+		start := time.Now()
+		_, err := c.Check(ctx, &healthpb.HealthCheckRequest{})
+		return time.Since(start), err
+	}
+
+	if d, err := call(""); err != nil || d < 50*time.Millisecond {
+		t.Errorf("Check() took %v and returned %v, want 50ms of latency and no error", d, err)
+	}
+	if _, err := call("broken"); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Check() by broken returned %v, want RESOURCE_EXHAUSTED", err)
+	}
+	if d, err := call("lossy"); status.Code(err) != codes.DeadlineExceeded || d < time.Second {
+		t.Errorf("Check() by lossy took %v and returned %v, want the deadline exceeded", d, err)
+	}
+
+	// The faults change at runtime.
+	if err := i.SetConfig(Config{}); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := call("broken"); err != nil || d >= 50*time.Millisecond {
+		t.Errorf("Check() without faults took %v and returned %v", d, err)
+	}
+}
+
+func TestErrorRate(t *testing.T) {
+	i, err := New(Config{Seed: 1, Rules: []Rule{{ErrorRate: 0.25}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := 0
+	for n := 0; n < 1000; n++ {
+		if f := i.fault(check, nil); f.err != nil {
+			if status.Code(f.err) != codes.Unavailable {
+				t.Fatalf("injected %v, want UNAVAILABLE", f.err)
+			}
+			failed++
+		}
+	}
+	if failed < 200 || failed > 300 {
+		t.Errorf("%d of 1000 calls failed, want about 250", failed)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	i, err := New(Config{Rules: []Rule{
+		{Method: "/cart/*", ErrorRate: 1},
+		{Method: "/product/*", Metadata: map[string]string{"User-Agent": "bot"}, DropRate: 1},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var handled int32
+	srv := httptest.NewServer(i.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&handled, 1)
+		w.Write([]byte("ok"))
+	})))
+	defer srv.Close()
+	get := func(path, agent string) (*http.Response, error) {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		req.Header.Set("User-Agent", agent)
+		return http.DefaultClient.Do(req)
+	}
+
+	if res, err := get("/cart/checkout", ""); err != nil || res.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("GET /cart/checkout = %v, %v, want 503", res, err)
+	}
+	if res, err := get("/product/OLJCESPC7Z", "browser"); err != nil || res.StatusCode != http.StatusOK {
+		t.Errorf("GET /product/OLJCESPC7Z by a browser = %v, %v, want 200", res, err)
+	}
+	if res, err := get("/product/OLJCESPC7Z", "bot"); err == nil {
+		t.Errorf("GET /product/OLJCESPC7Z by a bot = %v, want the response dropped", res.Status)
+	}
+	if n := atomic.LoadInt32(&handled); n != 2 {
+		t.Errorf("%d requests handled, want 2", n)
+	}
+}
+
+func TestAdminHandler(t *testing.T) {
+	i, err := New(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(i.AdminHandler())
+	defer srv.Close()
+	do := func(method, body string) int {
+		req, _ := http.NewRequest(method, srv.URL, strings.NewReader(body))
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	if code := do(http.MethodPut, `{"rules": [{"method": "/hipstershop.CartService/*", "error_rate": 0.5, "error_code": "INTERNAL"}]}`); code != http.StatusOK {
+		t.Fatalf("PUT = %d, want 200", code)
+	}
+	if rules := i.Config().Rules; len(rules) != 1 || rules[0].ErrorCode != codes.Internal {
+		t.Errorf("rules after PUT = %+v", rules)
+	}
+	if code := do(http.MethodPut, `{"rules": [{"drop_rate": 2}]}`); code != http.StatusBadRequest {
+		t.Errorf("PUT of an invalid config = %d, want 400", code)
+	}
+	if len(i.Config().Rules) != 1 {
+		t.Error("an invalid config replaced the faults")
+	}
+	if code := do(http.MethodDelete, ""); code != http.StatusOK || len(i.Config().Rules) != 0 {
+		t.Errorf("DELETE = %d and left %+v", code, i.Config().Rules)
+	}
+	if code := do(http.MethodPost, "{}"); code != http.StatusMethodNotAllowed {
+		t.Errorf("POST = %d, want 405", code)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faultinject")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "faults.json")
+	err = ioutil.WriteFile(path, []byte(`{
+		"seed": 42,
+		"rules": [{
+			"method": "/hipstershop.ProductCatalogService/*",
+			"metadata": {"x-caller": "frontend"},
+			"latency": {"distribution": "uniform", "min": "10ms", "max": "20ms"},
+			"drop_rate": 0.01
+		}]
+	}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Seed != 42 || len(c.Rules) != 1 || c.Rules[0].Latency.Max != fake.Duration(20*time.Millisecond) {
+		t.Errorf("Load() = %+v", c)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(`{"rules": [{"latency": {"distribution": "zipf"}}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() accepted an unknown latency distribution")
+	}
+}
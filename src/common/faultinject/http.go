@@ -0,0 +1,118 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package faultinject
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// httpStatus maps the codes of injected errors to the status of the failed
+// HTTP requests. Other codes fail with 500 Internal Server Error.
+var httpStatus = map[codes.Code]int{
+	codes.Canceled:           499,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+}
+
+// headers returns the headers of r with lower case keys, as gRPC metadata.
+func headers(r *http.Request) map[string][]string {
+	md := make(map[string][]string, len(r.Header))
+	for k, v := range r.Header {
+		md[strings.ToLower(k)] = v
+	}
+	return md
+}
+
+// Middleware injects faults into the requests served by next, matching the
+// Method of rules against their path. Dropped requests are aborted once
+// handled, without a response.
+func (i *Injector) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f := i.fault(r.URL.Path, headers(r))
+		if err := f.wait(r.Context()); err != nil {
+			return
+		}
+		if f.err != nil {
+			code, ok := httpStatus[status.Code(f.err)]
+			if !ok {
+				code = http.StatusInternalServerError
+			}
+			http.Error(w, status.Convert(f.err).Message(), code)
+			return
+		}
+		if !f.drop {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(discardWriter{header: make(http.Header)}, r)
+		panic(http.ErrAbortHandler)
+	})
+}
+
+// discardWriter is a ResponseWriter writing nowhere.
+type discardWriter struct {
+	header http.Header
+}
+
+func (w discardWriter) Header() http.Header       { return w.header }
+func (discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (discardWriter) WriteHeader(int)             {}
+
+// AdminHandler serves the config of the injector as JSON: GET returns it,
+// PUT replaces it with the one in the request body and DELETE stops
+// injecting faults.
+func (i *Injector) AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			c, err := parse(b)
+			if err == nil {
+				err = i.SetConfig(c)
+			}
+			if err != nil {
+				http.Error(w, "invalid faults: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		case http.MethodDelete:
+			i.SetConfig(Config{})
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(i.Config())
+	})
+}
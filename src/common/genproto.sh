@@ -14,8 +14,9 @@
 # See the License for the specific language governing permissions and
 # limitations under the License.
 
-#!/bin/bash -e
+# Generates the Go code for pb/demo.proto shared by all Go services.
 
+cd "$(dirname "$0")"
 PATH=$PATH:$GOPATH/bin
 protodir=../../pb
 
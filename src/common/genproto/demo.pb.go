@@ -39,6 +39,76 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+// Stages in the life of a placed order.
+type OrderStatus int32
+
+const (
+	OrderStatus_ORDER_STATUS_UNSPECIFIED OrderStatus = 0
+	OrderStatus_PAID                     OrderStatus = 1
+	OrderStatus_SHIPPED                  OrderStatus = 2
+	OrderStatus_IN_TRANSIT               OrderStatus = 3
+	OrderStatus_DELIVERED                OrderStatus = 4
+	OrderStatus_CANCELLED                OrderStatus = 5
+)
+
+var OrderStatus_name = map[int32]string{
+	0: "ORDER_STATUS_UNSPECIFIED",
+	1: "PAID",
+	2: "SHIPPED",
+	3: "IN_TRANSIT",
+	4: "DELIVERED",
+	5: "CANCELLED",
+}
+
+var OrderStatus_value = map[string]int32{
+	"ORDER_STATUS_UNSPECIFIED": 0,
+	"PAID":                     1,
+	"SHIPPED":                  2,
+	"IN_TRANSIT":               3,
+	"DELIVERED":                4,
+	"CANCELLED":                5,
+}
+
+func (x OrderStatus) String() string {
+	return proto.EnumName(OrderStatus_name, int32(x))
+}
+
+func (OrderStatus) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_ca53982754088a9d, []int{0}
+}
+
+type FraudDecision int32
+
+const (
+	FraudDecision_FRAUD_DECISION_UNSPECIFIED FraudDecision = 0
+	FraudDecision_ACCEPT                     FraudDecision = 1
+	// Accepted, but to be looked at by hand.
+	FraudDecision_REVIEW FraudDecision = 2
+	FraudDecision_REJECT FraudDecision = 3
+)
+
+var FraudDecision_name = map[int32]string{
+	0: "FRAUD_DECISION_UNSPECIFIED",
+	1: "ACCEPT",
+	2: "REVIEW",
+	3: "REJECT",
+}
+
+var FraudDecision_value = map[string]int32{
+	"FRAUD_DECISION_UNSPECIFIED": 0,
+	"ACCEPT":                     1,
+	"REVIEW":                     2,
+	"REJECT":                     3,
+}
+
+func (x FraudDecision) String() string {
+	return proto.EnumName(FraudDecision_name, int32(x))
+}
+
+func (FraudDecision) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_ca53982754088a9d, []int{1}
+}
+
 type CartItem struct {
 	ProductId            string   `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
 	Quantity             int32    `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
@@ -784,6 +854,141 @@ func (m *ShipOrderResponse) GetTrackingId() string {
 	return ""
 }
 
+type TrackShipmentRequest struct {
+	TrackingId           string   `protobuf:"bytes,1,opt,name=tracking_id,json=trackingId,proto3" json:"tracking_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TrackShipmentRequest) Reset()         { *m = TrackShipmentRequest{} }
+func (m *TrackShipmentRequest) String() string { return proto.CompactTextString(m) }
+func (*TrackShipmentRequest) ProtoMessage()    {}
+func (*TrackShipmentRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ca53982754088a9d, []int{17}
+}
+
+func (m *TrackShipmentRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TrackShipmentRequest.Unmarshal(m, b)
+}
+func (m *TrackShipmentRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TrackShipmentRequest.Marshal(b, m, deterministic)
+}
+func (m *TrackShipmentRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TrackShipmentRequest.Merge(m, src)
+}
+func (m *TrackShipmentRequest) XXX_Size() int {
+	return xxx_messageInfo_TrackShipmentRequest.Size(m)
+}
+func (m *TrackShipmentRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TrackShipmentRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TrackShipmentRequest proto.InternalMessageInfo
+
+func (m *TrackShipmentRequest) GetTrackingId() string {
+	if m != nil {
+		return m.TrackingId
+	}
+	return ""
+}
+
+type ShipmentEvent struct {
+	TrackingId string `protobuf:"bytes,1,opt,name=tracking_id,json=trackingId,proto3" json:"tracking_id,omitempty"`
+	// One of SHIPPED, IN_TRANSIT, DELIVERED or CANCELLED.
+	Status OrderStatus `protobuf:"varint,2,opt,name=status,proto3,enum=hipstershop.OrderStatus" json:"status,omitempty"`
+	// Time of the update, in seconds since the Unix epoch.
+	Time                 int64    `protobuf:"varint,3,opt,name=time,proto3" json:"time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ShipmentEvent) Reset()         { *m = ShipmentEvent{} }
+func (m *ShipmentEvent) String() string { return proto.CompactTextString(m) }
+func (*ShipmentEvent) ProtoMessage()    {}
+func (*ShipmentEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ca53982754088a9d, []int{18}
+}
+
+func (m *ShipmentEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ShipmentEvent.Unmarshal(m, b)
+}
+func (m *ShipmentEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ShipmentEvent.Marshal(b, m, deterministic)
+}
+func (m *ShipmentEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ShipmentEvent.Merge(m, src)
+}
+func (m *ShipmentEvent) XXX_Size() int {
+	return xxx_messageInfo_ShipmentEvent.Size(m)
+}
+func (m *ShipmentEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_ShipmentEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ShipmentEvent proto.InternalMessageInfo
+
+func (m *ShipmentEvent) GetTrackingId() string {
+	if m != nil {
+		return m.TrackingId
+	}
+	return ""
+}
+
+func (m *ShipmentEvent) GetStatus() OrderStatus {
+	if m != nil {
+		return m.Status
+	}
+	return OrderStatus_ORDER_STATUS_UNSPECIFIED
+}
+
+func (m *ShipmentEvent) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+type CancelShipmentRequest struct {
+	TrackingId           string   `protobuf:"bytes,1,opt,name=tracking_id,json=trackingId,proto3" json:"tracking_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CancelShipmentRequest) Reset()         { *m = CancelShipmentRequest{} }
+func (m *CancelShipmentRequest) String() string { return proto.CompactTextString(m) }
+func (*CancelShipmentRequest) ProtoMessage()    {}
+func (*CancelShipmentRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ca53982754088a9d, []int{19}
+}
+
+func (m *CancelShipmentRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CancelShipmentRequest.Unmarshal(m, b)
+}
+func (m *CancelShipmentRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CancelShipmentRequest.Marshal(b, m, deterministic)
+}
+func (m *CancelShipmentRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelShipmentRequest.Merge(m, src)
+}
+func (m *CancelShipmentRequest) XXX_Size() int {
+	return xxx_messageInfo_CancelShipmentRequest.Size(m)
+}
+func (m *CancelShipmentRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelShipmentRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CancelShipmentRequest proto.InternalMessageInfo
+
+func (m *CancelShipmentRequest) GetTrackingId() string {
+	if m != nil {
+		return m.TrackingId
+	}
+	return ""
+}
+
 type Address struct {
 	StreetAddress        string   `protobuf:"bytes,1,opt,name=street_address,json=streetAddress,proto3" json:"street_address,omitempty"`
 	City                 string   `protobuf:"bytes,2,opt,name=city,proto3" json:"city,omitempty"`
@@ -799,7 +1004,7 @@ func (m *Address) Reset()         { *m = Address{} }
 func (m *Address) String() string { return proto.CompactTextString(m) }
 func (*Address) ProtoMessage()    {}
 func (*Address) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ca53982754088a9d, []int{17}
+	return fileDescriptor_ca53982754088a9d, []int{20}
 }
 
 func (m *Address) XXX_Unmarshal(b []byte) error {
@@ -878,7 +1083,7 @@ func (m *Money) Reset()         { *m = Money{} }
 func (m *Money) String() string { return proto.CompactTextString(m) }
 func (*Money) ProtoMessage()    {}
 func (*Money) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ca53982754088a9d, []int{18}
+	return fileDescriptor_ca53982754088a9d, []int{21}
 }
 
 func (m *Money) XXX_Unmarshal(b []byte) error {
@@ -932,7 +1137,7 @@ func (m *GetSupportedCurrenciesResponse) Reset()         { *m = GetSupportedCurr
 func (m *GetSupportedCurrenciesResponse) String() string { return proto.CompactTextString(m) }
 func (*GetSupportedCurrenciesResponse) ProtoMessage()    {}
 func (*GetSupportedCurrenciesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ca53982754088a9d, []int{19}
+	return fileDescriptor_ca53982754088a9d, []int{22}
 }
 
 func (m *GetSupportedCurrenciesResponse) XXX_Unmarshal(b []byte) error {
@@ -973,7 +1178,7 @@ func (m *CurrencyConversionRequest) Reset()         { *m = CurrencyConversionReq
 func (m *CurrencyConversionRequest) String() string { return proto.CompactTextString(m) }
 func (*CurrencyConversionRequest) ProtoMessage()    {}
 func (*CurrencyConversionRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ca53982754088a9d, []int{20}
+	return fileDescriptor_ca53982754088a9d, []int{23}
 }
 
 func (m *CurrencyConversionRequest) XXX_Unmarshal(b []byte) error {
@@ -1022,7 +1227,7 @@ func (m *CreditCardInfo) Reset()         { *m = CreditCardInfo{} }
 func (m *CreditCardInfo) String() string { return proto.CompactTextString(m) }
 func (*CreditCardInfo) ProtoMessage()    {}
 func (*CreditCardInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ca53982754088a9d, []int{21}
+	return fileDescriptor_ca53982754088a9d, []int{24}
 }
 
 func (m *CreditCardInfo) XXX_Unmarshal(b []byte) error {
@@ -1083,7 +1288,7 @@ func (m *ChargeRequest) Reset()         { *m = ChargeRequest{} }
 func (m *ChargeRequest) String() string { return proto.CompactTextString(m) }
 func (*ChargeRequest) ProtoMessage()    {}
 func (*ChargeRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ca53982754088a9d, []int{22}
+	return fileDescriptor_ca53982754088a9d, []int{25}
 }
 
 func (m *ChargeRequest) XXX_Unmarshal(b []byte) error {
@@ -1129,7 +1334,7 @@ func (m *ChargeResponse) Reset()         { *m = ChargeResponse{} }
 func (m *ChargeResponse) String() string { return proto.CompactTextString(m) }
 func (*ChargeResponse) ProtoMessage()    {}
 func (*ChargeResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ca53982754088a9d, []int{23}
+	return fileDescriptor_ca53982754088a9d, []int{26}
 }
 
 func (m *ChargeResponse) XXX_Unmarshal(b []byte) error {
@@ -1158,18 +1363,22 @@ func (m *ChargeResponse) GetTransactionId() string {
 }
 
 type OrderItem struct {
-	Item                 *CartItem `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
-	Cost                 *Money    `protobuf:"bytes,2,opt,name=cost,proto3" json:"cost,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
-	XXX_unrecognized     []byte    `json:"-"`
-	XXX_sizecache        int32     `json:"-"`
+	Item *CartItem `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
+	Cost *Money    `protobuf:"bytes,2,opt,name=cost,proto3" json:"cost,omitempty"`
+	// Tax on the whole line, i.e. on cost times quantity.
+	Tax *Money `protobuf:"bytes,3,opt,name=tax,proto3" json:"tax,omitempty"`
+	// Discount from promotions on the whole line.
+	Discount             *Money   `protobuf:"bytes,4,opt,name=discount,proto3" json:"discount,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *OrderItem) Reset()         { *m = OrderItem{} }
 func (m *OrderItem) String() string { return proto.CompactTextString(m) }
 func (*OrderItem) ProtoMessage()    {}
 func (*OrderItem) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ca53982754088a9d, []int{24}
+	return fileDescriptor_ca53982754088a9d, []int{27}
 }
 
 func (m *OrderItem) XXX_Unmarshal(b []byte) error {
@@ -1204,22 +1413,103 @@ func (m *OrderItem) GetCost() *Money {
 	return nil
 }
 
+func (m *OrderItem) GetTax() *Money {
+	if m != nil {
+		return m.Tax
+	}
+	return nil
+}
+
+func (m *OrderItem) GetDiscount() *Money {
+	if m != nil {
+		return m.Discount
+	}
+	return nil
+}
+
+// Discount is the amount a promotion code takes off an order.
+type Discount struct {
+	PromoCode            string   `protobuf:"bytes,1,opt,name=promo_code,json=promoCode,proto3" json:"promo_code,omitempty"`
+	Description          string   `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Amount               *Money   `protobuf:"bytes,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Discount) Reset()         { *m = Discount{} }
+func (m *Discount) String() string { return proto.CompactTextString(m) }
+func (*Discount) ProtoMessage()    {}
+func (*Discount) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ca53982754088a9d, []int{28}
+}
+
+func (m *Discount) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Discount.Unmarshal(m, b)
+}
+func (m *Discount) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Discount.Marshal(b, m, deterministic)
+}
+func (m *Discount) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Discount.Merge(m, src)
+}
+func (m *Discount) XXX_Size() int {
+	return xxx_messageInfo_Discount.Size(m)
+}
+func (m *Discount) XXX_DiscardUnknown() {
+	xxx_messageInfo_Discount.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Discount proto.InternalMessageInfo
+
+func (m *Discount) GetPromoCode() string {
+	if m != nil {
+		return m.PromoCode
+	}
+	return ""
+}
+
+func (m *Discount) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *Discount) GetAmount() *Money {
+	if m != nil {
+		return m.Amount
+	}
+	return nil
+}
+
 type OrderResult struct {
-	OrderId              string       `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
-	ShippingTrackingId   string       `protobuf:"bytes,2,opt,name=shipping_tracking_id,json=shippingTrackingId,proto3" json:"shipping_tracking_id,omitempty"`
-	ShippingCost         *Money       `protobuf:"bytes,3,opt,name=shipping_cost,json=shippingCost,proto3" json:"shipping_cost,omitempty"`
-	ShippingAddress      *Address     `protobuf:"bytes,4,opt,name=shipping_address,json=shippingAddress,proto3" json:"shipping_address,omitempty"`
-	Items                []*OrderItem `protobuf:"bytes,5,rep,name=items,proto3" json:"items,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
-	XXX_unrecognized     []byte       `json:"-"`
-	XXX_sizecache        int32        `json:"-"`
+	OrderId            string       `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	ShippingTrackingId string       `protobuf:"bytes,2,opt,name=shipping_tracking_id,json=shippingTrackingId,proto3" json:"shipping_tracking_id,omitempty"`
+	ShippingCost       *Money       `protobuf:"bytes,3,opt,name=shipping_cost,json=shippingCost,proto3" json:"shipping_cost,omitempty"`
+	ShippingAddress    *Address     `protobuf:"bytes,4,opt,name=shipping_address,json=shippingAddress,proto3" json:"shipping_address,omitempty"`
+	Items              []*OrderItem `protobuf:"bytes,5,rep,name=items,proto3" json:"items,omitempty"`
+	// Sum of the tax of all items.
+	TotalTax *Money `protobuf:"bytes,6,opt,name=total_tax,json=totalTax,proto3" json:"total_tax,omitempty"`
+	// Whether item costs already include tax. If not, total_tax is charged on
+	// top of them.
+	TaxInclusive bool `protobuf:"varint,7,opt,name=tax_inclusive,json=taxInclusive,proto3" json:"tax_inclusive,omitempty"`
+	// Discounts of the applied promotion codes. They are subtracted from the
+	// item and shipping costs.
+	Discounts     []*Discount `protobuf:"bytes,8,rep,name=discounts,proto3" json:"discounts,omitempty"`
+	TotalDiscount *Money      `protobuf:"bytes,9,opt,name=total_discount,json=totalDiscount,proto3" json:"total_discount,omitempty"`
+	// Amount charged to the credit card.
+	Total                *Money   `protobuf:"bytes,10,opt,name=total,proto3" json:"total,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *OrderResult) Reset()         { *m = OrderResult{} }
 func (m *OrderResult) String() string { return proto.CompactTextString(m) }
 func (*OrderResult) ProtoMessage()    {}
 func (*OrderResult) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ca53982754088a9d, []int{25}
+	return fileDescriptor_ca53982754088a9d, []int{29}
 }
 
 func (m *OrderResult) XXX_Unmarshal(b []byte) error {
@@ -1275,6 +1565,41 @@ func (m *OrderResult) GetItems() []*OrderItem {
 	return nil
 }
 
+func (m *OrderResult) GetTotalTax() *Money {
+	if m != nil {
+		return m.TotalTax
+	}
+	return nil
+}
+
+func (m *OrderResult) GetTaxInclusive() bool {
+	if m != nil {
+		return m.TaxInclusive
+	}
+	return false
+}
+
+func (m *OrderResult) GetDiscounts() []*Discount {
+	if m != nil {
+		return m.Discounts
+	}
+	return nil
+}
+
+func (m *OrderResult) GetTotalDiscount() *Money {
+	if m != nil {
+		return m.TotalDiscount
+	}
+	return nil
+}
+
+func (m *OrderResult) GetTotal() *Money {
+	if m != nil {
+		return m.Total
+	}
+	return nil
+}
+
 type SendOrderConfirmationRequest struct {
 	Email                string       `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
 	Order                *OrderResult `protobuf:"bytes,2,opt,name=order,proto3" json:"order,omitempty"`
@@ -1287,7 +1612,7 @@ func (m *SendOrderConfirmationRequest) Reset()         { *m = SendOrderConfirmat
 func (m *SendOrderConfirmationRequest) String() string { return proto.CompactTextString(m) }
 func (*SendOrderConfirmationRequest) ProtoMessage()    {}
 func (*SendOrderConfirmationRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ca53982754088a9d, []int{26}
+	return fileDescriptor_ca53982754088a9d, []int{30}
 }
 
 func (m *SendOrderConfirmationRequest) XXX_Unmarshal(b []byte) error {
@@ -1323,21 +1648,30 @@ func (m *SendOrderConfirmationRequest) GetOrder() *OrderResult {
 }
 
 type PlaceOrderRequest struct {
-	UserId               string          `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	UserCurrency         string          `protobuf:"bytes,2,opt,name=user_currency,json=userCurrency,proto3" json:"user_currency,omitempty"`
-	Address              *Address        `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
-	Email                string          `protobuf:"bytes,5,opt,name=email,proto3" json:"email,omitempty"`
-	CreditCard           *CreditCardInfo `protobuf:"bytes,6,opt,name=credit_card,json=creditCard,proto3" json:"credit_card,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
-	XXX_unrecognized     []byte          `json:"-"`
-	XXX_sizecache        int32           `json:"-"`
+	UserId       string          `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	UserCurrency string          `protobuf:"bytes,2,opt,name=user_currency,json=userCurrency,proto3" json:"user_currency,omitempty"`
+	Address      *Address        `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	Email        string          `protobuf:"bytes,5,opt,name=email,proto3" json:"email,omitempty"`
+	CreditCard   *CreditCardInfo `protobuf:"bytes,6,opt,name=credit_card,json=creditCard,proto3" json:"credit_card,omitempty"`
+	// Client-chosen key that identifies a checkout attempt. Requests repeating
+	// a key are answered with the result of the first attempt instead of
+	// placing a new order.
+	IdempotencyKey string `protobuf:"bytes,7,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	// Promotion codes to apply to the order.
+	PromoCodes []string `protobuf:"bytes,8,rep,name=promo_codes,json=promoCodes,proto3" json:"promo_codes,omitempty"`
+	// Billing address of the credit card. Only its country is used, to screen
+	// the order for fraud; it is taken to be the shipping address if unset.
+	BillingAddress       *Address `protobuf:"bytes,9,opt,name=billing_address,json=billingAddress,proto3" json:"billing_address,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *PlaceOrderRequest) Reset()         { *m = PlaceOrderRequest{} }
 func (m *PlaceOrderRequest) String() string { return proto.CompactTextString(m) }
 func (*PlaceOrderRequest) ProtoMessage()    {}
 func (*PlaceOrderRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ca53982754088a9d, []int{27}
+	return fileDescriptor_ca53982754088a9d, []int{31}
 }
 
 func (m *PlaceOrderRequest) XXX_Unmarshal(b []byte) error {
@@ -1393,6 +1727,27 @@ func (m *PlaceOrderRequest) GetCreditCard() *CreditCardInfo {
 	return nil
 }
 
+func (m *PlaceOrderRequest) GetIdempotencyKey() string {
+	if m != nil {
+		return m.IdempotencyKey
+	}
+	return ""
+}
+
+func (m *PlaceOrderRequest) GetPromoCodes() []string {
+	if m != nil {
+		return m.PromoCodes
+	}
+	return nil
+}
+
+func (m *PlaceOrderRequest) GetBillingAddress() *Address {
+	if m != nil {
+		return m.BillingAddress
+	}
+	return nil
+}
+
 type PlaceOrderResponse struct {
 	Order                *OrderResult `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
@@ -1404,7 +1759,7 @@ func (m *PlaceOrderResponse) Reset()         { *m = PlaceOrderResponse{} }
 func (m *PlaceOrderResponse) String() string { return proto.CompactTextString(m) }
 func (*PlaceOrderResponse) ProtoMessage()    {}
 func (*PlaceOrderResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ca53982754088a9d, []int{28}
+	return fileDescriptor_ca53982754088a9d, []int{32}
 }
 
 func (m *PlaceOrderResponse) XXX_Unmarshal(b []byte) error {
@@ -1432,58 +1787,564 @@ func (m *PlaceOrderResponse) GetOrder() *OrderResult {
 	return nil
 }
 
-type AdRequest struct {
-	// List of important key words from the current page describing the context.
-	ContextKeys          []string `protobuf:"bytes,1,rep,name=context_keys,json=contextKeys,proto3" json:"context_keys,omitempty"`
+type PreviewOrderRequest struct {
+	UserId               string   `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	UserCurrency         string   `protobuf:"bytes,2,opt,name=user_currency,json=userCurrency,proto3" json:"user_currency,omitempty"`
+	Address              *Address `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	PromoCodes           []string `protobuf:"bytes,4,rep,name=promo_codes,json=promoCodes,proto3" json:"promo_codes,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *AdRequest) Reset()         { *m = AdRequest{} }
-func (m *AdRequest) String() string { return proto.CompactTextString(m) }
-func (*AdRequest) ProtoMessage()    {}
-func (*AdRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ca53982754088a9d, []int{29}
+func (m *PreviewOrderRequest) Reset()         { *m = PreviewOrderRequest{} }
+func (m *PreviewOrderRequest) String() string { return proto.CompactTextString(m) }
+func (*PreviewOrderRequest) ProtoMessage()    {}
+func (*PreviewOrderRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ca53982754088a9d, []int{33}
 }
 
-func (m *AdRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_AdRequest.Unmarshal(m, b)
+func (m *PreviewOrderRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PreviewOrderRequest.Unmarshal(m, b)
 }
-func (m *AdRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_AdRequest.Marshal(b, m, deterministic)
+func (m *PreviewOrderRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PreviewOrderRequest.Marshal(b, m, deterministic)
 }
-func (m *AdRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_AdRequest.Merge(m, src)
+func (m *PreviewOrderRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PreviewOrderRequest.Merge(m, src)
 }
-func (m *AdRequest) XXX_Size() int {
-	return xxx_messageInfo_AdRequest.Size(m)
+func (m *PreviewOrderRequest) XXX_Size() int {
+	return xxx_messageInfo_PreviewOrderRequest.Size(m)
 }
-func (m *AdRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_AdRequest.DiscardUnknown(m)
+func (m *PreviewOrderRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PreviewOrderRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_AdRequest proto.InternalMessageInfo
+var xxx_messageInfo_PreviewOrderRequest proto.InternalMessageInfo
 
-func (m *AdRequest) GetContextKeys() []string {
+func (m *PreviewOrderRequest) GetUserId() string {
 	if m != nil {
-		return m.ContextKeys
+		return m.UserId
 	}
-	return nil
+	return ""
 }
 
-type AdResponse struct {
-	Ads                  []*Ad    `protobuf:"bytes,1,rep,name=ads,proto3" json:"ads,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *PreviewOrderRequest) GetUserCurrency() string {
+	if m != nil {
+		return m.UserCurrency
+	}
+	return ""
 }
 
-func (m *AdResponse) Reset()         { *m = AdResponse{} }
-func (m *AdResponse) String() string { return proto.CompactTextString(m) }
-func (*AdResponse) ProtoMessage()    {}
+func (m *PreviewOrderRequest) GetAddress() *Address {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *PreviewOrderRequest) GetPromoCodes() []string {
+	if m != nil {
+		return m.PromoCodes
+	}
+	return nil
+}
+
+// The pricing of an order, as in OrderResult.
+type PreviewOrderResponse struct {
+	Items         []*OrderItem `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	ShippingCost  *Money       `protobuf:"bytes,2,opt,name=shipping_cost,json=shippingCost,proto3" json:"shipping_cost,omitempty"`
+	TotalTax      *Money       `protobuf:"bytes,3,opt,name=total_tax,json=totalTax,proto3" json:"total_tax,omitempty"`
+	TaxInclusive  bool         `protobuf:"varint,4,opt,name=tax_inclusive,json=taxInclusive,proto3" json:"tax_inclusive,omitempty"`
+	Discounts     []*Discount  `protobuf:"bytes,5,rep,name=discounts,proto3" json:"discounts,omitempty"`
+	TotalDiscount *Money       `protobuf:"bytes,6,opt,name=total_discount,json=totalDiscount,proto3" json:"total_discount,omitempty"`
+	// Amount PlaceOrder would charge to the credit card.
+	Total                *Money   `protobuf:"bytes,7,opt,name=total,proto3" json:"total,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PreviewOrderResponse) Reset()         { *m = PreviewOrderResponse{} }
+func (m *PreviewOrderResponse) String() string { return proto.CompactTextString(m) }
+func (*PreviewOrderResponse) ProtoMessage()    {}
+func (*PreviewOrderResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ca53982754088a9d, []int{34}
+}
+
+func (m *PreviewOrderResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PreviewOrderResponse.Unmarshal(m, b)
+}
+func (m *PreviewOrderResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PreviewOrderResponse.Marshal(b, m, deterministic)
+}
+func (m *PreviewOrderResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PreviewOrderResponse.Merge(m, src)
+}
+func (m *PreviewOrderResponse) XXX_Size() int {
+	return xxx_messageInfo_PreviewOrderResponse.Size(m)
+}
+func (m *PreviewOrderResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PreviewOrderResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PreviewOrderResponse proto.InternalMessageInfo
+
+func (m *PreviewOrderResponse) GetItems() []*OrderItem {
+	if m != nil {
+		return m.Items
+	}
+	return nil
+}
+
+func (m *PreviewOrderResponse) GetShippingCost() *Money {
+	if m != nil {
+		return m.ShippingCost
+	}
+	return nil
+}
+
+func (m *PreviewOrderResponse) GetTotalTax() *Money {
+	if m != nil {
+		return m.TotalTax
+	}
+	return nil
+}
+
+func (m *PreviewOrderResponse) GetTaxInclusive() bool {
+	if m != nil {
+		return m.TaxInclusive
+	}
+	return false
+}
+
+func (m *PreviewOrderResponse) GetDiscounts() []*Discount {
+	if m != nil {
+		return m.Discounts
+	}
+	return nil
+}
+
+func (m *PreviewOrderResponse) GetTotalDiscount() *Money {
+	if m != nil {
+		return m.TotalDiscount
+	}
+	return nil
+}
+
+func (m *PreviewOrderResponse) GetTotal() *Money {
+	if m != nil {
+		return m.Total
+	}
+	return nil
+}
+
+// An order as kept by the checkout service after it was placed.
+type OrderRecord struct {
+	Order  *OrderResult `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	UserId string       `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Time the order was placed, in seconds since the Unix epoch.
+	PlacedAt int64 `protobuf:"varint,3,opt,name=placed_at,json=placedAt,proto3" json:"placed_at,omitempty"`
+	// Outcome of the fraud screening of the order.
+	FraudCheck           *FraudCheck `protobuf:"bytes,4,opt,name=fraud_check,json=fraudCheck,proto3" json:"fraud_check,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *OrderRecord) Reset()         { *m = OrderRecord{} }
+func (m *OrderRecord) String() string { return proto.CompactTextString(m) }
+func (*OrderRecord) ProtoMessage()    {}
+func (*OrderRecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ca53982754088a9d, []int{35}
+}
+
+func (m *OrderRecord) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OrderRecord.Unmarshal(m, b)
+}
+func (m *OrderRecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OrderRecord.Marshal(b, m, deterministic)
+}
+func (m *OrderRecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OrderRecord.Merge(m, src)
+}
+func (m *OrderRecord) XXX_Size() int {
+	return xxx_messageInfo_OrderRecord.Size(m)
+}
+func (m *OrderRecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_OrderRecord.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OrderRecord proto.InternalMessageInfo
+
+func (m *OrderRecord) GetOrder() *OrderResult {
+	if m != nil {
+		return m.Order
+	}
+	return nil
+}
+
+func (m *OrderRecord) GetUserId() string {
+	if m != nil {
+		return m.UserId
+	}
+	return ""
+}
+
+func (m *OrderRecord) GetPlacedAt() int64 {
+	if m != nil {
+		return m.PlacedAt
+	}
+	return 0
+}
+
+func (m *OrderRecord) GetFraudCheck() *FraudCheck {
+	if m != nil {
+		return m.FraudCheck
+	}
+	return nil
+}
+
+type FraudCheck struct {
+	Decision FraudDecision `protobuf:"varint,1,opt,name=decision,proto3,enum=hipstershop.FraudDecision" json:"decision,omitempty"`
+	// Names of the rules that led to the decision.
+	Reasons              []string `protobuf:"bytes,2,rep,name=reasons,proto3" json:"reasons,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FraudCheck) Reset()         { *m = FraudCheck{} }
+func (m *FraudCheck) String() string { return proto.CompactTextString(m) }
+func (*FraudCheck) ProtoMessage()    {}
+func (*FraudCheck) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ca53982754088a9d, []int{36}
+}
+
+func (m *FraudCheck) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FraudCheck.Unmarshal(m, b)
+}
+func (m *FraudCheck) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FraudCheck.Marshal(b, m, deterministic)
+}
+func (m *FraudCheck) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FraudCheck.Merge(m, src)
+}
+func (m *FraudCheck) XXX_Size() int {
+	return xxx_messageInfo_FraudCheck.Size(m)
+}
+func (m *FraudCheck) XXX_DiscardUnknown() {
+	xxx_messageInfo_FraudCheck.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FraudCheck proto.InternalMessageInfo
+
+func (m *FraudCheck) GetDecision() FraudDecision {
+	if m != nil {
+		return m.Decision
+	}
+	return FraudDecision_FRAUD_DECISION_UNSPECIFIED
+}
+
+func (m *FraudCheck) GetReasons() []string {
+	if m != nil {
+		return m.Reasons
+	}
+	return nil
+}
+
+type GetOrderRequest struct {
+	OrderId              string   `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetOrderRequest) Reset()         { *m = GetOrderRequest{} }
+func (m *GetOrderRequest) String() string { return proto.CompactTextString(m) }
+func (*GetOrderRequest) ProtoMessage()    {}
+func (*GetOrderRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ca53982754088a9d, []int{37}
+}
+
+func (m *GetOrderRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetOrderRequest.Unmarshal(m, b)
+}
+func (m *GetOrderRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetOrderRequest.Marshal(b, m, deterministic)
+}
+func (m *GetOrderRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetOrderRequest.Merge(m, src)
+}
+func (m *GetOrderRequest) XXX_Size() int {
+	return xxx_messageInfo_GetOrderRequest.Size(m)
+}
+func (m *GetOrderRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetOrderRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetOrderRequest proto.InternalMessageInfo
+
+func (m *GetOrderRequest) GetOrderId() string {
+	if m != nil {
+		return m.OrderId
+	}
+	return ""
+}
+
+type WatchOrderRequest struct {
+	OrderId              string   `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchOrderRequest) Reset()         { *m = WatchOrderRequest{} }
+func (m *WatchOrderRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchOrderRequest) ProtoMessage()    {}
+func (*WatchOrderRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ca53982754088a9d, []int{38}
+}
+
+func (m *WatchOrderRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchOrderRequest.Unmarshal(m, b)
+}
+func (m *WatchOrderRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchOrderRequest.Marshal(b, m, deterministic)
+}
+func (m *WatchOrderRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchOrderRequest.Merge(m, src)
+}
+func (m *WatchOrderRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchOrderRequest.Size(m)
+}
+func (m *WatchOrderRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchOrderRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchOrderRequest proto.InternalMessageInfo
+
+func (m *WatchOrderRequest) GetOrderId() string {
+	if m != nil {
+		return m.OrderId
+	}
+	return ""
+}
+
+type OrderEvent struct {
+	OrderId string      `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Status  OrderStatus `protobuf:"varint,2,opt,name=status,proto3,enum=hipstershop.OrderStatus" json:"status,omitempty"`
+	// Time of the update, in seconds since the Unix epoch.
+	Time                 int64    `protobuf:"varint,3,opt,name=time,proto3" json:"time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OrderEvent) Reset()         { *m = OrderEvent{} }
+func (m *OrderEvent) String() string { return proto.CompactTextString(m) }
+func (*OrderEvent) ProtoMessage()    {}
+func (*OrderEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ca53982754088a9d, []int{39}
+}
+
+func (m *OrderEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OrderEvent.Unmarshal(m, b)
+}
+func (m *OrderEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OrderEvent.Marshal(b, m, deterministic)
+}
+func (m *OrderEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OrderEvent.Merge(m, src)
+}
+func (m *OrderEvent) XXX_Size() int {
+	return xxx_messageInfo_OrderEvent.Size(m)
+}
+func (m *OrderEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_OrderEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OrderEvent proto.InternalMessageInfo
+
+func (m *OrderEvent) GetOrderId() string {
+	if m != nil {
+		return m.OrderId
+	}
+	return ""
+}
+
+func (m *OrderEvent) GetStatus() OrderStatus {
+	if m != nil {
+		return m.Status
+	}
+	return OrderStatus_ORDER_STATUS_UNSPECIFIED
+}
+
+func (m *OrderEvent) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+type ListOrdersRequest struct {
+	UserId string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Maximum number of orders to return. The server picks a default if unset.
+	PageSize int32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token of a previous ListOrders response, to continue listing.
+	PageToken            string   `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListOrdersRequest) Reset()         { *m = ListOrdersRequest{} }
+func (m *ListOrdersRequest) String() string { return proto.CompactTextString(m) }
+func (*ListOrdersRequest) ProtoMessage()    {}
+func (*ListOrdersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ca53982754088a9d, []int{40}
+}
+
+func (m *ListOrdersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListOrdersRequest.Unmarshal(m, b)
+}
+func (m *ListOrdersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListOrdersRequest.Marshal(b, m, deterministic)
+}
+func (m *ListOrdersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListOrdersRequest.Merge(m, src)
+}
+func (m *ListOrdersRequest) XXX_Size() int {
+	return xxx_messageInfo_ListOrdersRequest.Size(m)
+}
+func (m *ListOrdersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListOrdersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListOrdersRequest proto.InternalMessageInfo
+
+func (m *ListOrdersRequest) GetUserId() string {
+	if m != nil {
+		return m.UserId
+	}
+	return ""
+}
+
+func (m *ListOrdersRequest) GetPageSize() int32 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *ListOrdersRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
+type ListOrdersResponse struct {
+	// Orders of the user, most recent first.
+	Orders []*OrderRecord `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	// Token for the next page, empty if there are no more orders.
+	NextPageToken        string   `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListOrdersResponse) Reset()         { *m = ListOrdersResponse{} }
+func (m *ListOrdersResponse) String() string { return proto.CompactTextString(m) }
+func (*ListOrdersResponse) ProtoMessage()    {}
+func (*ListOrdersResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ca53982754088a9d, []int{41}
+}
+
+func (m *ListOrdersResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListOrdersResponse.Unmarshal(m, b)
+}
+func (m *ListOrdersResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListOrdersResponse.Marshal(b, m, deterministic)
+}
+func (m *ListOrdersResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListOrdersResponse.Merge(m, src)
+}
+func (m *ListOrdersResponse) XXX_Size() int {
+	return xxx_messageInfo_ListOrdersResponse.Size(m)
+}
+func (m *ListOrdersResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListOrdersResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListOrdersResponse proto.InternalMessageInfo
+
+func (m *ListOrdersResponse) GetOrders() []*OrderRecord {
+	if m != nil {
+		return m.Orders
+	}
+	return nil
+}
+
+func (m *ListOrdersResponse) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
+type AdRequest struct {
+	// List of important key words from the current page describing the context.
+	ContextKeys          []string `protobuf:"bytes,1,rep,name=context_keys,json=contextKeys,proto3" json:"context_keys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AdRequest) Reset()         { *m = AdRequest{} }
+func (m *AdRequest) String() string { return proto.CompactTextString(m) }
+func (*AdRequest) ProtoMessage()    {}
+func (*AdRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ca53982754088a9d, []int{42}
+}
+
+func (m *AdRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AdRequest.Unmarshal(m, b)
+}
+func (m *AdRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AdRequest.Marshal(b, m, deterministic)
+}
+func (m *AdRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AdRequest.Merge(m, src)
+}
+func (m *AdRequest) XXX_Size() int {
+	return xxx_messageInfo_AdRequest.Size(m)
+}
+func (m *AdRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AdRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AdRequest proto.InternalMessageInfo
+
+func (m *AdRequest) GetContextKeys() []string {
+	if m != nil {
+		return m.ContextKeys
+	}
+	return nil
+}
+
+type AdResponse struct {
+	Ads                  []*Ad    `protobuf:"bytes,1,rep,name=ads,proto3" json:"ads,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AdResponse) Reset()         { *m = AdResponse{} }
+func (m *AdResponse) String() string { return proto.CompactTextString(m) }
+func (*AdResponse) ProtoMessage()    {}
 func (*AdResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ca53982754088a9d, []int{30}
+	return fileDescriptor_ca53982754088a9d, []int{43}
 }
 
 func (m *AdResponse) XXX_Unmarshal(b []byte) error {
@@ -1525,7 +2386,7 @@ func (m *Ad) Reset()         { *m = Ad{} }
 func (m *Ad) String() string { return proto.CompactTextString(m) }
 func (*Ad) ProtoMessage()    {}
 func (*Ad) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ca53982754088a9d, []int{31}
+	return fileDescriptor_ca53982754088a9d, []int{44}
 }
 
 func (m *Ad) XXX_Unmarshal(b []byte) error {
@@ -1561,6 +2422,8 @@ func (m *Ad) GetText() string {
 }
 
 func init() {
+	proto.RegisterEnum("hipstershop.OrderStatus", OrderStatus_name, OrderStatus_value)
+	proto.RegisterEnum("hipstershop.FraudDecision", FraudDecision_name, FraudDecision_value)
 	proto.RegisterType((*CartItem)(nil), "hipstershop.CartItem")
 	proto.RegisterType((*AddItemRequest)(nil), "hipstershop.AddItemRequest")
 	proto.RegisterType((*EmptyCartRequest)(nil), "hipstershop.EmptyCartRequest")
@@ -1578,6 +2441,9 @@ func init() {
 	proto.RegisterType((*GetQuoteResponse)(nil), "hipstershop.GetQuoteResponse")
 	proto.RegisterType((*ShipOrderRequest)(nil), "hipstershop.ShipOrderRequest")
 	proto.RegisterType((*ShipOrderResponse)(nil), "hipstershop.ShipOrderResponse")
+	proto.RegisterType((*TrackShipmentRequest)(nil), "hipstershop.TrackShipmentRequest")
+	proto.RegisterType((*ShipmentEvent)(nil), "hipstershop.ShipmentEvent")
+	proto.RegisterType((*CancelShipmentRequest)(nil), "hipstershop.CancelShipmentRequest")
 	proto.RegisterType((*Address)(nil), "hipstershop.Address")
 	proto.RegisterType((*Money)(nil), "hipstershop.Money")
 	proto.RegisterType((*GetSupportedCurrenciesResponse)(nil), "hipstershop.GetSupportedCurrenciesResponse")
@@ -1586,10 +2452,20 @@ func init() {
 	proto.RegisterType((*ChargeRequest)(nil), "hipstershop.ChargeRequest")
 	proto.RegisterType((*ChargeResponse)(nil), "hipstershop.ChargeResponse")
 	proto.RegisterType((*OrderItem)(nil), "hipstershop.OrderItem")
+	proto.RegisterType((*Discount)(nil), "hipstershop.Discount")
 	proto.RegisterType((*OrderResult)(nil), "hipstershop.OrderResult")
 	proto.RegisterType((*SendOrderConfirmationRequest)(nil), "hipstershop.SendOrderConfirmationRequest")
 	proto.RegisterType((*PlaceOrderRequest)(nil), "hipstershop.PlaceOrderRequest")
 	proto.RegisterType((*PlaceOrderResponse)(nil), "hipstershop.PlaceOrderResponse")
+	proto.RegisterType((*PreviewOrderRequest)(nil), "hipstershop.PreviewOrderRequest")
+	proto.RegisterType((*PreviewOrderResponse)(nil), "hipstershop.PreviewOrderResponse")
+	proto.RegisterType((*OrderRecord)(nil), "hipstershop.OrderRecord")
+	proto.RegisterType((*FraudCheck)(nil), "hipstershop.FraudCheck")
+	proto.RegisterType((*GetOrderRequest)(nil), "hipstershop.GetOrderRequest")
+	proto.RegisterType((*WatchOrderRequest)(nil), "hipstershop.WatchOrderRequest")
+	proto.RegisterType((*OrderEvent)(nil), "hipstershop.OrderEvent")
+	proto.RegisterType((*ListOrdersRequest)(nil), "hipstershop.ListOrdersRequest")
+	proto.RegisterType((*ListOrdersResponse)(nil), "hipstershop.ListOrdersResponse")
 	proto.RegisterType((*AdRequest)(nil), "hipstershop.AdRequest")
 	proto.RegisterType((*AdResponse)(nil), "hipstershop.AdResponse")
 	proto.RegisterType((*Ad)(nil), "hipstershop.Ad")
@@ -1933,6 +2809,10 @@ var _ProductCatalogService_serviceDesc = grpc.ServiceDesc{
 type ShippingServiceClient interface {
 	GetQuote(ctx context.Context, in *GetQuoteRequest, opts ...grpc.CallOption) (*GetQuoteResponse, error)
 	ShipOrder(ctx context.Context, in *ShipOrderRequest, opts ...grpc.CallOption) (*ShipOrderResponse, error)
+	// Streams the progress of a shipment, starting with the updates that
+	// already happened, until it is delivered or cancelled.
+	TrackShipment(ctx context.Context, in *TrackShipmentRequest, opts ...grpc.CallOption) (ShippingService_TrackShipmentClient, error)
+	CancelShipment(ctx context.Context, in *CancelShipmentRequest, opts ...grpc.CallOption) (*Empty, error)
 }
 
 type shippingServiceClient struct {
@@ -1961,10 +2841,55 @@ func (c *shippingServiceClient) ShipOrder(ctx context.Context, in *ShipOrderRequ
 	return out, nil
 }
 
+func (c *shippingServiceClient) TrackShipment(ctx context.Context, in *TrackShipmentRequest, opts ...grpc.CallOption) (ShippingService_TrackShipmentClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_ShippingService_serviceDesc.Streams[0], "/hipstershop.ShippingService/TrackShipment", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &shippingServiceTrackShipmentClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ShippingService_TrackShipmentClient interface {
+	Recv() (*ShipmentEvent, error)
+	grpc.ClientStream
+}
+
+type shippingServiceTrackShipmentClient struct {
+	grpc.ClientStream
+}
+
+func (x *shippingServiceTrackShipmentClient) Recv() (*ShipmentEvent, error) {
+	m := new(ShipmentEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *shippingServiceClient) CancelShipment(ctx context.Context, in *CancelShipmentRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/hipstershop.ShippingService/CancelShipment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ShippingServiceServer is the server API for ShippingService service.
 type ShippingServiceServer interface {
 	GetQuote(context.Context, *GetQuoteRequest) (*GetQuoteResponse, error)
 	ShipOrder(context.Context, *ShipOrderRequest) (*ShipOrderResponse, error)
+	// Streams the progress of a shipment, starting with the updates that
+	// already happened, until it is delivered or cancelled.
+	TrackShipment(*TrackShipmentRequest, ShippingService_TrackShipmentServer) error
+	CancelShipment(context.Context, *CancelShipmentRequest) (*Empty, error)
 }
 
 func RegisterShippingServiceServer(s *grpc.Server, srv ShippingServiceServer) {
@@ -2007,6 +2932,45 @@ func _ShippingService_ShipOrder_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ShippingService_TrackShipment_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TrackShipmentRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ShippingServiceServer).TrackShipment(m, &shippingServiceTrackShipmentServer{stream})
+}
+
+type ShippingService_TrackShipmentServer interface {
+	Send(*ShipmentEvent) error
+	grpc.ServerStream
+}
+
+type shippingServiceTrackShipmentServer struct {
+	grpc.ServerStream
+}
+
+func (x *shippingServiceTrackShipmentServer) Send(m *ShipmentEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ShippingService_CancelShipment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelShipmentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShippingServiceServer).CancelShipment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hipstershop.ShippingService/CancelShipment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShippingServiceServer).CancelShipment(ctx, req.(*CancelShipmentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _ShippingService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "hipstershop.ShippingService",
 	HandlerType: (*ShippingServiceServer)(nil),
@@ -2019,8 +2983,18 @@ var _ShippingService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ShipOrder",
 			Handler:    _ShippingService_ShipOrder_Handler,
 		},
+		{
+			MethodName: "CancelShipment",
+			Handler:    _ShippingService_CancelShipment_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "TrackShipment",
+			Handler:       _ShippingService_TrackShipment_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "demo.proto",
 }
 
@@ -2254,6 +3228,14 @@ var _EmailService_serviceDesc = grpc.ServiceDesc{
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type CheckoutServiceClient interface {
 	PlaceOrder(ctx context.Context, in *PlaceOrderRequest, opts ...grpc.CallOption) (*PlaceOrderResponse, error)
+	GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*OrderRecord, error)
+	ListOrders(ctx context.Context, in *ListOrdersRequest, opts ...grpc.CallOption) (*ListOrdersResponse, error)
+	// Prices the user's cart like PlaceOrder would, without charging, shipping
+	// or emptying the cart.
+	PreviewOrder(ctx context.Context, in *PreviewOrderRequest, opts ...grpc.CallOption) (*PreviewOrderResponse, error)
+	// Streams the status updates of an order, starting with the ones that
+	// already happened, until it is delivered or cancelled.
+	WatchOrder(ctx context.Context, in *WatchOrderRequest, opts ...grpc.CallOption) (CheckoutService_WatchOrderClient, error)
 }
 
 type checkoutServiceClient struct {
@@ -2273,9 +3255,76 @@ func (c *checkoutServiceClient) PlaceOrder(ctx context.Context, in *PlaceOrderRe
 	return out, nil
 }
 
+func (c *checkoutServiceClient) GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*OrderRecord, error) {
+	out := new(OrderRecord)
+	err := c.cc.Invoke(ctx, "/hipstershop.CheckoutService/GetOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *checkoutServiceClient) ListOrders(ctx context.Context, in *ListOrdersRequest, opts ...grpc.CallOption) (*ListOrdersResponse, error) {
+	out := new(ListOrdersResponse)
+	err := c.cc.Invoke(ctx, "/hipstershop.CheckoutService/ListOrders", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *checkoutServiceClient) PreviewOrder(ctx context.Context, in *PreviewOrderRequest, opts ...grpc.CallOption) (*PreviewOrderResponse, error) {
+	out := new(PreviewOrderResponse)
+	err := c.cc.Invoke(ctx, "/hipstershop.CheckoutService/PreviewOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *checkoutServiceClient) WatchOrder(ctx context.Context, in *WatchOrderRequest, opts ...grpc.CallOption) (CheckoutService_WatchOrderClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_CheckoutService_serviceDesc.Streams[0], "/hipstershop.CheckoutService/WatchOrder", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &checkoutServiceWatchOrderClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CheckoutService_WatchOrderClient interface {
+	Recv() (*OrderEvent, error)
+	grpc.ClientStream
+}
+
+type checkoutServiceWatchOrderClient struct {
+	grpc.ClientStream
+}
+
+func (x *checkoutServiceWatchOrderClient) Recv() (*OrderEvent, error) {
+	m := new(OrderEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // CheckoutServiceServer is the server API for CheckoutService service.
 type CheckoutServiceServer interface {
 	PlaceOrder(context.Context, *PlaceOrderRequest) (*PlaceOrderResponse, error)
+	GetOrder(context.Context, *GetOrderRequest) (*OrderRecord, error)
+	ListOrders(context.Context, *ListOrdersRequest) (*ListOrdersResponse, error)
+	// Prices the user's cart like PlaceOrder would, without charging, shipping
+	// or emptying the cart.
+	PreviewOrder(context.Context, *PreviewOrderRequest) (*PreviewOrderResponse, error)
+	// Streams the status updates of an order, starting with the ones that
+	// already happened, until it is delivered or cancelled.
+	WatchOrder(*WatchOrderRequest, CheckoutService_WatchOrderServer) error
 }
 
 func RegisterCheckoutServiceServer(s *grpc.Server, srv CheckoutServiceServer) {
@@ -2300,6 +3349,81 @@ func _CheckoutService_PlaceOrder_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CheckoutService_GetOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CheckoutServiceServer).GetOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hipstershop.CheckoutService/GetOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CheckoutServiceServer).GetOrder(ctx, req.(*GetOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CheckoutService_ListOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOrdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CheckoutServiceServer).ListOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hipstershop.CheckoutService/ListOrders",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CheckoutServiceServer).ListOrders(ctx, req.(*ListOrdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CheckoutService_PreviewOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PreviewOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CheckoutServiceServer).PreviewOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hipstershop.CheckoutService/PreviewOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CheckoutServiceServer).PreviewOrder(ctx, req.(*PreviewOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CheckoutService_WatchOrder_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchOrderRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CheckoutServiceServer).WatchOrder(m, &checkoutServiceWatchOrderServer{stream})
+}
+
+type CheckoutService_WatchOrderServer interface {
+	Send(*OrderEvent) error
+	grpc.ServerStream
+}
+
+type checkoutServiceWatchOrderServer struct {
+	grpc.ServerStream
+}
+
+func (x *checkoutServiceWatchOrderServer) Send(m *OrderEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _CheckoutService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "hipstershop.CheckoutService",
 	HandlerType: (*CheckoutServiceServer)(nil),
@@ -2308,8 +3432,26 @@ var _CheckoutService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "PlaceOrder",
 			Handler:    _CheckoutService_PlaceOrder_Handler,
 		},
+		{
+			MethodName: "GetOrder",
+			Handler:    _CheckoutService_GetOrder_Handler,
+		},
+		{
+			MethodName: "ListOrders",
+			Handler:    _CheckoutService_ListOrders_Handler,
+		},
+		{
+			MethodName: "PreviewOrder",
+			Handler:    _CheckoutService_PreviewOrder_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchOrder",
+			Handler:       _CheckoutService_WatchOrder_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "demo.proto",
 }
 
@@ -2380,99 +3522,147 @@ var _AdService_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("demo.proto", fileDescriptor_ca53982754088a9d) }
 
 var fileDescriptor_ca53982754088a9d = []byte{
-	// 1500 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0xef, 0x72, 0x13, 0xb7,
-	0x16, 0xcf, 0x26, 0xb1, 0x1d, 0x1f, 0xc7, 0x4e, 0xa2, 0x9b, 0x04, 0xb3, 0x81, 0x10, 0x94, 0x81,
-	0x0b, 0x17, 0x08, 0x4c, 0xee, 0x9d, 0xe1, 0x03, 0xdc, 0xd2, 0x8c, 0xc9, 0x18, 0x4f, 0xa1, 0xd0,
-	0x0d, 0xe9, 0xd0, 0xa1, 0x53, 0xcf, 0xb2, 0x12, 0xf1, 0x96, 0xec, 0x6a, 0x91, 0xb4, 0x19, 0xcc,
-	0xc7, 0xf6, 0x01, 0xfa, 0x1e, 0x7d, 0x81, 0xce, 0xf4, 0x11, 0xfa, 0xbd, 0xaf, 0xd0, 0xe7, 0xe8,
-	0x48, 0xbb, 0xda, 0x7f, 0xb1, 0x13, 0xf8, 0xd2, 0x6f, 0xab, 0xa3, 0x9f, 0xce, 0xf9, 0xe9, 0xe8,
-	0xfc, 0xb3, 0x01, 0x08, 0x0d, 0xd8, 0x4e, 0xc4, 0x99, 0x64, 0xa8, 0x35, 0xf2, 0x23, 0x21, 0x29,
-	0x17, 0x23, 0x16, 0xe1, 0x7d, 0x58, 0xe8, 0xb9, 0x5c, 0x0e, 0x24, 0x0d, 0xd0, 0x65, 0x80, 0x88,
-	0x33, 0x12, 0x7b, 0x72, 0xe8, 0x93, 0xae, 0xb5, 0x65, 0xdd, 0x68, 0x3a, 0xcd, 0x54, 0x32, 0x20,
-	0xc8, 0x86, 0x85, 0xf7, 0xb1, 0x1b, 0x4a, 0x5f, 0x8e, 0xbb, 0xb3, 0x5b, 0xd6, 0x8d, 0x9a, 0x93,
-	0xad, 0xf1, 0x4b, 0xe8, 0xec, 0x11, 0xa2, 0xb4, 0x38, 0xf4, 0x7d, 0x4c, 0x85, 0x44, 0x17, 0xa0,
-	0x11, 0x0b, 0xca, 0x73, 0x4d, 0x75, 0xb5, 0x1c, 0x10, 0x74, 0x13, 0xe6, 0x7d, 0x49, 0x03, 0xad,
-	0xa2, 0xb5, 0xbb, 0xb6, 0x53, 0x60, 0xb3, 0x63, 0xa8, 0x38, 0x1a, 0x82, 0x6f, 0xc1, 0xf2, 0x7e,
-	0x10, 0xc9, 0xb1, 0x12, 0x9f, 0xa7, 0x17, 0xdf, 0x84, 0x4e, 0x9f, 0xca, 0x4f, 0x82, 0x3e, 0x85,
-	0x79, 0x85, 0x9b, 0xce, 0xf1, 0x16, 0xd4, 0x14, 0x01, 0xd1, 0x9d, 0xdd, 0x9a, 0x9b, 0x4e, 0x32,
-	0xc1, 0xe0, 0x06, 0xd4, 0x34, 0x4b, 0xfc, 0x2d, 0xd8, 0x4f, 0x7d, 0x21, 0x1d, 0xea, 0xb1, 0x20,
-	0xa0, 0x21, 0x71, 0xa5, 0xcf, 0x42, 0x71, 0xae, 0x43, 0xae, 0x40, 0x2b, 0x77, 0x7b, 0x62, 0xb2,
-	0xe9, 0x40, 0xe6, 0x77, 0x81, 0xbf, 0x80, 0x8d, 0x89, 0x7a, 0x45, 0xc4, 0x42, 0x41, 0xab, 0xe7,
-	0xad, 0x53, 0xe7, 0x7f, 0xb7, 0xa0, 0xf1, 0x22, 0x59, 0xa2, 0x0e, 0xcc, 0x66, 0x04, 0x66, 0x7d,
-	0x82, 0x10, 0xcc, 0x87, 0x6e, 0x40, 0xf5, 0x6b, 0x34, 0x1d, 0xfd, 0x8d, 0xb6, 0xa0, 0x45, 0xa8,
-	0xf0, 0xb8, 0x1f, 0x29, 0x43, 0xdd, 0x39, 0xbd, 0x55, 0x14, 0xa1, 0x2e, 0x34, 0x22, 0xdf, 0x93,
-	0x31, 0xa7, 0xdd, 0x79, 0xbd, 0x6b, 0x96, 0xe8, 0x2e, 0x34, 0x23, 0xee, 0x7b, 0x74, 0x18, 0x0b,
-	0xd2, 0xad, 0xe9, 0x27, 0x46, 0x25, 0xef, 0x3d, 0x63, 0x21, 0x1d, 0x3b, 0x0b, 0x1a, 0x74, 0x28,
-	0x08, 0xda, 0x04, 0xf0, 0x5c, 0x49, 0x8f, 0x18, 0xf7, 0xa9, 0xe8, 0xd6, 0x13, 0xf2, 0xb9, 0x04,
-	0x3f, 0x81, 0x55, 0x75, 0xf9, 0x94, 0x7f, 0x7e, 0xeb, 0x7b, 0xb0, 0x90, 0x5e, 0x31, 0xb9, 0x72,
-	0x6b, 0x77, 0xb5, 0x64, 0x27, 0x3d, 0xe0, 0x64, 0x28, 0xbc, 0x0d, 0x2b, 0x7d, 0x6a, 0x14, 0x99,
-	0x57, 0xa9, 0xf8, 0x03, 0xdf, 0x81, 0xb5, 0x03, 0xea, 0x72, 0x6f, 0x94, 0x1b, 0x4c, 0x80, 0xab,
-	0x50, 0x7b, 0x1f, 0x53, 0x3e, 0x4e, 0xb1, 0xc9, 0x02, 0x3f, 0x81, 0xf5, 0x2a, 0x3c, 0xe5, 0xb7,
-	0x03, 0x0d, 0x4e, 0x45, 0x7c, 0x7c, 0x0e, 0x3d, 0x03, 0xc2, 0x21, 0x2c, 0xf5, 0xa9, 0xfc, 0x26,
-	0x66, 0x92, 0x1a, 0x93, 0x3b, 0xd0, 0x70, 0x09, 0xe1, 0x54, 0x08, 0x6d, 0xb4, 0xaa, 0x62, 0x2f,
-	0xd9, 0x73, 0x0c, 0xe8, 0xf3, 0xa2, 0x76, 0x0f, 0x96, 0x73, 0x7b, 0x29, 0xe7, 0x3b, 0xb0, 0xe0,
-	0x31, 0x21, 0xf5, 0xdb, 0x59, 0x53, 0xdf, 0xae, 0xa1, 0x30, 0x87, 0x82, 0x60, 0x06, 0xcb, 0x07,
-	0x23, 0x3f, 0x7a, 0xce, 0x09, 0xe5, 0xff, 0x08, 0xe7, 0xff, 0xc1, 0x4a, 0xc1, 0x60, 0x1e, 0xfe,
-	0x92, 0xbb, 0xde, 0x3b, 0x3f, 0x3c, 0xca, 0x73, 0x0b, 0x8c, 0x68, 0x40, 0xf0, 0x2f, 0x16, 0x34,
-	0x52, 0xbb, 0xe8, 0x1a, 0x74, 0x84, 0xe4, 0x94, 0xca, 0x61, 0x91, 0x65, 0xd3, 0x69, 0x27, 0x52,
-	0x03, 0x43, 0x30, 0xef, 0x99, 0x32, 0xd7, 0x74, 0xf4, 0xb7, 0x0a, 0x00, 0x21, 0x5d, 0x49, 0xd3,
-	0x7c, 0x48, 0x16, 0x2a, 0x13, 0x3c, 0x16, 0x87, 0x92, 0x8f, 0x4d, 0x26, 0xa4, 0x4b, 0x74, 0x11,
-	0x16, 0x3e, 0xfa, 0xd1, 0xd0, 0x63, 0x84, 0xea, 0x44, 0xa8, 0x39, 0x8d, 0x8f, 0x7e, 0xd4, 0x63,
-	0x84, 0xe2, 0x57, 0x50, 0xd3, 0xae, 0x44, 0xdb, 0xd0, 0xf6, 0x62, 0xce, 0x69, 0xe8, 0x8d, 0x13,
-	0x60, 0xc2, 0x66, 0xd1, 0x08, 0x15, 0x5a, 0x19, 0x8e, 0x43, 0x5f, 0x0a, 0xcd, 0x66, 0xce, 0x49,
-	0x16, 0x4a, 0x1a, 0xba, 0x21, 0x13, 0x9a, 0x4e, 0xcd, 0x49, 0x16, 0xb8, 0x0f, 0x9b, 0x7d, 0x2a,
-	0x0f, 0xe2, 0x28, 0x62, 0x5c, 0x52, 0xd2, 0x4b, 0xf4, 0xf8, 0x34, 0x8f, 0xcb, 0x6b, 0xd0, 0x29,
-	0x99, 0x34, 0x05, 0xa3, 0x5d, 0xb4, 0x29, 0xf0, 0xf7, 0x70, 0xb1, 0x97, 0x09, 0xc2, 0x13, 0xca,
-	0x85, 0xcf, 0x42, 0xf3, 0xc8, 0xd7, 0x61, 0xfe, 0x2d, 0x67, 0xc1, 0x19, 0x31, 0xa2, 0xf7, 0x55,
-	0xc9, 0x93, 0x2c, 0xb9, 0x58, 0xe2, 0xc9, 0xba, 0x64, 0xda, 0x01, 0x7f, 0x59, 0xd0, 0xe9, 0x71,
-	0x4a, 0x7c, 0x55, 0xaf, 0xc9, 0x20, 0x7c, 0xcb, 0xd0, 0x6d, 0x40, 0x9e, 0x96, 0x0c, 0x3d, 0x97,
-	0x93, 0x61, 0x18, 0x07, 0x6f, 0x28, 0x4f, 0xfd, 0xb1, 0xec, 0x65, 0xd8, 0xaf, 0xb5, 0x1c, 0x5d,
-	0x87, 0xa5, 0x22, 0xda, 0x3b, 0x39, 0x49, 0x5b, 0x52, 0x3b, 0x87, 0xf6, 0x4e, 0x4e, 0xd0, 0xff,
-	0x61, 0xa3, 0x88, 0xa3, 0x1f, 0x22, 0x9f, 0xeb, 0xf2, 0x39, 0x1c, 0x53, 0x97, 0xa7, 0xbe, 0xeb,
-	0xe6, 0x67, 0xf6, 0x33, 0xc0, 0x77, 0xd4, 0xe5, 0xe8, 0x11, 0x5c, 0x9a, 0x72, 0x3c, 0x60, 0xa1,
-	0x1c, 0xe9, 0x27, 0xaf, 0x39, 0x17, 0x27, 0x9d, 0x7f, 0xa6, 0x00, 0x78, 0x0c, 0xed, 0xde, 0xc8,
-	0xe5, 0x47, 0x59, 0x4e, 0xff, 0x07, 0xea, 0x6e, 0xa0, 0x22, 0xe4, 0x0c, 0xe7, 0xa5, 0x08, 0xf4,
-	0x10, 0x5a, 0x05, 0xeb, 0x69, 0xc3, 0xdc, 0x28, 0x67, 0x48, 0xc9, 0x89, 0x0e, 0xe4, 0x4c, 0xf0,
-	0x7d, 0xe8, 0x18, 0xd3, 0xf9, 0xd3, 0x4b, 0xee, 0x86, 0xc2, 0xf5, 0xf4, 0x15, 0xb2, 0x64, 0x69,
-	0x17, 0xa4, 0x03, 0x82, 0x7f, 0x80, 0xa6, 0xce, 0x30, 0x3d, 0x13, 0x98, 0x6e, 0x6d, 0x9d, 0xdb,
-	0xad, 0x55, 0x54, 0xa8, 0xca, 0x90, 0xf2, 0x9c, 0x18, 0x15, 0x6a, 0x1f, 0xff, 0x34, 0x0b, 0x2d,
-	0x93, 0xc2, 0xf1, 0xb1, 0x54, 0x89, 0xc2, 0xd4, 0x32, 0x27, 0xd4, 0xd0, 0xeb, 0x01, 0x41, 0xf7,
-	0x60, 0x55, 0x8c, 0xfc, 0x28, 0x52, 0xb9, 0x5d, 0x4c, 0xf2, 0x24, 0x9a, 0x90, 0xd9, 0x7b, 0x99,
-	0x25, 0x3b, 0xba, 0x0f, 0xed, 0xec, 0x84, 0x66, 0x33, 0x37, 0x95, 0xcd, 0xa2, 0x01, 0xf6, 0x98,
-	0x90, 0xe8, 0x11, 0x2c, 0x67, 0x07, 0x4d, 0x6d, 0x98, 0x3f, 0xa3, 0x82, 0x2d, 0x19, 0xb4, 0xa9,
-	0x19, 0xb7, 0x4d, 0x25, 0xab, 0xe9, 0x4a, 0xb6, 0x5e, 0x3a, 0x95, 0x39, 0xd4, 0x94, 0x32, 0x02,
-	0x97, 0x0e, 0x68, 0x48, 0xb4, 0xbc, 0xc7, 0xc2, 0xb7, 0x3e, 0x0f, 0x74, 0xd8, 0x14, 0xda, 0x0d,
-	0x0d, 0x5c, 0xff, 0xd8, 0xb4, 0x1b, 0xbd, 0x40, 0x3b, 0x50, 0xd3, 0xae, 0x49, 0x7d, 0xdc, 0x3d,
-	0x6d, 0x23, 0xf1, 0xa9, 0x93, 0xc0, 0xf0, 0x9f, 0x16, 0xac, 0xbc, 0x38, 0x76, 0x3d, 0x5a, 0xaa,
-	0xd1, 0x53, 0x27, 0x91, 0x6d, 0x68, 0xeb, 0x0d, 0x53, 0x0a, 0x52, 0x3f, 0x2f, 0x2a, 0xa1, 0xa9,
-	0x06, 0xc5, 0x0a, 0x3f, 0xf7, 0x29, 0x15, 0x3e, 0xbb, 0x49, 0xad, 0x78, 0x93, 0x4a, 0x6c, 0xd7,
-	0x3f, 0x2f, 0xb6, 0x1f, 0x03, 0x2a, 0x5e, 0x2b, 0x6b, 0xb9, 0xa9, 0x77, 0xac, 0x4f, 0xf3, 0xce,
-	0x0e, 0x34, 0xf7, 0x88, 0x71, 0xca, 0x55, 0x58, 0xf4, 0x58, 0x28, 0xe9, 0x07, 0x39, 0x7c, 0x47,
-	0xc7, 0xa6, 0x2a, 0xb6, 0x52, 0xd9, 0x57, 0x74, 0x2c, 0xf0, 0x5d, 0x00, 0x85, 0x4f, 0xad, 0x5d,
-	0x85, 0x39, 0x97, 0x98, 0xe6, 0xbe, 0x54, 0xf1, 0x81, 0xa3, 0xf6, 0xf0, 0x03, 0x98, 0xdd, 0x23,
-	0x4a, 0xb3, 0x62, 0xce, 0xa9, 0x27, 0x87, 0x31, 0x37, 0x2f, 0xda, 0x32, 0xb2, 0x43, 0x7e, 0xac,
-	0xfa, 0x8d, 0xb2, 0x62, 0xfa, 0x8d, 0xfa, 0xde, 0xfd, 0xc3, 0x82, 0x96, 0xca, 0xb0, 0x03, 0xca,
-	0x4f, 0x7c, 0x8f, 0xa2, 0x87, 0xba, 0x8b, 0xe9, 0xa4, 0xdc, 0xa8, 0x7a, 0xbc, 0x30, 0x78, 0xdb,
-	0xe5, 0x50, 0x4f, 0x26, 0xd3, 0x19, 0xf4, 0x00, 0x1a, 0xe9, 0x74, 0x5c, 0x39, 0x5d, 0x9e, 0x99,
-	0xed, 0x95, 0x53, 0x19, 0x8e, 0x67, 0xd0, 0x97, 0xd0, 0xcc, 0xe6, 0x70, 0x74, 0xf9, 0xb4, 0xfe,
-	0xa2, 0x82, 0x89, 0xe6, 0x77, 0x7f, 0xb6, 0x60, 0xad, 0x3c, 0xbf, 0x9a, 0x6b, 0xfd, 0x08, 0xff,
-	0x9a, 0x30, 0xdc, 0xa2, 0x7f, 0x97, 0xd4, 0x4c, 0x1f, 0xab, 0xed, 0x1b, 0xe7, 0x03, 0x93, 0x07,
-	0x53, 0x2c, 0x66, 0x61, 0x2d, 0x1d, 0xbc, 0x7a, 0xae, 0x74, 0x8f, 0xd9, 0x91, 0x61, 0xd1, 0x87,
-	0xc5, 0xe2, 0x94, 0x89, 0x26, 0xdc, 0xc2, 0xbe, 0x7a, 0xca, 0x52, 0x75, 0xe8, 0xc3, 0x33, 0xe8,
-	0x31, 0x40, 0x3e, 0x64, 0xa2, 0xcd, 0xaa, 0xab, 0xcb, 0xd3, 0xa7, 0x3d, 0x71, 0x26, 0xc4, 0x33,
-	0xe8, 0x35, 0x74, 0xca, 0x63, 0x25, 0xc2, 0x25, 0xe4, 0xc4, 0x11, 0xd5, 0xde, 0x3e, 0x13, 0x93,
-	0x79, 0xe1, 0x57, 0x0b, 0x96, 0x0e, 0xd2, 0xe2, 0x65, 0xee, 0x3f, 0x80, 0x05, 0x33, 0x0d, 0xa2,
-	0x4b, 0x55, 0xd2, 0xc5, 0xa1, 0xd4, 0xbe, 0x3c, 0x65, 0x37, 0xf3, 0xc0, 0x53, 0x68, 0x66, 0x43,
-	0x5a, 0x25, 0x58, 0xaa, 0xd3, 0xa2, 0xbd, 0x39, 0x6d, 0x3b, 0x23, 0xfb, 0x9b, 0x05, 0x4b, 0xa6,
-	0xf4, 0x18, 0xb2, 0xaf, 0x61, 0x7d, 0xf2, 0x90, 0x33, 0xf1, 0xd9, 0x6e, 0x55, 0x09, 0x9f, 0x31,
-	0x1d, 0xe1, 0x19, 0xd4, 0x87, 0x46, 0x32, 0xf0, 0x48, 0x74, 0xbd, 0x9c, 0x0b, 0xd3, 0xc6, 0x21,
-	0x7b, 0x42, 0x73, 0xc1, 0x33, 0xbb, 0x87, 0xd0, 0x79, 0xe1, 0x8e, 0x03, 0x1a, 0x66, 0x19, 0xdc,
-	0x83, 0x7a, 0xd2, 0x91, 0x91, 0x5d, 0xd6, 0x5c, 0x9c, 0x10, 0xec, 0x8d, 0x89, 0x7b, 0x99, 0x43,
-	0x46, 0xb0, 0xb8, 0xaf, 0x2a, 0xa8, 0x51, 0xfa, 0x4a, 0xfd, 0x60, 0x99, 0xd0, 0x48, 0xd0, 0xcd,
-	0x4a, 0x34, 0x4c, 0x6f, 0x36, 0x53, 0x72, 0xf6, 0x0d, 0x2c, 0xf5, 0x46, 0xd4, 0x7b, 0xc7, 0xe2,
-	0xec, 0x06, 0xcf, 0x01, 0xf2, 0xba, 0x5b, 0x89, 0xee, 0x53, 0x7d, 0xc6, 0xbe, 0x32, 0x75, 0x3f,
-	0xbb, 0xcd, 0x13, 0x55, 0x82, 0x8d, 0xf6, 0x07, 0x50, 0xef, 0xab, 0x19, 0x5c, 0xa0, 0xf5, 0x6a,
-	0x39, 0x4d, 0x35, 0x5e, 0x38, 0x25, 0x37, 0x9a, 0xde, 0xd4, 0xf5, 0x9f, 0x1b, 0xff, 0xfd, 0x3b,
-	0x00, 0x00, 0xff, 0xff, 0xb2, 0xa0, 0x6e, 0x6c, 0xea, 0x10, 0x00, 0x00,
+	// 2266 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x59, 0xdb, 0x72, 0xdb, 0xc6,
+	0xf9, 0x17, 0x78, 0xe6, 0x47, 0x91, 0xa2, 0x36, 0x92, 0x4d, 0x53, 0x3e, 0xc8, 0xeb, 0x7f, 0x1c,
+	0x9f, 0xa2, 0x78, 0x94, 0xff, 0xd4, 0xe9, 0x38, 0x69, 0xca, 0x21, 0x69, 0x99, 0x89, 0x22, 0xab,
+	0x20, 0xe5, 0xa4, 0x93, 0x4e, 0x39, 0x30, 0xb0, 0x36, 0x51, 0x93, 0x00, 0xbc, 0x58, 0x28, 0xa2,
+	0x6f, 0xfb, 0x00, 0x7d, 0x80, 0xde, 0xf6, 0xb6, 0x77, 0x9d, 0xe9, 0x4c, 0x1f, 0x21, 0x0f, 0xd1,
+	0xcb, 0xde, 0xf5, 0xa6, 0x4f, 0xd0, 0xd9, 0x05, 0x16, 0x27, 0x12, 0xa2, 0x94, 0xe9, 0xb4, 0x77,
+	0xd8, 0x6f, 0x7f, 0xbb, 0xfb, 0xed, 0x77, 0xde, 0x0f, 0x00, 0x06, 0x99, 0xd9, 0x7b, 0x0e, 0xb5,
+	0x99, 0x8d, 0x6a, 0x13, 0xd3, 0x71, 0x19, 0xa1, 0xee, 0xc4, 0x76, 0x70, 0x1f, 0x2a, 0x5d, 0x8d,
+	0xb2, 0x01, 0x23, 0x33, 0x74, 0x03, 0xc0, 0xa1, 0xb6, 0xe1, 0xe9, 0x6c, 0x6c, 0x1a, 0x2d, 0x65,
+	0x57, 0xb9, 0x57, 0x55, 0xab, 0x01, 0x65, 0x60, 0xa0, 0x36, 0x54, 0xde, 0x79, 0x9a, 0xc5, 0x4c,
+	0x36, 0x6f, 0xe5, 0x76, 0x95, 0x7b, 0x45, 0x35, 0x1c, 0xe3, 0x11, 0x34, 0x3a, 0x86, 0xc1, 0x77,
+	0x51, 0xc9, 0x3b, 0x8f, 0xb8, 0x0c, 0x5d, 0x85, 0xb2, 0xe7, 0x12, 0x1a, 0xed, 0x54, 0xe2, 0xc3,
+	0x81, 0x81, 0xee, 0x43, 0xc1, 0x64, 0x64, 0x26, 0xb6, 0xa8, 0xed, 0x6f, 0xef, 0xc5, 0xb8, 0xd9,
+	0x93, 0xac, 0xa8, 0x02, 0x82, 0x1f, 0x42, 0xb3, 0x3f, 0x73, 0xd8, 0x9c, 0x93, 0x57, 0xed, 0x8b,
+	0xef, 0x43, 0xe3, 0x80, 0xb0, 0x0b, 0x41, 0x0f, 0xa1, 0xc0, 0x71, 0xd9, 0x3c, 0x3e, 0x84, 0x22,
+	0x67, 0xc0, 0x6d, 0xe5, 0x76, 0xf3, 0xd9, 0x4c, 0xfa, 0x18, 0x5c, 0x86, 0xa2, 0xe0, 0x12, 0xbf,
+	0x84, 0xf6, 0xa1, 0xe9, 0x32, 0x95, 0xe8, 0xf6, 0x6c, 0x46, 0x2c, 0x43, 0x63, 0xa6, 0x6d, 0xb9,
+	0x2b, 0x05, 0x72, 0x0b, 0x6a, 0x91, 0xd8, 0xfd, 0x23, 0xab, 0x2a, 0x84, 0x72, 0x77, 0xf1, 0x2f,
+	0x60, 0x67, 0xe9, 0xbe, 0xae, 0x63, 0x5b, 0x2e, 0x49, 0xaf, 0x57, 0x16, 0xd6, 0xff, 0x4d, 0x81,
+	0xf2, 0xb1, 0x3f, 0x44, 0x0d, 0xc8, 0x85, 0x0c, 0xe4, 0x4c, 0x03, 0x21, 0x28, 0x58, 0xda, 0x8c,
+	0x08, 0x6d, 0x54, 0x55, 0xf1, 0x8d, 0x76, 0xa1, 0x66, 0x10, 0x57, 0xa7, 0xa6, 0xc3, 0x0f, 0x6a,
+	0xe5, 0xc5, 0x54, 0x9c, 0x84, 0x5a, 0x50, 0x76, 0x4c, 0x9d, 0x79, 0x94, 0xb4, 0x0a, 0x62, 0x56,
+	0x0e, 0xd1, 0x27, 0x50, 0x75, 0xa8, 0xa9, 0x93, 0xb1, 0xe7, 0x1a, 0xad, 0xa2, 0x50, 0x31, 0x4a,
+	0x48, 0xef, 0x1b, 0xdb, 0x22, 0x73, 0xb5, 0x22, 0x40, 0x27, 0xae, 0x81, 0x6e, 0x02, 0xe8, 0x1a,
+	0x23, 0x6f, 0x6c, 0x6a, 0x12, 0xb7, 0x55, 0xf2, 0x99, 0x8f, 0x28, 0xf8, 0x39, 0x6c, 0xf1, 0xcb,
+	0x07, 0xfc, 0x47, 0xb7, 0x7e, 0x0c, 0x95, 0xe0, 0x8a, 0xfe, 0x95, 0x6b, 0xfb, 0x5b, 0x89, 0x73,
+	0x82, 0x05, 0x6a, 0x88, 0xc2, 0x77, 0x60, 0xf3, 0x80, 0xc8, 0x8d, 0xa4, 0x56, 0x52, 0xf2, 0xc0,
+	0x1f, 0xc3, 0xf6, 0x90, 0x68, 0x54, 0x9f, 0x44, 0x07, 0xfa, 0xc0, 0x2d, 0x28, 0xbe, 0xf3, 0x08,
+	0x9d, 0x07, 0x58, 0x7f, 0x80, 0x9f, 0xc3, 0x95, 0x34, 0x3c, 0xe0, 0x6f, 0x0f, 0xca, 0x94, 0xb8,
+	0xde, 0x74, 0x05, 0x7b, 0x12, 0x84, 0x2d, 0xd8, 0x38, 0x20, 0xec, 0x57, 0x9e, 0xcd, 0x88, 0x3c,
+	0x72, 0x0f, 0xca, 0x9a, 0x61, 0x50, 0xe2, 0xba, 0xe2, 0xd0, 0xf4, 0x16, 0x1d, 0x7f, 0x4e, 0x95,
+	0xa0, 0xcb, 0x59, 0x6d, 0x07, 0x9a, 0xd1, 0x79, 0x01, 0xcf, 0x1f, 0x43, 0x45, 0xb7, 0x5d, 0x26,
+	0x74, 0xa7, 0x64, 0xea, 0xae, 0xcc, 0x31, 0x27, 0xae, 0x81, 0x6d, 0x68, 0x0e, 0x27, 0xa6, 0xf3,
+	0x82, 0x1a, 0x84, 0xfe, 0x57, 0x78, 0xfe, 0x7f, 0xd8, 0x8c, 0x1d, 0x18, 0x99, 0x3f, 0xa3, 0x9a,
+	0xfe, 0xd6, 0xb4, 0xde, 0x44, 0xbe, 0x05, 0x92, 0x34, 0x30, 0xf0, 0x13, 0xd8, 0x1a, 0xf1, 0x11,
+	0x5f, 0x3a, 0x23, 0x56, 0xa8, 0xfa, 0x95, 0x0b, 0x4f, 0xa1, 0x2e, 0xd7, 0xf4, 0x4f, 0x89, 0xb5,
+	0x7a, 0x05, 0x7a, 0x0c, 0x25, 0x97, 0x69, 0xcc, 0x73, 0x85, 0x3f, 0x35, 0xf6, 0x5b, 0x89, 0xeb,
+	0x08, 0xbe, 0x87, 0x62, 0x5e, 0x0d, 0x70, 0xdc, 0xff, 0x98, 0x39, 0x23, 0xc2, 0xc9, 0xf2, 0xaa,
+	0xf8, 0xc6, 0x9f, 0xc1, 0x76, 0x57, 0xb3, 0x74, 0x32, 0xbd, 0x34, 0xc7, 0x7f, 0x50, 0xa0, 0x1c,
+	0x88, 0x18, 0x7d, 0x08, 0x0d, 0x97, 0x51, 0x42, 0xd8, 0x38, 0xae, 0x90, 0xaa, 0x5a, 0xf7, 0xa9,
+	0x12, 0x86, 0xa0, 0xa0, 0xcb, 0x88, 0x5e, 0x55, 0xc5, 0x37, 0xb7, 0x75, 0xce, 0x1e, 0x09, 0x5c,
+	0xdf, 0x1f, 0x70, 0xa7, 0xd7, 0x6d, 0xcf, 0x62, 0x74, 0x2e, 0x9d, 0x3e, 0x18, 0xa2, 0x6b, 0x50,
+	0x79, 0x6f, 0x3a, 0x63, 0xdd, 0x36, 0x88, 0xf0, 0xf9, 0xa2, 0x5a, 0x7e, 0x6f, 0x3a, 0x5d, 0xdb,
+	0x20, 0xf8, 0x3b, 0x28, 0x0a, 0xab, 0x41, 0x77, 0xa0, 0xae, 0x7b, 0x94, 0x12, 0x4b, 0x9f, 0xfb,
+	0x40, 0x9f, 0x9b, 0x75, 0x49, 0xe4, 0x68, 0x7e, 0xb0, 0x67, 0x99, 0xcc, 0x17, 0x5f, 0x5e, 0xf5,
+	0x07, 0x9c, 0x6a, 0x69, 0x96, 0xed, 0x0a, 0x76, 0x8a, 0xaa, 0x3f, 0xc0, 0x07, 0x70, 0xf3, 0x80,
+	0xb0, 0xa1, 0xe7, 0x38, 0x36, 0x65, 0xc4, 0xe8, 0xfa, 0xfb, 0x98, 0x24, 0x72, 0xc1, 0x0f, 0xa1,
+	0x91, 0x38, 0x52, 0xc6, 0xc6, 0x7a, 0xfc, 0x4c, 0x17, 0xff, 0x06, 0xae, 0x75, 0x43, 0x82, 0x75,
+	0x4a, 0xa8, 0x6b, 0xda, 0x96, 0x14, 0xf9, 0x5d, 0x28, 0xbc, 0xa6, 0xf6, 0xec, 0x1c, 0x77, 0x10,
+	0xf3, 0x3c, 0xba, 0x33, 0xdb, 0xbf, 0x98, 0x2f, 0xc9, 0x12, 0xb3, 0x85, 0x00, 0xfe, 0xa1, 0x40,
+	0xa3, 0x4b, 0x89, 0x61, 0xf2, 0xd4, 0x64, 0x0c, 0xac, 0xd7, 0x36, 0x7a, 0x04, 0x48, 0x17, 0x94,
+	0xb1, 0xae, 0x51, 0x63, 0x6c, 0x79, 0xb3, 0x57, 0x84, 0x06, 0xf2, 0x68, 0xea, 0x21, 0xf6, 0x48,
+	0xd0, 0xd1, 0x5d, 0xd8, 0x88, 0xa3, 0xf5, 0xd3, 0xd3, 0x20, 0xfb, 0xd6, 0x23, 0x68, 0xf7, 0xf4,
+	0x14, 0x7d, 0x01, 0x3b, 0x71, 0x1c, 0x39, 0x73, 0x4c, 0x2a, 0x32, 0xc5, 0x78, 0x4e, 0x34, 0x1a,
+	0xc8, 0xae, 0x15, 0xad, 0xe9, 0x87, 0x80, 0x5f, 0x13, 0x8d, 0xa2, 0x2f, 0xe1, 0x7a, 0xc6, 0xf2,
+	0x99, 0x6d, 0xb1, 0x89, 0x50, 0x79, 0x51, 0xbd, 0xb6, 0x6c, 0xfd, 0x37, 0x1c, 0x80, 0xe7, 0x50,
+	0xef, 0x4e, 0x34, 0xfa, 0x26, 0x0c, 0x5f, 0x0f, 0xa0, 0xa4, 0xcd, 0xb8, 0x85, 0x9c, 0x23, 0xbc,
+	0x00, 0x81, 0x3e, 0x87, 0x5a, 0xec, 0xf4, 0xa0, 0x36, 0xd8, 0x49, 0x06, 0x83, 0x84, 0x10, 0x55,
+	0x88, 0x38, 0xc1, 0x4f, 0xa0, 0x21, 0x8f, 0x8e, 0x54, 0xcf, 0xa8, 0x66, 0xb9, 0x9a, 0x2e, 0xae,
+	0x10, 0x3a, 0x4b, 0x3d, 0x46, 0x1d, 0x18, 0xf8, 0x2f, 0x0a, 0x54, 0x85, 0x57, 0x8a, 0xfa, 0x47,
+	0x56, 0x26, 0xca, 0xca, 0xca, 0x84, 0x9b, 0x05, 0x8f, 0x82, 0xad, 0x5c, 0xe6, 0xcd, 0xc4, 0x3c,
+	0xfa, 0x3f, 0xc8, 0x33, 0xed, 0xac, 0x95, 0xcf, 0x84, 0xf1, 0x69, 0xb4, 0x07, 0x15, 0xc3, 0x74,
+	0x85, 0x37, 0xb5, 0x0a, 0x99, 0xd0, 0x10, 0x83, 0x7f, 0x80, 0x4a, 0x2f, 0xf8, 0x0e, 0x8a, 0xb6,
+	0x99, 0x1d, 0x77, 0xaa, 0xaa, 0xa0, 0x08, 0x8f, 0x4a, 0xe5, 0xf2, 0xdc, 0x62, 0x2e, 0x8f, 0xd4,
+	0x94, 0x5f, 0xa5, 0x26, 0xfc, 0xaf, 0x3c, 0xd4, 0x64, 0xf4, 0xf5, 0xa6, 0x8c, 0x3b, 0xbe, 0xcd,
+	0x87, 0x91, 0x80, 0xcb, 0x62, 0x2c, 0x42, 0xe1, 0x96, 0x3b, 0x31, 0x1d, 0x87, 0xc7, 0xaa, 0x78,
+	0xd0, 0xf2, 0x39, 0x40, 0x72, 0x6e, 0x14, 0x05, 0xcf, 0x27, 0x50, 0x0f, 0x57, 0x08, 0xe1, 0x66,
+	0xf3, 0xb3, 0x2e, 0x81, 0x5d, 0x2e, 0xe4, 0x2f, 0xa1, 0x19, 0x2e, 0x94, 0xb1, 0xae, 0x70, 0x4e,
+	0xf2, 0xd9, 0x90, 0xe8, 0x80, 0x80, 0x1e, 0xc9, 0x24, 0x54, 0x14, 0x49, 0xe8, 0xca, 0x62, 0xd4,
+	0x8e, 0x65, 0x21, 0x5e, 0xe2, 0x30, 0x9b, 0x69, 0xd3, 0x31, 0xd7, 0x6c, 0x29, 0x5b, 0x5d, 0x02,
+	0x34, 0xd2, 0xce, 0x78, 0xe8, 0x63, 0xda, 0xd9, 0xd8, 0xb4, 0xf4, 0xa9, 0xe7, 0x9a, 0xa7, 0xa4,
+	0x55, 0xde, 0x55, 0xee, 0x55, 0xd4, 0x75, 0xa6, 0x9d, 0x0d, 0x24, 0x0d, 0x7d, 0x0a, 0x55, 0xa9,
+	0x5f, 0xb7, 0x55, 0x59, 0x92, 0x0c, 0xa5, 0xc6, 0xd5, 0x08, 0x87, 0x7e, 0x0e, 0x0d, 0x9f, 0x95,
+	0xd0, 0x7c, 0xaa, 0x99, 0xfc, 0xd4, 0x05, 0x32, 0xb4, 0x9b, 0x7b, 0x50, 0x14, 0x84, 0x16, 0x64,
+	0xae, 0xf0, 0x01, 0xd8, 0x80, 0xeb, 0x43, 0x62, 0x19, 0x42, 0x0e, 0x5d, 0xdb, 0x7a, 0x6d, 0xd2,
+	0x99, 0x70, 0xfb, 0x58, 0x65, 0x44, 0x66, 0x9a, 0x39, 0x95, 0x95, 0x91, 0x18, 0xa0, 0x3d, 0x28,
+	0x0a, 0x53, 0x08, 0x5c, 0x64, 0x49, 0x26, 0xf4, 0x6d, 0x48, 0xf5, 0x61, 0xf8, 0xef, 0x39, 0xd8,
+	0x3c, 0x9e, 0x6a, 0x3a, 0x49, 0x94, 0x13, 0x99, 0x45, 0xf3, 0x1d, 0xa8, 0x8b, 0x09, 0x19, 0xca,
+	0x03, 0xbb, 0x5a, 0xe7, 0x44, 0x19, 0xcd, 0xe3, 0xc5, 0x48, 0xfe, 0x22, 0xc5, 0x48, 0x78, 0x93,
+	0x62, 0xfc, 0x26, 0xa9, 0xd8, 0x54, 0xba, 0x54, 0x6c, 0x42, 0x1f, 0xc1, 0x86, 0x69, 0x90, 0x99,
+	0x63, 0x33, 0x91, 0x87, 0xde, 0x92, 0xb9, 0x50, 0x7f, 0x55, 0x6d, 0xc4, 0xc8, 0x5f, 0x93, 0x79,
+	0x50, 0xc6, 0x07, 0x8e, 0xec, 0x9b, 0x80, 0x5f, 0xc6, 0xfb, 0x9e, 0xec, 0xa2, 0x2f, 0x60, 0xe3,
+	0x95, 0x39, 0x9d, 0xc6, 0xad, 0xbc, 0x7a, 0xce, 0xad, 0x1a, 0x01, 0x38, 0x18, 0xe3, 0x1e, 0xa0,
+	0xb8, 0x7c, 0xc3, 0x32, 0x35, 0x50, 0x93, 0x72, 0x31, 0x35, 0xfd, 0x49, 0x81, 0x0f, 0x8e, 0x29,
+	0x39, 0x35, 0xc9, 0x0f, 0xff, 0x43, 0x45, 0xa5, 0x64, 0x55, 0x48, 0xcb, 0x0a, 0xff, 0x33, 0x07,
+	0x5b, 0x49, 0x36, 0x83, 0xfb, 0x86, 0xae, 0xae, 0x5c, 0xc4, 0xd5, 0x17, 0x42, 0x52, 0xee, 0x82,
+	0x21, 0x29, 0x11, 0x23, 0xf2, 0x3f, 0x25, 0x46, 0x14, 0x56, 0xc5, 0x88, 0xe2, 0x4f, 0x8e, 0x11,
+	0xa5, 0x4b, 0xc7, 0x88, 0xf2, 0xaa, 0x18, 0xf1, 0x67, 0x25, 0x4c, 0x0c, 0xba, 0x4d, 0x8d, 0xcb,
+	0x9a, 0x55, 0xdc, 0x7c, 0x72, 0x09, 0xf3, 0xd9, 0x81, 0xaa, 0xc3, 0xad, 0xd6, 0x18, 0x6b, 0x2c,
+	0x28, 0x92, 0x2b, 0x3e, 0xa1, 0xc3, 0xd0, 0x67, 0x50, 0x7b, 0x4d, 0x35, 0xcf, 0x18, 0xeb, 0x13,
+	0xa2, 0xbf, 0x0d, 0x62, 0xfe, 0xd5, 0xc4, 0x59, 0xcf, 0xf8, 0x7c, 0x97, 0x4f, 0xab, 0xf0, 0x3a,
+	0xfc, 0xc6, 0xbf, 0x05, 0x88, 0x66, 0xd0, 0xcf, 0xa0, 0x62, 0x10, 0xdd, 0xe4, 0x75, 0x9f, 0x60,
+	0xb8, 0xb1, 0xdf, 0x5e, 0xdc, 0xa4, 0x17, 0x20, 0xd4, 0x10, 0xcb, 0x2b, 0x62, 0x4a, 0x34, 0xd7,
+	0xb6, 0xe4, 0xab, 0x5d, 0x0e, 0xf1, 0x23, 0xf1, 0x9a, 0x4b, 0x78, 0x48, 0x76, 0xae, 0xc4, 0x7b,
+	0xb0, 0xf9, 0xad, 0xc6, 0xf4, 0xc9, 0x45, 0xf1, 0x33, 0x00, 0x01, 0xf5, 0x5f, 0x25, 0xe7, 0x26,
+	0xe1, 0xff, 0xc4, 0x7b, 0x64, 0x02, 0x9b, 0xfc, 0x09, 0x2e, 0xe0, 0xab, 0xdb, 0x19, 0x5c, 0x63,
+	0xda, 0x1b, 0x32, 0x76, 0xcd, 0xf7, 0x44, 0xf6, 0x89, 0x38, 0x61, 0x68, 0xbe, 0x27, 0xa2, 0x5a,
+	0xe1, 0x93, 0xcc, 0x7e, 0x4b, 0x64, 0x67, 0x41, 0xc0, 0x47, 0x9c, 0x80, 0x2d, 0x40, 0xf1, 0x93,
+	0xc2, 0xa7, 0x7e, 0x49, 0x5c, 0x48, 0x3a, 0xed, 0x52, 0x6b, 0xe2, 0x66, 0xa7, 0x06, 0x38, 0x5e,
+	0x33, 0x5b, 0xe4, 0x8c, 0x8d, 0x63, 0x67, 0xf9, 0x66, 0x55, 0xe7, 0xe4, 0xe3, 0xf0, 0xbc, 0x3d,
+	0xa8, 0x76, 0x0c, 0x79, 0xa3, 0xdb, 0xb0, 0xae, 0xdb, 0x16, 0xe3, 0xeb, 0xde, 0x92, 0xb9, 0x7c,
+	0x2c, 0xd4, 0x02, 0xda, 0xd7, 0x64, 0xee, 0xe2, 0x4f, 0x00, 0x3a, 0x46, 0xc8, 0xd7, 0x6d, 0xc8,
+	0x6b, 0x86, 0x64, 0x6a, 0x23, 0x15, 0xb1, 0x54, 0x3e, 0x87, 0x9f, 0x42, 0xae, 0x63, 0xf0, 0x9d,
+	0x79, 0x42, 0xa0, 0x44, 0x67, 0x63, 0x8f, 0xca, 0x44, 0x59, 0x93, 0xb4, 0x13, 0x3a, 0x15, 0x72,
+	0x27, 0x67, 0x4c, 0x3e, 0xc3, 0xf8, 0xf7, 0x03, 0x07, 0x6a, 0x31, 0x15, 0xa1, 0xeb, 0xd0, 0x7a,
+	0xa1, 0xf6, 0xfa, 0xea, 0x78, 0x38, 0xea, 0x8c, 0x4e, 0x86, 0xe3, 0x93, 0xa3, 0xe1, 0x71, 0xbf,
+	0x3b, 0x78, 0x36, 0xe8, 0xf7, 0x9a, 0x6b, 0xa8, 0x02, 0x85, 0xe3, 0xce, 0xa0, 0xd7, 0x54, 0x50,
+	0x0d, 0xca, 0xc3, 0xe7, 0x83, 0xe3, 0xe3, 0x7e, 0xaf, 0x99, 0x43, 0x0d, 0x80, 0xc1, 0xd1, 0x78,
+	0xa4, 0x76, 0x8e, 0x86, 0x83, 0x51, 0x33, 0x8f, 0xea, 0x50, 0xed, 0xf5, 0x0f, 0x07, 0x2f, 0xfb,
+	0x6a, 0xbf, 0xd7, 0x2c, 0xf0, 0x61, 0xb7, 0x73, 0xd4, 0xed, 0x1f, 0x1e, 0xf6, 0x7b, 0xcd, 0xe2,
+	0x83, 0x21, 0xd4, 0x13, 0xb6, 0x8e, 0x6e, 0x42, 0xfb, 0x99, 0xda, 0x39, 0xe9, 0x8d, 0x7b, 0xfd,
+	0xee, 0x60, 0x38, 0x78, 0x71, 0x94, 0x3a, 0x15, 0xa0, 0xd4, 0xe9, 0x76, 0xfb, 0xc7, 0xa3, 0xa6,
+	0xc2, 0xbf, 0xd5, 0xfe, 0xcb, 0x41, 0xff, 0xdb, 0x66, 0xce, 0xff, 0xfe, 0xaa, 0xdf, 0x1d, 0x35,
+	0xf3, 0xfb, 0x3f, 0x2a, 0x50, 0xe3, 0xe5, 0xf3, 0x90, 0xd0, 0x53, 0x53, 0x27, 0xe8, 0x73, 0xf1,
+	0x46, 0x15, 0x15, 0xf7, 0x4e, 0x3a, 0xcc, 0xc7, 0x3a, 0x88, 0xed, 0x64, 0xb8, 0xf1, 0x5b, 0x6c,
+	0x6b, 0xe8, 0x29, 0x94, 0x83, 0x36, 0x5f, 0x6a, 0x75, 0xb2, 0xf9, 0xd7, 0xde, 0x5c, 0x28, 0xdf,
+	0xf1, 0x1a, 0xfa, 0x25, 0x54, 0xc3, 0x86, 0x22, 0xba, 0xb1, 0xb8, 0x7f, 0x7c, 0x83, 0xa5, 0xc7,
+	0xef, 0xff, 0x5e, 0x81, 0xed, 0x64, 0x23, 0x4e, 0x5e, 0xeb, 0x77, 0xf0, 0xc1, 0x92, 0x2e, 0x1d,
+	0xfa, 0x28, 0xb1, 0x4d, 0x76, 0x7f, 0xb0, 0x7d, 0x6f, 0x35, 0xd0, 0xb7, 0x3b, 0xce, 0x45, 0x0e,
+	0xb6, 0x83, 0x0e, 0x52, 0x57, 0x63, 0xda, 0xd4, 0x7e, 0x23, 0xb9, 0x38, 0x80, 0xf5, 0x78, 0xbb,
+	0x0c, 0x2d, 0xb9, 0x45, 0xfb, 0xf6, 0xc2, 0x49, 0xe9, 0xee, 0x15, 0x5e, 0x43, 0x3d, 0x80, 0xa8,
+	0x5b, 0x86, 0x6e, 0xa6, 0x45, 0x9d, 0x6c, 0xa3, 0xb5, 0x97, 0x36, 0xb7, 0xf0, 0x1a, 0xfa, 0x1e,
+	0x1a, 0xc9, 0xfe, 0x18, 0xc2, 0x09, 0xe4, 0xd2, 0x5e, 0x5b, 0xfb, 0xce, 0xb9, 0x98, 0x50, 0x0a,
+	0x3f, 0xe6, 0x60, 0x63, 0x18, 0x64, 0x5d, 0x79, 0xff, 0x01, 0x54, 0x64, 0x5b, 0x0b, 0x5d, 0x4f,
+	0x33, 0x1d, 0xef, 0xae, 0xb5, 0x6f, 0x64, 0xcc, 0x86, 0x12, 0x38, 0x84, 0x6a, 0xd8, 0x6d, 0x4a,
+	0x19, 0x4b, 0xba, 0xed, 0xd5, 0xbe, 0x99, 0x35, 0x1d, 0xee, 0xa6, 0x42, 0x3d, 0xd1, 0x85, 0x42,
+	0x49, 0x2d, 0x2c, 0xeb, 0x50, 0xb5, 0xdb, 0x0b, 0xbb, 0x86, 0xbd, 0x28, 0xbc, 0xf6, 0x58, 0x41,
+	0x5f, 0x41, 0x23, 0xd9, 0x28, 0x4a, 0x49, 0x77, 0x69, 0x17, 0x29, 0xc3, 0xb0, 0xff, 0xaa, 0xc0,
+	0x86, 0xac, 0xc7, 0xa4, 0x30, 0xbf, 0x87, 0x2b, 0xcb, 0x5b, 0x2c, 0x4b, 0xcd, 0xea, 0x61, 0x5a,
+	0xa0, 0xe7, 0xf4, 0x66, 0xf0, 0x1a, 0x3a, 0x80, 0xb2, 0xdf, 0x6e, 0x61, 0xe8, 0x6e, 0x92, 0xeb,
+	0xac, 0x66, 0x4c, 0x7b, 0x49, 0x01, 0x82, 0xd7, 0xf6, 0x4f, 0xa0, 0x71, 0xac, 0xcd, 0xf9, 0x0d,
+	0x25, 0xdf, 0x5d, 0x28, 0xf9, 0xfd, 0x00, 0x94, 0x94, 0x60, 0xa2, 0x3f, 0xd1, 0xde, 0x59, 0x3a,
+	0x17, 0x5a, 0xd7, 0x04, 0xd6, 0xfb, 0xbc, 0xfe, 0x97, 0x9b, 0x7e, 0x07, 0xdb, 0x4b, 0x9f, 0x41,
+	0xe8, 0x7e, 0xca, 0x5a, 0xb3, 0x9f, 0x4a, 0x19, 0xa2, 0xff, 0x63, 0x1e, 0x36, 0x44, 0x21, 0x62,
+	0x7b, 0xe1, 0x15, 0x5e, 0x00, 0x44, 0xd5, 0x7a, 0xca, 0xfd, 0x16, 0x9e, 0x49, 0xed, 0x5b, 0x99,
+	0xf3, 0x31, 0x7f, 0xae, 0xc8, 0x8a, 0x64, 0xd1, 0x31, 0x12, 0x9b, 0x65, 0xa6, 0x57, 0xbc, 0xc6,
+	0xd9, 0x8a, 0x12, 0x74, 0x8a, 0xad, 0x85, 0x1a, 0xa1, 0x7d, 0x2b, 0x73, 0x3e, 0x64, 0xeb, 0x04,
+	0xd6, 0xe3, 0x75, 0x3a, 0xda, 0x4d, 0x05, 0x92, 0x85, 0x97, 0x46, 0xfb, 0xf6, 0x39, 0x88, 0x70,
+	0xdb, 0x01, 0x40, 0x54, 0x51, 0xa5, 0xf8, 0x5c, 0x28, 0xb5, 0xda, 0x57, 0x17, 0x6f, 0x1c, 0x3a,
+	0xd9, 0xfe, 0x73, 0x5e, 0x23, 0x48, 0xb5, 0x3c, 0x85, 0xd2, 0x01, 0xef, 0x9d, 0xba, 0xe8, 0x4a,
+	0x3a, 0xdf, 0x2f, 0xdd, 0x2b, 0xaa, 0x16, 0xf0, 0xda, 0xab, 0x92, 0xf8, 0xff, 0xf6, 0xe9, 0xbf,
+	0x07, 0x00, 0x9f, 0x4f, 0xa6, 0x0d, 0x8d, 0x1b, 0x00, 0x00,
 }
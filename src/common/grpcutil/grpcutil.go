@@ -0,0 +1,88 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package grpcutil builds the gRPC servers and client connections of the Go
+// services.
+package grpcutil
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/tracing"
+)
+
+// ShutdownTimeout bounds the time taken to flush the spans of a service that
+// is asked to terminate.
+const ShutdownTimeout = 5 * time.Second
+
+// NewServer returns a gRPC server with the given options. If tracing is on,
+// incoming RPCs are traced before any interceptor in opts runs.
+func NewServer(tracing bool, opts ...grpc.ServerOption) *grpc.Server {
+	if tracing {
+		opts = append([]grpc.ServerOption{
+			grpc.ChainUnaryInterceptor(otelgrpc.UnaryServerInterceptor()),
+			grpc.ChainStreamInterceptor(otelgrpc.StreamServerInterceptor()),
+		}, opts...)
+	}
+	return grpc.NewServer(opts...)
+}
+
+// Serve serves srv on lis until the process is asked to terminate with
+// SIGINT or SIGTERM. It then stops srv gracefully, letting the RPCs in flight
+// finish, and flushes the spans that are not exported yet.
+func Serve(srv *grpc.Server, lis net.Listener, log logrus.FieldLogger) error {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(lis) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case sig := <-sigs:
+		log.Infof("received %v, shutting down", sig)
+	}
+	srv.GracefulStop()
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	return tracing.Shutdown(ctx)
+}
+
+// DialOptions returns the options shared by all connections between the
+// services: they are plaintext and, if tracing is on, propagate the trace
+// context of outgoing RPCs.
+func DialOptions(tracing bool) []grpc.DialOption {
+	opts := []grpc.DialOption{grpc.WithInsecure()}
+	if tracing {
+		opts = append(opts,
+			grpc.WithChainUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
+			grpc.WithChainStreamInterceptor(otelgrpc.StreamClientInterceptor()))
+	}
+	return opts
+}
+
+// Dial connects to addr with DialOptions followed by opts.
+func Dial(ctx context.Context, addr string, tracing bool, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	return grpc.DialContext(ctx, addr, append(DialOptions(tracing), opts...)...)
+}
@@ -0,0 +1,96 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcutil
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestServerAndDial(t *testing.T) {
+	for _, tracing := range []bool{false, true} {
+		lis, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var intercepted bool
+		srv := NewServer(tracing, grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			intercepted = true
+			return handler(ctx, req)
+		}))
+		healthpb.RegisterHealthServer(srv, health.NewServer())
+		go srv.Serve(lis)
+
+		conn, err := Dial(context.Background(), lis.Addr().String(), tracing)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatalf("tracing=%v: Check() failed: %+v", tracing, err)
+		}
+		if res.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("tracing=%v: Check() = %v, want SERVING", tracing, res.GetStatus())
+		}
+		if !intercepted {
+			t.Errorf("tracing=%v: the interceptor passed to NewServer did not run", tracing)
+		}
+		conn.Close()
+		srv.Stop()
+	}
+}
+
+func TestServeStopsOnSignal(t *testing.T) {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(false)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	done := make(chan error, 1)
+	go func() { done <- Serve(srv, lis, log) }()
+
+	conn, err := Dial(context.Background(), lis.Addr().String(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true)); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve() = %v after SIGTERM", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve() did not return after SIGTERM")
+	}
+}
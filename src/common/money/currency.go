@@ -0,0 +1,42 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package money
+
+import "strings"
+
+// defaultMinorUnits is the number of decimals of the currencies missing from
+// minorUnits, which is most of them.
+const defaultMinorUnits = 2
+
+// minorUnits holds the currencies of ISO 4217 whose minor unit is not a
+// hundredth of the major unit, by their number of decimals.
+var minorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+
+	"CLF": 4, "UYW": 4,
+}
+
+// MinorUnits returns the number of decimals of the currency with the given
+// ISO 4217 code, such as 2 for USD and 0 for JPY. Unknown currencies have 2.
+func MinorUnits(currencyCode string) int {
+	if n, ok := minorUnits[strings.ToUpper(currencyCode)]; ok {
+		return n
+	}
+	return defaultMinorUnits
+}
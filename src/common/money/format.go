@@ -0,0 +1,243 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package money
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+// DefaultLocale is the locale of Format for unsupported locales.
+const DefaultLocale = "en-US"
+
+const nbsp = "\u00a0"
+
+// locale holds the CLDR currency format of a locale.
+type locale struct {
+	decimal string
+	group   string
+	// minGrouping is the number of digits the integer part needs before
+	// it is grouped, beyond the first group.
+	minGrouping int
+	// symbolAfter places the symbol after the number, as in "#,##0.00 ¤",
+	// rather than before it, as in "¤#,##0.00".
+	symbolAfter bool
+	// symbols overrides defaultSymbols.
+	symbols map[string]string
+}
+
+// defaultSymbols are the symbols of currencies in locales that do not
+// override them. Currencies missing here are shown with their code.
+var defaultSymbols = map[string]string{
+	"AUD": "A$",
+	"BRL": "R$",
+	"CAD": "CA$",
+	"CNY": "CN¥",
+	"EUR": "€",
+	"GBP": "£",
+	"HKD": "HK$",
+	"ILS": "₪",
+	"INR": "₹",
+	"JPY": "JP¥",
+	"KRW": "₩",
+	"MXN": "MX$",
+	"NZD": "NZ$",
+	"USD": "US$",
+}
+
+// locales are the locales Format supports, by BCP 47 tag.
+var locales = map[string]*locale{
+	"en-US": {decimal: ".", group: ",", minGrouping: 1,
+		symbols: map[string]string{"USD": "$", "JPY": "¥"}},
+	"en-GB": {decimal: ".", group: ",", minGrouping: 1,
+		symbols: map[string]string{"JPY": "JP¥"}},
+	"en-CA": {decimal: ".", group: ",", minGrouping: 1,
+		symbols: map[string]string{"CAD": "$", "JPY": "JP¥"}},
+	"fr-FR": {decimal: ",", group: "\u202f", minGrouping: 1, symbolAfter: true,
+		symbols: map[string]string{"USD": "$US", "CAD": "$CA", "GBP": "£GB", "JPY": "JPY"}},
+	"fr-CA": {decimal: ",", group: nbsp, minGrouping: 1, symbolAfter: true,
+		symbols: map[string]string{"USD": "$" + nbsp + "US", "CAD": "$", "JPY": "¥"}},
+	"de-DE": {decimal: ",", group: ".", minGrouping: 1, symbolAfter: true,
+		symbols: map[string]string{"USD": "$", "JPY": "¥"}},
+	"es-ES": {decimal: ",", group: ".", minGrouping: 2, symbolAfter: true,
+		symbols: map[string]string{"JPY": "JPY"}},
+	"ja-JP": {decimal: ".", group: ",", minGrouping: 1,
+		symbols: map[string]string{"USD": "$", "JPY": "￥", "CNY": "元"}},
+	"tr-TR": {decimal: ",", group: ".", minGrouping: 1,
+		symbols: map[string]string{"USD": "$", "TRY": "₺"}},
+}
+
+// languageDefaults are the locales used for tags that only name a language,
+// or a region not supported on its own.
+var languageDefaults = map[string]string{
+	"en": "en-US",
+	"fr": "fr-FR",
+	"de": "de-DE",
+	"es": "es-ES",
+	"ja": "ja-JP",
+	"tr": "tr-TR",
+}
+
+// SupportedLocale returns the supported locale closest to tag, such as
+// "fr-FR" for "fr-BE" or "fr", and false if there is none.
+func SupportedLocale(tag string) (string, bool) {
+	tag = strings.Replace(strings.TrimSpace(tag), "_", "-", -1)
+	parts := strings.Split(tag, "-")
+	lang := strings.ToLower(parts[0])
+	if len(parts) > 1 {
+		full := lang + "-" + strings.ToUpper(parts[len(parts)-1])
+		if _, ok := locales[full]; ok {
+			return full, true
+		}
+	}
+	full, ok := languageDefaults[lang]
+	return full, ok
+}
+
+func lookupLocale(tag string) (string, *locale) {
+	name, ok := SupportedLocale(tag)
+	if !ok {
+		name = DefaultLocale
+	}
+	return name, locales[name]
+}
+
+// Symbol returns the symbol of the currency in locale, or its code if it has
+// none.
+func Symbol(currencyCode, locale string) string {
+	_, l := lookupLocale(locale)
+	if s, ok := l.symbols[currencyCode]; ok {
+		return s
+	}
+	if s, ok := defaultSymbols[currencyCode]; ok {
+		return s
+	}
+	return currencyCode
+}
+
+// Format formats m for locale, such as "-$1,234.56" in en-US or
+// "1.234,56 €" in de-DE. It has as many decimals as the currency has, rounded
+// with halves to even. Unsupported locales are formatted like DefaultLocale.
+func Format(m pb.Money, locale string) string {
+	name, l := lookupLocale(locale)
+	if rounded, err := Round(m, HalfEven); err == nil {
+		m = rounded
+	}
+	neg := m.GetUnits() < 0 || m.GetNanos() < 0
+	units, nanos := uint64(m.GetUnits()), m.GetNanos()
+	if neg {
+		units, nanos = uint64(-m.GetUnits()), -nanos
+	}
+
+	var b strings.Builder
+	digits := strconv.FormatUint(units, 10)
+	if len(digits) < 3+l.minGrouping {
+		b.WriteString(digits)
+	} else {
+		head := len(digits) % 3
+		if head == 0 {
+			head = 3
+		}
+		b.WriteString(digits[:head])
+		for i := head; i < len(digits); i += 3 {
+			b.WriteString(l.group)
+			b.WriteString(digits[i : i+3])
+		}
+	}
+	if n := MinorUnits(m.GetCurrencyCode()); n > 0 {
+		frac := strconv.FormatInt(int64(nanos), 10)
+		frac = strings.Repeat("0", 9-len(frac)) + frac
+		b.WriteString(l.decimal)
+		b.WriteString(frac[:n])
+	}
+	number := b.String()
+
+	symbol := Symbol(m.GetCurrencyCode(), name)
+	var s string
+	if l.symbolAfter {
+		s = number + nbsp + symbol
+	} else if r, _ := utf8.DecodeLastRuneInString(symbol); unicode.IsLetter(r) {
+		// Symbols ending in a letter are kept apart from the digits.
+		s = symbol + nbsp + number
+	} else {
+		s = symbol + number
+	}
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+// Parse parses an amount of the currency with the given code, such as
+// "1234.5" or "-1,234.50". Commas may separate groups of three digits, and
+// there can be at most as many decimals as the currency has.
+func Parse(s, currencyCode string) (pb.Money, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	if neg || strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], s[i+1:]
+		if frac == "" || len(frac) > MinorUnits(currencyCode) {
+			return pb.Money{}, ErrInvalidAmount
+		}
+	}
+	if groups := strings.Split(intPart, ","); len(groups) > 1 {
+		if len(groups[0]) == 0 || len(groups[0]) > 3 {
+			return pb.Money{}, ErrInvalidAmount
+		}
+		for _, g := range groups[1:] {
+			if len(g) != 3 {
+				return pb.Money{}, ErrInvalidAmount
+			}
+		}
+		intPart = strings.Join(groups, "")
+	}
+	if intPart == "" || !isDigits(intPart) || !isDigits(frac) {
+		return pb.Money{}, ErrInvalidAmount
+	}
+
+	units, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return pb.Money{}, ErrOverflow
+	}
+	var nanos int64
+	if frac != "" {
+		nanos, _ = strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 32)
+	}
+	if neg {
+		units, nanos = -units, -nanos
+	}
+	return pb.Money{
+		CurrencyCode: currencyCode,
+		Units:        units,
+		Nanos:        int32(nanos)}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,332 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package money
+
+import (
+	"errors"
+	"math/big"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+const (
+	nanosMin = -999999999
+	nanosMax = +999999999
+	nanosMod = 1000000000
+)
+
+var (
+	ErrInvalidValue        = errors.New("one of the specified money values is invalid")
+	ErrMismatchingCurrency = errors.New("mismatching currency codes")
+	ErrOverflow            = errors.New("money value overflows")
+	ErrInvalidFactor       = errors.New("invalid decimal factor")
+	ErrInvalidRatios       = errors.New("ratios must not be negative and must not all be zero")
+	ErrInvalidAmount       = errors.New("invalid amount")
+)
+
+// IsValid checks if specified value has a valid units/nanos signs and ranges.
+func IsValid(m pb.Money) bool {
+	return signMatches(m) && validNanos(m.GetNanos())
+}
+
+func signMatches(m pb.Money) bool {
+	return m.GetNanos() == 0 || m.GetUnits() == 0 || (m.GetNanos() < 0) == (m.GetUnits() < 0)
+}
+
+func validNanos(nanos int32) bool { return nanosMin <= nanos && nanos <= nanosMax }
+
+// IsZero returns true if the specified money value is equal to zero.
+func IsZero(m pb.Money) bool { return m.GetUnits() == 0 && m.GetNanos() == 0 }
+
+// IsPositive returns true if the specified money value is valid and is
+// positive.
+func IsPositive(m pb.Money) bool {
+	return IsValid(m) && m.GetUnits() > 0 || (m.GetUnits() == 0 && m.GetNanos() > 0)
+}
+
+// IsNegative returns true if the specified money value is valid and is
+// negative.
+func IsNegative(m pb.Money) bool {
+	return IsValid(m) && m.GetUnits() < 0 || (m.GetUnits() == 0 && m.GetNanos() < 0)
+}
+
+// AreSameCurrency returns true if values l and r have a currency code and
+// they are the same values.
+func AreSameCurrency(l, r pb.Money) bool {
+	return l.GetCurrencyCode() == r.GetCurrencyCode() && l.GetCurrencyCode() != ""
+}
+
+// AreEquals returns true if values l and r are the equal, including the
+// currency. This does not check validity of the provided values.
+func AreEquals(l, r pb.Money) bool {
+	return l.GetCurrencyCode() == r.GetCurrencyCode() &&
+		l.GetUnits() == r.GetUnits() && l.GetNanos() == r.GetNanos()
+}
+
+// Negate returns the same amount with the sign negated.
+func Negate(m pb.Money) pb.Money {
+	return pb.Money{
+		Units:        -m.GetUnits(),
+		Nanos:        -m.GetNanos(),
+		CurrencyCode: m.GetCurrencyCode()}
+}
+
+// Must panics if the given error is not nil. This can be used with other
+// functions like: "m := Must(Sum(a,b))".
+func Must(v pb.Money, err error) pb.Money {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Sum adds two values. Returns an error if one of the values are invalid,
+// currency codes are not matching (unless currency code is unspecified for
+// both) or the result does not fit.
+func Sum(l, r pb.Money) (pb.Money, error) {
+	if !IsValid(l) || !IsValid(r) {
+		return pb.Money{}, ErrInvalidValue
+	} else if l.GetCurrencyCode() != r.GetCurrencyCode() {
+		return pb.Money{}, ErrMismatchingCurrency
+	}
+	units, ok := addInt64(l.GetUnits(), r.GetUnits())
+	if !ok {
+		return pb.Money{}, ErrOverflow
+	}
+	nanos := l.GetNanos() + r.GetNanos()
+
+	if units == 0 || (units > 0 && nanos >= 0) || (units < 0 && nanos <= 0) {
+		// same sign <units, nanos>
+		if units, ok = addInt64(units, int64(nanos/nanosMod)); !ok {
+			return pb.Money{}, ErrOverflow
+		}
+		nanos = nanos % nanosMod
+	} else {
+		// different sign. nanos guaranteed to not to go over the limit
+		if units > 0 {
+			units--
+			nanos += nanosMod
+		} else {
+			units++
+			nanos -= nanosMod
+		}
+	}
+
+	return pb.Money{
+		Units:        units,
+		Nanos:        nanos,
+		CurrencyCode: l.GetCurrencyCode()}, nil
+}
+
+// addInt64 returns a+b, and false if it overflows.
+func addInt64(a, b int64) (int64, bool) {
+	c := a + b
+	return c, (c > a) == (b > 0)
+}
+
+// Subtract subtracts r from l. Returns an error under the same conditions as
+// Sum.
+func Subtract(l, r pb.Money) (pb.Money, error) {
+	if !IsValid(r) {
+		return pb.Money{}, ErrInvalidValue
+	}
+	return Sum(l, Negate(r))
+}
+
+// MultiplySlow multiplies m by n, panicking if the result does not fit.
+//
+// Deprecated: Use Multiply, which returns an error instead.
+func MultiplySlow(m pb.Money, n uint32) pb.Money {
+	return Must(Multiply(m, int64(n)))
+}
+
+// Multiply multiplies m by n. Returns an error if m is invalid or the result
+// does not fit.
+func Multiply(m pb.Money, n int64) (pb.Money, error) {
+	if !IsValid(m) {
+		return pb.Money{}, ErrInvalidValue
+	}
+	p := toNanos(m)
+	return fromNanos(p.Mul(p, big.NewInt(n)), m.GetCurrencyCode())
+}
+
+// MultiplyDecimal multiplies m by factor, a decimal number such as "0.0725".
+// The result is rounded like MultiplyRat does.
+func MultiplyDecimal(m pb.Money, factor string) (pb.Money, error) {
+	r, ok := new(big.Rat).SetString(factor)
+	if !ok {
+		return pb.Money{}, ErrInvalidFactor
+	}
+	return MultiplyRat(m, r)
+}
+
+// MultiplyRat multiplies m by the rational factor r. The result is rounded to
+// the nearest nano, with halves rounded away from zero. Returns an error if m
+// is invalid or the result does not fit.
+func MultiplyRat(m pb.Money, r *big.Rat) (pb.Money, error) {
+	if !IsValid(m) {
+		return pb.Money{}, ErrInvalidValue
+	}
+	p := new(big.Rat).Mul(new(big.Rat).SetInt(toNanos(m)), r)
+	return fromNanos(roundQuo(p.Num(), p.Denom(), HalfUp), m.GetCurrencyCode())
+}
+
+// Compare returns -1, 0 or +1 depending on whether l is less than, equal to
+// or greater than r. Returns an error if one of the values is invalid or the
+// currency codes are not matching.
+func Compare(l, r pb.Money) (int, error) {
+	if !IsValid(l) || !IsValid(r) {
+		return 0, ErrInvalidValue
+	} else if l.GetCurrencyCode() != r.GetCurrencyCode() {
+		return 0, ErrMismatchingCurrency
+	}
+	switch {
+	case l.GetUnits() < r.GetUnits():
+		return -1, nil
+	case l.GetUnits() > r.GetUnits():
+		return +1, nil
+	case l.GetNanos() < r.GetNanos():
+		return -1, nil
+	case l.GetNanos() > r.GetNanos():
+		return +1, nil
+	}
+	return 0, nil
+}
+
+// Less reports whether l is less than r. Returns an error under the same
+// conditions as Compare.
+func Less(l, r pb.Money) (bool, error) {
+	c, err := Compare(l, r)
+	return c < 0, err
+}
+
+// Allocate splits m into shares in proportion to ratios, such as 1:1:1 to
+// split it three ways. The shares are whole multiples of the minor unit of the
+// currency, except that the part of m below the minor unit goes to the first
+// share with a non-zero ratio; the minor units left over by rounding the
+// shares down go one by one to the following ones. The shares always add up
+// to m. Returns an error if m is invalid, or if a ratio is negative or all of
+// them are zero.
+func Allocate(m pb.Money, ratios []int64) ([]pb.Money, error) {
+	if !IsValid(m) {
+		return nil, ErrInvalidValue
+	}
+	sum := new(big.Int)
+	for _, r := range ratios {
+		if r < 0 {
+			return nil, ErrInvalidRatios
+		}
+		sum.Add(sum, big.NewInt(r))
+	}
+	if sum.Sign() == 0 {
+		return nil, ErrInvalidRatios
+	}
+
+	step := minorUnit(m.GetCurrencyCode())
+	whole, rest := new(big.Int).QuoRem(toNanos(m), step, new(big.Int))
+	shares := make([]*big.Int, len(ratios))
+	left := new(big.Int).Set(whole)
+	for i, r := range ratios {
+		shares[i] = new(big.Int).Mul(whole, big.NewInt(r))
+		shares[i].Quo(shares[i], sum)
+		left.Sub(left, shares[i])
+	}
+	// Fewer minor units are left over than there are non-zero ratios.
+	unit := big.NewInt(int64(left.Sign()))
+	first := true
+	for i, r := range ratios {
+		if r == 0 {
+			continue
+		}
+		if left.Sign() != 0 {
+			shares[i].Add(shares[i], unit)
+			left.Sub(left, unit)
+		}
+		shares[i].Mul(shares[i], step)
+		if first {
+			shares[i].Add(shares[i], rest)
+			first = false
+		}
+	}
+
+	out := make([]pb.Money, len(shares))
+	for i, s := range shares {
+		var err error
+		if out[i], err = fromNanos(s, m.GetCurrencyCode()); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// RoundingMode tells how to round amounts that fall between two multiples of
+// the minor unit of their currency.
+type RoundingMode int
+
+const (
+	// HalfEven rounds to the nearest multiple, and halves to the even one.
+	HalfEven RoundingMode = iota
+	// HalfUp rounds to the nearest multiple, and halves away from zero.
+	HalfUp
+	// Truncate rounds towards zero.
+	Truncate
+)
+
+// Round rounds m to the minor unit of its currency, such as cents for USD or
+// whole yens for JPY. Returns an error if m is invalid or the result does not
+// fit.
+func Round(m pb.Money, mode RoundingMode) (pb.Money, error) {
+	if !IsValid(m) {
+		return pb.Money{}, ErrInvalidValue
+	}
+	step := minorUnit(m.GetCurrencyCode())
+	q := roundQuo(toNanos(m), step, mode)
+	return fromNanos(q.Mul(q, step), m.GetCurrencyCode())
+}
+
+// roundQuo returns n/d rounded to an integer according to mode. d must be
+// positive.
+func roundQuo(n, d *big.Int, mode RoundingMode) *big.Int {
+	q, rem := new(big.Int).QuoRem(n, d, new(big.Int))
+	if rem.Sign() == 0 || mode == Truncate {
+		return q
+	}
+	half := rem.Abs(rem).Lsh(rem, 1).Cmp(d)
+	if half > 0 || (half == 0 && (mode == HalfUp || q.Bit(0) == 1)) {
+		q.Add(q, big.NewInt(int64(n.Sign())))
+	}
+	return q
+}
+
+// minorUnit returns the minor unit of the currency in nanos.
+func minorUnit(currencyCode string) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(9-MinorUnits(currencyCode))), nil)
+}
+
+func toNanos(m pb.Money) *big.Int {
+	n := new(big.Int).Mul(big.NewInt(m.GetUnits()), big.NewInt(nanosMod))
+	return n.Add(n, big.NewInt(int64(m.GetNanos())))
+}
+
+func fromNanos(n *big.Int, currencyCode string) (pb.Money, error) {
+	units, nanos := new(big.Int).QuoRem(n, big.NewInt(nanosMod), new(big.Int))
+	if !units.IsInt64() {
+		return pb.Money{}, ErrOverflow
+	}
+	return pb.Money{
+		Units:        units.Int64(),
+		Nanos:        int32(nanos.Int64()),
+		CurrencyCode: currencyCode}, nil
+}
@@ -0,0 +1,558 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package money
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+func mmc(u int64, n int32, c string) pb.Money { return pb.Money{Units: u, Nanos: n, CurrencyCode: c} }
+func mm(u int64, n int32) pb.Money            { return mmc(u, n, "") }
+
+func TestIsValid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   pb.Money
+		want bool
+	}{
+		{"valid -/-", mm(-981273891273, -999999999), true},
+		{"invalid -/+", mm(-981273891273, +999999999), false},
+		{"valid +/+", mm(981273891273, 999999999), true},
+		{"invalid +/-", mm(981273891273, -999999999), false},
+		{"invalid +/+overflow", mm(3, 1000000000), false},
+		{"invalid +/-overflow", mm(3, -1000000000), false},
+		{"invalid -/+overflow", mm(-3, 1000000000), false},
+		{"invalid -/-overflow", mm(-3, -1000000000), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsValid(tt.in); got != tt.want {
+				t.Errorf("IsValid(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsZero(t *testing.T) {
+	tests := []struct {
+		name string
+		in   pb.Money
+		want bool
+	}{
+		{"zero", mm(0, 0), true},
+		{"not-zero (-/+)", mm(-1, +1), false},
+		{"not-zero (-/-)", mm(-1, -1), false},
+		{"not-zero (+/+)", mm(+1, +1), false},
+		{"not-zero (+/-)", mm(+1, -1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsZero(tt.in); got != tt.want {
+				t.Errorf("IsZero(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPositive(t *testing.T) {
+	tests := []struct {
+		name string
+		in   pb.Money
+		want bool
+	}{
+		{"zero", mm(0, 0), false},
+		{"positive (+/+)", mm(+1, +1), true},
+		{"invalid (-/+)", mm(-1, +1), false},
+		{"negative (-/-)", mm(-1, -1), false},
+		{"invalid (+/-)", mm(+1, -1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsPositive(tt.in); got != tt.want {
+				t.Errorf("IsPositive(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsNegative(t *testing.T) {
+	tests := []struct {
+		name string
+		in   pb.Money
+		want bool
+	}{
+		{"zero", mm(0, 0), false},
+		{"positive (+/+)", mm(+1, +1), false},
+		{"invalid (-/+)", mm(-1, +1), false},
+		{"negative (-/-)", mm(-1, -1), true},
+		{"invalid (+/-)", mm(+1, -1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsNegative(tt.in); got != tt.want {
+				t.Errorf("IsNegative(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAreSameCurrency(t *testing.T) {
+	type args struct {
+		l pb.Money
+		r pb.Money
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"both empty currency", args{mmc(1, 0, ""), mmc(2, 0, "")}, false},
+		{"left empty currency", args{mmc(1, 0, ""), mmc(2, 0, "USD")}, false},
+		{"right empty currency", args{mmc(1, 0, "USD"), mmc(2, 0, "")}, false},
+		{"mismatching", args{mmc(1, 0, "USD"), mmc(2, 0, "CAD")}, false},
+		{"matching", args{mmc(1, 0, "USD"), mmc(2, 0, "USD")}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AreSameCurrency(tt.args.l, tt.args.r); got != tt.want {
+				t.Errorf("AreSameCurrency([%v],[%v]) = %v, want %v", tt.args.l, tt.args.r, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAreEquals(t *testing.T) {
+	type args struct {
+		l pb.Money
+		r pb.Money
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"equals", args{mmc(1, 2, "USD"), mmc(1, 2, "USD")}, true},
+		{"mismatching currency", args{mmc(1, 2, "USD"), mmc(1, 2, "CAD")}, false},
+		{"mismatching units", args{mmc(10, 20, "USD"), mmc(1, 20, "USD")}, false},
+		{"mismatching nanos", args{mmc(1, 2, "USD"), mmc(1, 20, "USD")}, false},
+		{"negated", args{mmc(1, 2, "USD"), mmc(-1, -2, "USD")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AreEquals(tt.args.l, tt.args.r); got != tt.want {
+				t.Errorf("AreEquals([%v],[%v]) = %v, want %v", tt.args.l, tt.args.r, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   pb.Money
+		want pb.Money
+	}{
+		{"zero", mm(0, 0), mm(0, 0)},
+		{"negative", mm(-1, -200), mm(1, 200)},
+		{"positive", mm(1, 200), mm(-1, -200)},
+		{"carries currency code", mmc(0, 0, "XXX"), mmc(0, 0, "XXX")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Negate(tt.in); !AreEquals(got, tt.want) {
+				t.Errorf("Negate([%v]) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMust_pass(t *testing.T) {
+	v := Must(mm(2, 3), nil)
+	if !AreEquals(v, mm(2, 3)) {
+		t.Errorf("returned the wrong value: %v", v)
+	}
+}
+
+func TestMust_panic(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Logf("panic captured: %v", r)
+		}
+	}()
+	Must(mm(2, 3), fmt.Errorf("some error"))
+	t.Fatal("this should not have executed due to the panic above")
+}
+
+func TestSum(t *testing.T) {
+	type args struct {
+		l pb.Money
+		r pb.Money
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    pb.Money
+		wantErr error
+	}{
+		{"0+0=0", args{mm(0, 0), mm(0, 0)}, mm(0, 0), nil},
+		{"Error: currency code on left", args{mmc(0, 0, "XXX"), mm(0, 0)}, mm(0, 0), ErrMismatchingCurrency},
+		{"Error: currency code on right", args{mm(0, 0), mmc(0, 0, "YYY")}, mm(0, 0), ErrMismatchingCurrency},
+		{"Error: currency code mismatch", args{mmc(0, 0, "AAA"), mmc(0, 0, "BBB")}, mm(0, 0), ErrMismatchingCurrency},
+		{"Error: invalid +/-", args{mm(+1, -1), mm(0, 0)}, mm(0, 0), ErrInvalidValue},
+		{"Error: invalid -/+", args{mm(0, 0), mm(-1, +2)}, mm(0, 0), ErrInvalidValue},
+		{"Error: invalid nanos", args{mm(0, 1000000000), mm(1, 0)}, mm(0, 0), ErrInvalidValue},
+		{"both positive (no carry)", args{mm(2, 200000000), mm(2, 200000000)}, mm(4, 400000000), nil},
+		{"both positive (nanos=max)", args{mm(2, 111111111), mm(2, 888888888)}, mm(4, 999999999), nil},
+		{"both positive (carry)", args{mm(2, 200000000), mm(2, 900000000)}, mm(5, 100000000), nil},
+		{"both negative (no carry)", args{mm(-2, -200000000), mm(-2, -200000000)}, mm(-4, -400000000), nil},
+		{"both negative (carry)", args{mm(-2, -200000000), mm(-2, -900000000)}, mm(-5, -100000000), nil},
+		{"mixed (larger positive, just decimals)", args{mm(11, 0), mm(-2, 0)}, mm(9, 0), nil},
+		{"mixed (larger negative, just decimals)", args{mm(-11, 0), mm(2, 0)}, mm(-9, 0), nil},
+		{"mixed (larger positive, no borrow)", args{mm(11, 100000000), mm(-2, -100000000)}, mm(9, 0), nil},
+		{"mixed (larger positive, with borrow)", args{mm(11, 100000000), mm(-2, -9000000 /*.09*/)}, mm(9, 91000000 /*.091*/), nil},
+		{"mixed (larger negative, no borrow)", args{mm(-11, -100000000), mm(2, 100000000)}, mm(-9, 0), nil},
+		{"mixed (larger negative, with borrow)", args{mm(-11, -100000000), mm(2, 9000000 /*.09*/)}, mm(-9, -91000000 /*.091*/), nil},
+		{"0+negative", args{mm(0, 0), mm(-2, -100000000)}, mm(-2, -100000000), nil},
+		{"negative+0", args{mm(-2, -100000000), mm(0, 0)}, mm(-2, -100000000), nil},
+		{"negative nanos (carry)", args{mm(0, -600000000), mm(0, -600000000)}, mm(-1, -200000000), nil},
+		{"mixed units cancel out (carry)", args{mm(1, 600000000), mm(-1, -700000000)}, mm(0, -100000000), nil},
+		{"Error: overflow", args{mm(math.MaxInt64, 0), mm(1, 0)}, mm(0, 0), ErrOverflow},
+		{"Error: overflow (carry)", args{mm(math.MaxInt64, 500000000), mm(0, 500000000)}, mm(0, 0), ErrOverflow},
+		{"Error: underflow", args{mm(math.MinInt64, 0), mm(-1, 0)}, mm(0, 0), ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Sum(tt.args.l, tt.args.r)
+			if err != tt.wantErr {
+				t.Errorf("Sum([%v],[%v]): expected err=\"%v\" got=\"%v\"", tt.args.l, tt.args.r, tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Sum([%v],[%v]) = %v, want %v", tt.args.l, tt.args.r, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		l, r    pb.Money
+		want    pb.Money
+		wantErr error
+	}{
+		{"positive result", mmc(5, 100000000, "USD"), mmc(2, 200000000, "USD"), mmc(2, 900000000, "USD"), nil},
+		{"negative result", mmc(2, 200000000, "USD"), mmc(5, 100000000, "USD"), mmc(-2, -900000000, "USD"), nil},
+		{"Error: currency code mismatch", mmc(1, 0, "USD"), mmc(1, 0, "EUR"), pb.Money{}, ErrMismatchingCurrency},
+		{"Error: invalid value", mm(1, 0), mm(1, -1), pb.Money{}, ErrInvalidValue},
+		{"Error: overflow", mm(math.MinInt64, 0), mm(1, 0), pb.Money{}, ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Subtract(tt.l, tt.r)
+			if err != tt.wantErr {
+				t.Errorf("Subtract([%v],[%v]): expected err=\"%v\" got=\"%v\"", tt.l, tt.r, tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Subtract([%v],[%v]) = %v, want %v", tt.l, tt.r, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiply(t *testing.T) {
+	tests := []struct {
+		name    string
+		m       pb.Money
+		n       int64
+		want    pb.Money
+		wantErr error
+	}{
+		{"by zero", mmc(67, 990000000, "USD"), 0, mmc(0, 0, "USD"), nil},
+		{"by one", mmc(67, 990000000, "USD"), 1, mmc(67, 990000000, "USD"), nil},
+		{"carry into units", mmc(67, 990000000, "USD"), 3, mmc(203, 970000000, "USD"), nil},
+		{"negative factor", mm(1, 500000000), -3, mm(-4, -500000000), nil},
+		{"large factor", mm(0, 1), 1e12, mm(1000, 0), nil},
+		{"Error: invalid value", mm(1, -1), 2, pb.Money{}, ErrInvalidValue},
+		{"Error: overflow", mm(math.MaxInt64/2+1, 0), 2, pb.Money{}, ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Multiply(tt.m, tt.n)
+			if err != tt.wantErr {
+				t.Errorf("Multiply([%v], %d): expected err=\"%v\" got=\"%v\"", tt.m, tt.n, tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Multiply([%v], %d) = %v, want %v", tt.m, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiplyDecimal(t *testing.T) {
+	got, err := MultiplyDecimal(mmc(67, 990000000, "USD"), "0.0725")
+	if want := mmc(4, 929275000, "USD"); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("MultiplyDecimal() = %v, %v; want %v", got, err, want)
+	}
+	if _, err := MultiplyDecimal(mm(1, 0), "seven"); err != ErrInvalidFactor {
+		t.Errorf("MultiplyDecimal(seven): expected err=\"%v\" got=\"%v\"", ErrInvalidFactor, err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name    string
+		l, r    pb.Money
+		want    int
+		wantErr error
+	}{
+		{"equal", mm(1, 500000000), mm(1, 500000000), 0, nil},
+		{"less units", mm(1, 900000000), mm(2, 0), -1, nil},
+		{"greater nanos", mm(1, 2), mm(1, 1), +1, nil},
+		{"negatives", mm(-1, -2), mm(-1, -1), -1, nil},
+		{"negative and positive", mm(0, -1), mm(0, 1), -1, nil},
+		{"Error: currency code mismatch", mmc(1, 0, "USD"), mmc(1, 0, "EUR"), 0, ErrMismatchingCurrency},
+		{"Error: invalid value", mm(1, -1), mm(1, 0), 0, ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compare(tt.l, tt.r)
+			if err != tt.wantErr || got != tt.want {
+				t.Errorf("Compare([%v],[%v]) = %d, %v; want %d, %v", tt.l, tt.r, got, err, tt.want, tt.wantErr)
+			}
+			less, _ := Less(tt.l, tt.r)
+			if less != (tt.want < 0) {
+				t.Errorf("Less([%v],[%v]) = %v", tt.l, tt.r, less)
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		m       pb.Money
+		ratios  []int64
+		want    []pb.Money
+		wantErr error
+	}{
+		{"even split", mmc(3, 0, "USD"), []int64{1, 1, 1},
+			[]pb.Money{mmc(1, 0, "USD"), mmc(1, 0, "USD"), mmc(1, 0, "USD")}, nil},
+		{"cents left over", mmc(0, 100000000, "USD"), []int64{1, 1, 1},
+			[]pb.Money{mmc(0, 40000000, "USD"), mmc(0, 30000000, "USD"), mmc(0, 30000000, "USD")}, nil},
+		{"uneven ratios", mmc(10, 0, "USD"), []int64{70, 30},
+			[]pb.Money{mmc(7, 0, "USD"), mmc(3, 0, "USD")}, nil},
+		{"no decimals", mmc(100, 0, "JPY"), []int64{1, 1, 1},
+			[]pb.Money{mmc(34, 0, "JPY"), mmc(33, 0, "JPY"), mmc(33, 0, "JPY")}, nil},
+		{"fraction of a cent to the first share", mmc(1, 5, "USD"), []int64{0, 1, 1},
+			[]pb.Money{mmc(0, 0, "USD"), mmc(0, 500000005, "USD"), mmc(0, 500000000, "USD")}, nil},
+		{"zero ratios get nothing", mmc(0, 20000000, "USD"), []int64{1, 0, 1, 0, 1},
+			[]pb.Money{mmc(0, 10000000, "USD"), mmc(0, 0, "USD"), mmc(0, 10000000, "USD"), mmc(0, 0, "USD"), mmc(0, 0, "USD")}, nil},
+		{"negative", mmc(-1, 0, "USD"), []int64{1, 2},
+			[]pb.Money{mmc(0, -340000000, "USD"), mmc(0, -660000000, "USD")}, nil},
+		{"Error: invalid value", mm(1, -1), []int64{1}, nil, ErrInvalidValue},
+		{"Error: no ratios", mm(1, 0), nil, nil, ErrInvalidRatios},
+		{"Error: zero ratios", mm(1, 0), []int64{0, 0}, nil, ErrInvalidRatios},
+		{"Error: negative ratio", mm(1, 0), []int64{2, -1}, nil, ErrInvalidRatios},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Allocate(tt.m, tt.ratios)
+			if err != tt.wantErr {
+				t.Errorf("Allocate([%v], %v): expected err=\"%v\" got=\"%v\"", tt.m, tt.ratios, tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Allocate([%v], %v) = %v, want %v", tt.m, tt.ratios, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiplyRat(t *testing.T) {
+	tests := []struct {
+		name    string
+		m       pb.Money
+		r       string
+		want    pb.Money
+		wantErr error
+	}{
+		{"by one", mmc(67, 990000000, "USD"), "1", mmc(67, 990000000, "USD"), nil},
+		{"by zero", mm(67, 990000000), "0", mm(0, 0), nil},
+		{"exact", mm(12, 490000000), "0.5", mm(6, 245000000), nil},
+		{"carry into units", mm(67, 990000000), "0.0725", mm(4, 929275000), nil},
+		{"round half up", mm(0, 1), "0.5", mm(0, 1), nil},
+		{"round down", mm(0, 1), "0.49", mm(0, 0), nil},
+		{"negative rounds away from zero", mm(0, -1), "0.5", mm(0, -1), nil},
+		{"negative", mm(-2, -500000000), "2", mm(-5, 0), nil},
+		{"negative factor", mm(2, 500000000), "-1", mm(-2, -500000000), nil},
+		{"repeating fraction", mm(10, 0), "1/3", mm(3, 333333333), nil},
+		{"Error: invalid value", mm(1, -1), "1", pb.Money{}, ErrInvalidValue},
+		{"Error: overflow", mm(math.MaxInt64, 0), "2", pb.Money{}, ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := new(big.Rat).SetString(tt.r)
+			if !ok {
+				t.Fatalf("bad factor %q", tt.r)
+			}
+			got, err := MultiplyRat(tt.m, r)
+			if err != tt.wantErr {
+				t.Errorf("MultiplyRat([%v], %s): expected err=\"%v\" got=\"%v\"", tt.m, tt.r, tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MultiplyRat([%v], %s) = %v, want %v", tt.m, tt.r, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinorUnits(t *testing.T) {
+	for code, want := range map[string]int{"USD": 2, "EUR": 2, "JPY": 0, "jpy": 0, "KWD": 3, "CLF": 4, "XXX": 2, "": 2} {
+		if got := MinorUnits(code); got != want {
+			t.Errorf("MinorUnits(%q) = %d, want %d", code, got, want)
+		}
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		name    string
+		m       pb.Money
+		mode    RoundingMode
+		want    pb.Money
+		wantErr error
+	}{
+		{"already rounded", mmc(67, 990000000, "USD"), HalfEven, mmc(67, 990000000, "USD"), nil},
+		{"half even down", mmc(4, 925000000, "USD"), HalfEven, mmc(4, 920000000, "USD"), nil},
+		{"half even up", mmc(4, 935000000, "USD"), HalfEven, mmc(4, 940000000, "USD"), nil},
+		{"half even above half", mmc(4, 925000001, "USD"), HalfEven, mmc(4, 930000000, "USD"), nil},
+		{"half up", mmc(4, 925000000, "USD"), HalfUp, mmc(4, 930000000, "USD"), nil},
+		{"half up below half", mmc(4, 924999999, "USD"), HalfUp, mmc(4, 920000000, "USD"), nil},
+		{"truncate", mmc(4, 929999999, "USD"), Truncate, mmc(4, 920000000, "USD"), nil},
+		{"carry into units", mmc(4, 995000000, "USD"), HalfUp, mmc(5, 0, "USD"), nil},
+		{"no decimals", mmc(1234, 500000000, "JPY"), HalfEven, mmc(1234, 0, "JPY"), nil},
+		{"no decimals odd units", mmc(1235, 500000000, "JPY"), HalfEven, mmc(1236, 0, "JPY"), nil},
+		{"three decimals", mmc(1, 234500000, "KWD"), HalfUp, mmc(1, 235000000, "KWD"), nil},
+		{"negative half up", mmc(-4, -925000000, "USD"), HalfUp, mmc(-4, -930000000, "USD"), nil},
+		{"negative half even", mmc(-4, -925000000, "USD"), HalfEven, mmc(-4, -920000000, "USD"), nil},
+		{"negative truncate", mmc(-4, -929999999, "USD"), Truncate, mmc(-4, -920000000, "USD"), nil},
+		{"negative carry into units", mmc(0, -999000000, "USD"), HalfEven, mmc(-1, 0, "USD"), nil},
+		{"unknown currency", mmc(1, 5000000, "XXX"), HalfUp, mmc(1, 10000000, "XXX"), nil},
+		{"Error: invalid value", mmc(1, -1, "USD"), HalfEven, pb.Money{}, ErrInvalidValue},
+		{"Error: overflow", mmc(math.MaxInt64, 999999999, "USD"), HalfUp, pb.Money{}, ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Round(tt.m, tt.mode)
+			if err != tt.wantErr {
+				t.Errorf("Round([%v], %d): expected err=\"%v\" got=\"%v\"", tt.m, tt.mode, tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Round([%v], %d) = %v, want %v", tt.m, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSupportedLocale(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+		ok   bool
+	}{
+		{"en-US", "en-US", true},
+		{"en_gb", "en-GB", true},
+		{"fr-CA", "fr-CA", true},
+		{"fr-BE", "fr-FR", true},
+		{"de", "de-DE", true},
+		{"zh-Hant-TW", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := SupportedLocale(tt.tag)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("SupportedLocale(%q) = %q, %v; want %q, %v", tt.tag, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		m      pb.Money
+		locale string
+		want   string
+	}{
+		{mmc(1234, 560000000, "USD"), "en-US", "$1,234.56"},
+		{mmc(-1234, -560000000, "USD"), "en-US", "-$1,234.56"},
+		{mmc(0, -4000000, "USD"), "en-US", "$0.00"},
+		{mmc(999, 995000000, "USD"), "en-US", "$1,000.00"},
+		{mmc(1234567, 0, "JPY"), "en-US", "¥1,234,567"},
+		{mmc(1234567, 0, "JPY"), "ja-JP", "￥1,234,567"},
+		{mmc(1, 234500000, "KWD"), "en-US", "KWD\u00a01.234"},
+		{mmc(1234, 560000000, "TRY"), "tr-TR", "₺1.234,56"},
+		{mmc(1234, 560000000, "TRY"), "en-US", "TRY\u00a01,234.56"},
+		{mmc(1234, 560000000, "EUR"), "de-DE", "1.234,56\u00a0€"},
+		{mmc(1234, 560000000, "EUR"), "fr-FR", "1\u202f234,56\u00a0€"},
+		{mmc(1234, 560000000, "EUR"), "es-ES", "1234,56\u00a0€"},
+		{mmc(12345, 560000000, "EUR"), "es-ES", "12.345,56\u00a0€"},
+		{mmc(-5, 0, "USD"), "fr-FR", "-5,00\u00a0$US"},
+		{mmc(5, 0, "CAD"), "en-US", "CA$5.00"},
+		{mmc(5, 0, "CAD"), "en-CA", "$5.00"},
+		{mmc(5, 0, "USD"), "en-CA", "US$5.00"},
+		{mmc(5, 0, "USD"), "xx", "$5.00"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.m, tt.locale); got != tt.want {
+			t.Errorf("Format([%v], %q) = %q, want %q", tt.m, tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     pb.Money
+		wantErr  error
+	}{
+		{"1234.56", "USD", mmc(1234, 560000000, "USD"), nil},
+		{"  1,234.5 ", "USD", mmc(1234, 500000000, "USD"), nil},
+		{"-0.05", "USD", mmc(0, -50000000, "USD"), nil},
+		{"+12", "USD", mmc(12, 0, "USD"), nil},
+		{"1,234,567", "JPY", mmc(1234567, 0, "JPY"), nil},
+		{"1.234", "KWD", mmc(1, 234000000, "KWD"), nil},
+		{"1.5", "JPY", pb.Money{}, ErrInvalidAmount},
+		{"1.234", "USD", pb.Money{}, ErrInvalidAmount},
+		{"12,34.00", "USD", pb.Money{}, ErrInvalidAmount},
+		{",123", "USD", pb.Money{}, ErrInvalidAmount},
+		{"1.", "USD", pb.Money{}, ErrInvalidAmount},
+		{".5", "USD", pb.Money{}, ErrInvalidAmount},
+		{"$5", "USD", pb.Money{}, ErrInvalidAmount},
+		{"1e3", "USD", pb.Money{}, ErrInvalidAmount},
+		{"", "USD", pb.Money{}, ErrInvalidAmount},
+		{"99999999999999999999", "USD", pb.Money{}, ErrOverflow},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in, tt.currency)
+		if err != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q, %s) = %v, %v; want %v, %v", tt.in, tt.currency, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
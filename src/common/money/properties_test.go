@@ -0,0 +1,231 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package money
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+// amount is a valid money value in one of a few currencies with different
+// minor units, small enough that sums and small multiples do not overflow.
+type amount struct{ pb.Money }
+
+var testCurrencies = []string{"USD", "JPY", "KWD"}
+
+func (amount) Generate(r *rand.Rand, size int) reflect.Value {
+	units := r.Int63n(1e12)
+	nanos := r.Int31n(nanosMod)
+	switch r.Intn(4) {
+	case 0:
+		units = 0
+	case 1:
+		nanos = 0
+	}
+	if r.Intn(2) == 0 {
+		units, nanos = -units, -nanos
+	}
+	return reflect.ValueOf(amount{pb.Money{
+		CurrencyCode: testCurrencies[r.Intn(len(testCurrencies))],
+		Units:        units,
+		Nanos:        nanos}})
+}
+
+// in returns the amount in the currency of other.
+func (a amount) in(other amount) pb.Money {
+	m := a.Money
+	m.CurrencyCode = other.GetCurrencyCode()
+	return m
+}
+
+func check(t *testing.T, f interface{}) {
+	t.Helper()
+	if err := quick.Check(f, &quick.Config{MaxCount: 1000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSumProperties(t *testing.T) {
+	t.Run("commutative", func(t *testing.T) {
+		check(t, func(a, b amount) bool {
+			l, err1 := Sum(a.Money, b.in(a))
+			r, err2 := Sum(b.in(a), a.Money)
+			return err1 == nil && err2 == nil && reflect.DeepEqual(l, r)
+		})
+	})
+	t.Run("valid", func(t *testing.T) {
+		check(t, func(a, b amount) bool {
+			s, err := Sum(a.Money, b.in(a))
+			return err == nil && IsValid(s)
+		})
+	})
+	t.Run("subtract undoes sum", func(t *testing.T) {
+		check(t, func(a, b amount) bool {
+			s, err := Sum(a.Money, b.in(a))
+			if err != nil {
+				return false
+			}
+			d, err := Subtract(s, b.in(a))
+			return err == nil && AreEquals(d, a.Money)
+		})
+	})
+	t.Run("matches big arithmetic", func(t *testing.T) {
+		check(t, func(a, b amount) bool {
+			s, err := Sum(a.Money, b.in(a))
+			want := new(big.Int).Add(toNanos(a.Money), toNanos(b.in(a)))
+			return err == nil && toNanos(s).Cmp(want) == 0
+		})
+	})
+}
+
+func TestMultiplyProperties(t *testing.T) {
+	t.Run("repeated sum", func(t *testing.T) {
+		check(t, func(a amount, n uint8) bool {
+			want := pb.Money{CurrencyCode: a.GetCurrencyCode()}
+			for i := 0; i < int(n); i++ {
+				want = Must(Sum(want, a.Money))
+			}
+			got, err := Multiply(a.Money, int64(n))
+			return err == nil && AreEquals(got, want)
+		})
+	})
+	t.Run("distributive", func(t *testing.T) {
+		check(t, func(a amount, x, y int16) bool {
+			l, err := Multiply(a.Money, int64(x)+int64(y))
+			if err != nil {
+				return false
+			}
+			r := Must(Sum(Must(Multiply(a.Money, int64(x))), Must(Multiply(a.Money, int64(y)))))
+			return AreEquals(l, r)
+		})
+	})
+	t.Run("overflow is an error", func(t *testing.T) {
+		check(t, func(a amount) bool {
+			if a.GetUnits() > -2 && a.GetUnits() < 2 {
+				return true
+			}
+			_, err := Multiply(a.Money, math.MaxInt64/2+1)
+			return err == ErrOverflow
+		})
+	})
+}
+
+func TestCompareProperties(t *testing.T) {
+	t.Run("antisymmetric", func(t *testing.T) {
+		check(t, func(a, b amount) bool {
+			l, err1 := Compare(a.Money, b.in(a))
+			r, err2 := Compare(b.in(a), a.Money)
+			return err1 == nil && err2 == nil && l == -r
+		})
+	})
+	t.Run("sign of difference", func(t *testing.T) {
+		check(t, func(a, b amount) bool {
+			c, err := Compare(a.Money, b.in(a))
+			if err != nil {
+				return false
+			}
+			d := Must(Subtract(a.Money, b.in(a)))
+			switch c {
+			case -1:
+				return IsNegative(d)
+			case +1:
+				return IsPositive(d)
+			}
+			return IsZero(d)
+		})
+	})
+}
+
+func TestAllocateProperties(t *testing.T) {
+	ratios := func(raw []uint16) []int64 {
+		out := make([]int64, len(raw))
+		for i, r := range raw {
+			out[i] = int64(r)
+		}
+		return append(out, 1)
+	}
+	t.Run("shares add up", func(t *testing.T) {
+		check(t, func(a amount, raw []uint16) bool {
+			shares, err := Allocate(a.Money, ratios(raw))
+			if err != nil {
+				return false
+			}
+			sum := pb.Money{CurrencyCode: a.GetCurrencyCode()}
+			for _, s := range shares {
+				if !IsValid(s) {
+					return false
+				}
+				sum = Must(Sum(sum, s))
+			}
+			return AreEquals(sum, a.Money)
+		})
+	})
+	// Shares are off their exact proportion of the amount by less than a minor
+	// unit from rounding down, another one from the leftovers, and a third for
+	// the first share, which takes the fraction of a minor unit.
+	t.Run("proportional", func(t *testing.T) {
+		check(t, func(a amount, raw []uint16) bool {
+			r := ratios(raw)
+			shares, err := Allocate(a.Money, r)
+			if err != nil {
+				return false
+			}
+			var total int64
+			for _, x := range r {
+				total += x
+			}
+			unit := new(big.Rat).SetInt(minorUnit(a.GetCurrencyCode()))
+			twoUnits := new(big.Rat).Add(unit, unit)
+			first := true
+			for i, s := range shares {
+				exact := new(big.Rat).SetFrac(new(big.Int).Mul(toNanos(a.Money), big.NewInt(r[i])), big.NewInt(total))
+				diff := new(big.Rat).Sub(new(big.Rat).SetInt(toNanos(s)), exact)
+				limit := twoUnits
+				if r[i] != 0 && first {
+					limit = new(big.Rat).Add(twoUnits, unit)
+					first = false
+				}
+				if diff.Abs(diff).Cmp(limit) >= 0 {
+					return false
+				}
+			}
+			return true
+		})
+	})
+}
+
+func TestRoundProperties(t *testing.T) {
+	for _, mode := range []RoundingMode{HalfEven, HalfUp, Truncate} {
+		check(t, func(a amount) bool {
+			r, err := Round(a.Money, mode)
+			if err != nil {
+				return false
+			}
+			again, err := Round(r, mode)
+			if err != nil || !AreEquals(again, r) {
+				return false
+			}
+			diff := new(big.Int).Sub(toNanos(a.Money), toNanos(r))
+			step := minorUnit(a.GetCurrencyCode())
+			return diff.Abs(diff).Cmp(step) < 0 && new(big.Int).Rem(toNanos(r), step).Sign() == 0
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resilience
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// Closed lets every call through.
+	Closed State = iota
+	// Open rejects every call.
+	Open
+	// HalfOpen lets a few probe calls through to find out whether the
+	// downstream service has recovered.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// breaker is a circuit breaker counting consecutive failures.
+type breaker struct {
+	policy BreakerPolicy
+	now    func() time.Time
+
+	mu        sync.Mutex
+	state     State
+	gen       uint64    // incremented on every state change
+	failures  int       // consecutive failures while closed
+	openedAt  time.Time // when last opened
+	probes    int       // probe calls in flight while half-open
+	successes int       // successful probes while half-open
+}
+
+// transition is a change of the state of a breaker.
+type transition struct {
+	from, to State
+}
+
+func (b *breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// allow reports whether a call may go through. If it does, its outcome must
+// be passed to done along with gen. The breaker goes half-open on the first
+// call after the open timeout.
+func (b *breaker) allow() (gen uint64, ok bool, t *transition) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == Open && !b.now().Before(b.openedAt.Add(time.Duration(b.policy.OpenTimeout))) {
+		t = b.setLocked(HalfOpen)
+	}
+	switch b.state {
+	case Open:
+		return b.gen, false, t
+	case HalfOpen:
+		if b.probes >= b.policy.HalfOpenProbes {
+			return b.gen, false, t
+		}
+		b.probes++
+	}
+	return b.gen, true, t
+}
+
+// done records the outcome of a call let through by allow. Calls let
+// through before the last state change are ignored.
+func (b *breaker) done(gen uint64, failed bool) *transition {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if gen != b.gen {
+		return nil
+	}
+	switch b.state {
+	case Closed:
+		if !failed {
+			b.failures = 0
+			return nil
+		}
+		b.failures++
+		if b.failures >= b.policy.FailureThreshold {
+			return b.setLocked(Open)
+		}
+	case HalfOpen:
+		b.probes--
+		if failed {
+			return b.setLocked(Open)
+		}
+		b.successes++
+		if b.successes >= b.policy.HalfOpenProbes {
+			return b.setLocked(Closed)
+		}
+	}
+	return nil
+}
+
+func (b *breaker) setLocked(s State) *transition {
+	t := &transition{from: b.state, to: s}
+	b.state = s
+	b.gen++
+	b.failures = 0
+	b.probes = 0
+	b.successes = 0
+	if s == Open {
+		b.openedAt = b.now()
+	}
+	return t
+}
@@ -0,0 +1,200 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resilience
+
+import (
+	"context"
+	"expvar"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Client guards the calls to one downstream service according to a Policy.
+// Its interceptors are installed on the connection to that service.
+type Client struct {
+	target string
+	policy Policy
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+
+	breaker breaker
+
+	rndMu sync.Mutex
+	rnd   *rand.Rand
+
+	metrics  *expvar.Map
+	calls    expvar.Int
+	failures expvar.Int
+	retries  expvar.Int
+	rejected expvar.Int
+	opened   expvar.Int
+}
+
+// NewClient returns a Client for the downstream service target.
+func NewClient(target string, policy Policy) *Client {
+	policy = policy.WithDefaults()
+	c := &Client{
+		target:  target,
+		policy:  policy,
+		now:     time.Now,
+		sleep:   sleep,
+		rnd:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		metrics: new(expvar.Map).Init(),
+	}
+	c.breaker = breaker{policy: policy.Breaker, now: func() time.Time { return c.now() }}
+	c.metrics.Set("state", expvar.Func(func() interface{} { return c.breaker.State().String() }))
+	c.metrics.Set("calls", &c.calls)
+	c.metrics.Set("failures", &c.failures)
+	c.metrics.Set("retries", &c.retries)
+	c.metrics.Set("rejected", &c.rejected)
+	c.metrics.Set("opened", &c.opened)
+	return c
+}
+
+// State returns the state of the circuit breaker.
+func (c *Client) State() State {
+	return c.breaker.State()
+}
+
+// Metrics returns the breaker state and call counters of the client, for
+// publishing with expvar.Publish.
+func (c *Client) Metrics() *expvar.Map {
+	return c.metrics
+}
+
+// UnaryClientInterceptor bounds every attempt of a call with the policy
+// timeout, retries idempotent calls failing with a transient error, and
+// rejects calls while the circuit breaker is open.
+func (c *Client) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		retry := c.policy.Retry
+		for attempt := 1; ; attempt++ {
+			err := c.attempt(ctx, func(ctx context.Context) error {
+				actx, cancel := context.WithTimeout(ctx, time.Duration(c.policy.Timeout))
+				defer cancel()
+				return invoker(actx, method, req, reply, cc, opts...)
+			})
+			if err == nil || attempt >= retry.MaxAttempts || !retry.idempotent(method) ||
+				!retryable(err) || ctx.Err() != nil {
+				return err
+			}
+			c.rndMu.Lock()
+			delay := retry.backoff(attempt, c.rnd)
+			c.rndMu.Unlock()
+			if deadline, ok := ctx.Deadline(); ok && c.now().Add(delay).After(deadline) {
+				return err
+			}
+			c.retries.Add(1)
+			trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(
+				attribute.String("peer.service", c.target),
+				attribute.String("rpc.method", method),
+				attribute.Int("rpc.retry.attempt", attempt+1),
+				attribute.String("rpc.retry.cause", status.Code(err).String()),
+				attribute.String("rpc.retry.backoff", delay.String())))
+			if err := c.sleep(ctx, delay); err != nil {
+				return status.FromContextError(err).Err()
+			}
+		}
+	}
+}
+
+// StreamClientInterceptor rejects streams while the circuit breaker is
+// open. Streams are neither bounded nor retried, as they may legitimately
+// run for long.
+func (c *Client) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		var cs grpc.ClientStream
+		err := c.attempt(ctx, func(ctx context.Context) error {
+			var err error
+			cs, err = streamer(ctx, desc, cc, method, opts...)
+			return err
+		})
+		return cs, err
+	}
+}
+
+// attempt makes one call through the circuit breaker.
+func (c *Client) attempt(ctx context.Context, call func(ctx context.Context) error) error {
+	gen, ok, t := c.breaker.allow()
+	c.recordTransition(ctx, t)
+	if !ok {
+		c.rejected.Add(1)
+		trace.SpanFromContext(ctx).AddEvent("circuit_breaker.rejected", trace.WithAttributes(
+			attribute.String("peer.service", c.target)))
+		return status.Errorf(codes.Unavailable, "%s service circuit breaker is open", c.target)
+	}
+	c.calls.Add(1)
+	err := call(ctx)
+	failed := isFailure(ctx, err)
+	if failed {
+		c.failures.Add(1)
+	}
+	c.recordTransition(ctx, c.breaker.done(gen, failed))
+	return err
+}
+
+func (c *Client) recordTransition(ctx context.Context, t *transition) {
+	if t == nil {
+		return
+	}
+	if t.to == Open {
+		c.opened.Add(1)
+	}
+	trace.SpanFromContext(ctx).AddEvent("circuit_breaker.state_change", trace.WithAttributes(
+		attribute.String("peer.service", c.target),
+		attribute.String("circuit_breaker.from", t.from.String()),
+		attribute.String("circuit_breaker.to", t.to.String())))
+}
+
+// isFailure reports whether err counts against the health of the downstream
+// service. Errors about the request, and calls abandoned by the caller, do
+// not.
+func isFailure(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
+
+// retryable reports whether a failed attempt may succeed if retried.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
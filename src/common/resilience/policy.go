@@ -0,0 +1,227 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package resilience guards the calls to a downstream gRPC service with
+// deadlines, retries of idempotent RPCs and a circuit breaker.
+package resilience
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration written in JSON as a string such as "250ms".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("invalid duration %s", b)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Policy controls how the calls to one downstream service are guarded. Its
+// unset fields take their value from DefaultPolicy.
+type Policy struct {
+	// Timeout bounds every attempt of a unary call. The deadline of the
+	// caller still applies if it is earlier.
+	Timeout Duration `json:"timeout,omitempty"`
+
+	Retry   RetryPolicy   `json:"retry"`
+	Breaker BreakerPolicy `json:"breaker"`
+}
+
+// RetryPolicy controls the retries of idempotent unary calls that failed
+// with a transient error.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts, including the first one.
+	MaxAttempts int `json:"max_attempts,omitempty"`
+
+	// The delay before the n-th retry is InitialBackoff * Multiplier^(n-1),
+	// capped at MaxBackoff, and then randomly spread by up to Jitter (a
+	// fraction) either way.
+	InitialBackoff Duration `json:"initial_backoff,omitempty"`
+	MaxBackoff     Duration `json:"max_backoff,omitempty"`
+	Multiplier     float64  `json:"multiplier,omitempty"`
+	Jitter         float64  `json:"jitter,omitempty"`
+
+	// Idempotent lists the methods that are safe to retry, by name (such as
+	// "GetCart") or full name (such as "/hipstershop.CartService/GetCart").
+	// Other methods are never retried.
+	Idempotent []string `json:"idempotent,omitempty"`
+}
+
+// BreakerPolicy controls the circuit breaker of a downstream service.
+type BreakerPolicy struct {
+	// FailureThreshold is the number of consecutive failed calls that opens
+	// the breaker.
+	FailureThreshold int `json:"failure_threshold,omitempty"`
+
+	// OpenTimeout is how long the breaker rejects calls once open, before it
+	// lets probe calls through.
+	OpenTimeout Duration `json:"open_timeout,omitempty"`
+
+	// HalfOpenProbes is the number of probe calls let through at once while
+	// half-open. The breaker closes once that many have succeeded and opens
+	// again as soon as one fails.
+	HalfOpenProbes int `json:"half_open_probes,omitempty"`
+}
+
+// DefaultPolicy fills in the fields left unset in a Policy.
+var DefaultPolicy = Policy{
+	Timeout: Duration(time.Second),
+	Retry: RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: Duration(50 * time.Millisecond),
+		MaxBackoff:     Duration(time.Second),
+		Multiplier:     2,
+		Jitter:         0.2,
+	},
+	Breaker: BreakerPolicy{
+		FailureThreshold: 5,
+		OpenTimeout:      Duration(10 * time.Second),
+		HalfOpenProbes:   1,
+	},
+}
+
+// WithDefaults returns p with its unset fields taken from DefaultPolicy.
+func (p Policy) WithDefaults() Policy {
+	d := DefaultPolicy
+	if p.Timeout == 0 {
+		p.Timeout = d.Timeout
+	}
+	if p.Retry.MaxAttempts == 0 {
+		p.Retry.MaxAttempts = d.Retry.MaxAttempts
+	}
+	if p.Retry.InitialBackoff == 0 {
+		p.Retry.InitialBackoff = d.Retry.InitialBackoff
+	}
+	if p.Retry.MaxBackoff == 0 {
+		p.Retry.MaxBackoff = d.Retry.MaxBackoff
+	}
+	if p.Retry.Multiplier == 0 {
+		p.Retry.Multiplier = d.Retry.Multiplier
+	}
+	if p.Retry.Jitter == 0 {
+		p.Retry.Jitter = d.Retry.Jitter
+	}
+	if p.Breaker.FailureThreshold == 0 {
+		p.Breaker.FailureThreshold = d.Breaker.FailureThreshold
+	}
+	if p.Breaker.OpenTimeout == 0 {
+		p.Breaker.OpenTimeout = d.Breaker.OpenTimeout
+	}
+	if p.Breaker.HalfOpenProbes == 0 {
+		p.Breaker.HalfOpenProbes = d.Breaker.HalfOpenProbes
+	}
+	return p
+}
+
+func (p Policy) validate() error {
+	switch {
+	case p.Timeout < 0:
+		return fmt.Errorf("timeout must not be negative")
+	case p.Retry.MaxAttempts < 0:
+		return fmt.Errorf("retry.max_attempts must not be negative")
+	case p.Retry.InitialBackoff < 0 || p.Retry.MaxBackoff < 0:
+		return fmt.Errorf("retry backoffs must not be negative")
+	case p.Retry.Multiplier < 0:
+		return fmt.Errorf("retry.multiplier must not be negative")
+	case p.Retry.Jitter < 0 || p.Retry.Jitter > 1:
+		return fmt.Errorf("retry.jitter must be between 0 and 1")
+	case p.Breaker.FailureThreshold < 0 || p.Breaker.HalfOpenProbes < 0:
+		return fmt.Errorf("breaker thresholds must not be negative")
+	case p.Breaker.OpenTimeout < 0:
+		return fmt.Errorf("breaker.open_timeout must not be negative")
+	}
+	return nil
+}
+
+// idempotent reports whether fullMethod, such as
+// "/hipstershop.CartService/GetCart", may be retried.
+func (p RetryPolicy) idempotent(fullMethod string) bool {
+	name := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	for _, m := range p.Idempotent {
+		if m == fullMethod || m == name {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before the retry following the given number of
+// failed attempts.
+func (p RetryPolicy) backoff(attempts int, rnd *rand.Rand) time.Duration {
+	d := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempts-1))
+	if d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	d *= 1 + p.Jitter*(2*rnd.Float64()-1)
+	return time.Duration(d)
+}
+
+// Config holds the policies of the downstream services, by name.
+type Config map[string]Policy
+
+// Policy returns the policy for target, with defaults filled in.
+func (c Config) Policy(target string) Policy {
+	return c[target].WithDefaults()
+}
+
+// Merge returns the policies of c overridden, target by target, by those of
+// other.
+func (c Config) Merge(other Config) Config {
+	out := make(Config, len(c)+len(other))
+	for k, v := range c {
+		out[k] = v
+	}
+	for k, v := range other {
+		out[k] = v
+	}
+	return out
+}
+
+// Load reads the policies in the JSON file at path, an object mapping
+// downstream names to policies.
+func Load(path string) (Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse resilience policies %s: %v", path, err)
+	}
+	for target, p := range c {
+		if err := p.validate(); err != nil {
+			return nil, fmt.Errorf("invalid resilience policy for %s: %v", target, err)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,237 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resilience
+
+import (
+	"context"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var t0 = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type testClock struct{ now time.Time }
+
+func (c *testClock) Now() time.Time { return c.now }
+
+// newTestClient returns a client whose clock only moves when told to and
+// whose backoffs are recorded instead of waited for.
+func newTestClient(p Policy) (*Client, *testClock, *[]time.Duration) {
+	c := NewClient("test", p)
+	clock := &testClock{now: t0}
+	c.now = clock.Now
+	var slept []time.Duration
+	c.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		clock.now = clock.now.Add(d)
+		return nil
+	}
+	return c, clock, &slept
+}
+
+// invoker fails with the given codes, one per call, then succeeds.
+func invoker(calls *int, failures ...codes.Code) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		*calls++
+		if *calls <= len(failures) {
+			return status.Error(failures[*calls-1], "failed")
+		}
+		return nil
+	}
+}
+
+var testPolicy = Policy{
+	Timeout: Duration(time.Second),
+	Retry: RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: Duration(100 * time.Millisecond),
+		MaxBackoff:     Duration(time.Second),
+		Multiplier:     2,
+		Jitter:         0.1,
+		Idempotent:     []string{"Get"},
+	},
+	Breaker: BreakerPolicy{
+		FailureThreshold: 3,
+		OpenTimeout:      Duration(10 * time.Second),
+		HalfOpenProbes:   1,
+	},
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		failures  []codes.Code
+		wantCode  codes.Code
+		wantCalls int
+	}{
+		{"transient error retried", "/svc.Service/Get", []codes.Code{codes.Unavailable, codes.DeadlineExceeded}, codes.OK, 3},
+		{"attempts exhausted", "/svc.Service/Get", []codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable}, codes.Unavailable, 3},
+		{"non-idempotent not retried", "/svc.Service/Put", []codes.Code{codes.Unavailable}, codes.Unavailable, 1},
+		{"permanent error not retried", "/svc.Service/Get", []codes.Code{codes.InvalidArgument}, codes.InvalidArgument, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, slept := newTestClient(testPolicy)
+			var calls int
+			err := c.UnaryClientInterceptor()(context.Background(), tt.method, nil, nil, nil, invoker(&calls, tt.failures...))
+			if status.Code(err) != tt.wantCode || calls != tt.wantCalls {
+				t.Errorf("got %v after %d calls, want %v after %d", err, calls, tt.wantCode, tt.wantCalls)
+			}
+			if len(*slept) != tt.wantCalls-1 {
+				t.Errorf("backed off %d times, want %d", len(*slept), tt.wantCalls-1)
+			}
+		})
+	}
+}
+
+func TestRetriesStopAtCallerDeadline(t *testing.T) {
+	c, _, _ := newTestClient(testPolicy)
+	c.now = time.Now
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var calls int
+	err := c.UnaryClientInterceptor()(ctx, "/svc.Service/Get", nil, nil, nil, invoker(&calls, codes.Unavailable, codes.Unavailable))
+	if status.Code(err) != codes.Unavailable || calls != 1 {
+		t.Errorf("got %v after %d calls, want Unavailable after 1: the backoff outlasts the deadline", err, calls)
+	}
+}
+
+func TestAttemptTimeout(t *testing.T) {
+	c := NewClient("test", Policy{Timeout: Duration(10 * time.Millisecond)})
+	err := c.UnaryClientInterceptor()(context.Background(), "/svc.Service/Put", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Error("attempt has no deadline")
+			}
+			<-ctx.Done()
+			return status.FromContextError(ctx.Err()).Err()
+		})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("got %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: Duration(time.Second), MaxBackoff: Duration(5 * time.Second), Multiplier: 2, Jitter: 0.2}
+	rnd := rand.New(rand.NewSource(1))
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		got := p.backoff(i+1, rnd)
+		if lo, hi := time.Duration(float64(want)*0.8), time.Duration(float64(want)*1.2); got < lo || got > hi {
+			t.Errorf("backoff(%d) = %s, want within [%s, %s]", i+1, got, lo, hi)
+		}
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	p := testPolicy
+	p.Retry.Idempotent = nil
+	c, clock, _ := newTestClient(p)
+	call := c.UnaryClientInterceptor()
+	ctx := context.Background()
+	var calls int
+	fail := invoker(&calls, codes.Unavailable, codes.Unavailable, codes.Unavailable, codes.Unavailable)
+
+	for i := 0; i < p.Breaker.FailureThreshold; i++ {
+		call(ctx, "/svc.Service/Put", nil, nil, nil, fail)
+	}
+	if c.State() != Open {
+		t.Fatalf("state after %d failures = %s, want open", p.Breaker.FailureThreshold, c.State())
+	}
+	if err := call(ctx, "/svc.Service/Put", nil, nil, nil, fail); status.Code(err) != codes.Unavailable || calls != 3 {
+		t.Errorf("call while open = %v after %d calls, want rejection without a call", err, calls)
+	}
+
+	// A failed probe opens the breaker again.
+	clock.now = clock.now.Add(time.Duration(p.Breaker.OpenTimeout))
+	call(ctx, "/svc.Service/Put", nil, nil, nil, fail)
+	if c.State() != Open || calls != 4 {
+		t.Fatalf("state after failed probe = %s after %d calls, want open after 4", c.State(), calls)
+	}
+
+	// A successful one closes it.
+	clock.now = clock.now.Add(time.Duration(p.Breaker.OpenTimeout))
+	if err := call(ctx, "/svc.Service/Put", nil, nil, nil, fail); err != nil {
+		t.Fatal(err)
+	}
+	if c.State() != Closed {
+		t.Errorf("state after successful probe = %s, want closed", c.State())
+	}
+	if got := c.Metrics().String(); got != `{"calls": 5, "failures": 4, "opened": 2, "rejected": 1, "retries": 0, "state": "closed"}` {
+		t.Errorf("metrics = %s", got)
+	}
+}
+
+func TestBreakerIgnoresCallerErrors(t *testing.T) {
+	c, _, _ := newTestClient(testPolicy)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 2*testPolicy.Breaker.FailureThreshold; i++ {
+		var calls int
+		c.UnaryClientInterceptor()(context.Background(), "/svc.Service/Put", nil, nil, nil, invoker(&calls, codes.NotFound))
+		c.UnaryClientInterceptor()(ctx, "/svc.Service/Put", nil, nil, nil, invoker(&calls, codes.Canceled))
+	}
+	if c.State() != Closed {
+		t.Errorf("state = %s, want closed", c.State())
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resilience")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "resilience.json")
+	err = ioutil.WriteFile(path, []byte(`{
+		"cart": {"timeout": "250ms", "retry": {"max_attempts": 5, "idempotent": ["GetCart"]}},
+		"payment": {"breaker": {"failure_threshold": 10, "open_timeout": "1m"}}
+	}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cart := c.Policy("cart")
+	if cart.Timeout != Duration(250*time.Millisecond) || cart.Retry.MaxAttempts != 5 || !cart.Retry.idempotent("/hipstershop.CartService/GetCart") {
+		t.Errorf("cart policy = %+v", cart)
+	}
+	if cart.Breaker != DefaultPolicy.Breaker {
+		t.Errorf("cart breaker = %+v, want the default", cart.Breaker)
+	}
+	if p := c.Policy("payment"); p.Breaker.FailureThreshold != 10 || p.Breaker.OpenTimeout != Duration(time.Minute) || p.Retry.idempotent("Charge") {
+		t.Errorf("payment policy = %+v", p)
+	}
+	if p := c.Policy("unknown"); p.Timeout != DefaultPolicy.Timeout {
+		t.Errorf("policy of unlisted target = %+v, want the default", p)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(`{"cart": {"timeout": "-1s"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() accepted a negative timeout")
+	}
+}
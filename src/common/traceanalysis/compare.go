@@ -0,0 +1,105 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traceanalysis
+
+import "sort"
+
+// LatencyChange compares the latency of a service or operation in two runs.
+// The changes are relative, 0.1 meaning 10% slower, and absent if the
+// service or operation is missing from either run.
+type LatencyChange struct {
+	Service   string   `json:"service"`
+	Operation string   `json:"operation,omitempty"`
+	Base      Latency  `json:"base"`
+	Other     Latency  `json:"other"`
+	P50Change *float64 `json:"p50_change,omitempty"`
+	P99Change *float64 `json:"p99_change,omitempty"`
+}
+
+// Comparison compares the reports of two runs, such as one sampling all
+// traces and one sampling some.
+type Comparison struct {
+	BaseTraces  int             `json:"base_traces"`
+	OtherTraces int             `json:"other_traces"`
+	BaseSpans   int             `json:"base_spans"`
+	OtherSpans  int             `json:"other_spans"`
+	Services    []LatencyChange `json:"services"`
+	Operations  []LatencyChange `json:"operations"`
+}
+
+// Compare compares the latencies in the reports of two runs.
+func Compare(base, other Report) Comparison {
+	c := Comparison{
+		BaseTraces:  base.Traces,
+		OtherTraces: other.Traces,
+		BaseSpans:   base.Spans,
+		OtherSpans:  other.Spans,
+	}
+
+	services := make(map[string]*LatencyChange)
+	for _, s := range base.Services {
+		services[s.Service] = &LatencyChange{Service: s.Service, Base: s.Latency}
+	}
+	for _, s := range other.Services {
+		if services[s.Service] == nil {
+			services[s.Service] = &LatencyChange{Service: s.Service}
+		}
+		services[s.Service].Other = s.Latency
+	}
+	for _, ch := range services {
+		c.Services = append(c.Services, ch.withChanges())
+	}
+
+	operations := make(map[operation]*LatencyChange)
+	for _, o := range base.Operations {
+		operations[operation{o.Service, o.Operation}] = &LatencyChange{Service: o.Service, Operation: o.Operation, Base: o.Latency}
+	}
+	for _, o := range other.Operations {
+		op := operation{o.Service, o.Operation}
+		if operations[op] == nil {
+			operations[op] = &LatencyChange{Service: o.Service, Operation: o.Operation}
+		}
+		operations[op].Other = o.Latency
+	}
+	for _, ch := range operations {
+		c.Operations = append(c.Operations, ch.withChanges())
+	}
+
+	for _, changes := range [][]LatencyChange{c.Services, c.Operations} {
+		sort.Slice(changes, func(i, j int) bool {
+			if changes[i].Service != changes[j].Service {
+				return changes[i].Service < changes[j].Service
+			}
+			return changes[i].Operation < changes[j].Operation
+		})
+	}
+	return c
+}
+
+func (ch LatencyChange) withChanges() LatencyChange {
+	if ch.Base.Count > 0 && ch.Other.Count > 0 {
+		ch.P50Change = change(ch.Base.P50, ch.Other.P50)
+		ch.P99Change = change(ch.Base.P99, ch.Other.P99)
+	}
+	return ch
+}
+
+func change(base, other float64) *float64 {
+	if base == 0 {
+		return nil
+	}
+	c := (other - base) / base
+	return &c
+}
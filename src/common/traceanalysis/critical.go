@@ -0,0 +1,114 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traceanalysis
+
+import (
+	"sort"
+	"time"
+)
+
+// CriticalPath breaks the latency of an operation down into the time spent
+// in each operation on its critical path: the chain of spans that, if
+// shorter, would have made it end sooner.
+type CriticalPath struct {
+	Service   string        `json:"service"`
+	Operation string        `json:"operation"`
+	Count     int           `json:"count"`
+	Mean      float64       `json:"mean_ms"`
+	Segments  []PathSegment `json:"segments"`
+}
+
+// PathSegment is the time an operation is on a critical path, outside of its
+// own children on it.
+type PathSegment struct {
+	Service   string  `json:"service"`
+	Operation string  `json:"operation"`
+	Mean      float64 `json:"mean_ms"` // per analyzed span
+	Share     float64 `json:"share"`   // of the latency of the analyzed spans
+}
+
+// criticalPath adds the time each span under s, s included, spends on the
+// critical path of s to self.
+func criticalPath(s *Span, self map[operation]time.Duration) {
+	walkCriticalPath(s, s.End(), self)
+}
+
+// walkCriticalPath attributes the time from the start of s to end: to the
+// child of s ending last before end, recursively, then to the children of s
+// ending before that one started, and the rest to s.
+func walkCriticalPath(s *Span, end time.Time, self map[operation]time.Duration) {
+	children := append([]*Span(nil), s.children...)
+	sort.Slice(children, func(i, j int) bool { return children[i].End().After(children[j].End()) })
+	cursor := end
+	for _, c := range children {
+		if !c.Start.Before(cursor) {
+			continue // started after the part of the path left
+		}
+		childEnd := c.End()
+		if childEnd.After(cursor) {
+			childEnd = cursor
+		}
+		self[operation{s.Service, s.Name}] += cursor.Sub(childEnd)
+		walkCriticalPath(c, childEnd, self)
+		cursor = c.Start
+		if cursor.Before(s.Start) {
+			cursor = s.Start
+		}
+	}
+	if cursor.After(s.Start) {
+		self[operation{s.Service, s.Name}] += cursor.Sub(s.Start)
+	}
+}
+
+// pathStats accumulates the critical paths of the spans of an operation.
+type pathStats struct {
+	count int
+	total time.Duration
+	self  map[operation]time.Duration
+}
+
+func newPathStats() *pathStats {
+	return &pathStats{self: make(map[operation]time.Duration)}
+}
+
+func (p *pathStats) add(s *Span) {
+	p.count++
+	p.total += s.Duration
+	criticalPath(s, p.self)
+}
+
+func (p *pathStats) criticalPath(op operation) CriticalPath {
+	cp := CriticalPath{
+		Service:   op.service,
+		Operation: op.name,
+		Count:     p.count,
+		Mean:      ms(p.total / time.Duration(p.count)),
+	}
+	for o, d := range p.self {
+		seg := PathSegment{Service: o.service, Operation: o.name, Mean: ms(d / time.Duration(p.count))}
+		if p.total > 0 {
+			seg.Share = float64(d) / float64(p.total)
+		}
+		cp.Segments = append(cp.Segments, seg)
+	}
+	sort.Slice(cp.Segments, func(i, j int) bool {
+		a, b := cp.Segments[i], cp.Segments[j]
+		if a.Mean != b.Mean {
+			return a.Mean > b.Mean
+		}
+		return a.Service+a.Operation < b.Service+b.Operation
+	})
+	return cp
+}
@@ -0,0 +1,87 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traceanalysis
+
+import (
+	"time"
+)
+
+// The types below are the JSON of the traces exported by Jaeger.
+
+type jaegerTrace struct {
+	TraceID   string                   `json:"traceID"`
+	Spans     []jaegerSpan             `json:"spans"`
+	Processes map[string]jaegerProcess `json:"processes"`
+}
+
+type jaegerSpan struct {
+	TraceID       string            `json:"traceID"`
+	SpanID        string            `json:"spanID"`
+	ParentSpanID  string            `json:"parentSpanID"` // in older exports
+	OperationName string            `json:"operationName"`
+	References    []jaegerReference `json:"references"`
+	StartTime     int64             `json:"startTime"` // in microseconds since the epoch
+	Duration      int64             `json:"duration"`  // in microseconds
+	Tags          []jaegerTag       `json:"tags"`
+	ProcessID     string            `json:"processID"`
+}
+
+type jaegerReference struct {
+	RefType string `json:"refType"`
+	TraceID string `json:"traceID"`
+	SpanID  string `json:"spanID"`
+}
+
+type jaegerTag struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+type jaegerProcess struct {
+	ServiceName string `json:"serviceName"`
+}
+
+func (t jaegerTrace) spans() []*Span {
+	spans := make([]*Span, 0, len(t.Spans))
+	for _, js := range t.Spans {
+		s := &Span{
+			TraceID:  js.TraceID,
+			SpanID:   js.SpanID,
+			ParentID: js.ParentSpanID,
+			Service:  t.Processes[js.ProcessID].ServiceName,
+			Name:     js.OperationName,
+			Start:    time.Unix(0, js.StartTime*int64(time.Microsecond)),
+			Duration: time.Duration(js.Duration) * time.Microsecond,
+		}
+		if s.TraceID == "" {
+			s.TraceID = t.TraceID
+		}
+		for _, ref := range js.References {
+			if ref.RefType == "CHILD_OF" || s.ParentID == "" {
+				s.ParentID = ref.SpanID
+			}
+		}
+		for _, tag := range js.Tags {
+			switch tag.Key {
+			case "error":
+				s.Error = s.Error || tag.Value == true || tag.Value == "true"
+			case "otel.status_code":
+				s.Error = s.Error || tag.Value == "ERROR"
+			}
+		}
+		spans = append(spans, s)
+	}
+	return spans
+}
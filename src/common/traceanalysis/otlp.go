@@ -0,0 +1,130 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traceanalysis
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The types below are the JSON mapping of the OTLP trace protos, which have
+// changed names across versions.
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans                  []otlpScopeSpans `json:"scopeSpans"`
+	InstrumentationLibrarySpans []otlpScopeSpans `json:"instrumentationLibrarySpans"`
+}
+
+type otlpScopeSpans struct {
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId"`
+	Name              string     `json:"name"`
+	StartTimeUnixNano otlpUint64 `json:"startTimeUnixNano"`
+	EndTimeUnixNano   otlpUint64 `json:"endTimeUnixNano"`
+	Status            otlpStatus `json:"status"`
+}
+
+type otlpStatus struct {
+	Code json.RawMessage `json:"code"` // a number or the name of the code
+}
+
+// failed reports whether the status is STATUS_CODE_ERROR.
+func (s otlpStatus) failed() bool {
+	c := strings.Trim(string(s.Code), `"`)
+	return c == "2" || c == "STATUS_CODE_ERROR"
+}
+
+type otlpKeyValue struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+// otlpUint64 is a 64-bit integer, written in JSON as a string or a number.
+type otlpUint64 uint64
+
+func (n *otlpUint64) UnmarshalJSON(b []byte) error {
+	v, err := strconv.ParseUint(strings.Trim(string(b), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s", b)
+	}
+	*n = otlpUint64(v)
+	return nil
+}
+
+// otlpID returns the lower case hex form of a trace or span ID, which is
+// written in hex by the OTLP JSON exporters but in base64 by some.
+func otlpID(id string) (string, error) {
+	if id == "" {
+		return "", nil
+	}
+	if b, err := hex.DecodeString(id); err == nil && (len(b) == 8 || len(b) == 16) {
+		return strings.ToLower(id), nil
+	}
+	b, err := base64.StdEncoding.DecodeString(id)
+	if err != nil {
+		return "", fmt.Errorf("invalid ID %q", id)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (rs otlpResourceSpans) spans() ([]*Span, error) {
+	var service string
+	for _, kv := range rs.Resource.Attributes {
+		if kv.Key == "service.name" {
+			service = kv.Value.StringValue
+		}
+	}
+	var spans []*Span
+	for _, ss := range append(rs.ScopeSpans, rs.InstrumentationLibrarySpans...) {
+		for _, o := range ss.Spans {
+			s := &Span{
+				Service: service,
+				Name:    o.Name,
+				Start:   time.Unix(0, int64(o.StartTimeUnixNano)),
+				Error:   o.Status.failed(),
+			}
+			if o.EndTimeUnixNano > o.StartTimeUnixNano {
+				s.Duration = time.Duration(o.EndTimeUnixNano - o.StartTimeUnixNano)
+			}
+			var err error
+			if s.TraceID, err = otlpID(o.TraceID); err != nil {
+				return nil, err
+			}
+			if s.SpanID, err = otlpID(o.SpanID); err != nil {
+				return nil, err
+			}
+			if s.ParentID, err = otlpID(o.ParentSpanID); err != nil {
+				return nil, err
+			}
+			spans = append(spans, s)
+		}
+	}
+	return spans, nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traceanalysis
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Table is a part of a report or comparison, as rows of text.
+type Table struct {
+	Name    string
+	Columns []string
+	Rows    [][]string
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', 3, 64)
+}
+
+func latencyColumns(prefix string) []string {
+	cols := []string{"count", "errors", "mean_ms", "p50_ms", "p90_ms", "p99_ms", "max_ms"}
+	if prefix != "" {
+		for i, c := range cols {
+			cols[i] = prefix + "_" + c
+		}
+	}
+	return cols
+}
+
+func latencyRow(l Latency) []string {
+	return []string{strconv.Itoa(l.Count), strconv.Itoa(l.Errors), num(l.Mean), num(l.P50), num(l.P90), num(l.P99), num(l.Max)}
+}
+
+// Tables returns the parts of r: the latencies of the services and of their
+// operations, the span counts of the traces and the critical paths.
+func (r Report) Tables() []Table {
+	services := Table{Name: "services", Columns: append([]string{"service"}, latencyColumns("")...)}
+	for _, s := range r.Services {
+		services.Rows = append(services.Rows, append([]string{s.Service}, latencyRow(s.Latency)...))
+	}
+	operations := Table{Name: "operations", Columns: append([]string{"service", "operation"}, latencyColumns("")...)}
+	for _, o := range r.Operations {
+		operations.Rows = append(operations.Rows, append([]string{o.Service, o.Operation}, latencyRow(o.Latency)...))
+	}
+	counts := Table{Name: "span_counts", Columns: []string{"service", "operation", "traces", "mean", "min", "p50", "p90", "max"}}
+	for _, c := range r.SpanCounts {
+		counts.Rows = append(counts.Rows, []string{c.Service, c.Operation, strconv.Itoa(c.Traces), num(c.Mean),
+			strconv.Itoa(c.Min), strconv.Itoa(c.P50), strconv.Itoa(c.P90), strconv.Itoa(c.Max)})
+	}
+	paths := Table{Name: "critical_paths", Columns: []string{"service", "operation", "count", "mean_ms", "segment_service", "segment_operation", "segment_mean_ms", "segment_share"}}
+	for _, p := range r.CriticalPaths {
+		for _, s := range p.Segments {
+			paths.Rows = append(paths.Rows, []string{p.Service, p.Operation, strconv.Itoa(p.Count), num(p.Mean),
+				s.Service, s.Operation, num(s.Mean), num(s.Share)})
+		}
+	}
+	return []Table{services, operations, counts, paths}
+}
+
+// Tables returns the parts of c: the changes of the latencies of the
+// services and of their operations.
+func (c Comparison) Tables() []Table {
+	columns := append(append(latencyColumns("base"), latencyColumns("other")...), "p50_change", "p99_change")
+	services := Table{Name: "services", Columns: append([]string{"service"}, columns...)}
+	for _, ch := range c.Services {
+		services.Rows = append(services.Rows, append([]string{ch.Service}, changeRow(ch)...))
+	}
+	operations := Table{Name: "operations", Columns: append([]string{"service", "operation"}, columns...)}
+	for _, ch := range c.Operations {
+		operations.Rows = append(operations.Rows, append([]string{ch.Service, ch.Operation}, changeRow(ch)...))
+	}
+	return []Table{services, operations}
+}
+
+func changeRow(ch LatencyChange) []string {
+	row := append(latencyRow(ch.Base), latencyRow(ch.Other)...)
+	for _, c := range []*float64{ch.P50Change, ch.P99Change} {
+		if c == nil {
+			row = append(row, "")
+		} else {
+			row = append(row, num(*c))
+		}
+	}
+	return row
+}
+
+// WriteText writes tables aligned in columns, each under its name.
+func WriteText(w io.Writer, tables []Table) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for i, t := range tables {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintf(tw, "# %s\n", t.Name)
+		fmt.Fprintln(tw, strings.Join(t.Columns, "\t"))
+		for _, row := range t.Rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+	}
+	return tw.Flush()
+}
+
+// WriteCSV writes tables as CSV, each starting with its header and
+// separated from the next by an empty line.
+func WriteCSV(w io.Writer, tables []Table) error {
+	cw := csv.NewWriter(w)
+	for i, t := range tables {
+		if i > 0 {
+			cw.Flush()
+			fmt.Fprintln(w)
+		}
+		if err := cw.Write(t.Columns); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(t.Rows); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes v, a report or comparison, as indented JSON.
+func WriteJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,249 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traceanalysis
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Latency summarizes the durations of a set of spans, in milliseconds.
+type Latency struct {
+	Count  int     `json:"count"`
+	Errors int     `json:"errors"`
+	Mean   float64 `json:"mean_ms"`
+	P50    float64 `json:"p50_ms"`
+	P90    float64 `json:"p90_ms"`
+	P99    float64 `json:"p99_ms"`
+	Max    float64 `json:"max_ms"`
+}
+
+// durations collects the durations of spans.
+type durations struct {
+	ds     []time.Duration
+	errors int
+}
+
+func (d *durations) add(s *Span) {
+	d.ds = append(d.ds, s.Duration)
+	if s.Error {
+		d.errors++
+	}
+}
+
+func (d *durations) latency() Latency {
+	l := Latency{Count: len(d.ds), Errors: d.errors}
+	if len(d.ds) == 0 {
+		return l
+	}
+	sort.Slice(d.ds, func(i, j int) bool { return d.ds[i] < d.ds[j] })
+	var sum time.Duration
+	for _, v := range d.ds {
+		sum += v
+	}
+	l.Mean = ms(sum / time.Duration(len(d.ds)))
+	l.P50 = ms(percentile(d.ds, 0.50))
+	l.P90 = ms(percentile(d.ds, 0.90))
+	l.P99 = ms(percentile(d.ds, 0.99))
+	l.Max = ms(d.ds[len(d.ds)-1])
+	return l
+}
+
+// percentile returns the nearest-rank percentile p of the sorted ds.
+func percentile(ds []time.Duration, p float64) time.Duration {
+	i := int(math.Ceil(p*float64(len(ds)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return ds[i]
+}
+
+// ms converts d to milliseconds.
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// ServiceLatency is the latency of the calls served by a service, measured
+// by the spans where they enter it.
+type ServiceLatency struct {
+	Service string `json:"service"`
+	Latency
+}
+
+// OperationLatency is the latency of all spans of an operation of a
+// service, such as an RPC it serves or calls.
+type OperationLatency struct {
+	Service   string `json:"service"`
+	Operation string `json:"operation"`
+	Latency
+}
+
+// SpanCounts is the number of spans of the traces starting with an
+// operation.
+type SpanCounts struct {
+	Service   string  `json:"service"`
+	Operation string  `json:"operation"`
+	Traces    int     `json:"traces"`
+	Mean      float64 `json:"mean"`
+	Min       int     `json:"min"`
+	P50       int     `json:"p50"`
+	P90       int     `json:"p90"`
+	Max       int     `json:"max"`
+}
+
+// Report is the analysis of a set of traces.
+type Report struct {
+	Traces        int                `json:"traces"`
+	Spans         int                `json:"spans"`
+	Services      []ServiceLatency   `json:"services"`
+	Operations    []OperationLatency `json:"operations"`
+	SpanCounts    []SpanCounts       `json:"span_counts"`
+	CriticalPaths []CriticalPath     `json:"critical_paths"`
+}
+
+// DefaultCriticalPaths are the operations whose critical paths are analyzed
+// by default: placing an order and the requests served by the frontend.
+var DefaultCriticalPaths = []string{
+	"checkoutservice:hipstershop.CheckoutService/PlaceOrder",
+	"frontend:*",
+}
+
+// matchOperation reports whether s matches pattern, written
+// "service:operation". A trailing "*" in either part matches any suffix.
+func matchOperation(pattern string, s *Span) bool {
+	i := strings.Index(pattern, ":")
+	if i < 0 {
+		return match(pattern, s.Name)
+	}
+	return match(pattern[:i], s.Service) && match(pattern[i+1:], s.Name)
+}
+
+func match(pattern, v string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(v, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == v
+}
+
+type operation struct {
+	service, name string
+}
+
+// Analyze reports the latencies of the services and their operations in
+// traces, and the critical paths of the operations entering a service that
+// match one of criticalPaths, written "service:operation".
+func Analyze(traces []*Trace, criticalPaths []string) Report {
+	r := Report{Traces: len(traces)}
+	services := make(map[string]*durations)
+	operations := make(map[operation]*durations)
+	counts := make(map[operation][]int)
+	paths := make(map[operation]*pathStats)
+	var pathOrder []operation
+	for _, t := range traces {
+		r.Spans += len(t.Spans)
+		if t.Root != nil {
+			root := operation{t.Root.Service, t.Root.Name}
+			counts[root] = append(counts[root], len(t.Spans))
+		}
+		for _, s := range t.Spans {
+			op := operation{s.Service, s.Name}
+			if operations[op] == nil {
+				operations[op] = new(durations)
+			}
+			operations[op].add(s)
+			if !s.entry() {
+				continue
+			}
+			if services[s.Service] == nil {
+				services[s.Service] = new(durations)
+			}
+			services[s.Service].add(s)
+			for _, p := range criticalPaths {
+				if !matchOperation(p, s) {
+					continue
+				}
+				if paths[op] == nil {
+					paths[op] = newPathStats()
+					pathOrder = append(pathOrder, op)
+				}
+				paths[op].add(s)
+				break
+			}
+		}
+	}
+
+	for name, d := range services {
+		r.Services = append(r.Services, ServiceLatency{Service: name, Latency: d.latency()})
+	}
+	sort.Slice(r.Services, func(i, j int) bool { return r.Services[i].Service < r.Services[j].Service })
+	for op, d := range operations {
+		r.Operations = append(r.Operations, OperationLatency{Service: op.service, Operation: op.name, Latency: d.latency()})
+	}
+	sort.Slice(r.Operations, func(i, j int) bool {
+		a, b := r.Operations[i], r.Operations[j]
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		return a.Operation < b.Operation
+	})
+	for op, n := range counts {
+		r.SpanCounts = append(r.SpanCounts, spanCounts(op, n))
+	}
+	sort.Slice(r.SpanCounts, func(i, j int) bool {
+		a, b := r.SpanCounts[i], r.SpanCounts[j]
+		if a.Traces != b.Traces {
+			return a.Traces > b.Traces
+		}
+		return a.Service+a.Operation < b.Service+b.Operation
+	})
+	for _, op := range pathOrder {
+		r.CriticalPaths = append(r.CriticalPaths, paths[op].criticalPath(op))
+	}
+	sort.Slice(r.CriticalPaths, func(i, j int) bool {
+		a, b := r.CriticalPaths[i], r.CriticalPaths[j]
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		return a.Operation < b.Operation
+	})
+	return r
+}
+
+func spanCounts(op operation, n []int) SpanCounts {
+	sort.Ints(n)
+	sum := 0
+	for _, v := range n {
+		sum += v
+	}
+	rank := func(p float64) int {
+		i := int(math.Ceil(p*float64(len(n)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		return n[i]
+	}
+	return SpanCounts{
+		Service:   op.service,
+		Operation: op.name,
+		Traces:    len(n),
+		Mean:      float64(sum) / float64(len(n)),
+		Min:       n[0],
+		P50:       rank(0.50),
+		P90:       rank(0.90),
+		Max:       n[len(n)-1],
+	}
+}
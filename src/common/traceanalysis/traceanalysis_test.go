@@ -0,0 +1,228 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traceanalysis
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const jaegerExport = `{"data": [{
+	"traceID": "00000000000000000000000000000001",
+	"spans": [
+		{"traceID": "00000000000000000000000000000001", "spanID": "0000000000000001", "operationName": "POST /cart/checkout",
+		 "references": [], "startTime": 1000000, "duration": 100000, "processID": "p1", "tags": []},
+		{"traceID": "00000000000000000000000000000001", "spanID": "0000000000000002", "operationName": "hipstershop.CheckoutService/PlaceOrder",
+		 "references": [{"refType": "CHILD_OF", "traceID": "00000000000000000000000000000001", "spanID": "0000000000000001"}],
+		 "startTime": 1010000, "duration": 80000, "processID": "p2",
+		 "tags": [{"key": "otel.status_code", "type": "string", "value": "ERROR"}]}
+	],
+	"processes": {"p1": {"serviceName": "frontend"}, "p2": {"serviceName": "checkoutservice"}}
+}]}`
+
+// otlpExport is written as by the file exporter of the services, followed
+// by a request with base64 IDs and newer field names.
+const otlpExport = `{"resourceSpans": [{"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "shippingservice"}}]},
+	"instrumentationLibrarySpans": [{"instrumentationLibrary": {"name": "otelgrpc"}, "spans": [
+		{"traceId": "00000000000000000000000000000001", "spanId": "0000000000000003", "parentSpanId": "0000000000000002",
+		 "name": "hipstershop.ShippingService/ShipOrder", "kind": 2,
+		 "startTimeUnixNano": "1020000000", "endTimeUnixNano": "1050000000", "status": {}}
+	]}]}]}
+{"resourceSpans": [{"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "paymentservice"}}]},
+	"scopeSpans": [{"spans": [
+		{"traceId": "AAAAAAAAAAAAAAAAAAAAAQ==", "spanId": "AAAAAAAAAAQ=", "parentSpanId": "AAAAAAAAAAI=",
+		 "name": "hipstershop.PaymentService/Charge",
+		 "startTimeUnixNano": 1060000000, "endTimeUnixNano": 1070000000, "status": {"code": "STATUS_CODE_ERROR"}}
+	]}]}]}
+`
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "traceanalysis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "jaeger.json"), []byte(jaegerExport), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "otlp.ndjson"), []byte(otlpExport), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	traces, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traces) != 1 || len(traces[0].Spans) != 4 {
+		t.Fatalf("Load() = %d traces, want 1 of 4 spans", len(traces))
+	}
+	type summary struct {
+		Service, Name, Parent string
+		Start                 int64 // in ms
+		Duration              time.Duration
+		Error                 bool
+	}
+	var got []summary
+	for _, s := range traces[0].Spans {
+		parent := ""
+		if s.parent != nil {
+			parent = s.parent.Name
+		}
+		got = append(got, summary{s.Service, s.Name, parent, s.Start.UnixNano() / 1e6, s.Duration, s.Error})
+	}
+	want := []summary{
+		{"frontend", "POST /cart/checkout", "", 1000, 100 * time.Millisecond, false},
+		{"checkoutservice", "hipstershop.CheckoutService/PlaceOrder", "POST /cart/checkout", 1010, 80 * time.Millisecond, true},
+		{"shippingservice", "hipstershop.ShippingService/ShipOrder", "hipstershop.CheckoutService/PlaceOrder", 1020, 30 * time.Millisecond, false},
+		{"paymentservice", "hipstershop.PaymentService/Charge", "hipstershop.CheckoutService/PlaceOrder", 1060, 10 * time.Millisecond, true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() spans:\n%+v\nwant:\n%+v", got, want)
+	}
+	if traces[0].Root != traces[0].Spans[0] {
+		t.Errorf("root is %s, want POST /cart/checkout", traces[0].Root.Name)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	for _, in := range []string{
+		`{"data": [`,
+		`{"resourceSpans": [{"scopeSpans": [{"spans": [{"traceId": "not an id"}]}]}]}`,
+		`{"resourceSpans": [{"scopeSpans": [{"spans": [{"startTimeUnixNano": "soon"}]}]}]}`,
+	} {
+		if _, err := Read(strings.NewReader(in)); err == nil {
+			t.Errorf("Read(%s) succeeded", in)
+		}
+	}
+}
+
+// span returns a span of trace "t" starting and ending at the given
+// milliseconds.
+func span(id, parent, service, name string, start, end int) *Span {
+	return &Span{
+		TraceID:  "t",
+		SpanID:   id,
+		ParentID: parent,
+		Service:  service,
+		Name:     name,
+		Start:    time.Unix(0, 0).Add(time.Duration(start) * time.Millisecond),
+		Duration: time.Duration(end-start) * time.Millisecond,
+	}
+}
+
+func TestCriticalPath(t *testing.T) {
+	traces := Assemble([]*Span{
+		span("r", "", "frontend", "GET /", 0, 100),
+		span("a", "r", "frontend", "A", 10, 40),
+		span("b", "r", "frontend", "B", 20, 90),
+		span("c", "r", "frontend", "C", 50, 60), // hidden by B
+		span("b1", "b", "backend", "B1", 30, 80),
+	})
+	self := make(map[operation]time.Duration)
+	criticalPath(traces[0].Root, self)
+	want := map[operation]time.Duration{
+		{"frontend", "GET /"}: 20 * time.Millisecond,
+		{"frontend", "A"}:     10 * time.Millisecond,
+		{"frontend", "B"}:     20 * time.Millisecond,
+		{"backend", "B1"}:     50 * time.Millisecond,
+	}
+	if !reflect.DeepEqual(self, want) {
+		t.Errorf("critical path = %v, want %v", self, want)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	var spans []*Span
+	for i := 1; i <= 100; i++ {
+		trace := string(rune('a'+i%26)) + strings.Repeat("x", i/26)
+		root := span("r", "", "frontend", "GET /", 0, 10+i)
+		call := span("c", "r", "frontend", "hipstershop.CartService/GetCart", 1, 5+i)
+		served := span("s", "c", "cartservice", "hipstershop.CartService/GetCart", 2, 4+i)
+		for _, s := range []*Span{root, call, served} {
+			s.TraceID = trace
+		}
+		served.Error = i%10 == 0
+		spans = append(spans, root, call, served)
+	}
+	r := Analyze(Assemble(spans), DefaultCriticalPaths)
+
+	if r.Traces != 100 || r.Spans != 300 {
+		t.Errorf("analyzed %d traces of %d spans, want 100 of 300", r.Traces, r.Spans)
+	}
+	wantServices := []ServiceLatency{
+		{"cartservice", Latency{Count: 100, Errors: 10, Mean: 52.5, P50: 52, P90: 92, P99: 101, Max: 102}},
+		{"frontend", Latency{Count: 100, Mean: 60.5, P50: 60, P90: 100, P99: 109, Max: 110}},
+	}
+	if !reflect.DeepEqual(r.Services, wantServices) {
+		t.Errorf("services = %+v, want %+v", r.Services, wantServices)
+	}
+	if len(r.Operations) != 3 || r.Operations[2].Operation != "hipstershop.CartService/GetCart" || r.Operations[2].Service != "frontend" {
+		t.Errorf("operations = %+v", r.Operations)
+	}
+	wantCounts := []SpanCounts{{Service: "frontend", Operation: "GET /", Traces: 100, Mean: 3, Min: 3, P50: 3, P90: 3, Max: 3}}
+	if !reflect.DeepEqual(r.SpanCounts, wantCounts) {
+		t.Errorf("span counts = %+v, want %+v", r.SpanCounts, wantCounts)
+	}
+	if len(r.CriticalPaths) != 1 {
+		t.Fatalf("critical paths = %+v, want the one of GET /", r.CriticalPaths)
+	}
+	share := 0.0
+	for _, s := range r.CriticalPaths[0].Segments {
+		share += s.Share
+	}
+	if p := r.CriticalPaths[0]; p.Operation != "GET /" || p.Count != 100 || math.Abs(share-1) > 1e-9 {
+		t.Errorf("critical path = %+v, want shares of GET / adding up to 1", p)
+	}
+	if s := r.CriticalPaths[0].Segments[0]; s.Service != "cartservice" || s.Mean != 52.5 {
+		t.Errorf("longest segment = %+v, want cartservice for 52.5ms", s)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	base := Report{Services: []ServiceLatency{
+		{"frontend", Latency{Count: 10, P50: 100, P99: 200}},
+		{"adservice", Latency{Count: 10, P50: 10, P99: 20}},
+	}}
+	other := Report{Services: []ServiceLatency{
+		{"frontend", Latency{Count: 5, P50: 110, P99: 150}},
+	}}
+	c := Compare(base, other)
+	if len(c.Services) != 2 {
+		t.Fatalf("compared %d services, want 2", len(c.Services))
+	}
+	if ad := c.Services[0]; ad.Service != "adservice" || ad.P50Change != nil {
+		t.Errorf("adservice = %+v, want no change as it is missing from other", ad)
+	}
+	fe := c.Services[1]
+	if fe.P50Change == nil || math.Abs(*fe.P50Change-0.1) > 1e-9 || math.Abs(*fe.P99Change+0.25) > 1e-9 {
+		t.Errorf("frontend = %+v, want p50 +10%% and p99 -25%%", fe)
+	}
+
+	var b bytes.Buffer
+	if err := WriteCSV(&b, c.Tables()[:1]); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "service,base_count,") || !strings.HasSuffix(lines[2], ",0.100,-0.250") {
+		t.Errorf("CSV =\n%s", b.String())
+	}
+}
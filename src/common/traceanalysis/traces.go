@@ -0,0 +1,175 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package traceanalysis reports the latency of the services from the spans
+// they exported, as Jaeger JSON or OTLP JSON.
+package traceanalysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Span is an operation of a service.
+type Span struct {
+	TraceID  string
+	SpanID   string
+	ParentID string // empty for root spans
+	Service  string
+	Name     string
+	Start    time.Time
+	Duration time.Duration
+	Error    bool
+
+	parent   *Span // nil if not in the trace
+	children []*Span
+}
+
+// End returns when s ended.
+func (s *Span) End() time.Time {
+	return s.Start.Add(s.Duration)
+}
+
+// entry reports whether s is where a call entered its service: it has no
+// parent in the trace, or its parent is in another service.
+func (s *Span) entry() bool {
+	return s.parent == nil || s.parent.Service != s.Service
+}
+
+// Trace is the spans of a request, across services.
+type Trace struct {
+	ID    string
+	Spans []*Span
+	Root  *Span // the earliest span without a parent in the trace
+}
+
+// document holds the spans of a Jaeger or OTLP JSON export, whichever it is.
+type document struct {
+	Data          []jaegerTrace       `json:"data"`
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+// Read returns the spans in r, a sequence of JSON documents that are Jaeger
+// exports, such as those of the Jaeger UI and API, or OTLP export requests,
+// such as those written by the file exporter of the services.
+func Read(r io.Reader) ([]*Span, error) {
+	var spans []*Span
+	dec := json.NewDecoder(r)
+	for {
+		var doc document
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			return spans, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range doc.Data {
+			spans = append(spans, t.spans()...)
+		}
+		for _, rs := range doc.ResourceSpans {
+			s, err := rs.spans()
+			if err != nil {
+				return nil, err
+			}
+			spans = append(spans, s...)
+		}
+	}
+}
+
+// Load returns the traces of the spans in the files at paths. The files in a
+// directory are all read.
+func Load(paths ...string) ([]*Trace, error) {
+	var spans []*Span
+	for _, path := range paths {
+		files := []string{path}
+		if fi, err := os.Stat(path); err != nil {
+			return nil, err
+		} else if fi.IsDir() {
+			entries, err := ioutil.ReadDir(path)
+			if err != nil {
+				return nil, err
+			}
+			files = files[:0]
+			for _, e := range entries {
+				if !e.IsDir() {
+					files = append(files, filepath.Join(path, e.Name()))
+				}
+			}
+		}
+		for _, file := range files {
+			s, err := readFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read spans from %s: %v", file, err)
+			}
+			spans = append(spans, s...)
+		}
+	}
+	return Assemble(spans), nil
+}
+
+func readFile(path string) ([]*Span, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Assemble groups spans into traces, sorted by ID, linking each span to its
+// parent. Spans exported twice are only kept once.
+func Assemble(spans []*Span) []*Trace {
+	byTrace := make(map[string]map[string]*Span)
+	for _, s := range spans {
+		t := byTrace[s.TraceID]
+		if t == nil {
+			t = make(map[string]*Span)
+			byTrace[s.TraceID] = t
+		}
+		t[s.SpanID] = s
+	}
+
+	traces := make([]*Trace, 0, len(byTrace))
+	for id, byID := range byTrace {
+		t := &Trace{ID: id}
+		for _, s := range byID {
+			s.parent, s.children = nil, nil
+			t.Spans = append(t.Spans, s)
+		}
+		sort.Slice(t.Spans, func(i, j int) bool {
+			if !t.Spans[i].Start.Equal(t.Spans[j].Start) {
+				return t.Spans[i].Start.Before(t.Spans[j].Start)
+			}
+			return t.Spans[i].SpanID < t.Spans[j].SpanID
+		})
+		for _, s := range t.Spans {
+			if p, ok := byID[s.ParentID]; ok && s.ParentID != "" {
+				s.parent = p
+				p.children = append(p.children, s)
+			} else if t.Root == nil {
+				t.Root = s
+			}
+		}
+		traces = append(traces, t)
+	}
+	sort.Slice(traces, func(i, j int) bool { return traces[i].ID < traces[j].ID })
+	return traces
+}
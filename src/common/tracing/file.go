@@ -0,0 +1,274 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// fileExporter writes spans to a file as newline-delimited OTLP JSON: each
+// batch of spans is a line holding an ExportTraceServiceRequest, which the
+// OpenTelemetry collector and tools reading OTLP JSON can load.
+type fileExporter struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// newFileExporter returns an exporter appending spans to the file at path.
+func newFileExporter(path string) (*fileExporter, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileExporter{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (e *fileExporter) ExportSpans(ctx context.Context, spans []tracesdk.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	req := otlpRequest(spans)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.f == nil {
+		return nil
+	}
+	return e.enc.Encode(req)
+}
+
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.f == nil {
+		return nil
+	}
+	err := e.f.Close()
+	e.f = nil
+	return err
+}
+
+// The types below are the JSON mapping of the OTLP trace protos.
+
+type otlpExportRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource                    otlpResource                      `json:"resource"`
+	InstrumentationLibrarySpans []otlpInstrumentationLibrarySpans `json:"instrumentationLibrarySpans"`
+	SchemaURL                   string                            `json:"schemaUrl,omitempty"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpInstrumentationLibrarySpans struct {
+	InstrumentationLibrary otlpInstrumentationLibrary `json:"instrumentationLibrary"`
+	Spans                  []otlpSpan                 `json:"spans"`
+}
+
+type otlpInstrumentationLibrary struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Links             []otlpLink     `json:"links,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpLink struct {
+	TraceID    string         `json:"traceId"`
+	SpanID     string         `json:"spanId"`
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+// OTLP status codes.
+const (
+	otlpStatusUnset = 0
+	otlpStatusOK    = 1
+	otlpStatusError = 2
+)
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// otlpValue is an AnyValue, of which one field is set.
+type otlpValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"` // int64 are strings in JSON
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpValue `json:"values"`
+}
+
+// otlpRequest converts spans to an OTLP export request, grouping them by
+// resource and instrumentation library.
+func otlpRequest(spans []tracesdk.ReadOnlySpan) otlpExportRequest {
+	var req otlpExportRequest
+	resources := make(map[*resource.Resource]int)                             // index in req.ResourceSpans
+	libraries := make(map[*resource.Resource]map[instrumentation.Library]int) // index in InstrumentationLibrarySpans
+	for _, s := range spans {
+		res := s.Resource()
+		ri, ok := resources[res]
+		if !ok {
+			ri = len(req.ResourceSpans)
+			resources[res] = ri
+			libraries[res] = make(map[instrumentation.Library]int)
+			rs := otlpResourceSpans{}
+			if res != nil {
+				rs.Resource.Attributes = otlpAttributes(res.Attributes())
+				rs.SchemaURL = res.SchemaURL()
+			}
+			req.ResourceSpans = append(req.ResourceSpans, rs)
+		}
+		rs := &req.ResourceSpans[ri]
+		lib := s.InstrumentationLibrary()
+		li, ok := libraries[res][lib]
+		if !ok {
+			li = len(rs.InstrumentationLibrarySpans)
+			libraries[res][lib] = li
+			rs.InstrumentationLibrarySpans = append(rs.InstrumentationLibrarySpans, otlpInstrumentationLibrarySpans{
+				InstrumentationLibrary: otlpInstrumentationLibrary{Name: lib.Name, Version: lib.Version},
+			})
+		}
+		ils := &rs.InstrumentationLibrarySpans[li]
+		ils.Spans = append(ils.Spans, otlpSpanOf(s))
+	}
+	return req
+}
+
+func otlpSpanOf(s tracesdk.ReadOnlySpan) otlpSpan {
+	sc := s.SpanContext()
+	span := otlpSpan{
+		TraceID:           sc.TraceID().String(),
+		SpanID:            sc.SpanID().String(),
+		Name:              s.Name(),
+		Kind:              int(s.SpanKind()), // the values of the OTLP SpanKind
+		StartTimeUnixNano: strconv.FormatInt(s.StartTime().UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.EndTime().UnixNano(), 10),
+		Attributes:        otlpAttributes(s.Attributes()),
+	}
+	if p := s.Parent(); p.HasSpanID() {
+		span.ParentSpanID = p.SpanID().String()
+	}
+	for _, e := range s.Events() {
+		span.Events = append(span.Events, otlpEvent{
+			TimeUnixNano: strconv.FormatInt(e.Time.UnixNano(), 10),
+			Name:         e.Name,
+			Attributes:   otlpAttributes(e.Attributes),
+		})
+	}
+	for _, l := range s.Links() {
+		span.Links = append(span.Links, otlpLink{
+			TraceID:    l.SpanContext.TraceID().String(),
+			SpanID:     l.SpanContext.SpanID().String(),
+			Attributes: otlpAttributes(l.Attributes),
+		})
+	}
+	switch st := s.Status(); st.Code {
+	case codes.Ok:
+		span.Status.Code = otlpStatusOK
+	case codes.Error:
+		span.Status = otlpStatus{Code: otlpStatusError, Message: st.Description}
+	default:
+		span.Status.Code = otlpStatusUnset
+	}
+	return span
+}
+
+func otlpAttributes(attrs []attribute.KeyValue) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	kvs := make([]otlpKeyValue, len(attrs))
+	for i, kv := range attrs {
+		kvs[i] = otlpKeyValue{Key: string(kv.Key), Value: otlpValueOf(kv.Value)}
+	}
+	return kvs
+}
+
+func otlpValueOf(v attribute.Value) otlpValue {
+	switch v.Type() {
+	case attribute.BOOL:
+		b := v.AsBool()
+		return otlpValue{BoolValue: &b}
+	case attribute.INT64:
+		s := strconv.FormatInt(v.AsInt64(), 10)
+		return otlpValue{IntValue: &s}
+	case attribute.FLOAT64:
+		f := v.AsFloat64()
+		return otlpValue{DoubleValue: &f}
+	case attribute.ARRAY:
+		// Arrays hold bools, ints, int64s, float64s or strings.
+		arr := reflect.ValueOf(v.AsArray())
+		a := otlpArrayValue{Values: make([]otlpValue, arr.Len())}
+		for i := range a.Values {
+			switch e := arr.Index(i); e.Kind() {
+			case reflect.Bool:
+				a.Values[i] = otlpValueOf(attribute.BoolValue(e.Bool()))
+			case reflect.Int, reflect.Int64:
+				a.Values[i] = otlpValueOf(attribute.Int64Value(e.Int()))
+			case reflect.Float64:
+				a.Values[i] = otlpValueOf(attribute.Float64Value(e.Float()))
+			default:
+				a.Values[i] = otlpValueOf(attribute.StringValue(e.String()))
+			}
+		}
+		return otlpValue{ArrayValue: &a}
+	default:
+		s := v.Emit()
+		return otlpValue{StringValue: &s}
+	}
+}
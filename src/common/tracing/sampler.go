@@ -0,0 +1,415 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Samplers, as named in TRACES_SAMPLER and sampling strategies.
+const (
+	AlwaysSample = "always"
+	NeverSample  = "never"
+	Ratio        = "ratio"        // the fraction Ratio of the traces
+	RateLimited  = "rate_limited" // at most TracesPerSecond traces a second
+)
+
+// defaultPollInterval is how often a sampling strategy is reloaded if
+// TRACES_SAMPLING_POLL_INTERVAL is unset.
+const defaultPollInterval = 30 * time.Second
+
+// SamplerConfig configures the sampling decision for new traces.
+type SamplerConfig struct {
+	Type            string  `json:"type"`
+	Ratio           float64 `json:"ratio,omitempty"`
+	TracesPerSecond float64 `json:"traces_per_second,omitempty"`
+}
+
+func (c SamplerConfig) validate() error {
+	switch c.Type {
+	case AlwaysSample, NeverSample:
+	case Ratio:
+		if c.Ratio < 0 || c.Ratio > 1 {
+			return fmt.Errorf("ratio must be between 0 and 1")
+		}
+	case RateLimited:
+		if c.TracesPerSecond <= 0 {
+			return fmt.Errorf("traces_per_second must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown sampler %q", c.Type)
+	}
+	return nil
+}
+
+// sampler returns the sampler c configures.
+func (c SamplerConfig) sampler() tracesdk.Sampler {
+	switch c.Type {
+	case NeverSample:
+		return tracesdk.NeverSample()
+	case Ratio:
+		return tracesdk.TraceIDRatioBased(c.Ratio)
+	case RateLimited:
+		return newRateLimitedSampler(c.TracesPerSecond)
+	default:
+		return tracesdk.AlwaysSample()
+	}
+}
+
+// SamplingRule samples the traces starting with some spans differently.
+type SamplingRule struct {
+	// Span is the name of the root spans the rule applies to, such as
+	// "hipstershop.CheckoutService/PlaceOrder" for an RPC or
+	// "POST /cart/checkout" for a route of the frontend. A trailing "*"
+	// matches any suffix.
+	Span    string        `json:"span"`
+	Sampler SamplerConfig `json:"sampler"`
+}
+
+func (r SamplingRule) matches(name string) bool {
+	if strings.HasSuffix(r.Span, "*") {
+		return strings.HasPrefix(name, strings.TrimSuffix(r.Span, "*"))
+	}
+	return r.Span == name
+}
+
+// Strategy decides which traces are sampled. Spans with a parent follow the
+// decision taken for it, so the service starting a trace samples it for all
+// services.
+type Strategy struct {
+	// Default samples the traces that no rule matches.
+	Default SamplerConfig `json:"default"`
+
+	// Rules are tried in order, and the first one matching the root span of
+	// a trace samples it.
+	Rules []SamplingRule `json:"rules,omitempty"`
+
+	// SampleErrors exports the spans ending with an error even in traces
+	// that are not sampled. These spans are recorded, at some cost, but
+	// only exported if they fail.
+	SampleErrors bool `json:"sample_errors,omitempty"`
+}
+
+func (s Strategy) validate() error {
+	if err := s.Default.validate(); err != nil {
+		return err
+	}
+	for i, r := range s.Rules {
+		if r.Span == "" {
+			return fmt.Errorf("rule %d has no span", i)
+		}
+		if err := r.Sampler.validate(); err != nil {
+			return fmt.Errorf("rule %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// strategySampler samples as its strategy.
+type strategySampler struct {
+	strategy Strategy
+	fallback tracesdk.Sampler
+	rules    []tracesdk.Sampler // of strategy.Rules
+}
+
+func newStrategySampler(s Strategy) *strategySampler {
+	ss := &strategySampler{strategy: s, fallback: s.Default.sampler()}
+	for _, r := range s.Rules {
+		ss.rules = append(ss.rules, r.Sampler.sampler())
+	}
+	return ss
+}
+
+func (s *strategySampler) ShouldSample(p tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	psc := trace.SpanContextFromContext(p.ParentContext)
+	var res tracesdk.SamplingResult
+	switch {
+	case psc.IsSampled():
+		res = tracesdk.SamplingResult{Decision: tracesdk.RecordAndSample, Tracestate: psc.TraceState()}
+	case psc.IsValid():
+		res = tracesdk.SamplingResult{Decision: tracesdk.Drop, Tracestate: psc.TraceState()}
+	default:
+		sampler := s.fallback
+		for i, r := range s.strategy.Rules {
+			if r.matches(p.Name) {
+				sampler = s.rules[i]
+				break
+			}
+		}
+		res = sampler.ShouldSample(p)
+	}
+	if res.Decision == tracesdk.Drop && s.strategy.SampleErrors {
+		res.Decision = tracesdk.RecordOnly
+	}
+	return res
+}
+
+func (s *strategySampler) Description() string {
+	b, _ := json.Marshal(s.strategy)
+	return "Strategy" + string(b)
+}
+
+// rateLimitedSampler samples at most a number of traces a second, allowing
+// bursts of as many.
+type rateLimitedSampler struct {
+	perSecond float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newRateLimitedSampler(perSecond float64) *rateLimitedSampler {
+	return &rateLimitedSampler{perSecond: perSecond, tokens: math.Max(perSecond, 1), now: time.Now}
+}
+
+func (s *rateLimitedSampler) ShouldSample(p tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	res := tracesdk.SamplingResult{
+		Decision:   tracesdk.Drop,
+		Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if !s.last.IsZero() {
+		s.tokens = math.Min(s.tokens+now.Sub(s.last).Seconds()*s.perSecond, math.Max(s.perSecond, 1))
+	}
+	s.last = now
+	if s.tokens >= 1 {
+		s.tokens--
+		res.Decision = tracesdk.RecordAndSample
+	}
+	return res
+}
+
+func (s *rateLimitedSampler) Description() string {
+	return fmt.Sprintf("RateLimited{%g}", s.perSecond)
+}
+
+// dynamicSampler samples as a strategy that changes at runtime.
+type dynamicSampler struct {
+	mu      sync.RWMutex
+	current *strategySampler
+}
+
+func (d *dynamicSampler) ShouldSample(p tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	d.mu.RLock()
+	s := d.current
+	d.mu.RUnlock()
+	return s.ShouldSample(p)
+}
+
+func (d *dynamicSampler) Description() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return "Dynamic" + d.current.Description()
+}
+
+// update switches to strategy s, and reports whether it differs from the
+// current one.
+func (d *dynamicSampler) update(s Strategy) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.current != nil && reflect.DeepEqual(d.current.strategy, s) {
+		return false
+	}
+	d.current = newStrategySampler(s)
+	return true
+}
+
+// loadStrategy reads the JSON strategy at src, a file path or an HTTP URL.
+func loadStrategy(src string) (Strategy, error) {
+	var b []byte
+	var err error
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		b, err = fetch(src)
+	} else {
+		b, err = ioutil.ReadFile(src)
+	}
+	if err != nil {
+		return Strategy{}, err
+	}
+	var s Strategy
+	if err := json.Unmarshal(b, &s); err != nil {
+		return Strategy{}, fmt.Errorf("failed to parse sampling strategy %s: %v", src, err)
+	}
+	if err := s.validate(); err != nil {
+		return Strategy{}, fmt.Errorf("invalid sampling strategy %s: %v", src, err)
+	}
+	return s, nil
+}
+
+func fetch(url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, res.Status)
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
+// watchStrategy reloads the strategy of d from src every interval. The
+// strategy is kept if it cannot be loaded.
+func watchStrategy(d *dynamicSampler, src string, interval time.Duration, log logrus.FieldLogger) {
+	for range time.Tick(interval) {
+		s, err := loadStrategy(src)
+		if err != nil {
+			log.Warnf("failed to reload the sampling strategy, keeping the current one: %+v", err)
+			continue
+		}
+		if d.update(s) {
+			log.Infof("sampling strategy updated from %q", src)
+		}
+	}
+}
+
+// newSampler returns the sampler configured by the environment:
+//
+//   - TRACES_SAMPLING_STRATEGY is the path or HTTP URL of a JSON Strategy,
+//     reloaded every TRACES_SAMPLING_POLL_INTERVAL.
+//   - Otherwise TRACES_SAMPLER is the sampler of all traces: always (the
+//     default), never, ratio of TRACES_SAMPLING_FRACTION or rate_limited to
+//     TRACES_SAMPLING_RATE traces a second. Setting TRACES_SAMPLING_FRACTION
+//     alone samples that ratio. TRACES_SAMPLE_ERRORS exports failed spans
+//     of the traces that are not sampled.
+func newSampler(log logrus.FieldLogger) (tracesdk.Sampler, error) {
+	if src := os.Getenv("TRACES_SAMPLING_STRATEGY"); src != "" {
+		interval := defaultPollInterval
+		if v := os.Getenv("TRACES_SAMPLING_POLL_INTERVAL"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid TRACES_SAMPLING_POLL_INTERVAL %q", v)
+			}
+			interval = d
+		}
+		s, err := loadStrategy(src)
+		if err != nil {
+			return nil, err
+		}
+		log.Infof("sampling as the strategy at %q, reloaded every %v", src, interval)
+		d := new(dynamicSampler)
+		d.update(s)
+		go watchStrategy(d, src, interval, log)
+		return d, nil
+	}
+
+	s, err := strategyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if s.SampleErrors {
+		log.Infof("sampling with %s, and the spans that fail", s.Default.Type)
+		return newStrategySampler(s), nil
+	}
+	// see https://pkg.go.dev/go.opentelemetry.io/otel/sdk/trace#ParentBased
+	sampler := tracesdk.ParentBased(s.Default.sampler())
+	log.Infof("sampling with %s", sampler.Description())
+	return sampler, nil
+}
+
+// strategyFromEnv returns the strategy without rules set by TRACES_SAMPLER
+// and its parameters.
+func strategyFromEnv() (Strategy, error) {
+	var s Strategy
+	fraction := os.Getenv("TRACES_SAMPLING_FRACTION")
+	s.Default.Type = os.Getenv("TRACES_SAMPLER")
+	if s.Default.Type == "" {
+		s.Default.Type = AlwaysSample
+		if fraction != "" {
+			s.Default.Type = Ratio
+		}
+	}
+	switch s.Default.Type {
+	case Ratio:
+		f, err := strconv.ParseFloat(fraction, 64)
+		if err != nil {
+			return Strategy{}, fmt.Errorf("failed to parse TRACES_SAMPLING_FRACTION (%s): %+v", fraction, err)
+		}
+		s.Default.Ratio = f
+	case RateLimited:
+		v := os.Getenv("TRACES_SAMPLING_RATE")
+		r, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return Strategy{}, fmt.Errorf("failed to parse TRACES_SAMPLING_RATE (%s): %+v", v, err)
+		}
+		s.Default.TracesPerSecond = r
+	}
+	if v := os.Getenv("TRACES_SAMPLE_ERRORS"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Strategy{}, fmt.Errorf("failed to parse TRACES_SAMPLE_ERRORS (%s): %+v", v, err)
+		}
+		s.SampleErrors = b
+	}
+	if err := s.validate(); err != nil {
+		return Strategy{}, errors.New("invalid TRACES_SAMPLER: " + err.Error())
+	}
+	return s, nil
+}
+
+// errorSpans passes the spans that end with an error but are not sampled to
+// its processors, as if they were sampled.
+type errorSpans []tracesdk.SpanProcessor
+
+func (p errorSpans) OnStart(context.Context, tracesdk.ReadWriteSpan) {}
+
+func (p errorSpans) OnEnd(s tracesdk.ReadOnlySpan) {
+	if s.SpanContext().IsSampled() || s.Status().Code != codes.Error {
+		return
+	}
+	for _, sp := range p {
+		sp.OnEnd(sampledSpan{s})
+	}
+}
+
+func (p errorSpans) Shutdown(context.Context) error   { return nil }
+func (p errorSpans) ForceFlush(context.Context) error { return nil }
+
+// sampledSpan is a span flagged as sampled.
+type sampledSpan struct {
+	tracesdk.ReadOnlySpan
+}
+
+func (s sampledSpan) SpanContext() trace.SpanContext {
+	sc := s.ReadOnlySpan.SpanContext()
+	return sc.WithTraceFlags(sc.TraceFlags().WithSampled(true))
+}
@@ -0,0 +1,240 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recorder is an exporter keeping the names of the spans it exports.
+type recorder struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (r *recorder) ExportSpans(_ context.Context, spans []tracesdk.ReadOnlySpan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range spans {
+		r.names = append(r.names, s.Name())
+	}
+	return nil
+}
+
+func (r *recorder) Shutdown(context.Context) error { return nil }
+
+func (r *recorder) exported() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.names...)
+}
+
+// newTestProvider returns a provider sampling with s and exporting to r as
+// the services do.
+func newTestProvider(s tracesdk.Sampler, r *recorder) *tracesdk.TracerProvider {
+	ssp := tracesdk.NewSimpleSpanProcessor(r)
+	return tracesdk.NewTracerProvider(
+		tracesdk.WithSampler(s),
+		tracesdk.WithSpanProcessor(ssp),
+		tracesdk.WithSpanProcessor(errorSpans{ssp}))
+}
+
+func TestStrategySampler(t *testing.T) {
+	s := newStrategySampler(Strategy{
+		Default: SamplerConfig{Type: Ratio, Ratio: 0},
+		Rules: []SamplingRule{
+			{Span: "hipstershop.CheckoutService/PlaceOrder", Sampler: SamplerConfig{Type: AlwaysSample}},
+			{Span: "GET /product/*", Sampler: SamplerConfig{Type: AlwaysSample}},
+			{Span: "GET *", Sampler: SamplerConfig{Type: NeverSample}},
+		},
+	})
+	tracer := tracesdk.NewTracerProvider(tracesdk.WithSampler(s)).Tracer("test")
+	for _, tt := range []struct {
+		name string
+		want bool
+	}{
+		{"hipstershop.CheckoutService/PlaceOrder", true},
+		{"hipstershop.CheckoutService/PreviewOrder", false},
+		{"GET /product/{id}", true},
+		{"GET /cart", false},
+	} {
+		ctx, span := tracer.Start(context.Background(), tt.name)
+		if got := span.SpanContext().IsSampled(); got != tt.want {
+			t.Errorf("%s sampled = %v, want %v", tt.name, got, tt.want)
+		}
+		// Children follow their root, whatever the rules say of them.
+		_, child := tracer.Start(ctx, "GET /cart")
+		if got := child.SpanContext().IsSampled(); got != tt.want {
+			t.Errorf("child of %s sampled = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	remote := trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+	if _, span := tracer.Start(remote, "hipstershop.CheckoutService/PreviewOrder"); !span.SpanContext().IsSampled() {
+		t.Error("the child of a sampled remote span is not sampled")
+	}
+}
+
+func TestRateLimitedSampler(t *testing.T) {
+	s := newRateLimitedSampler(2)
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	sampled := func() int {
+		n := 0
+		for i := 0; i < 10; i++ {
+			if s.ShouldSample(tracesdk.SamplingParameters{ParentContext: context.Background()}).Decision == tracesdk.RecordAndSample {
+				n++
+			}
+		}
+		return n
+	}
+
+	if n := sampled(); n != 2 {
+		t.Errorf("sampled %d traces at once, want a burst of 2", n)
+	}
+	now = now.Add(250 * time.Millisecond)
+	if n := sampled(); n != 0 {
+		t.Errorf("sampled %d traces after 250ms, want 0", n)
+	}
+	now = now.Add(250 * time.Millisecond)
+	if n := sampled(); n != 1 {
+		t.Errorf("sampled %d traces after 500ms, want 1", n)
+	}
+	now = now.Add(time.Hour)
+	if n := sampled(); n != 2 {
+		t.Errorf("sampled %d traces after an hour, want a burst of 2", n)
+	}
+}
+
+func TestSampleErrors(t *testing.T) {
+	var r recorder
+	tp := newTestProvider(newStrategySampler(Strategy{Default: SamplerConfig{Type: NeverSample}, SampleErrors: true}), &r)
+	tracer := tp.Tracer("test")
+
+	ctx, root := tracer.Start(context.Background(), "PlaceOrder")
+	_, ok := tracer.Start(ctx, "GetQuote")
+	ok.End()
+	_, failed := tracer.Start(ctx, "Charge")
+	failed.SetStatus(codes.Error, "declined")
+	failed.End()
+	root.End()
+
+	if got := r.exported(); len(got) != 1 || got[0] != "Charge" {
+		t.Errorf("exported %v, want only the failed span", got)
+	}
+
+	// Without SampleErrors, no span of the trace is recorded.
+	r = recorder{}
+	tp = newTestProvider(newStrategySampler(Strategy{Default: SamplerConfig{Type: NeverSample}}), &r)
+	_, failed = tp.Tracer("test").Start(context.Background(), "Charge")
+	failed.SetStatus(codes.Error, "declined")
+	failed.End()
+	if got := r.exported(); len(got) != 0 || failed.IsRecording() {
+		t.Errorf("exported %v, want nothing", got)
+	}
+}
+
+func TestRemoteStrategy(t *testing.T) {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	var strategy atomic.Value
+	strategy.Store(`{"default": {"type": "never"}}`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strategy.Load().(string)))
+	}))
+	defer srv.Close()
+	setenv(t, map[string]string{
+		"TRACES_SAMPLING_STRATEGY":      srv.URL,
+		"TRACES_SAMPLING_POLL_INTERVAL": "10ms",
+	})
+
+	s, err := newSampler(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := tracesdk.NewTracerProvider(tracesdk.WithSampler(s)).Tracer("test")
+	if _, span := tracer.Start(context.Background(), "GET /"); span.SpanContext().IsSampled() {
+		t.Error("sampled a trace with the never strategy")
+	}
+
+	// An invalid strategy is ignored.
+	strategy.Store(`{"default": {"type": "sometimes"}}`)
+	time.Sleep(50 * time.Millisecond)
+	strategy.Store(`{"default": {"type": "never"}, "rules": [{"span": "GET /", "sampler": {"type": "always"}}]}`)
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(s.Description(), "rules") {
+		if time.Now().After(deadline) {
+			t.Fatalf("strategy not updated: %s", s.Description())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, span := tracer.Start(context.Background(), "GET /"); !span.SpanContext().IsSampled() {
+		t.Error("the updated strategy did not sample GET /")
+	}
+}
+
+func TestStrategyFromEnv(t *testing.T) {
+	for _, tt := range []struct {
+		env  map[string]string
+		want Strategy
+	}{
+		{map[string]string{}, Strategy{Default: SamplerConfig{Type: AlwaysSample}}},
+		{map[string]string{"TRACES_SAMPLING_FRACTION": "0.1"}, Strategy{Default: SamplerConfig{Type: Ratio, Ratio: 0.1}}},
+		{map[string]string{"TRACES_SAMPLER": "rate_limited", "TRACES_SAMPLING_RATE": "5", "TRACES_SAMPLE_ERRORS": "true"},
+			Strategy{Default: SamplerConfig{Type: RateLimited, TracesPerSecond: 5}, SampleErrors: true}},
+	} {
+		t.Run("", func(t *testing.T) {
+			setenv(t, map[string]string{"TRACES_SAMPLER": "", "TRACES_SAMPLING_FRACTION": "", "TRACES_SAMPLING_RATE": "", "TRACES_SAMPLE_ERRORS": ""})
+			setenv(t, tt.env)
+			got, err := strategyFromEnv()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Default != tt.want.Default || got.SampleErrors != tt.want.SampleErrors {
+				t.Errorf("strategyFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+	for _, env := range []map[string]string{
+		{"TRACES_SAMPLER": "sometimes"},
+		{"TRACES_SAMPLER": "ratio", "TRACES_SAMPLING_FRACTION": "2"},
+		{"TRACES_SAMPLER": "rate_limited", "TRACES_SAMPLING_RATE": "0"},
+	} {
+		t.Run("", func(t *testing.T) {
+			setenv(t, env)
+			if _, err := strategyFromEnv(); err == nil {
+				t.Errorf("strategyFromEnv() accepted %v", env)
+			}
+		})
+	}
+}
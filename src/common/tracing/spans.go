@@ -0,0 +1,118 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+// Attributes describing the orders, carts and payments of the spans of the
+// services.
+const (
+	OrderIDKey            = attribute.Key("order.id")
+	CartItemsKey          = attribute.Key("cart.items") // the quantity of items in the cart
+	MoneyCurrencyKey      = attribute.Key("money.currency")
+	MoneyAmountKey        = attribute.Key("money.amount")
+	ShippingTrackingIDKey = attribute.Key("shipping.tracking_id")
+	UserIDKey             = attribute.Key("enduser.id") // the session ID
+	FallbackKey           = attribute.Key("fallback")   // what was done instead
+	ErrorMessageKey       = attribute.Key("error.message")
+
+	// Personal data, recorded only if TRACES_RECORD_PII is true.
+	EmailKey = attribute.Key("enduser.email")
+)
+
+// piiKeys are the attributes holding personal data.
+var piiKeys = map[attribute.Key]bool{
+	EmailKey: true,
+}
+
+// recordPII is 1 if the attributes in piiKeys are recorded.
+var recordPII int32
+
+// setRecordPII sets whether the attributes holding personal data are
+// recorded.
+func setRecordPII(record bool) {
+	var v int32
+	if record {
+		v = 1
+	}
+	atomic.StoreInt32(&recordPII, v)
+}
+
+// Attributes returns kvs less those holding personal data, such as
+// EmailKey, unless TRACES_RECORD_PII is true.
+func Attributes(kvs ...attribute.KeyValue) []attribute.KeyValue {
+	if atomic.LoadInt32(&recordPII) == 1 {
+		return kvs
+	}
+	out := kvs[:0:0]
+	for _, kv := range kvs {
+		if !piiKeys[kv.Key] {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
+
+// Money returns the attributes of the amount m.
+func Money(m *pb.Money) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		MoneyCurrencyKey.String(m.GetCurrencyCode()),
+		MoneyAmountKey.Float64(float64(m.GetUnits()) + float64(m.GetNanos())/1e9),
+	}
+}
+
+// SetAttributes sets Attributes(kvs...) on the span of ctx.
+func SetAttributes(ctx context.Context, kvs ...attribute.KeyValue) {
+	if kvs = Attributes(kvs...); len(kvs) > 0 {
+		trace.SpanFromContext(ctx).SetAttributes(kvs...)
+	}
+}
+
+// AddEvent adds the event name with Attributes(kvs...) to the span of ctx.
+func AddEvent(ctx context.Context, name string, kvs ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).AddEvent(name, trace.WithAttributes(Attributes(kvs...)...))
+}
+
+// Fallback records on the span of ctx that fallback was done instead of an
+// operation that failed with err.
+func Fallback(ctx context.Context, fallback string, err error) {
+	kvs := []attribute.KeyValue{FallbackKey.String(fallback)}
+	if err != nil {
+		kvs = append(kvs, ErrorMessageKey.String(err.Error()))
+	}
+	AddEvent(ctx, "fallback", kvs...)
+}
+
+// Run calls fn with ctx holding a child span named name, which has the
+// attributes Attributes(kvs...) and ends with the error returned by fn.
+func Run(ctx context.Context, tracer trace.Tracer, name string, fn func(ctx context.Context) error, kvs ...attribute.KeyValue) error {
+	ctx, span := tracer.Start(ctx, name, trace.WithAttributes(Attributes(kvs...)...))
+	defer span.End()
+	err := fn(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
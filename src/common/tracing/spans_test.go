@@ -0,0 +1,85 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+func TestRun(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := tracesdk.NewTracerProvider(tracesdk.WithSyncer(exporter)).Tracer("test")
+	ctx, parent := tracer.Start(context.Background(), "PlaceOrder")
+
+	errCharge := errors.New("card declined")
+	err := Run(ctx, tracer, "charge", func(ctx context.Context) error {
+		SetAttributes(ctx, Money(&pb.Money{CurrencyCode: "EUR", Units: 12, Nanos: 500000000})...)
+		Fallback(ctx, "review", errors.New("scorer unavailable"))
+		return errCharge
+	}, OrderIDKey.String("o1"), EmailKey.String("someone@example.com"))
+	if err != errCharge {
+		t.Errorf("Run() = %v, want the error of fn", err)
+	}
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(spans))
+	}
+	s := spans[0]
+	if s.Name != "charge" || s.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("span %s is not the child of PlaceOrder", s.Name)
+	}
+	wantAttrs := []attribute.KeyValue{
+		OrderIDKey.String("o1"),
+		MoneyCurrencyKey.String("EUR"),
+		MoneyAmountKey.Float64(12.5),
+	}
+	if !reflect.DeepEqual(s.Attributes, wantAttrs) {
+		t.Errorf("attributes = %v, want %v without the email", s.Attributes, wantAttrs)
+	}
+	if s.Status.Code != codes.Error || s.Status.Description != "card declined" {
+		t.Errorf("status = %+v, want the error", s.Status)
+	}
+	var events []string
+	for _, e := range s.Events {
+		events = append(events, e.Name)
+	}
+	if !reflect.DeepEqual(events, []string{"fallback", "exception"}) {
+		t.Errorf("events = %v, want the fallback and the error", events)
+	}
+}
+
+func TestAttributesRecordPII(t *testing.T) {
+	kvs := []attribute.KeyValue{UserIDKey.String("u1"), EmailKey.String("someone@example.com")}
+	if got := Attributes(kvs...); !reflect.DeepEqual(got, kvs[:1]) {
+		t.Errorf("Attributes() = %v, want the email left out", got)
+	}
+	setRecordPII(true)
+	defer setRecordPII(false)
+	if got := Attributes(kvs...); !reflect.DeepEqual(got, kvs) {
+		t.Errorf("Attributes() = %v with TRACES_RECORD_PII, want all of them", got)
+	}
+}
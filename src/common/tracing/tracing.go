@@ -0,0 +1,234 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing sets up OpenTelemetry tracing for the Go services.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// Enabled reports whether tracing is on, that is DISABLE_TRACING is unset.
+func Enabled() bool {
+	return os.Getenv("DISABLE_TRACING") == ""
+}
+
+// Exporters, as listed in TRACES_EXPORTER.
+const (
+	Jaeger   = "jaeger"    // to the Jaeger agent at JAEGER_SERVICE_ADDR
+	OTLPGRPC = "otlp-grpc" // to OTEL_EXPORTER_OTLP_ENDPOINT, over gRPC
+	OTLPHTTP = "otlp-http" // to OTEL_EXPORTER_OTLP_ENDPOINT, over HTTP
+	Stdout   = "stdout"    // as JSON, next to the logs
+	File     = "file"      // as newline-delimited OTLP JSON, to TRACES_FILE
+)
+
+// defaultJaegerAgentPort is the port of the Jaeger agent if
+// JAEGER_SERVICE_ADDR has none.
+const defaultJaegerAgentPort = "6831"
+
+var (
+	providerMu sync.Mutex
+	provider   *tracesdk.TracerProvider // installed by Init
+)
+
+// Init installs the global tracer provider of the service, and the W3C trace
+// context and baggage propagators. Spans are exported to each of the
+// exporters in the comma-separated TRACES_EXPORTER, which defaults to jaeger.
+// Call Shutdown before exiting to flush them. Attributes holding personal
+// data are only recorded if TRACES_RECORD_PII is true.
+func Init(serviceName string, log logrus.FieldLogger) (*tracesdk.TracerProvider, error) {
+	if v := os.Getenv("TRACES_RECORD_PII"); v != "" {
+		record, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse TRACES_RECORD_PII (%s): %+v", v, err)
+		}
+		if record {
+			log.Warn("recording personal data in spans")
+		}
+		setRecordPII(record)
+	}
+	tp, err := newTracerProvider(serviceName, log)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	providerMu.Lock()
+	provider = tp
+	providerMu.Unlock()
+	return tp, nil
+}
+
+// Shutdown exports the spans that are not exported yet and stops the tracer
+// provider installed by Init, if any.
+func Shutdown(ctx context.Context) error {
+	providerMu.Lock()
+	tp := provider
+	provider = nil
+	providerMu.Unlock()
+	if tp == nil {
+		return nil
+	}
+	return tp.Shutdown(ctx)
+}
+
+func newTracerProvider(serviceName string, log logrus.FieldLogger) (*tracesdk.TracerProvider, error) {
+	sampler, err := newSampler(log)
+	if err != nil {
+		return nil, err
+	}
+	res, err := newResource(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	opts := []tracesdk.TracerProviderOption{
+		tracesdk.WithSampler(sampler),
+		tracesdk.WithResource(res),
+	}
+	names := os.Getenv("TRACES_EXPORTER")
+	if names == "" {
+		names = Jaeger
+	}
+	var processors errorSpans
+	for _, name := range strings.Split(names, ",") {
+		exporter, err := newExporter(strings.TrimSpace(name), log)
+		if err != nil {
+			return nil, err
+		}
+		bsp := tracesdk.NewBatchSpanProcessor(exporter, tracesdk.WithMaxExportBatchSize(95))
+		processors = append(processors, bsp)
+		opts = append(opts, tracesdk.WithSpanProcessor(bsp))
+	}
+	// Only the sampler may record spans that are not sampled, to export
+	// those that fail.
+	opts = append(opts, tracesdk.WithSpanProcessor(processors))
+	return tracesdk.NewTracerProvider(opts...), nil
+}
+
+// newExporter returns the exporter called name.
+func newExporter(name string, log logrus.FieldLogger) (tracesdk.SpanExporter, error) {
+	switch name {
+	case Jaeger:
+		// for reference, see also:
+		// https://github.com/open-telemetry/opentelemetry-go/blob/main/example/jaeger/main.go
+		svcAddr := os.Getenv("JAEGER_SERVICE_ADDR")
+		if svcAddr == "" {
+			return nil, errors.New("missing JAEGER_SERVICE_ADDR, can't initialize Jaeger exporter")
+		}
+		host, port, err := jaegerAgentHostPort(svcAddr)
+		if err != nil {
+			return nil, err
+		}
+		exporter, err := jaeger.New(jaeger.WithAgentEndpoint(jaeger.WithAgentHost(host), jaeger.WithAgentPort(port)))
+		if err != nil {
+			return nil, err
+		}
+		log.Infof("created jaeger exporter to agent at %s:%s", host, port)
+		return exporter, nil
+	case OTLPGRPC, OTLPHTTP:
+		// The endpoint, headers and TLS settings are read from the
+		// OTEL_EXPORTER_OTLP_* variables.
+		var client otlptrace.Client
+		if name == OTLPGRPC {
+			client = otlptracegrpc.NewClient()
+		} else {
+			client = otlptracehttp.NewClient()
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		exporter, err := otlptrace.New(ctx, client)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s exporter: %v", name, err)
+		}
+		log.Infof("created %s exporter", name)
+		return exporter, nil
+	case Stdout:
+		log.Info("created stdout exporter")
+		return stdouttrace.New()
+	case File:
+		path := os.Getenv("TRACES_FILE")
+		if path == "" {
+			return nil, errors.New("missing TRACES_FILE, can't initialize file exporter")
+		}
+		exporter, err := newFileExporter(path)
+		if err != nil {
+			return nil, err
+		}
+		log.Infof("created file exporter to %q", path)
+		return exporter, nil
+	default:
+		return nil, fmt.Errorf("unknown exporter %q in TRACES_EXPORTER", name)
+	}
+}
+
+// jaegerAgentHostPort splits the address of the Jaeger agent, which may have
+// no port.
+func jaegerAgentHostPort(addr string) (host, port string, err error) {
+	if !strings.Contains(addr, ":") || (strings.HasPrefix(addr, "[") && strings.HasSuffix(addr, "]")) {
+		return strings.Trim(addr, "[]"), defaultJaegerAgentPort, nil
+	}
+	host, port, err = net.SplitHostPort(addr)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid JAEGER_SERVICE_ADDR %q: %v", addr, err)
+	}
+	if port == "" {
+		port = defaultJaegerAgentPort
+	}
+	return host, port, nil
+}
+
+// newResource describes the service instance: its name, the version in
+// SERVICE_VERSION, its host name as instance ID and, in Kubernetes, the pod
+// in POD_NAME and POD_NAMESPACE. OTEL_RESOURCE_ATTRIBUTES adds or overrides
+// attributes.
+func newResource(serviceName string) (*resource.Resource, error) {
+	attrs := []attribute.KeyValue{semconv.ServiceNameKey.String(serviceName)}
+	if v := os.Getenv("SERVICE_VERSION"); v != "" {
+		attrs = append(attrs, semconv.ServiceVersionKey.String(v))
+	}
+	if host, err := os.Hostname(); err == nil {
+		attrs = append(attrs, semconv.ServiceInstanceIDKey.String(host), semconv.HostNameKey.String(host))
+	}
+	if v := os.Getenv("POD_NAME"); v != "" {
+		attrs = append(attrs, semconv.K8SPodNameKey.String(v))
+	}
+	if v := os.Getenv("POD_NAMESPACE"); v != "" {
+		attrs = append(attrs, semconv.K8SNamespaceNameKey.String(v))
+	}
+	return resource.New(context.Background(),
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(attrs...),
+		resource.WithFromEnv())
+}
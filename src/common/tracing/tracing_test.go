@@ -0,0 +1,198 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewSampler(t *testing.T) {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	defer os.Unsetenv("TRACES_SAMPLING_FRACTION")
+
+	for _, tt := range []struct {
+		fraction string
+		want     string
+	}{
+		{"", "ParentBased{root:AlwaysOnSampler,remoteParentSampled:AlwaysOnSampler,remoteParentNotSampled:AlwaysOffSampler,localParentSampled:AlwaysOnSampler,localParentNotSampled:AlwaysOffSampler}"},
+		{"0.25", "ParentBased{root:TraceIDRatioBased{0.25},remoteParentSampled:AlwaysOnSampler,remoteParentNotSampled:AlwaysOffSampler,localParentSampled:AlwaysOnSampler,localParentNotSampled:AlwaysOffSampler}"},
+	} {
+		os.Setenv("TRACES_SAMPLING_FRACTION", tt.fraction)
+		s, err := newSampler(log)
+		if err != nil {
+			t.Fatalf("fraction %q: %+v", tt.fraction, err)
+		}
+		if got := s.Description(); got != tt.want {
+			t.Errorf("fraction %q: sampler = %s, want %s", tt.fraction, got, tt.want)
+		}
+	}
+
+	os.Setenv("TRACES_SAMPLING_FRACTION", "most")
+	if _, err := newSampler(log); err == nil {
+		t.Error("newSampler() accepted an invalid fraction")
+	}
+}
+
+func TestJaegerAgentHostPort(t *testing.T) {
+	for _, tt := range []struct {
+		addr, host, port string
+	}{
+		{"jaeger-agent:6832", "jaeger-agent", "6832"},
+		{"jaeger-agent", "jaeger-agent", "6831"},
+		{"jaeger-agent:", "jaeger-agent", "6831"},
+		{"10.0.0.1:6831", "10.0.0.1", "6831"},
+		{"[::1]:6832", "::1", "6832"},
+		{"[::1]", "::1", "6831"},
+	} {
+		host, port, err := jaegerAgentHostPort(tt.addr)
+		if err != nil {
+			t.Errorf("jaegerAgentHostPort(%q) failed: %+v", tt.addr, err)
+			continue
+		}
+		if host != tt.host || port != tt.port {
+			t.Errorf("jaegerAgentHostPort(%q) = %q, %q, want %q, %q", tt.addr, host, port, tt.host, tt.port)
+		}
+	}
+	if _, _, err := jaegerAgentHostPort("::1:6831:"); err == nil {
+		t.Error("jaegerAgentHostPort() accepted an invalid address")
+	}
+}
+
+// setenv sets the environment variables in env until the test ends.
+func setenv(t *testing.T, env map[string]string) {
+	for k, v := range env {
+		old, ok := os.LookupEnv(k)
+		os.Setenv(k, v)
+		k := k
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(k, old)
+			} else {
+				os.Unsetenv(k)
+			}
+		})
+	}
+}
+
+func TestInitWithFileExporter(t *testing.T) {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	dir, err := ioutil.TempDir("", "tracing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "traces.ndjson")
+	setenv(t, map[string]string{
+		"TRACES_EXPORTER":          "file",
+		"TRACES_FILE":              path,
+		"SERVICE_VERSION":          "v1.2.3",
+		"POD_NAME":                 "checkoutservice-abc",
+		"OTEL_RESOURCE_ATTRIBUTES": "deployment.environment=test",
+	})
+
+	if _, err := Init("checkoutservice", log); err != nil {
+		t.Fatal(err)
+	}
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "PlaceOrder", trace.WithSpanKind(trace.SpanKindServer))
+	_, child := otel.Tracer("test").Start(ctx, "charge")
+	child.SetAttributes(attribute.Int64("cart.items", 3), attribute.Array("tags", []string{"a", "b"}))
+	child.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", 2)))
+	child.RecordError(errors.New("declined"))
+	child.SetStatus(codes.Error, "declined")
+	child.End()
+	parent.End()
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spans []otlpSpan
+	var resource map[string]string
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		var req otlpExportRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			t.Fatalf("invalid line %s: %v", line, err)
+		}
+		for _, rs := range req.ResourceSpans {
+			resource = make(map[string]string)
+			for _, kv := range rs.Resource.Attributes {
+				resource[kv.Key] = *kv.Value.StringValue
+			}
+			for _, ils := range rs.InstrumentationLibrarySpans {
+				spans = append(spans, ils.Spans...)
+			}
+		}
+	}
+
+	for k, want := range map[string]string{
+		"service.name":           "checkoutservice",
+		"service.version":        "v1.2.3",
+		"k8s.pod.name":           "checkoutservice-abc",
+		"deployment.environment": "test",
+	} {
+		if got := resource[k]; got != want {
+			t.Errorf("resource attribute %s = %q, want %q", k, got, want)
+		}
+	}
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(spans))
+	}
+	c, p := spans[0], spans[1]
+	if c.Name != "charge" || p.Name != "PlaceOrder" {
+		t.Fatalf("exported spans %q and %q, want charge and PlaceOrder", c.Name, p.Name)
+	}
+	if c.TraceID != p.TraceID || c.ParentSpanID != p.SpanID || p.ParentSpanID != "" {
+		t.Errorf("charge is not the child of PlaceOrder: %+v, %+v", c, p)
+	}
+	if p.Kind != 2 {
+		t.Errorf("PlaceOrder has kind %d, want 2 (server)", p.Kind)
+	}
+	if c.Status.Code != otlpStatusError || c.Status.Message != "declined" {
+		t.Errorf("charge has status %+v, want an error", c.Status)
+	}
+	if len(c.Attributes) != 2 || *c.Attributes[0].Value.IntValue != "3" || len(c.Attributes[1].Value.ArrayValue.Values) != 2 {
+		t.Errorf("charge has attributes %+v", c.Attributes)
+	}
+	if len(c.Events) != 2 || c.Events[0].Name != "retry" || c.Events[1].Name != "exception" {
+		t.Errorf("charge has events %+v, want retry and exception", c.Events)
+	}
+}
+
+func TestInitWithUnknownExporter(t *testing.T) {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	setenv(t, map[string]string{"TRACES_EXPORTER": "stdout,zipkin"})
+	if _, err := Init("frontend", log); err == nil {
+		t.Error("Init() accepted an unknown exporter")
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"expvar"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/fake"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/resilience"
+)
+
+// defaultFakes stand in for the backends disabled with
+// RECOMMENDATION_SVC_DISABLED, unless FAKES_CONFIG fakes them otherwise.
+var defaultFakes = fake.Config{
+	"recommendation": {Methods: map[string]fake.Method{
+		"ListRecommendations": {Responses: []fake.Response{
+			fake.Reply(&pb.ListRecommendationsResponse{ProductIds: []string{"OLJCESPC7Z"}}),
+		}},
+	}},
+}
+
+// disabledEnv are the variables disabling the backends with default fakes.
+var disabledEnv = map[string]string{
+	"recommendation": "RECOMMENDATION_SVC_DISABLED",
+}
+
+// loadFakes returns the fakes of the backends that are not called: those in
+// the JSON file named by FAKES_CONFIG, if set, and the default fakes of the
+// backends disabled by their environment variable.
+func loadFakes(log logrus.FieldLogger) (fake.Config, error) {
+	fakes := fake.Config{}
+	for name, env := range disabledEnv {
+		if os.Getenv(env) != "" {
+			log.Infof("%s service disabled, using its default fake", name)
+			fakes[name] = defaultFakes[name]
+		}
+	}
+	path := os.Getenv("FAKES_CONFIG")
+	if path == "" {
+		return fakes, nil
+	}
+	log.Infof("loading fakes from %q", path)
+	c, err := fake.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	for name := range c {
+		log.Infof("%s service faked as configured in %q", name, path)
+	}
+	return fakes.Merge(c), nil
+}
+
+// mustMapBackendEnv is like mustMapEnv for the address of the backend called
+// name, which is not needed if the backend is faked.
+func (fe *frontendServer) mustMapBackendEnv(target *string, name, envKey string) {
+	if _, ok := fe.fakes[name]; ok {
+		return
+	}
+	mustMapEnv(target, envKey)
+}
+
+// dialBackends sets up the clients of all backends, connecting to those that
+// are not faked. Calls to fakes are guarded by the resilience policy of their
+// backend, as real ones.
+func (fe *frontendServer) dialBackends(ctx context.Context, config resilience.Config, backends *expvar.Map) {
+	fakeConn := func(name string) (*fake.Conn, bool) {
+		s, ok := fe.fakes[name]
+		if !ok {
+			return nil, false
+		}
+		c := resilienceClient(config, backends, name)
+		return fake.NewConn(name, s,
+			fake.WithUnaryInterceptor(c.UnaryClientInterceptor()),
+			fake.WithStreamInterceptor(c.StreamClientInterceptor())), true
+	}
+	dial := func(name, addr string) *grpc.ClientConn {
+		var conn *grpc.ClientConn
+		mustConnGRPC(ctx, &conn, addr, guard(config, backends, name)...)
+		return conn
+	}
+
+	if f, ok := fakeConn("currency"); ok {
+		fe.currencySvc = fake.NewCurrencyServiceClient(f)
+	} else {
+		fe.currencySvc = pb.NewCurrencyServiceClient(dial("currency", fe.currencySvcAddr))
+	}
+	if f, ok := fakeConn("productcatalog"); ok {
+		fe.productCatalogSvc = fake.NewProductCatalogServiceClient(f)
+	} else {
+		fe.productCatalogSvc = pb.NewProductCatalogServiceClient(dial("productcatalog", fe.productCatalogSvcAddr))
+	}
+	if f, ok := fakeConn("cart"); ok {
+		fe.cartSvc = fake.NewCartServiceClient(f)
+	} else {
+		fe.cartSvc = pb.NewCartServiceClient(dial("cart", fe.cartSvcAddr))
+	}
+	if f, ok := fakeConn("recommendation"); ok {
+		fe.recommendationSvc = fake.NewRecommendationServiceClient(f)
+	} else {
+		fe.recommendationSvc = pb.NewRecommendationServiceClient(dial("recommendation", fe.recommendationSvcAddr))
+	}
+	if f, ok := fakeConn("checkout"); ok {
+		fe.checkoutSvc = fake.NewCheckoutServiceClient(f)
+	} else {
+		fe.checkoutSvc = pb.NewCheckoutServiceClient(dial("checkout", fe.checkoutSvcAddr))
+	}
+	if f, ok := fakeConn("ad"); ok {
+		fe.adSvc = fake.NewAdServiceClient(f)
+	} else {
+		fe.adSvc = pb.NewAdServiceClient(dial("ad", fe.adSvcAddr))
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+// checkoutForm holds the values of the checkout form on the cart page and
+// the errors to show next to its fields.
+type checkoutForm struct {
+	Email           string
+	StreetAddress   string
+	ZipCode         string
+	City            string
+	State           string
+	Country         string
+	CardNumber      string
+	ExpirationMonth time.Month
+	ExpirationYear  int
+	CVV             string
+	PromoCodes      string // separated by commas or spaces
+
+	// Token identifies the order attempt to checkoutservice, which places
+	// the order only once per token. It is empty until the form is first
+	// rendered, and then carried over by every resubmission.
+	Token string
+
+	// Errors maps form field names to error messages. Errors that are not
+	// about a single field are keyed by "".
+	Errors map[string]string
+}
+
+// defaultCheckoutForm returns the form prefilled with demo values.
+func defaultCheckoutForm() checkoutForm {
+	return checkoutForm{
+		Email:           "someone@example.com",
+		StreetAddress:   "1600 Amphitheatre Parkway",
+		ZipCode:         "94043",
+		City:            "Mountain View",
+		State:           "CA",
+		Country:         "United States",
+		CardNumber:      "4432-8015-6152-0454",
+		ExpirationMonth: time.January,
+		ExpirationYear:  time.Now().Year() + 1,
+		CVV:             "672",
+	}
+}
+
+// parseCheckoutForm reads the submitted checkout form.
+func parseCheckoutForm(r *http.Request) checkoutForm {
+	month, _ := strconv.Atoi(r.FormValue("credit_card_expiration_month"))
+	year, _ := strconv.Atoi(r.FormValue("credit_card_expiration_year"))
+	return checkoutForm{
+		Email:           r.FormValue("email"),
+		StreetAddress:   r.FormValue("street_address"),
+		ZipCode:         r.FormValue("zip_code"),
+		City:            r.FormValue("city"),
+		State:           r.FormValue("state"),
+		Country:         r.FormValue("country"),
+		CardNumber:      r.FormValue("credit_card_number"),
+		ExpirationMonth: time.Month(month),
+		ExpirationYear:  year,
+		CVV:             r.FormValue("credit_card_cvv"),
+		PromoCodes:      r.FormValue("promo_codes"),
+		Token:           r.FormValue("checkout_token"),
+	}
+}
+
+// placeOrderRequest builds the PlaceOrderRequest for the form.
+func (f checkoutForm) placeOrderRequest() *pb.PlaceOrderRequest {
+	zipCode, _ := strconv.ParseInt(f.ZipCode, 10, 32)
+	cvv, _ := strconv.ParseInt(f.CVV, 10, 32)
+	return &pb.PlaceOrderRequest{
+		Email: f.Email,
+		CreditCard: &pb.CreditCardInfo{
+			CreditCardNumber:          f.CardNumber,
+			CreditCardExpirationMonth: int32(f.ExpirationMonth),
+			CreditCardExpirationYear:  int32(f.ExpirationYear),
+			CreditCardCvv:             int32(cvv)},
+		Address: &pb.Address{
+			StreetAddress: f.StreetAddress,
+			City:          f.City,
+			State:         f.State,
+			ZipCode:       int32(zipCode),
+			Country:       f.Country},
+		PromoCodes: strings.FieldsFunc(f.PromoCodes, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		}),
+	}
+}
+
+// checkoutFormFields are the names of the fields of the checkout form.
+var checkoutFormFields = map[string]bool{
+	"email":                        true,
+	"street_address":               true,
+	"zip_code":                     true,
+	"city":                         true,
+	"state":                        true,
+	"country":                      true,
+	"credit_card_number":           true,
+	"credit_card_expiration_month": true,
+	"credit_card_expiration_year":  true,
+	"credit_card_cvv":              true,
+	"promo_codes":                  true,
+}
+
+// checkoutFormErrors extracts the field violations reported by
+// checkoutservice. ok is false if err is not a validation error.
+func checkoutFormErrors(err error) (errs map[string]string, ok bool) {
+	st, isStatus := status.FromError(err)
+	if !isStatus || st.Code() != codes.InvalidArgument {
+		return nil, false
+	}
+	errs = make(map[string]string)
+	for _, d := range st.Details() {
+		br, isBadRequest := d.(*errdetails.BadRequest)
+		if !isBadRequest {
+			continue
+		}
+		for _, v := range br.GetFieldViolations() {
+			// Form fields are named after the last element of the
+			// proto field path, e.g. "address.zip_code" is "zip_code".
+			field := v.GetField()
+			field = field[strings.LastIndex(field, ".")+1:]
+			if !checkoutFormFields[field] {
+				field = ""
+			}
+			if _, dup := errs[field]; !dup {
+				errs[field] = v.GetDescription()
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil, false
+	}
+	return errs, true
+}
+
+// mergeFormErrors adds the errors of more that are not in errs yet.
+func mergeFormErrors(errs, more map[string]string) map[string]string {
+	if errs == nil {
+		errs = make(map[string]string, len(more))
+	}
+	for field, msg := range more {
+		if _, dup := errs[field]; !dup {
+			errs[field] = msg
+		}
+	}
+	return errs
+}
+
+// checkoutErrorDomain is the ErrorInfo domain of the errors returned by
+// checkoutservice.
+const checkoutErrorDomain = "checkoutservice.hipstershop"
+
+// checkoutFailure is how a failed order is presented to the shopper.
+type checkoutFailure struct {
+	status     int    // HTTP status code
+	message    string // shown above the checkout form
+	retryAfter time.Duration
+}
+
+// checkoutFailures maps the reasons reported by checkoutservice to the
+// failures shown for them.
+var checkoutFailures = map[string]checkoutFailure{
+	"PRODUCT_NOT_FOUND": {
+		status:  http.StatusNotFound,
+		message: "An item in your cart is no longer available. Please remove it and try again.",
+	},
+	"CURRENCY_UNSUPPORTED": {
+		status:  http.StatusBadRequest,
+		message: "Orders cannot be paid in the selected currency. Please choose another currency.",
+	},
+	"CARD_DECLINED": {
+		status:  http.StatusPaymentRequired,
+		message: "Your card was declined. Please check the card details or use another card.",
+	},
+	"PAYMENT_OUTCOME_UNKNOWN": {
+		status:  http.StatusGatewayTimeout,
+		message: "We could not confirm your payment. Please check your email for a confirmation, or contact support, before ordering again.",
+	},
+	"ORDER_REJECTED": {
+		status:  http.StatusForbidden,
+		message: "We could not accept your order. Please contact support if you believe this is a mistake.",
+	},
+	"SHIPPING_UNAVAILABLE": {
+		status:  http.StatusServiceUnavailable,
+		message: "We cannot ship your order right now. Please try again in a few moments.",
+	},
+	"DOWNSTREAM_TIMEOUT": {
+		status:  http.StatusGatewayTimeout,
+		message: "Placing your order took too long. Please check your email for a confirmation before trying again.",
+	},
+	"DOWNSTREAM_UNAVAILABLE": {
+		status:  http.StatusServiceUnavailable,
+		message: "Checkout is temporarily unavailable. Please try again in a few moments.",
+	},
+}
+
+// checkoutErrorReason returns the ErrorInfo reason of an error returned by
+// checkoutservice, or "" if it has none.
+func checkoutErrorReason(err error) string {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.GetDomain() == checkoutErrorDomain {
+			return info.GetReason()
+		}
+	}
+	return ""
+}
+
+// placeOrderFailure classifies a PlaceOrder error that is not about the
+// fields of the checkout form.
+func placeOrderFailure(err error) checkoutFailure {
+	st := status.Convert(err)
+	var (
+		reason     string
+		retryAfter time.Duration
+	)
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			if d.GetDomain() == checkoutErrorDomain {
+				reason = d.GetReason()
+			}
+		case *errdetails.RetryInfo:
+			retryAfter, _ = ptypes.Duration(d.GetRetryDelay())
+		}
+	}
+
+	f, ok := checkoutFailures[reason]
+	if !ok {
+		switch st.Code() {
+		case codes.Unavailable:
+			f = checkoutFailures["DOWNSTREAM_UNAVAILABLE"]
+		case codes.DeadlineExceeded:
+			f = checkoutFailures["DOWNSTREAM_TIMEOUT"]
+		default:
+			f = checkoutFailure{
+				status:  http.StatusInternalServerError,
+				message: "Something went wrong while placing your order. Please try again later.",
+			}
+		}
+	}
+	f.retryAfter = retryAfter
+	return f
+}
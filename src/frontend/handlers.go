@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"html/template"
+	"math"
 	"math/rand"
 	"net"
 	"net/http"
@@ -26,12 +27,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
-	pb "github.com/GoogleCloudPlatform/microservices-demo/src/frontend/genproto"
-	"github.com/GoogleCloudPlatform/microservices-demo/src/frontend/money"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/money"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/tracing"
 )
 
 type platformDetails struct {
@@ -105,6 +108,7 @@ func (fe *frontendServer) homeHandler(w http.ResponseWriter, r *http.Request) {
 		"session_id":    sessionID(r),
 		"request_id":    r.Context().Value(ctxKeyRequestID{}),
 		"user_currency": currentCurrency(r),
+		"locale":        currentLocale(r),
 		"show_currency": true,
 		"currencies":    currencies,
 		"products":      ps,
@@ -170,7 +174,7 @@ func (fe *frontendServer) productHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	recommendations, err := fe.getRecommendations(log, r.Context(), sessionID(r), []string{id})
+	recommendations, err := fe.getRecommendations(r.Context(), sessionID(r), []string{id})
 	if err != nil {
 		renderHTTPError(log, r, w, errors.Wrap(err, "failed to get product recommendations"), http.StatusInternalServerError)
 		return
@@ -186,6 +190,7 @@ func (fe *frontendServer) productHandler(w http.ResponseWriter, r *http.Request)
 		"request_id":      r.Context().Value(ctxKeyRequestID{}),
 		"ad":              fe.chooseAd(r.Context(), p.Categories, log),
 		"user_currency":   currentCurrency(r),
+		"locale":          currentLocale(r),
 		"show_currency":   true,
 		"currencies":      currencies,
 		"product":         product,
@@ -237,72 +242,155 @@ func (fe *frontendServer) emptyCartHandler(w http.ResponseWriter, r *http.Reques
 func (fe *frontendServer) viewCartHandler(w http.ResponseWriter, r *http.Request) {
 	log := r.Context().Value(ctxKeyLog{}).(logrus.FieldLogger)
 	log.Debug("view user cart")
-	currencies, err := fe.getCurrencies(r.Context())
-	if err != nil {
-		renderHTTPError(log, r, w, errors.Wrap(err, "could not retrieve currencies"), http.StatusInternalServerError)
-		return
-	}
-	cart, err := fe.getCart(r.Context(), sessionID(r))
-	if err != nil {
-		renderHTTPError(log, r, w, errors.Wrap(err, "could not retrieve cart"), http.StatusInternalServerError)
-		return
-	}
+	fe.renderCart(w, r, log, defaultCheckoutForm(), http.StatusOK)
+}
+
+// previewCartHandler renders the cart priced for the address and promo codes
+// of the submitted checkout form, without placing the order.
+func (fe *frontendServer) previewCartHandler(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value(ctxKeyLog{}).(logrus.FieldLogger)
+	log.Debug("preview user cart")
+	fe.renderCart(w, r, log, parseCheckoutForm(r), http.StatusOK)
+}
+
+// renderCart renders the cart page with the checkout form filled in from
+// form, responding with the given status code.
+func (fe *frontendServer) renderCart(w http.ResponseWriter, r *http.Request, log logrus.FieldLogger, form checkoutForm, code int) {
+	ctx := r.Context()
+	tracing.SetAttributes(ctx,
+		tracing.UserIDKey.String(sessionID(r)),
+		tracing.MoneyCurrencyKey.String(currentCurrency(r)))
 
-	recommendations, err := fe.getRecommendations(log, r.Context(), sessionID(r), cartIDs(cart))
+	var (
+		currencies      []string
+		cart            []*pb.CartItem
+		recommendations []*pb.Product
+	)
+	err := tracePhase(ctx, "cart.load", func(ctx context.Context) (err error) {
+		if currencies, err = fe.getCurrencies(ctx); err != nil {
+			return errors.Wrap(err, "could not retrieve currencies")
+		}
+		if cart, err = fe.getCart(ctx, sessionID(r)); err != nil {
+			return errors.Wrap(err, "could not retrieve cart")
+		}
+		tracing.SetAttributes(r.Context(), tracing.CartItemsKey.Int(cartSize(cart)))
+		if recommendations, err = fe.getRecommendations(ctx, sessionID(r), cartIDs(cart)); err != nil {
+			return errors.Wrap(err, "failed to get product recommendations")
+		}
+		return nil
+	})
 	if err != nil {
-		renderHTTPError(log, r, w, errors.Wrap(err, "failed to get product recommendations"), http.StatusInternalServerError)
+		renderHTTPError(log, r, w, err, http.StatusInternalServerError)
 		return
 	}
 
-	shippingCost, err := fe.getShippingQuote(log, r.Context(), cart, currentCurrency(r))
-	if err != nil {
-		renderHTTPError(log, r, w, errors.Wrap(err, "failed to get shipping quote"), http.StatusInternalServerError)
-		return
+	// The totals come from checkoutservice so that they are computed exactly
+	// like the amount charged for the order. They are priced for the address
+	// in form, which is the default one until the customer submits theirs.
+	var preview *pb.PreviewOrderResponse
+	if len(cart) > 0 {
+		err := tracePhase(ctx, "cart.preview", func(ctx context.Context) (err error) {
+			order := form.placeOrderRequest()
+			preview, err = fe.previewOrder(ctx, sessionID(r), currentCurrency(r), order.GetAddress(), order.GetPromoCodes())
+			if errs, ok := checkoutFormErrors(err); ok && len(order.GetPromoCodes()) > 0 {
+				// Show the cart without the rejected promo codes.
+				tracing.Fallback(ctx, "preview without promo codes", err)
+				form.Errors = mergeFormErrors(form.Errors, errs)
+				preview, err = fe.previewOrder(ctx, sessionID(r), currentCurrency(r), order.GetAddress(), nil)
+			}
+			if err != nil {
+				return err
+			}
+			tracing.SetAttributes(r.Context(), tracing.Money(preview.GetTotal())...)
+			return nil
+		})
+		if checkoutErrorReason(err) == "CART_EMPTY" {
+			// The cart was emptied since it was read.
+			cart = nil
+		} else if err != nil {
+			// Show the cart without totals; they are computed again when
+			// the order is placed.
+			log.WithField("error", err).Warn("failed to price the cart")
+			errs, ok := checkoutFormErrors(err)
+			if !ok {
+				errs = map[string]string{"": "We cannot compute the total of your order right now. Please try again in a few moments."}
+			}
+			form.Errors = mergeFormErrors(form.Errors, errs)
+		}
 	}
 
 	type cartItemView struct {
 		Item     *pb.Product
 		Quantity int32
-		Price    *pb.Money
+		Price    *pb.Money // nil if the cart could not be priced
 	}
-	items := make([]cartItemView, len(cart))
-	totalPrice := pb.Money{CurrencyCode: currentCurrency(r)}
-	for i, item := range cart {
-		p, err := fe.getProduct(r.Context(), item.GetProductId())
-		if err != nil {
-			renderHTTPError(log, r, w, errors.Wrapf(err, "could not retrieve product #%s", item.GetProductId()), http.StatusInternalServerError)
-			return
+	lines := preview.GetItems()
+	if preview == nil {
+		lines = make([]*pb.OrderItem, len(cart))
+		for i, item := range cart {
+			lines[i] = &pb.OrderItem{Item: item}
 		}
-		price, err := fe.convertCurrency(r.Context(), p.GetPriceUsd(), currentCurrency(r))
+	}
+	items := make([]cartItemView, len(lines))
+	err = tracePhase(ctx, "cart.items", func(ctx context.Context) error {
+		for i, item := range lines {
+			p, err := fe.getProduct(ctx, item.GetItem().GetProductId())
+			if err != nil {
+				return errors.Wrapf(err, "could not retrieve product #%s", item.GetItem().GetProductId())
+			}
+			items[i] = cartItemView{
+				Item:     p,
+				Quantity: item.GetItem().GetQuantity()}
+			if item.GetCost() == nil {
+				continue
+			}
+			multPrice, err := money.Multiply(*item.GetCost(), int64(item.GetItem().GetQuantity()))
+			if err != nil {
+				return errors.Wrap(err, "failed to price cart item")
+			}
+			items[i].Price = &multPrice
+		}
+		return nil
+	})
+	if err != nil {
+		renderHTTPError(log, r, w, err, http.StatusInternalServerError)
+		return
+	}
+
+	// A token per checkout form, kept when the submitted form is rendered
+	// again, lets checkoutservice recognize resubmissions of the same order.
+	if form.Token == "" {
+		checkoutToken, err := uuid.NewRandom()
 		if err != nil {
-			renderHTTPError(log, r, w, errors.Wrapf(err, "could not convert currency for product #%s", item.GetProductId()), http.StatusInternalServerError)
+			renderHTTPError(log, r, w, errors.Wrap(err, "failed to generate checkout token"), http.StatusInternalServerError)
 			return
 		}
-
-		multPrice := money.MultiplySlow(*price, uint32(item.GetQuantity()))
-		items[i] = cartItemView{
-			Item:     p,
-			Quantity: item.GetQuantity(),
-			Price:    &multPrice}
-		totalPrice = money.Must(money.Sum(totalPrice, multPrice))
+		form.Token = checkoutToken.String()
 	}
-	totalPrice = money.Must(money.Sum(totalPrice, *shippingCost))
 
+	months := make([]time.Month, 12)
+	for i := range months {
+		months[i] = time.Month(i + 1)
+	}
 	year := time.Now().Year()
+	w.WriteHeader(code)
 	if err := templates.ExecuteTemplate(w, "cart", map[string]interface{}{
-		"session_id":       sessionID(r),
-		"request_id":       r.Context().Value(ctxKeyRequestID{}),
-		"user_currency":    currentCurrency(r),
-		"currencies":       currencies,
-		"recommendations":  recommendations,
-		"cart_size":        cartSize(cart),
-		"shipping_cost":    shippingCost,
-		"show_currency":    true,
-		"total_cost":       totalPrice,
-		"items":            items,
-		"expiration_years": []int{year, year + 1, year + 2, year + 3, year + 4},
-		"platform_css":     plat.css,
-		"platform_name":    plat.provider,
+		"session_id":        sessionID(r),
+		"request_id":        r.Context().Value(ctxKeyRequestID{}),
+		"user_currency":     currentCurrency(r),
+		"locale":            currentLocale(r),
+		"currencies":        currencies,
+		"recommendations":   recommendations,
+		"cart_size":         cartSize(cart),
+		"preview":           preview,
+		"show_currency":     true,
+		"items":             items,
+		"checkout_form":     form,
+		"expiration_months": months,
+		"expiration_years":  []int{year, year + 1, year + 2, year + 3, year + 4},
+		"checkout_token":    form.Token,
+		"platform_css":      plat.css,
+		"platform_name":     plat.provider,
 	}); err != nil {
 		log.Println(err)
 	}
@@ -312,54 +400,62 @@ func (fe *frontendServer) placeOrderHandler(w http.ResponseWriter, r *http.Reque
 	log := r.Context().Value(ctxKeyLog{}).(logrus.FieldLogger)
 	log.Debug("placing order")
 
-	var (
-		email         = r.FormValue("email")
-		streetAddress = r.FormValue("street_address")
-		zipCode, _    = strconv.ParseInt(r.FormValue("zip_code"), 10, 32)
-		city          = r.FormValue("city")
-		state         = r.FormValue("state")
-		country       = r.FormValue("country")
-		ccNumber      = r.FormValue("credit_card_number")
-		ccMonth, _    = strconv.ParseInt(r.FormValue("credit_card_expiration_month"), 10, 32)
-		ccYear, _     = strconv.ParseInt(r.FormValue("credit_card_expiration_year"), 10, 32)
-		ccCVV, _      = strconv.ParseInt(r.FormValue("credit_card_cvv"), 10, 32)
-	)
-
-	order, err := pb.NewCheckoutServiceClient(fe.checkoutSvcConn).
-		PlaceOrder(r.Context(), &pb.PlaceOrderRequest{
-			Email: email,
-			CreditCard: &pb.CreditCardInfo{
-				CreditCardNumber:          ccNumber,
-				CreditCardExpirationMonth: int32(ccMonth),
-				CreditCardExpirationYear:  int32(ccYear),
-				CreditCardCvv:             int32(ccCVV)},
-			UserId:       sessionID(r),
-			UserCurrency: currentCurrency(r),
-			Address: &pb.Address{
-				StreetAddress: streetAddress,
-				City:          city,
-				State:         state,
-				ZipCode:       int32(zipCode),
-				Country:       country},
-		})
+	form := parseCheckoutForm(r)
+	req := form.placeOrderRequest()
+	req.UserId = sessionID(r)
+	req.UserCurrency = currentCurrency(r)
+	req.IdempotencyKey = form.Token
+	tracing.SetAttributes(r.Context(),
+		tracing.UserIDKey.String(req.GetUserId()),
+		tracing.MoneyCurrencyKey.String(req.GetUserCurrency()),
+		tracing.EmailKey.String(req.GetEmail()))
+
+	var order *pb.PlaceOrderResponse
+	err := tracePhase(r.Context(), "order.place", func(ctx context.Context) (err error) {
+		// The deadline is passed on to checkoutservice, which splits it
+		// across the services it calls.
+		ctx, cancel := context.WithTimeout(ctx, fe.checkoutBudget)
+		defer cancel()
+		order, err = fe.checkoutSvc.PlaceOrder(ctx, req)
+		return err
+	})
+	if errs, ok := checkoutFormErrors(err); ok {
+		log.WithField("errors", errs).Info("order rejected")
+		form.Errors = errs
+		fe.renderCart(w, r, log, form, http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		renderHTTPError(log, r, w, errors.Wrap(err, "failed to complete the order"), http.StatusInternalServerError)
+		failure := placeOrderFailure(err)
+		log.WithField("error", err).Error("failed to complete the order")
+		if failure.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(failure.retryAfter.Seconds()))))
+		}
+		form.Errors = map[string]string{"": failure.message}
+		fe.renderCart(w, r, log, form, failure.status)
 		return
 	}
 	log.WithField("order", order.GetOrder().GetOrderId()).Info("order placed")
+	tracing.SetAttributes(r.Context(), append(tracing.Money(order.GetOrder().GetTotal()),
+		tracing.OrderIDKey.String(order.GetOrder().GetOrderId()),
+		tracing.ShippingTrackingIDKey.String(order.GetOrder().GetShippingTrackingId()),
+		tracing.CartItemsKey.Int(orderSize(order.GetOrder())))...)
 
-	order.GetOrder().GetItems()
-	recommendations, _ := fe.getRecommendations(log, r.Context(), sessionID(r), nil)
-
-	totalPaid := *order.GetOrder().GetShippingCost()
-	for _, v := range order.GetOrder().GetItems() {
-		multPrice := money.MultiplySlow(*v.GetCost(), uint32(v.GetItem().GetQuantity()))
-		totalPaid = money.Must(money.Sum(totalPaid, multPrice))
-	}
-
-	currencies, err := fe.getCurrencies(r.Context())
+	var (
+		recommendations []*pb.Product
+		currencies      []string
+	)
+	err = tracePhase(r.Context(), "order.load", func(ctx context.Context) (err error) {
+		if recommendations, err = fe.getRecommendations(ctx, sessionID(r), nil); err != nil {
+			tracing.Fallback(ctx, "no recommendations", err)
+		}
+		if currencies, err = fe.getCurrencies(ctx); err != nil {
+			return errors.Wrap(err, "could not retrieve currencies")
+		}
+		return nil
+	})
 	if err != nil {
-		renderHTTPError(log, r, w, errors.Wrap(err, "could not retrieve currencies"), http.StatusInternalServerError)
+		renderHTTPError(log, r, w, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -367,10 +463,11 @@ func (fe *frontendServer) placeOrderHandler(w http.ResponseWriter, r *http.Reque
 		"session_id":      sessionID(r),
 		"request_id":      r.Context().Value(ctxKeyRequestID{}),
 		"user_currency":   currentCurrency(r),
+		"locale":          currentLocale(r),
 		"show_currency":   false,
 		"currencies":      currencies,
 		"order":           order.GetOrder(),
-		"total_paid":      &totalPaid,
+		"total_paid":      order.GetOrder().GetTotal(),
 		"recommendations": recommendations,
 		"platform_css":    plat.css,
 		"platform_name":   plat.provider,
@@ -418,6 +515,7 @@ func (fe *frontendServer) chooseAd(ctx context.Context, ctxKeys []string, log lo
 	ads, err := fe.getAd(ctx, ctxKeys)
 	if err != nil {
 		log.WithField("error", err).Warn("failed to retrieve ads")
+		tracing.Fallback(ctx, "no ad", err)
 		return nil
 	}
 	return ads[rand.Intn(len(ads))]
@@ -472,25 +570,13 @@ func cartSize(c []*pb.CartItem) int {
 	return cartSize
 }
 
-func renderMoney(money pb.Money) string {
-	return fmt.Sprintf("%s %d.%02d", money.GetCurrencyCode(), money.GetUnits(), money.GetNanos()/10000000)
-}
-
-func renderCurrencyLogo(currencyCode string) string {
-	logos := map[string]string{
-		"USD": "$",
-		"CAD": "$",
-		"JPY": "¥",
-		"EUR": "€",
-		"TRY": "₺",
-		"GBP": "£",
-	}
-
-	logo := "$" //default
-	if val, ok := logos[currencyCode]; ok {
-		logo = val
+// orderSize returns the quantity of items in the order.
+func orderSize(o *pb.OrderResult) int {
+	n := 0
+	for _, item := range o.GetItems() {
+		n += int(item.GetItem().GetQuantity())
 	}
-	return logo
+	return n
 }
 
 func stringinSlice(slice []string, val string) bool {
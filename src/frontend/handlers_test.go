@@ -0,0 +1,195 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+var testProduct = &pb.Product{Id: "OLJCESPC7Z", Name: "Vintage Typewriter",
+	PriceUsd: &pb.Money{CurrencyCode: "USD", Units: 67, Nanos: 990000000}}
+
+type stubCurrency struct{ pb.CurrencyServiceClient }
+
+func (stubCurrency) GetSupportedCurrencies(context.Context, *pb.Empty, ...grpc.CallOption) (*pb.GetSupportedCurrenciesResponse, error) {
+	return &pb.GetSupportedCurrenciesResponse{CurrencyCodes: []string{"USD"}}, nil
+}
+
+type stubCart struct{ pb.CartServiceClient }
+
+func (stubCart) GetCart(context.Context, *pb.GetCartRequest, ...grpc.CallOption) (*pb.Cart, error) {
+	return &pb.Cart{Items: []*pb.CartItem{{ProductId: testProduct.Id, Quantity: 1}}}, nil
+}
+
+type stubRecommendation struct{ pb.RecommendationServiceClient }
+
+func (stubRecommendation) ListRecommendations(context.Context, *pb.ListRecommendationsRequest, ...grpc.CallOption) (*pb.ListRecommendationsResponse, error) {
+	return &pb.ListRecommendationsResponse{}, nil
+}
+
+type stubCatalog struct{ pb.ProductCatalogServiceClient }
+
+func (stubCatalog) GetProduct(context.Context, *pb.GetProductRequest, ...grpc.CallOption) (*pb.Product, error) {
+	return testProduct, nil
+}
+
+// stubCheckout prices every cart at the price of its single item and fails
+// every order with placeErr, recording the idempotency keys it was sent.
+type stubCheckout struct {
+	pb.CheckoutServiceClient
+	previewErr error
+	placeErr   error
+	keys       []string
+}
+
+func (c *stubCheckout) PreviewOrder(context.Context, *pb.PreviewOrderRequest, ...grpc.CallOption) (*pb.PreviewOrderResponse, error) {
+	if c.previewErr != nil {
+		return nil, c.previewErr
+	}
+	return &pb.PreviewOrderResponse{
+		Items:        []*pb.OrderItem{{Item: &pb.CartItem{ProductId: testProduct.Id, Quantity: 1}, Cost: testProduct.PriceUsd}},
+		ShippingCost: &pb.Money{CurrencyCode: "USD"},
+		Total:        testProduct.PriceUsd}, nil
+}
+
+func (c *stubCheckout) PlaceOrder(_ context.Context, req *pb.PlaceOrderRequest, _ ...grpc.CallOption) (*pb.PlaceOrderResponse, error) {
+	c.keys = append(c.keys, req.GetIdempotencyKey())
+	return nil, c.placeErr
+}
+
+func newTestFrontend(checkout *stubCheckout) *frontendServer {
+	return &frontendServer{
+		currencySvc:       stubCurrency{},
+		cartSvc:           stubCart{},
+		recommendationSvc: stubRecommendation{},
+		productCatalogSvc: stubCatalog{},
+		checkoutSvc:       checkout,
+		checkoutBudget:    time.Second,
+	}
+}
+
+func serveTest(h http.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	ctx := context.WithValue(r.Context(), ctxKeyLog{}, logrus.FieldLogger(log))
+	ctx = context.WithValue(ctx, ctxKeySessionID{}, "session-1")
+	w := httptest.NewRecorder()
+	h(w, r.WithContext(ctx))
+	return w
+}
+
+var checkoutTokenRE = regexp.MustCompile(`name="checkout_token" value="([^"]*)"`)
+
+func renderedToken(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	m := checkoutTokenRE.FindStringSubmatch(w.Body.String())
+	if m == nil || m[1] == "" {
+		t.Fatalf("no checkout token in the page (status %d): %s", w.Code, w.Body)
+	}
+	return m[1]
+}
+
+func TestResubmittedCheckoutKeepsToken(t *testing.T) {
+	st, err := status.New(codes.DeadlineExceeded, "payment service timed out").WithDetails(
+		&errdetails.ErrorInfo{Reason: "DOWNSTREAM_TIMEOUT", Domain: checkoutErrorDomain})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkout := &stubCheckout{placeErr: st.Err()}
+	fe := newTestFrontend(checkout)
+
+	w := serveTest(fe.viewCartHandler, httptest.NewRequest(http.MethodGet, "/cart", nil))
+	token := renderedToken(t, w)
+
+	form := url.Values{
+		"email":                        {"someone@example.com"},
+		"street_address":               {"1600 Amphitheatre Parkway"},
+		"zip_code":                     {"94043"},
+		"city":                         {"Mountain View"},
+		"state":                        {"CA"},
+		"country":                      {"United States"},
+		"credit_card_number":           {"4432-8015-6152-0454"},
+		"credit_card_expiration_month": {"1"},
+		"credit_card_expiration_year":  {"2099"},
+		"credit_card_cvv":              {"672"},
+		"checkout_token":               {token},
+	}
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest(http.MethodPost, "/cart/checkout", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w = serveTest(fe.placeOrderHandler, r)
+		if w.Code != http.StatusGatewayTimeout {
+			t.Fatalf("submission %d: status %d, want %d", i+1, w.Code, http.StatusGatewayTimeout)
+		}
+		if got := renderedToken(t, w); got != token {
+			t.Fatalf("submission %d: form rendered again with token %q, want %q", i+1, got, token)
+		}
+	}
+	if len(checkout.keys) != 2 || checkout.keys[0] != token || checkout.keys[1] != token {
+		t.Errorf("idempotency keys = %q, want %q twice", checkout.keys, token)
+	}
+}
+
+func TestCartRendersWithoutPreview(t *testing.T) {
+	fe := newTestFrontend(&stubCheckout{previewErr: status.Error(codes.Unavailable, "shipping service unavailable")})
+
+	w := serveTest(fe.viewCartHandler, httptest.NewRequest(http.MethodGet, "/cart", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, testProduct.Name) {
+		t.Errorf("cart page does not list %q", testProduct.Name)
+	}
+	if !strings.Contains(body, "cannot compute the total") {
+		t.Error("cart page does not explain the missing total")
+	}
+	if strings.Contains(body, "Total Cost") {
+		t.Error("cart page shows a total it could not compute")
+	}
+}
+
+func TestCartEmptiedBeforePreview(t *testing.T) {
+	st, err := status.New(codes.FailedPrecondition, "cart is empty").WithDetails(
+		&errdetails.ErrorInfo{Reason: "CART_EMPTY", Domain: checkoutErrorDomain})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fe := newTestFrontend(&stubCheckout{previewErr: st.Err()})
+
+	w := serveTest(fe.viewCartHandler, httptest.NewRequest(http.MethodGet, "/cart", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want %d", w.Code, http.StatusOK)
+	}
+	if !strings.Contains(w.Body.String(), "Your shopping cart is empty!") {
+		t.Error("cart page does not show the empty cart")
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/money"
+)
+
+// currentLocale returns the locale prices are formatted in for r: the
+// preferred language of its Accept-Language header that formatting supports,
+// or money.DefaultLocale.
+func currentLocale(r *http.Request) string {
+	type language struct {
+		tag string
+		q   float64
+	}
+	var langs []language
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		fields := strings.Split(part, ";")
+		l := language{tag: strings.TrimSpace(fields[0]), q: 1}
+		for _, param := range fields[1:] {
+			if v := strings.TrimSpace(param); strings.HasPrefix(v, "q=") {
+				if q, err := strconv.ParseFloat(v[2:], 64); err == nil {
+					l.q = q
+				}
+			}
+		}
+		if l.tag != "" && l.tag != "*" && l.q > 0 {
+			langs = append(langs, l)
+		}
+	}
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+	for _, l := range langs {
+		if locale, ok := money.SupportedLocale(l.tag); ok {
+			return locale
+		}
+	}
+	return money.DefaultLocale
+}
+
+func renderMoney(locale string, m pb.Money) string {
+	return money.Format(m, locale)
+}
+
+func renderCurrencyLogo(locale, currencyCode string) string {
+	return money.Symbol(currencyCode, locale)
+}
@@ -16,25 +16,25 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
-	"strings"
-	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 
-	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/jaeger"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	tracesdk "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/fake"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/faultinject"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/grpcutil"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/logging"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/tracing"
 )
 
 const (
@@ -45,6 +45,8 @@ const (
 	cookiePrefix    = "shop_"
 	cookieSessionID = cookiePrefix + "session-id"
 	cookieCurrency  = cookiePrefix + "currency"
+
+	defaultCheckoutBudget = 10 * time.Second
 )
 
 var (
@@ -61,44 +63,39 @@ type ctxKeySessionID struct{}
 
 type frontendServer struct {
 	productCatalogSvcAddr string
-	productCatalogSvcConn *grpc.ClientConn
+	productCatalogSvc     pb.ProductCatalogServiceClient
 
 	currencySvcAddr string
-	currencySvcConn *grpc.ClientConn
+	currencySvc     pb.CurrencyServiceClient
 
 	cartSvcAddr string
-	cartSvcConn *grpc.ClientConn
+	cartSvc     pb.CartServiceClient
 
 	recommendationSvcAddr string
-	recommendationSvcConn *grpc.ClientConn
+	recommendationSvc     pb.RecommendationServiceClient
 
 	checkoutSvcAddr string
-	checkoutSvcConn *grpc.ClientConn
-
-	shippingSvcAddr string
-	shippingSvcConn *grpc.ClientConn
+	checkoutSvc     pb.CheckoutServiceClient
 
 	adSvcAddr string
-	adSvcConn *grpc.ClientConn
+	adSvc     pb.AdServiceClient
+
+	// checkoutBudget is how long placing an order may take in total.
+	checkoutBudget time.Duration
+
+	fakes fake.Config // of the backends that are faked
 }
 
 func main() {
 	ctx := context.Background()
-	log := logrus.New()
+	log := logging.New()
 	log.Level = logrus.DebugLevel
-	log.Formatter = &logrus.JSONFormatter{
-		FieldMap: logrus.FieldMap{
-			logrus.FieldKeyTime:  "timestamp",
-			logrus.FieldKeyLevel: "severity",
-			logrus.FieldKeyMsg:   "message",
-		},
-		TimestampFormat: time.RFC3339Nano,
-	}
-	log.Out = os.Stdout
 
-	if os.Getenv("DISABLE_TRACING") == "" {
+	if tracing.Enabled() {
 		log.Info("Tracing enabled.")
-		go initOpenTelemetry(log)
+		if _, err := tracing.Init("frontend", log); err != nil {
+			log.Fatal(err)
+		}
 
 	} else {
 		log.Info("Tracing disabled.")
@@ -110,29 +107,38 @@ func main() {
 	}
 	addr := os.Getenv("LISTEN_ADDR")
 	svc := new(frontendServer)
-	mustMapEnv(&svc.productCatalogSvcAddr, "PRODUCT_CATALOG_SERVICE_ADDR")
-	mustMapEnv(&svc.currencySvcAddr, "CURRENCY_SERVICE_ADDR")
-	mustMapEnv(&svc.cartSvcAddr, "CART_SERVICE_ADDR")
-	if os.Getenv("RECOMMENDATION_SVC_DISABLED") == "" {
-		mustMapEnv(&svc.recommendationSvcAddr, "RECOMMENDATION_SERVICE_ADDR")
+	fakes, err := loadFakes(log)
+	if err != nil {
+		log.Fatalf("failed to load fakes: %+v", err)
 	}
-	mustMapEnv(&svc.checkoutSvcAddr, "CHECKOUT_SERVICE_ADDR")
-	if os.Getenv("SHIPPING_SVC_DISABLED") == "" {
-		mustMapEnv(&svc.shippingSvcAddr, "SHIPPING_SERVICE_ADDR")
+	svc.fakes = fakes
+	svc.mustMapBackendEnv(&svc.productCatalogSvcAddr, "productcatalog", "PRODUCT_CATALOG_SERVICE_ADDR")
+	svc.mustMapBackendEnv(&svc.currencySvcAddr, "currency", "CURRENCY_SERVICE_ADDR")
+	svc.mustMapBackendEnv(&svc.cartSvcAddr, "cart", "CART_SERVICE_ADDR")
+	svc.mustMapBackendEnv(&svc.recommendationSvcAddr, "recommendation", "RECOMMENDATION_SERVICE_ADDR")
+	svc.mustMapBackendEnv(&svc.checkoutSvcAddr, "checkout", "CHECKOUT_SERVICE_ADDR")
+	svc.mustMapBackendEnv(&svc.adSvcAddr, "ad", "AD_SERVICE_ADDR")
+
+	svc.checkoutBudget = defaultCheckoutBudget
+	if v := os.Getenv("CHECKOUT_BUDGET"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("failed to parse CHECKOUT_BUDGET (%s) as time.Duration: %+v", v, err)
+		}
+		svc.checkoutBudget = d
 	}
-	mustMapEnv(&svc.adSvcAddr, "AD_SERVICE_ADDR")
 
-	mustConnGRPC(ctx, &svc.currencySvcConn, svc.currencySvcAddr)
-	mustConnGRPC(ctx, &svc.productCatalogSvcConn, svc.productCatalogSvcAddr)
-	mustConnGRPC(ctx, &svc.cartSvcConn, svc.cartSvcAddr)
-	if os.Getenv("RECOMMENDATION_SVC_DISABLED") == "" {
-		mustConnGRPC(ctx, &svc.recommendationSvcConn, svc.recommendationSvcAddr)
+	resilienceConfig, err := loadResilienceConfig(log)
+	if err != nil {
+		log.Fatalf("failed to load resilience policies: %+v", err)
 	}
-	if os.Getenv("SHIPPING_SVC_DISABLED") == "" {
-		mustConnGRPC(ctx, &svc.shippingSvcConn, svc.shippingSvcAddr)
+	backends := new(expvar.Map).Init()
+	expvar.Publish("backends", backends)
+	if metricsAddr := os.Getenv("METRICS_ADDR"); metricsAddr != "" {
+		go serveMetrics(log, metricsAddr)
 	}
-	mustConnGRPC(ctx, &svc.checkoutSvcConn, svc.checkoutSvcAddr)
-	mustConnGRPC(ctx, &svc.adSvcConn, svc.adSvcAddr)
+
+	svc.dialBackends(ctx, resilienceConfig, backends)
 
 	r := mux.NewRouter()
 	r.HandleFunc("/", svc.homeHandler).Methods(http.MethodGet, http.MethodHead)
@@ -143,88 +149,51 @@ func main() {
 	r.HandleFunc("/setCurrency", svc.setCurrencyHandler).Methods(http.MethodPost)
 	r.HandleFunc("/logout", svc.logoutHandler).Methods(http.MethodGet)
 	r.HandleFunc("/cart/checkout", svc.placeOrderHandler).Methods(http.MethodPost)
+	r.HandleFunc("/cart/preview", svc.previewCartHandler).Methods(http.MethodPost)
+	r.HandleFunc("/order/{id}/events", svc.orderEventsHandler).Methods(http.MethodGet)
 	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("./static/"))))
 	r.HandleFunc("/robots.txt", func(w http.ResponseWriter, _ *http.Request) { fmt.Fprint(w, "User-agent: *\nDisallow: /") })
 	r.HandleFunc("/_healthz", func(w http.ResponseWriter, _ *http.Request) { fmt.Fprint(w, "ok") })
+	r.Use(traceRequests)
+
+	faults, err := faultinject.FromEnv(log)
+	if err != nil {
+		log.Fatalf("failed to load faults: %+v", err)
+	}
 
 	var handler http.Handler = r
+	handler = faults.Middleware(handler)           // add fault injection
 	handler = &logHandler{log: log, next: handler} // add logging
 	handler = ensureSessionID(handler)             // add session ID
 
 	log.Infof("starting server on " + addr + ":" + srvPort)
-	log.Fatal(http.ListenAndServe(addr+":"+srvPort, handler))
-}
-
-// for reference, see also:
-// https://github.com/open-telemetry/opentelemetry-go/blob/main/example/jaeger/main.go
-func createTracerProvider(log logrus.FieldLogger) (*tracesdk.TracerProvider, error) {
-
-	// Create the Jaeger exporter
-
-	svcAddr := os.Getenv("JAEGER_SERVICE_ADDR")
-	if svcAddr == "" {
-		return nil, errors.New("missing JAEGER_SERVICE_ADDR, can't initialize Jaeger exporter")
-	}
-
-	splitJaegerAddr := strings.Split(svcAddr, ":")
-	jaegerAgentHost := splitJaegerAddr[0]
-	jaegerAgentPort := splitJaegerAddr[1]
-
-	exporter, err := jaeger.New(jaeger.WithAgentEndpoint(jaeger.WithAgentHost(jaegerAgentHost), jaeger.WithAgentPort(jaegerAgentPort)));
-	if err != nil {
-		return nil, err
-	}
-
-	log.Info("created jaeger exporter to collector at " + svcAddr)
-
-	var tp *tracesdk.TracerProvider
-
-	if os.Getenv("TRACES_SAMPLING_FRACTION") == "" {
-		log.Info("No sampling applied, choosing ParentBased(AlwaysSample)")
-		tp = tracesdk.NewTracerProvider(
-			tracesdk.WithBatcher(exporter, tracesdk.WithMaxExportBatchSize(95)),
-			// see https://pkg.go.dev/go.opentelemetry.io/otel/sdk/trace#ParentBased
-			tracesdk.WithSampler(tracesdk.ParentBased(tracesdk.AlwaysSample())),
-			tracesdk.WithResource(resource.NewWithAttributes(
-				semconv.SchemaURL,
-				semconv.ServiceNameKey.String("frontend"),
-			)),
-		)
-	} else {
-		fraction, err := strconv.ParseFloat(os.Getenv("TRACES_SAMPLING_FRACTION"), 64)
-		if err != nil {
-			panic(err)
-		}
-		log.Info(fmt.Sprintf("Applying sampling with fraction %v", fraction))
-		tp = tracesdk.NewTracerProvider(
-			tracesdk.WithBatcher(exporter, tracesdk.WithMaxExportBatchSize(95)),
-			// see https://pkg.go.dev/go.opentelemetry.io/otel/sdk/trace#TraceIDRatioBased
-			tracesdk.WithSampler(tracesdk.ParentBased(tracesdk.TraceIDRatioBased(fraction))),
-			tracesdk.WithResource(resource.NewWithAttributes(
-				semconv.SchemaURL,
-				semconv.ServiceNameKey.String("frontend"),
-			)),
-		)
+	if err := serve(log, &http.Server{Addr: addr + ":" + srvPort, Handler: handler}); err != nil {
+		log.Fatal(err)
 	}
-
-	
-
-	return tp, nil
 }
 
-func initOpenTelemetry(log logrus.FieldLogger) {
-
-
-
-	tp, err := createTracerProvider(log)
-	if err != nil {
-		log.Fatal(err)
+// serve runs srv until the process is asked to terminate with SIGINT or
+// SIGTERM. It then shuts srv down, letting the requests in flight finish, and
+// flushes the spans that are not exported yet.
+func serve(log logrus.FieldLogger, srv *http.Server) error {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errc:
+		return err
+	case sig := <-sigs:
+		log.Infof("received %v, shutting down", sig)
 	}
-
-	otel.SetTracerProvider(tp)
-
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
-
+	ctx, cancel := context.WithTimeout(context.Background(), grpcutil.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Warnf("failed to shut down gracefully: %+v", err)
+	}
+	return tracing.Shutdown(ctx)
 }
 
 func mustMapEnv(target *string, envKey string) {
@@ -235,27 +204,10 @@ func mustMapEnv(target *string, envKey string) {
 	*target = v
 }
 
-func mustConnGRPC(ctx context.Context, conn **grpc.ClientConn, addr string) {
+func mustConnGRPC(ctx context.Context, conn **grpc.ClientConn, addr string, opts ...grpc.DialOption) {
 	var err error
-
-	if os.Getenv("DISABLE_TRACING") == "" {
-		*conn, err = grpc.DialContext(ctx, addr,
-			grpc.WithInsecure(),
-			grpc.WithTimeout(time.Second*3),
-			grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
-			grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()),
-		)
-	} else {
-		*conn, err = grpc.DialContext(ctx, addr,
-			grpc.WithInsecure(),
-			grpc.WithTimeout(time.Second*3),
-		)
-	}
-
-
-
-
-
+	*conn, err = grpcutil.Dial(ctx, addr, tracing.Enabled(),
+		append([]grpc.DialOption{grpc.WithTimeout(time.Second * 3)}, opts...)...)
 	if err != nil {
 		panic(errors.Wrapf(err, "grpc: failed to connect %s", addr))
 	}
@@ -20,7 +20,15 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/tracing"
 )
 
 type ctxKeyLog struct{}
@@ -53,6 +61,14 @@ func (r *responseRecorder) WriteHeader(statusCode int) {
 	r.w.WriteHeader(statusCode)
 }
 
+// Flush sends buffered data to the client, for handlers streaming their
+// response.
+func (r *responseRecorder) Flush() {
+	if f, ok := r.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 func (lh *logHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	requestID, _ := uuid.NewRandom()
@@ -103,3 +119,40 @@ func ensureSessionID(next http.Handler) http.HandlerFunc {
 		next.ServeHTTP(w, r)
 	}
 }
+
+// tracePhase runs fn in a child span of the request named after a phase of
+// its handler, such as "cart.load".
+func tracePhase(ctx context.Context, phase string, fn func(ctx context.Context) error) error {
+	return tracing.Run(ctx, otel.Tracer("frontend"), phase, fn)
+}
+
+// traceRequests starts a server span for each request routed by mux, named
+// after its method and route such as "GET /product/{id}", which sampling
+// rules can match.
+func traceRequests(next http.Handler) http.Handler {
+	tracer := otel.Tracer("frontend")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.URL.Path
+		if t, err := mux.CurrentRoute(r).GetPathTemplate(); err == nil {
+			route = t
+		}
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(r.Method),
+				semconv.HTTPRouteKey.String(route),
+				semconv.HTTPTargetKey.String(r.URL.RequestURI())))
+		defer span.End()
+
+		rr := &responseRecorder{w: w}
+		next.ServeHTTP(rr, r.WithContext(ctx))
+		if rr.status == 0 {
+			rr.status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(rr.status))
+		if rr.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rr.status))
+		}
+	})
+}
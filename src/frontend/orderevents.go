@@ -0,0 +1,95 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+// orderStatusEvent is the data of a "status" Server-Sent Event.
+type orderStatusEvent struct {
+	OrderID string `json:"order_id"`
+	Status  string `json:"status"`
+	Time    int64  `json:"time"`
+}
+
+// orderEventsHandler streams the status updates of an order of the session
+// as Server-Sent Events. Each update is a "status" event; an "end" event
+// follows the last one so the browser stops reconnecting.
+func (fe *frontendServer) orderEventsHandler(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value(ctxKeyLog{}).(logrus.FieldLogger)
+	id := mux.Vars(r)["id"]
+	log = log.WithField("order", id)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		renderHTTPError(log, r, w, errors.New("streaming is not supported"), http.StatusInternalServerError)
+		return
+	}
+
+	checkout := fe.checkoutSvc
+	rec, err := checkout.GetOrder(r.Context(), &pb.GetOrderRequest{OrderId: id})
+	if status.Code(err) == codes.NotFound || (err == nil && rec.GetUserId() != sessionID(r)) {
+		renderHTTPError(log, r, w, errors.Errorf("order %s not found", id), http.StatusNotFound)
+		return
+	} else if err != nil {
+		renderHTTPError(log, r, w, errors.Wrap(err, "could not retrieve order"), http.StatusInternalServerError)
+		return
+	}
+
+	events, err := checkout.WatchOrder(r.Context(), &pb.WatchOrderRequest{OrderId: id})
+	if err != nil {
+		renderHTTPError(log, r, w, errors.Wrap(err, "could not watch order"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	for {
+		e, err := events.Recv()
+		if err == io.EOF || status.Code(err) == codes.NotFound {
+			// An order whose shipment is no longer tracked has no more
+			// updates.
+			fmt.Fprint(w, "event: end\ndata: {}\n\n")
+			flusher.Flush()
+			return
+		} else if err != nil {
+			// The browser reconnects and gets the events replayed.
+			if r.Context().Err() == nil {
+				log.WithField("error", err).Warn("order status stream failed")
+			}
+			return
+		}
+		data, err := json.Marshal(orderStatusEvent{OrderID: e.GetOrderId(), Status: e.GetStatus().String(), Time: e.GetTime()})
+		if err != nil {
+			log.WithField("error", err).Error("failed to encode order status event")
+			return
+		}
+		fmt.Fprintf(w, "event: status\ndata: %s\n\n", data)
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"expvar"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/resilience"
+)
+
+// defaultResilience holds the policies of the backend services unless
+// overridden by RESILIENCE_CONFIG. Only RPCs that can safely run twice are
+// retried. Ads are not worth waiting for, so their calls are neither given
+// long nor retried.
+var defaultResilience = resilience.Config{
+	"productcatalog": {
+		Timeout: resilience.Duration(time.Second),
+		Retry:   resilience.RetryPolicy{Idempotent: []string{"ListProducts", "GetProduct", "SearchProducts"}},
+	},
+	"currency": {
+		Timeout: resilience.Duration(time.Second),
+		Retry:   resilience.RetryPolicy{Idempotent: []string{"GetSupportedCurrencies", "Convert"}},
+	},
+	"cart": {
+		Timeout: resilience.Duration(time.Second),
+		Retry:   resilience.RetryPolicy{Idempotent: []string{"GetCart", "EmptyCart"}},
+	},
+	"recommendation": {
+		Timeout: resilience.Duration(time.Second),
+		Retry:   resilience.RetryPolicy{Idempotent: []string{"ListRecommendations"}},
+	},
+	"checkout": {
+		Timeout: resilience.Duration(15 * time.Second),
+		Retry:   resilience.RetryPolicy{Idempotent: []string{"PreviewOrder", "GetOrder", "ListOrders"}},
+	},
+	"ad": {
+		Timeout: resilience.Duration(100 * time.Millisecond),
+	},
+}
+
+// loadResilienceConfig returns the policies of the backend services. The
+// JSON file named by RESILIENCE_CONFIG, if set, replaces the policies of the
+// services it lists.
+func loadResilienceConfig(log logrus.FieldLogger) (resilience.Config, error) {
+	path := os.Getenv("RESILIENCE_CONFIG")
+	if path == "" {
+		return defaultResilience, nil
+	}
+	log.Infof("loading resilience policies from %q", path)
+	c, err := resilience.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return defaultResilience.Merge(c), nil
+}
+
+// resilienceClient returns the guard enforcing the resilience policy of the
+// backend called name. Its circuit breaker metrics are added to backends.
+func resilienceClient(config resilience.Config, backends *expvar.Map, name string) *resilience.Client {
+	c := resilience.NewClient(name, config.Policy(name))
+	backends.Set(name, c.Metrics())
+	return c
+}
+
+// guard returns the options installing the resilience policy of the backend
+// called name on its connection.
+func guard(config resilience.Config, backends *expvar.Map, name string) []grpc.DialOption {
+	c := resilienceClient(config, backends, name)
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(c.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(c.StreamClientInterceptor()),
+	}
+}
@@ -16,11 +16,8 @@ package main
 
 import (
 	"context"
-	"os"
-	"time"
 
-	pb "github.com/GoogleCloudPlatform/microservices-demo/src/frontend/genproto"
-	"github.com/sirupsen/logrus"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
 
 	"github.com/pkg/errors"
 )
@@ -30,7 +27,7 @@ const (
 )
 
 func (fe *frontendServer) getCurrencies(ctx context.Context) ([]string, error) {
-	currs, err := pb.NewCurrencyServiceClient(fe.currencySvcConn).
+	currs, err := fe.currencySvc.
 		GetSupportedCurrencies(ctx, &pb.Empty{})
 	if err != nil {
 		return nil, err
@@ -45,29 +42,29 @@ func (fe *frontendServer) getCurrencies(ctx context.Context) ([]string, error) {
 }
 
 func (fe *frontendServer) getProducts(ctx context.Context) ([]*pb.Product, error) {
-	resp, err := pb.NewProductCatalogServiceClient(fe.productCatalogSvcConn).
+	resp, err := fe.productCatalogSvc.
 		ListProducts(ctx, &pb.Empty{})
 	return resp.GetProducts(), err
 }
 
 func (fe *frontendServer) getProduct(ctx context.Context, id string) (*pb.Product, error) {
-	resp, err := pb.NewProductCatalogServiceClient(fe.productCatalogSvcConn).
+	resp, err := fe.productCatalogSvc.
 		GetProduct(ctx, &pb.GetProductRequest{Id: id})
 	return resp, err
 }
 
 func (fe *frontendServer) getCart(ctx context.Context, userID string) ([]*pb.CartItem, error) {
-	resp, err := pb.NewCartServiceClient(fe.cartSvcConn).GetCart(ctx, &pb.GetCartRequest{UserId: userID})
+	resp, err := fe.cartSvc.GetCart(ctx, &pb.GetCartRequest{UserId: userID})
 	return resp.GetItems(), err
 }
 
 func (fe *frontendServer) emptyCart(ctx context.Context, userID string) error {
-	_, err := pb.NewCartServiceClient(fe.cartSvcConn).EmptyCart(ctx, &pb.EmptyCartRequest{UserId: userID})
+	_, err := fe.cartSvc.EmptyCart(ctx, &pb.EmptyCartRequest{UserId: userID})
 	return err
 }
 
 func (fe *frontendServer) insertCart(ctx context.Context, userID, productID string, quantity int32) error {
-	_, err := pb.NewCartServiceClient(fe.cartSvcConn).AddItem(ctx, &pb.AddItemRequest{
+	_, err := fe.cartSvc.AddItem(ctx, &pb.AddItemRequest{
 		UserId: userID,
 		Item: &pb.CartItem{
 			ProductId: productID,
@@ -80,53 +77,24 @@ func (fe *frontendServer) convertCurrency(ctx context.Context, money *pb.Money,
 	if avoidNoopCurrencyConversionRPC && money.GetCurrencyCode() == currency {
 		return money, nil
 	}
-	return pb.NewCurrencyServiceClient(fe.currencySvcConn).
+	return fe.currencySvc.
 		Convert(ctx, &pb.CurrencyConversionRequest{
 			From:   money,
 			ToCode: currency})
 }
 
-func (fe *frontendServer) getShippingQuote(log logrus.FieldLogger, ctx context.Context, items []*pb.CartItem, currency string) (*pb.Money, error) {
-
-	var quote *pb.GetQuoteResponse
-	var err error
-
-	if os.Getenv("SHIPPING_SVC_DISABLED") != "" {
-		log.Info("Shipping service disabled. Mocking call, always 5.00 USD shipping quote.")
-		quote, err = &pb.GetQuoteResponse{
-			CostUsd: &pb.Money{
-				CurrencyCode: "USD",
-				Units:        int64(5),
-				Nanos:        int32(0)},
-		}, error(nil)
-	} else {
-		quote, err = pb.NewShippingServiceClient(fe.shippingSvcConn).GetQuote(ctx,
-			&pb.GetQuoteRequest{
-				Address: nil,
-				Items:   items})
-	}
-
-	if err != nil {
-		return nil, err
-	}
-	localized, err := fe.convertCurrency(ctx, quote.GetCostUsd(), currency)
-	return localized, errors.Wrap(err, "failed to convert currency for shipping cost")
+func (fe *frontendServer) previewOrder(ctx context.Context, userID, currency string, address *pb.Address, promoCodes []string) (*pb.PreviewOrderResponse, error) {
+	return fe.checkoutSvc.PreviewOrder(ctx, &pb.PreviewOrderRequest{
+		UserId:       userID,
+		UserCurrency: currency,
+		Address:      address,
+		PromoCodes:   promoCodes})
 }
 
-func (fe *frontendServer) getRecommendations(log logrus.FieldLogger, ctx context.Context, userID string, productIDs []string) ([]*pb.Product, error) {
-
-	var resp *pb.ListRecommendationsResponse
-	var err error
-
-	if os.Getenv("RECOMMENDATION_SVC_DISABLED") != "" {
-		log.Info("Recommendation service disabled. Mocking call, always recommending typewriter.")
-		resp, err = &pb.ListRecommendationsResponse{ProductIds: []string{"OLJCESPC7Z"}}, error(nil)
-
-	} else {
-		resp, err = pb.NewRecommendationServiceClient(fe.recommendationSvcConn).ListRecommendations(ctx,
-			&pb.ListRecommendationsRequest{UserId: userID, ProductIds: productIDs})
-	}
+func (fe *frontendServer) getRecommendations(ctx context.Context, userID string, productIDs []string) ([]*pb.Product, error) {
 
+	resp, err := fe.recommendationSvc.ListRecommendations(ctx,
+		&pb.ListRecommendationsRequest{UserId: userID, ProductIds: productIDs})
 	if err != nil {
 		return nil, err
 	}
@@ -145,10 +113,7 @@ func (fe *frontendServer) getRecommendations(log logrus.FieldLogger, ctx context
 }
 
 func (fe *frontendServer) getAd(ctx context.Context, ctxKeys []string) ([]*pb.Ad, error) {
-	ctx, cancel := context.WithTimeout(ctx, time.Millisecond*100)
-	defer cancel()
-
-	resp, err := pb.NewAdServiceClient(fe.adSvcConn).GetAds(ctx, &pb.AdRequest{
+	resp, err := fe.adSvc.GetAds(ctx, &pb.AdRequest{
 		ContextKeys: ctxKeys,
 	})
 	return resp.GetAds(), errors.Wrap(err, "failed to get ads")
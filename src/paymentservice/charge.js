@@ -27,7 +27,7 @@ const logger = pino({
 class CreditCardError extends Error {
   constructor (message) {
     super(message);
-    this.code = 400; // Invalid argument error
+    this.code = 3; // gRPC INVALID_ARGUMENT
   }
 }
 
@@ -27,9 +27,13 @@ import (
 	"sync"
 	"syscall"
 	"time"
-	"errors"
 
-	pb "github.com/GoogleCloudPlatform/microservices-demo/src/productcatalogservice/genproto"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/fake"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/faultinject"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/grpcutil"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/logging"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/tracing"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/golang/protobuf/jsonpb"
@@ -37,21 +41,12 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-
-	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/jaeger"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	tracesdk "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 )
 
 var (
 	cat          pb.ListProductsResponse
 	catalogMutex *sync.Mutex
 	log          *logrus.Logger
-	extraLatency time.Duration
 
 	port = "3550"
 
@@ -59,16 +54,7 @@ var (
 )
 
 func init() {
-	log = logrus.New()
-	log.Formatter = &logrus.JSONFormatter{
-		FieldMap: logrus.FieldMap{
-			logrus.FieldKeyTime:  "timestamp",
-			logrus.FieldKeyLevel: "severity",
-			logrus.FieldKeyMsg:   "message",
-		},
-		TimestampFormat: time.RFC3339Nano,
-	}
-	log.Out = os.Stdout
+	log = logging.New()
 	catalogMutex = &sync.Mutex{}
 	err := readCatalogFile(&cat)
 	if err != nil {
@@ -77,9 +63,11 @@ func init() {
 }
 
 func main() {
-	if os.Getenv("DISABLE_TRACING") == "" {
+	if tracing.Enabled() {
 		log.Info("Tracing enabled.")
-		initOpenTelemetry(log)
+		if _, err := tracing.Init("productcatalogservice", log); err != nil {
+			log.Fatal(err)
+		}
 	} else {
 		log.Info("Tracing disabled.")
 	}
@@ -87,16 +75,25 @@ func main() {
 
 	flag.Parse()
 
-	// set injected latency
+	faults, err := faultinject.FromEnv(log)
+	if err != nil {
+		log.Fatalf("failed to load faults: %+v", err)
+	}
+	// set injected latency, on the calls that no other fault matches
 	if s := os.Getenv("EXTRA_LATENCY"); s != "" {
 		v, err := time.ParseDuration(s)
 		if err != nil {
-			log.Fatalf("failed to parse EXTRA_LATENCY (%s) as time.Duration: %+v", v, err)
+			log.Fatalf("failed to parse EXTRA_LATENCY (%s) as time.Duration: %+v", s, err)
 		}
-		extraLatency = v
-		log.Infof("extra latency enabled (duration: %v)", extraLatency)
-	} else {
-		extraLatency = time.Duration(0)
+		c := faults.Config()
+		c.Rules = append(c.Rules, faultinject.Rule{
+			Method:  "/hipstershop.ProductCatalogService/*",
+			Latency: &fake.Latency{Distribution: fake.Constant, Value: fake.Duration(v)},
+		})
+		if err := faults.SetConfig(c); err != nil {
+			log.Fatal(err)
+		}
+		log.Infof("extra latency enabled (duration: %v)", v)
 	}
 
 	sigs := make(chan os.Signal, 1)
@@ -119,84 +116,31 @@ func main() {
 		port = os.Getenv("PORT")
 	}
 	log.Infof("starting grpc server at :%s", port)
-	run(port)
-	select {}
+	srv, l := listen(port, faults.ServerOptions()...)
+	if err := grpcutil.Serve(srv, l, log); err != nil {
+		log.Fatal(err)
+	}
 }
 
-func run(port string) string {
+// listen returns the server of the catalog with the given options, and the
+// listener on port it is to serve.
+func listen(port string, opts ...grpc.ServerOption) (*grpc.Server, net.Listener) {
 	l, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	var srv *grpc.Server
-
-	if os.Getenv("DISABLE_TRACING") == "" {
-		srv = grpc.NewServer(
-			grpc.UnaryInterceptor(otelgrpc.UnaryServerInterceptor()),
-			grpc.StreamInterceptor(otelgrpc.StreamServerInterceptor()),
-		)
-	} else {
-		srv = grpc.NewServer()
-	}
+	srv := grpcutil.NewServer(tracing.Enabled(), opts...)
 	
 
 	svc := &productCatalog{}
 
 	pb.RegisterProductCatalogServiceServer(srv, svc)
 	healthpb.RegisterHealthServer(srv, svc)
-	go srv.Serve(l)
-	return l.Addr().String()
+	return srv, l
 }
 
 
-// for reference, see also:
-// https://github.com/open-telemetry/opentelemetry-go/blob/main/example/jaeger/main.go
-func createTracerProvider(log logrus.FieldLogger) (*tracesdk.TracerProvider, error) {
-
-	// Create the Jaeger exporter
-
-	svcAddr := os.Getenv("JAEGER_SERVICE_ADDR")
-	if svcAddr == "" {
-		return nil, errors.New("missing JAEGER_SERVICE_ADDR, can't initialize Jaeger exporter")
-	}
-
-	splitJaegerAddr := strings.Split(svcAddr, ":")
-	jaegerAgentHost := splitJaegerAddr[0]
-	jaegerAgentPort := splitJaegerAddr[1]
-
-	// exp, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(url)))
-	exporter, err := jaeger.New(jaeger.WithAgentEndpoint(jaeger.WithAgentHost(jaegerAgentHost), jaeger.WithAgentPort(jaegerAgentPort)));
-	if err != nil {
-		return nil, err
-	}
-
-	log.Info("created jaeger exporter to collector at " + svcAddr)
-
-	tp := tracesdk.NewTracerProvider(
-		tracesdk.WithBatcher(exporter, tracesdk.WithMaxExportBatchSize(95)),
-		// see https://pkg.go.dev/go.opentelemetry.io/otel/sdk/trace#ParentBased
-		tracesdk.WithSampler(tracesdk.ParentBased(tracesdk.AlwaysSample())),
-		tracesdk.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String("productcatalogservice"),
-		)),
-	)
-	return tp, nil
-}
-
-func initOpenTelemetry(log logrus.FieldLogger) {
-	tp, err := createTracerProvider(log)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	otel.SetTracerProvider(tp)
-
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
-
-}
-
 type productCatalog struct{}
 
 func readCatalogFile(catalog *pb.ListProductsResponse) error {
@@ -234,12 +178,10 @@ func (p *productCatalog) Watch(req *healthpb.HealthCheckRequest, ws healthpb.Hea
 }
 
 func (p *productCatalog) ListProducts(context.Context, *pb.Empty) (*pb.ListProductsResponse, error) {
-	time.Sleep(extraLatency)
 	return &pb.ListProductsResponse{Products: parseCatalog()}, nil
 }
 
 func (p *productCatalog) GetProduct(ctx context.Context, req *pb.GetProductRequest) (*pb.Product, error) {
-	time.Sleep(extraLatency)
 	var found *pb.Product
 	for i := 0; i < len(parseCatalog()); i++ {
 		if req.Id == parseCatalog()[i].Id {
@@ -253,7 +195,6 @@ func (p *productCatalog) GetProduct(ctx context.Context, req *pb.GetProductReque
 }
 
 func (p *productCatalog) SearchProducts(ctx context.Context, req *pb.SearchProductsRequest) (*pb.SearchProductsResponse, error) {
-	time.Sleep(extraLatency)
 	// Intepret query as a substring match in name or description.
 	var ps []*pb.Product
 	for _, p := range parseCatalog() {
@@ -18,7 +18,7 @@ import (
 	"context"
 	"testing"
 
-	pb "github.com/GoogleCloudPlatform/microservices-demo/src/productcatalogservice/genproto"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
 	"github.com/golang/protobuf/proto"
 	"github.com/google/go-cmp/cmp"
 	"go.opencensus.io/plugin/ocgrpc"
@@ -29,8 +29,10 @@ import (
 
 func TestServer(t *testing.T) {
 	ctx := context.Background()
-	addr := run(port)
-	conn, err := grpc.Dial(addr,
+	srv, l := listen(port)
+	go srv.Serve(l)
+	defer srv.Stop()
+	conn, err := grpc.Dial(l.Addr().String(),
 		grpc.WithInsecure(),
 		grpc.WithStatsHandler(&ocgrpc.ClientHandler{}))
 	if err != nil {
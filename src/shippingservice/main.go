@@ -19,27 +19,19 @@ import (
 	"net"
 	"os"
 	"time"
-	"errors"
-	"strings"
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
-	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 
-	pb "github.com/GoogleCloudPlatform/microservices-demo/src/shippingservice/genproto"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/faultinject"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/grpcutil"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/logging"
+	"github.com/GoogleCloudPlatform/microservices-demo/src/common/tracing"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
-
-	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/jaeger"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	tracesdk "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
-
 )
 
 const (
@@ -49,23 +41,16 @@ const (
 var log *logrus.Logger
 
 func init() {
-	log = logrus.New()
+	log = logging.New()
 	log.Level = logrus.DebugLevel
-	log.Formatter = &logrus.JSONFormatter{
-		FieldMap: logrus.FieldMap{
-			logrus.FieldKeyTime:  "timestamp",
-			logrus.FieldKeyLevel: "severity",
-			logrus.FieldKeyMsg:   "message",
-		},
-		TimestampFormat: time.RFC3339Nano,
-	}
-	log.Out = os.Stdout
 }
 
 func main() {
-	if os.Getenv("DISABLE_TRACING") == "" {
+	if tracing.Enabled() {
 		log.Info("Tracing enabled.")
-		initOpenTelemetry(log)
+		if _, err := tracing.Init("shippingservice", log); err != nil {
+			log.Fatal(err)
+		}
 	} else {
 		log.Info("Tracing disabled.")
 	}
@@ -82,79 +67,46 @@ func main() {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
-	var srv *grpc.Server
-
-	if os.Getenv("DISABLE_TRACING") == "" {
-		srv = grpc.NewServer(
-			grpc.UnaryInterceptor(otelgrpc.UnaryServerInterceptor()),
-			grpc.StreamInterceptor(otelgrpc.StreamServerInterceptor()),
-		)
-	} else {
-		srv = grpc.NewServer()
+	faults, err := faultinject.FromEnv(log)
+	if err != nil {
+		log.Fatalf("failed to load faults: %+v", err)
 	}
+	srv := grpcutil.NewServer(tracing.Enabled(), faults.ServerOptions()...)
 
 	svc := &server{}
+	svc.shipments.transitAfter = mustDurationEnv("SHIPMENT_TRANSIT_AFTER", defaultTransitAfter)
+	svc.shipments.deliveryAfter = mustDurationEnv("SHIPMENT_DELIVERY_AFTER", defaultDeliveryAfter)
+	svc.shipments.retention = mustDurationEnv("SHIPMENT_RETENTION", defaultRetention)
 	pb.RegisterShippingServiceServer(srv, svc)
 	healthpb.RegisterHealthServer(srv, svc)
 	log.Infof("Shipping Service listening on port %s", port)
 
 	// Register reflection service on gRPC server.
 	reflection.Register(srv)
-	if err := srv.Serve(lis); err != nil {
+	if err := grpcutil.Serve(srv, lis, log); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
 }
 
 
-// for reference, see also:
-// https://github.com/open-telemetry/opentelemetry-go/blob/main/example/jaeger/main.go
-func createTracerProvider(log logrus.FieldLogger) (*tracesdk.TracerProvider, error) {
-
-	// Create the Jaeger exporter
-
-	svcAddr := os.Getenv("JAEGER_SERVICE_ADDR")
-	if svcAddr == "" {
-		return nil, errors.New("missing JAEGER_SERVICE_ADDR, can't initialize Jaeger exporter")
+// mustDurationEnv returns the duration in the environment variable key, or
+// def if it is not set.
+func mustDurationEnv(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
 	}
-
-	splitJaegerAddr := strings.Split(svcAddr, ":")
-	jaegerAgentHost := splitJaegerAddr[0]
-	jaegerAgentPort := splitJaegerAddr[1]
-
-	// exp, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(url)))
-	exporter, err := jaeger.New(jaeger.WithAgentEndpoint(jaeger.WithAgentHost(jaegerAgentHost), jaeger.WithAgentPort(jaegerAgentPort)));
+	d, err := time.ParseDuration(v)
 	if err != nil {
-		return nil, err
+		log.Fatalf("invalid %s %q: %v", key, v, err)
 	}
-
-	log.Info("created jaeger exporter to collector at " + svcAddr)
-
-	tp := tracesdk.NewTracerProvider(
-		tracesdk.WithBatcher(exporter, tracesdk.WithMaxExportBatchSize(95)),
-		// see https://pkg.go.dev/go.opentelemetry.io/otel/sdk/trace#ParentBased
-		tracesdk.WithSampler(tracesdk.ParentBased(tracesdk.AlwaysSample())),
-		tracesdk.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String("shippingservice"),
-		)),
-	)
-	return tp, nil
-}
-
-func initOpenTelemetry(log logrus.FieldLogger) {
-	tp, err := createTracerProvider(log)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	otel.SetTracerProvider(tp)
-
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
-
+	return d
 }
 
 // server controls RPC service responses.
-type server struct{}
+type server struct {
+	shipments shipmentTracker
+}
 
 // Check is for health checking.
 func (s *server) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
@@ -197,6 +149,7 @@ func (s *server) ShipOrder(ctx context.Context, in *pb.ShipOrderRequest) (*pb.Sh
 	// 1. Create a Tracking ID
 	baseAddress := fmt.Sprintf("%s, %s, %s", in.Address.StreetAddress, in.Address.City, in.Address.State)
 	id := CreateTrackingId(baseAddress)
+	s.shipments.add(id, time.Now())
 
 	// 2. Generate a response.
 	return &pb.ShipOrderResponse{
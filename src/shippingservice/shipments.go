@@ -0,0 +1,195 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
+)
+
+// Default delays, after a shipment is created, at which it is reported in
+// transit and delivered.
+const (
+	defaultTransitAfter  = 30 * time.Second
+	defaultDeliveryAfter = 2 * time.Minute
+)
+
+// defaultRetention is how long a delivered or cancelled shipment can still
+// be tracked.
+const defaultRetention = time.Hour
+
+// shipment is a simulated shipment. It is shipped when created, and goes
+// into transit and is delivered after fixed delays unless it is cancelled
+// first.
+type shipment struct {
+	created   time.Time
+	cancelled time.Time     // zero unless cancelled
+	cancel    chan struct{} // closed on cancellation
+}
+
+// wait waits until at, or until the shipment is cancelled.
+func (sh *shipment) wait(ctx context.Context, at time.Time) error {
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-sh.cancel:
+	case <-timer.C:
+	}
+	return nil
+}
+
+// cancelledBefore returns when the shipment was cancelled, if it was before
+// at.
+func (sh *shipment) cancelledBefore(at time.Time) (time.Time, bool) {
+	select {
+	case <-sh.cancel:
+		return sh.cancelled, sh.cancelled.Before(at)
+	default:
+		return time.Time{}, false
+	}
+}
+
+// shipmentTracker keeps the shipments created since the service started,
+// until they have been delivered or cancelled for the retention period.
+// The zero value uses the default delays and retention.
+type shipmentTracker struct {
+	transitAfter  time.Duration
+	deliveryAfter time.Duration
+	retention     time.Duration
+
+	mu        sync.Mutex
+	shipments map[string]*shipment
+	nextEvict time.Time
+}
+
+func (t *shipmentTracker) add(id string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.shipments == nil {
+		t.shipments = make(map[string]*shipment)
+	}
+	t.evict(now)
+	t.shipments[id] = &shipment{created: now, cancel: make(chan struct{})}
+}
+
+// evict drops the shipments delivered or cancelled more than the retention
+// period before now. It scans the shipments at most once per retention
+// period, so a shipment is kept for up to twice that long. t.mu must be held.
+func (t *shipmentTracker) evict(now time.Time) {
+	if now.Before(t.nextEvict) {
+		return
+	}
+	retention := t.retention
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+	t.nextEvict = now.Add(retention)
+	for id, sh := range t.shipments {
+		ended := sh.created.Add(t.delay(pb.OrderStatus_DELIVERED))
+		if !sh.cancelled.IsZero() && sh.cancelled.Before(ended) {
+			ended = sh.cancelled
+		}
+		if now.Sub(ended) > retention {
+			delete(t.shipments, id)
+		}
+	}
+}
+
+func (t *shipmentTracker) get(id string) (*shipment, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sh, ok := t.shipments[id]
+	return sh, ok
+}
+
+// cancelShipment cancels the shipment unless it has already been delivered.
+func (t *shipmentTracker) cancelShipment(id string, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sh, ok := t.shipments[id]
+	switch {
+	case !ok:
+		return status.Errorf(codes.NotFound, "no shipment with tracking ID %s", id)
+	case !sh.cancelled.IsZero():
+		return nil
+	case !now.Before(sh.created.Add(t.delay(pb.OrderStatus_DELIVERED))):
+		return status.Errorf(codes.FailedPrecondition, "shipment %s has already been delivered", id)
+	}
+	sh.cancelled = now
+	close(sh.cancel)
+	return nil
+}
+
+// delay returns when, after its creation, a shipment reaches s.
+func (t *shipmentTracker) delay(s pb.OrderStatus) time.Duration {
+	switch s {
+	case pb.OrderStatus_IN_TRANSIT:
+		if t.transitAfter > 0 {
+			return t.transitAfter
+		}
+		return defaultTransitAfter
+	case pb.OrderStatus_DELIVERED:
+		if t.deliveryAfter > 0 {
+			return t.deliveryAfter
+		}
+		return defaultDeliveryAfter
+	}
+	return 0
+}
+
+// track sends the updates of shipment id, waiting for those still to come,
+// until the shipment is delivered or cancelled.
+func (t *shipmentTracker) track(ctx context.Context, id string, send func(*pb.ShipmentEvent) error) error {
+	sh, ok := t.get(id)
+	if !ok {
+		return status.Errorf(codes.NotFound, "no shipment with tracking ID %s", id)
+	}
+	for _, s := range []pb.OrderStatus{pb.OrderStatus_SHIPPED, pb.OrderStatus_IN_TRANSIT, pb.OrderStatus_DELIVERED} {
+		at := sh.created.Add(t.delay(s))
+		if err := sh.wait(ctx, at); err != nil {
+			return err
+		}
+		if cancelled, ok := sh.cancelledBefore(at); ok {
+			return send(&pb.ShipmentEvent{TrackingId: id, Status: pb.OrderStatus_CANCELLED, Time: cancelled.Unix()})
+		}
+		if err := send(&pb.ShipmentEvent{TrackingId: id, Status: s, Time: at.Unix()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TrackShipment streams the updates of a shipment.
+func (s *server) TrackShipment(in *pb.TrackShipmentRequest, stream pb.ShippingService_TrackShipmentServer) error {
+	log.Infof("[TrackShipment] tracking_id=%q", in.GetTrackingId())
+	return s.shipments.track(stream.Context(), in.GetTrackingId(), stream.Send)
+}
+
+// CancelShipment cancels a shipment that has not been delivered yet.
+func (s *server) CancelShipment(ctx context.Context, in *pb.CancelShipmentRequest) (*pb.Empty, error) {
+	log.Infof("[CancelShipment] tracking_id=%q", in.GetTrackingId())
+	if err := s.shipments.cancelShipment(in.GetTrackingId(), time.Now()); err != nil {
+		return nil, err
+	}
+	return &pb.Empty{}, nil
+}
@@ -15,11 +15,15 @@
 package main
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
-	pb "github.com/GoogleCloudPlatform/microservices-demo/src/shippingservice/genproto"
+	pb "github.com/GoogleCloudPlatform/microservices-demo/src/common/genproto"
 )
 
 // TestGetQuote is a basic check on the GetQuote RPC service.
@@ -88,3 +92,88 @@ func TestShipOrder(t *testing.T) {
 		t.Errorf("TestShipOrder: Tracking ID is malformed - has %d characters, %d expected", len(res.TrackingId), 18)
 	}
 }
+
+func trackStatuses(t *testing.T, tr *shipmentTracker, id string) []pb.OrderStatus {
+	t.Helper()
+	var got []pb.OrderStatus
+	err := tr.track(context.Background(), id, func(e *pb.ShipmentEvent) error {
+		if e.TrackingId != id {
+			t.Errorf("event for tracking ID %q, want %q", e.TrackingId, id)
+		}
+		got = append(got, e.Status)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("track(%q) failed: %v", id, err)
+	}
+	return got
+}
+
+// TestTrackShipment checks the updates streamed for a shipment.
+func TestTrackShipment(t *testing.T) {
+	tr := &shipmentTracker{transitAfter: 10 * time.Millisecond, deliveryAfter: 20 * time.Millisecond}
+	tr.add("delivered", time.Now())
+	want := []pb.OrderStatus{pb.OrderStatus_SHIPPED, pb.OrderStatus_IN_TRANSIT, pb.OrderStatus_DELIVERED}
+	if got := trackStatuses(t, tr, "delivered"); !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered shipment: got %v, want %v", got, want)
+	}
+	// Past updates are replayed.
+	if got := trackStatuses(t, tr, "delivered"); !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered shipment, tracked again: got %v, want %v", got, want)
+	}
+	if err := tr.cancelShipment("delivered", time.Now()); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("cancelling a delivered shipment: got %v, want FailedPrecondition", err)
+	}
+
+	tr = &shipmentTracker{transitAfter: time.Hour, deliveryAfter: 2 * time.Hour}
+	tr.add("cancelled", time.Now())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		if err := tr.cancelShipment("cancelled", time.Now()); err != nil {
+			t.Errorf("cancelShipment failed: %v", err)
+		}
+	}()
+	want = []pb.OrderStatus{pb.OrderStatus_SHIPPED, pb.OrderStatus_CANCELLED}
+	if got := trackStatuses(t, tr, "cancelled"); !reflect.DeepEqual(got, want) {
+		t.Errorf("cancelled shipment: got %v, want %v", got, want)
+	}
+
+	err := tr.track(context.Background(), "unknown", func(*pb.ShipmentEvent) error { return nil })
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("tracking an unknown shipment: got %v, want NotFound", err)
+	}
+}
+
+// TestShipmentEviction checks that shipments are forgotten once they have
+// been delivered or cancelled for the retention period.
+func TestShipmentEviction(t *testing.T) {
+	tr := &shipmentTracker{transitAfter: time.Minute, deliveryAfter: 2 * time.Minute, retention: time.Hour}
+	start := time.Now()
+	tr.add("delivered", start)
+	tr.add("cancelled", start)
+	if err := tr.cancelShipment("cancelled", start.Add(time.Minute)); err != nil {
+		t.Fatalf("cancelShipment failed: %v", err)
+	}
+
+	// The first shipments ended about an hour ago: not yet evicted.
+	tr.add("trigger-1", start.Add(61*time.Minute))
+	for _, id := range []string{"delivered", "cancelled"} {
+		if _, ok := tr.get(id); !ok {
+			t.Errorf("shipment %q evicted too early", id)
+		}
+	}
+
+	// The next scan comes a retention period after the previous one.
+	tr.add("pending", start.Add(100*time.Minute))
+	tr.add("trigger-2", start.Add(122*time.Minute))
+	for _, id := range []string{"delivered", "cancelled"} {
+		if _, ok := tr.get(id); ok {
+			t.Errorf("shipment %q not evicted after the retention period", id)
+		}
+	}
+	for _, id := range []string{"pending", "trigger-1", "trigger-2"} {
+		if _, ok := tr.get(id); !ok {
+			t.Errorf("shipment %q evicted before the retention period", id)
+		}
+	}
+}